@@ -0,0 +1,445 @@
+package main
+
+import (
+	"api/src/apierror"
+	"api/src/cardfile"
+	"api/src/client"
+	"api/src/models"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/google/uuid"
+)
+
+// newFlagSet returns a flag set for a command that reports errors on stderr
+func (a *app) newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	fs.Usage = func() { fmt.Fprint(a.stderr, usage) }
+	return fs
+}
+
+// parse parses args and checks the number of positional arguments
+func (a *app) parse(fs *flag.FlagSet, args []string, positional int) error {
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() != positional {
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+// parseDeckID parses a deck ID argument
+func parseDeckID(s string) (uuid.UUID, error) {
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%q is not a deck ID", s)
+	}
+	return id, nil
+}
+
+func (a *app) login(args []string) error {
+	cfg, err := a.loadConfig()
+	if err != nil {
+		return err
+	}
+	if cfg.Server == "" {
+		cfg.Server = defaultServer
+	}
+
+	fs := a.newFlagSet("login")
+	fs.StringVar(&cfg.Server, "server", cfg.Server, "server URL")
+	token := fs.String("token", "", "personal access token, read from stdin when empty")
+	if err := a.parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	cfg.Token = *token
+	if cfg.Token == "" {
+		fmt.Fprint(a.stdout, "Personal access token: ")
+		line, err := a.stdin.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		cfg.Token = strings.TrimSpace(line)
+	}
+	if cfg.Token == "" {
+		return errors.New("no token given")
+	}
+
+	// Check the token before saving it. A token without decks:read still works for other commands.
+	_, err = client.New(cfg.Server, cfg.Token).ListDecks(context.Background())
+	if err != nil && client.ErrorCode(err) != apierror.CodeInsufficientScope {
+		return fmt.Errorf("checking the token: %w", err)
+	}
+
+	if err := a.saveConfig(cfg); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "Logged in to %s\n", cfg.Server)
+	return nil
+}
+
+func (a *app) logout() error {
+	cfg, err := a.loadConfig()
+	if err != nil {
+		return err
+	}
+	cfg.Token = ""
+	return a.saveConfig(cfg)
+}
+
+func (a *app) decks(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(a.stderr, usage)
+		return errUsage
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "list":
+		if err := a.parse(a.newFlagSet("decks list"), args[1:], 0); err != nil {
+			return err
+		}
+		decks, err := c.ListDecks(ctx)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tTITLE\tLABELS")
+		for _, d := range decks {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", d.ID, d.Title, strings.Join(d.Labels, ","))
+		}
+		return tw.Flush()
+
+	case "create":
+		fs := a.newFlagSet("decks create")
+		title := fs.String("title", "", "deck title")
+		description := fs.String("description", "", "deck description")
+		labels := fs.String("labels", "", "comma separated labels")
+		exam := fs.String("exam", "", "date of an exam on the deck, YYYY-MM-DD")
+		leechThreshold := fs.Int("leech-threshold", 0, "lapses that make a card a leech, 0 for the server default")
+		suspendLeeches := fs.Bool("suspend-leeches", false, "suspend leeches instead of only tagging them")
+		preset := fs.String("preset", "", "deck preset of the study options, your default preset when empty")
+		if err := a.parse(fs, args[1:], 0); err != nil {
+			return err
+		}
+		deck := models.Deck{Title: *title, Description: *description, Labels: splitLabels(*labels), LeechThreshold: *leechThreshold}
+		if *suspendLeeches {
+			deck.LeechAction = models.LeechSuspend
+		}
+		if *exam != "" {
+			deck.ExamDate = exam
+		}
+		if *preset != "" {
+			id, err := uuid.Parse(*preset)
+			if err != nil {
+				return fmt.Errorf("%q is not a deck preset ID", *preset)
+			}
+			deck.PresetID = &id
+		}
+		created, err := c.CreateDeck(ctx, deck)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(a.stdout, created.ID)
+		return nil
+
+	case "delete":
+		fs := a.newFlagSet("decks delete")
+		if err := a.parse(fs, args[1:], 1); err != nil {
+			return err
+		}
+		id, err := parseDeckID(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		return c.DeleteDeck(ctx, id)
+	}
+
+	fmt.Fprintf(a.stderr, "flashcards: unknown decks command %q\n\n%s", args[0], usage)
+	return errUsage
+}
+
+func (a *app) presets(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(a.stderr, usage)
+		return errUsage
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "list":
+		if err := a.parse(a.newFlagSet("presets list"), args[1:], 0); err != nil {
+			return err
+		}
+		presets, err := c.DeckPresets(ctx)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tNAME\tDEFAULT\tNEW/DAY\tREVIEWS/DAY\tSTEPS\tSCHEDULER")
+		for _, p := range presets {
+			isDefault := ""
+			if p.IsDefault {
+				isDefault = "yes"
+			}
+			steps := make([]string, len(p.LearningSteps))
+			for i, step := range p.LearningSteps {
+				steps[i] = fmt.Sprintf("%dm", step)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%s\t%s\n",
+				p.ID, p.Name, isDefault, p.NewPerDay, p.ReviewsPerDay, strings.Join(steps, " "), p.Scheduler)
+		}
+		return tw.Flush()
+
+	case "create":
+		preset := models.DefaultDeckPreset()
+		fs := a.newFlagSet("presets create")
+		fs.StringVar(&preset.Name, "name", "", "preset name")
+		fs.BoolVar(&preset.IsDefault, "default", false, "use the preset for decks without one")
+		fs.IntVar(&preset.NewPerDay, "new", preset.NewPerDay, "new cards a day in each deck")
+		fs.IntVar(&preset.ReviewsPerDay, "reviews", preset.ReviewsPerDay, "reviews a day in each deck")
+		steps := fs.String("steps", "1,10", "comma separated learning steps in minutes")
+		fs.IntVar(&preset.MaximumInterval, "max-interval", preset.MaximumInterval, "maximum interval in days")
+		random := fs.Bool("random", false, "introduce new cards in random order")
+		fs.StringVar(&preset.Scheduler, "scheduler", preset.Scheduler, "sm2 or leitner")
+		if err := a.parse(fs, args[1:], 0); err != nil {
+			return err
+		}
+		preset.LearningSteps = []int{}
+		for _, step := range splitLabels(*steps) {
+			minutes, err := strconv.Atoi(step)
+			if err != nil {
+				return fmt.Errorf("%q is not a number of minutes", step)
+			}
+			preset.LearningSteps = append(preset.LearningSteps, minutes)
+		}
+		if *random {
+			preset.NewOrder = models.NewOrderRandom
+		}
+		created, err := c.CreateDeckPreset(ctx, preset)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(a.stdout, created.ID)
+		return nil
+	}
+
+	fmt.Fprintf(a.stderr, "flashcards: unknown presets command %q\n\n%s", args[0], usage)
+	return errUsage
+}
+
+func splitLabels(s string) []string {
+	labels := []string{}
+	for _, l := range strings.Split(s, ",") {
+		if l = strings.TrimSpace(l); l != "" {
+			labels = append(labels, l)
+		}
+	}
+	return labels
+}
+
+func (a *app) push(args []string) error {
+	fs := a.newFlagSet("push")
+	deckArg := fs.String("deck", "", "deck to add the cards to, a new deck when empty")
+	if err := a.parse(fs, args, 1); err != nil {
+		return err
+	}
+	path := fs.Arg(0)
+
+	deck, err := readCardFile(path)
+	if err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	var deckID uuid.UUID
+	if *deckArg != "" {
+		if deckID, err = parseDeckID(*deckArg); err != nil {
+			return err
+		}
+	} else {
+		title := deck.Title
+		if title == "" {
+			title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+		created, err := c.CreateDeck(ctx, models.Deck{Title: title, Labels: []string{}})
+		if err != nil {
+			return err
+		}
+		deckID = created.ID
+		fmt.Fprintf(a.stdout, "Created deck %s (%s)\n", title, deckID)
+	}
+
+	for i, card := range deck.Cards {
+		starred := card.Starred
+		created, err := c.CreateFlashcard(ctx, deckID, models.Flashcard{Front: card.Front, Back: card.Back, Starred: &starred})
+		if err != nil {
+			return fmt.Errorf("pushed %d of %d cards: %w", i, len(deck.Cards), err)
+		}
+		for _, d := range created.Duplicates {
+			fmt.Fprintf(a.stderr, "warning: %q duplicates %q (%s)\n", card.Front, d.Front, d.FlashcardID)
+		}
+	}
+	fmt.Fprintf(a.stdout, "Pushed %d cards to deck %s\n", len(deck.Cards), deckID)
+	return nil
+}
+
+// readCardFile reads a card file in the format given by its extension
+func readCardFile(path string) (*cardfile.Deck, error) {
+	format, err := cardfile.FormatOf(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	deck, err := cardfile.Read(f, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return deck, nil
+}
+
+func (a *app) pull(args []string) error {
+	fs := a.newFlagSet("pull")
+	format := fs.String("format", "", "csv or markdown, from the file extension when empty")
+	if err := a.parse(fs, args, 2); err != nil {
+		return err
+	}
+	id, err := parseDeckID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	path := fs.Arg(1)
+	if *format == "" {
+		if *format, err = cardfile.FormatOf(path); err != nil {
+			return err
+		}
+	}
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	doc, err := c.ExportDeck(context.Background(), id)
+	if err != nil {
+		return err
+	}
+	deck := &cardfile.Deck{Title: doc.Deck.Title}
+	for _, f := range doc.Flashcards {
+		deck.Cards = append(deck.Cards, cardfile.Card{ID: f.ID.String(), Front: f.Front, Back: f.Back, Starred: f.Starred != nil && *f.Starred})
+	}
+
+	if path == "-" {
+		return cardfile.Write(a.stdout, *format, deck)
+	}
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := cardfile.Write(out, *format, deck); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "Wrote %d cards to %s\n", len(deck.Cards), path)
+	return nil
+}
+
+func (a *app) sync(args []string) error {
+	fs := a.newFlagSet("sync")
+	dryRun := fs.Bool("dry-run", false, "show the changes without making them")
+	deleteMissing := fs.Bool("delete", false, "delete the flashcards missing from the file")
+	yes := fs.Bool("yes", false, "delete without asking")
+	if err := a.parse(fs, args, 2); err != nil {
+		return err
+	}
+	id, err := parseDeckID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	path := fs.Arg(1)
+	if format, err := cardfile.FormatOf(path); err != nil || format != cardfile.FormatMarkdown {
+		return fmt.Errorf("%s: only Markdown files can be synced", path)
+	}
+	document, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	in := models.DeckSync{Document: string(document), DryRun: *dryRun, DeleteMissing: *deleteMissing}
+	if *deleteMissing && !*dryRun && !*yes {
+		// Show what would be deleted before deleting anything
+		in.DryRun = true
+		result, err := c.SyncDeck(context.Background(), id, in)
+		if err != nil {
+			return err
+		}
+		a.printSync(path, result)
+		if len(result.Deleted) > 0 {
+			answer, _ := a.ask(fmt.Sprintf("Delete %d flashcards missing from %s? [y/N] ", len(result.Deleted), path), "n", "y")
+			if answer != "y" {
+				fmt.Fprintln(a.stdout, "Nothing synced.")
+				return nil
+			}
+		}
+		in.DryRun = false
+	}
+	result, err := c.SyncDeck(context.Background(), id, in)
+	if err != nil {
+		return err
+	}
+
+	a.printSync(path, result)
+	if len(result.Missing) > 0 {
+		fmt.Fprintf(a.stdout, "Kept %d flashcards missing from %s, sync with -delete to delete them.\n", len(result.Missing), path)
+	}
+	if result.DryRun || result.Document == string(document) {
+		return nil
+	}
+	// Keep the card comments of new cards so the next sync updates them
+	return os.WriteFile(path, []byte(result.Document), 0o644)
+}
+
+// printSync prints the counts of changes of a sync
+func (a *app) printSync(path string, result *models.DeckSyncResult) {
+	verb := "Synced"
+	if result.DryRun {
+		verb = "Would sync"
+	}
+	fmt.Fprintf(a.stdout, "%s %s: %d created, %d updated, %d deleted, %d unchanged\n",
+		verb, path, len(result.Created), len(result.Updated), len(result.Deleted), result.Unchanged)
+}
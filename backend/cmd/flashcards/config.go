@@ -0,0 +1,77 @@
+package main
+
+import (
+	"api/src/client"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// defaultServer is used until login is given another one
+const defaultServer = "http://localhost:8000"
+
+// config is what login saves
+type config struct {
+	Server string `json:"server"`
+	Token  string `json:"token"`
+}
+
+// defaultConfigPath returns FLASHCARDS_CONFIG or config.json in the user's config directory
+func defaultConfigPath() (string, error) {
+	if path := os.Getenv("FLASHCARDS_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "flashcards", "config.json"), nil
+}
+
+// loadConfig reads the saved configuration. A missing file is an empty configuration.
+func (a *app) loadConfig() (config, error) {
+	var cfg config
+	data, err := os.ReadFile(a.configPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	err = json.Unmarshal(data, &cfg)
+	return cfg, err
+}
+
+// saveConfig writes the configuration, readable only by the user since it holds the token
+func (a *app) saveConfig(cfg config) error {
+	if err := os.MkdirAll(filepath.Dir(a.configPath), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(a.configPath, append(data, '\n'), 0o600)
+}
+
+// client returns an API client from the saved configuration and the environment
+func (a *app) client() (*client.Client, error) {
+	cfg, err := a.loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	if server := a.getenv("FLASHCARDS_SERVER"); server != "" {
+		cfg.Server = server
+	}
+	if token := a.getenv("FLASHCARDS_TOKEN"); token != "" {
+		cfg.Token = token
+	}
+	if cfg.Server == "" {
+		cfg.Server = defaultServer
+	}
+	if cfg.Token == "" {
+		return nil, errors.New("not logged in, run flashcards login first")
+	}
+	return client.New(cfg.Server, cfg.Token), nil
+}
@@ -0,0 +1,158 @@
+// Command flashcards manages decks from the terminal through the flashcard API.
+//
+// Log in once with a personal access token, then list, create and delete decks,
+// push CSV or Markdown card files into decks, pull decks into files and study:
+//
+//	flashcards login -server http://localhost:8000
+//	flashcards decks list
+//	flashcards push -deck 0b7c... notes/capitals.md
+//	flashcards pull 0b7c... notes/capitals.md
+//	flashcards sync 0b7c... notes/capitals.md
+//	flashcards study 0b7c...
+//	flashcards quiz -answers 0b7c... > quiz.md
+//	flashcards play -match 0b7c...
+//
+// The server and token can also be given with FLASHCARDS_SERVER and FLASHCARDS_TOKEN.
+// See package api/src/cardfile for the file formats.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `Usage: flashcards <command> [arguments]
+
+Commands:
+  login [-server URL] [-token TOKEN]      save the server and a personal access token
+  logout                                  forget the saved token
+  decks list                              list your decks
+  decks create -title T [-description D] [-labels a,b] [-exam YYYY-MM-DD]
+               [-leech-threshold N] [-suspend-leeches] [-preset PRESET_ID]
+  decks delete DECK_ID                    delete a deck and its cards
+  presets list                            list your deck presets of study options
+  presets create -name N [-default] [-new N] [-reviews N] [-steps 1,10]
+                 [-max-interval DAYS] [-random] [-scheduler sm2|leitner]
+                                          create a deck preset, -default for the preset
+                                          of decks without one
+  push [-deck DECK_ID] FILE               add the cards of a .csv or .md file to a deck,
+                                          creating a deck when -deck is not given
+  pull [-format csv|markdown] DECK_ID FILE
+                                          write a deck to a file, - for stdout
+  sync [-dry-run] [-delete [-yes]] DECK_ID FILE
+                                          make a deck match a .md file and add card
+                                          comments for new cards to the file; -delete
+                                          deletes the cards missing from the file after
+                                          asking, unless -yes is given
+  study [-practice|-cram] [-filtered] [-type] [-shuffle] [-new N] [-limit N] DECK_ID
+                                          study the due and new cards of a deck in the
+                                          terminal, every card with -practice, or toward
+                                          the exam of the deck with -cram; -type to type
+                                          the answers, -filtered to study a filtered deck;
+                                          -new defaults to the daily limit of the deck
+  plan DECK_ID                            print the day-by-day cram plan of a deck with
+                                          an exam date
+  leeches [-unsuspend FLASHCARD_ID]       list the cards you keep forgetting, or bring a
+                                          suspended one back to study
+  stats [-days N] [-deck DECK_ID]         print your reviews, retention, cards by state and
+                                          the cards due in the next days
+  goal [-cards N|-minutes N] [-rollover HOUR]
+                                          print or set your daily goal and the hour your
+                                          study days start at
+  streak                                  print your streak of daily goals met and your
+                                          last four weeks
+  quiz [-seed N] [-questions N] [-choices N] [-answers] DECK_ID
+                                          print a multiple-choice quiz; the same seed
+                                          prints the same quiz again
+  play [-match] [-size N] [-seed N] DECK_ID
+                                          play a timed round of true/false statements,
+                                          or match fronts with backs with -match
+`
+
+// errUsage is returned for malformed command lines, after the usage has been printed
+var errUsage = errors.New("invalid usage")
+
+// app holds what commands need from the process, so they can be run in tests
+type app struct {
+	stdin      *bufio.Reader
+	stdout     io.Writer
+	stderr     io.Writer
+	configPath string
+	getenv     func(string) string
+}
+
+func main() {
+	path, err := defaultConfigPath()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "flashcards:", err)
+		os.Exit(1)
+	}
+	a := &app{
+		stdin:      bufio.NewReader(os.Stdin),
+		stdout:     os.Stdout,
+		stderr:     os.Stderr,
+		configPath: path,
+		getenv:     os.Getenv,
+	}
+	os.Exit(a.run(os.Args[1:]))
+}
+
+// run executes a command line and returns the exit status
+func (a *app) run(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(a.stderr, usage)
+		return 2
+	}
+
+	var err error
+	switch args[0] {
+	case "login":
+		err = a.login(args[1:])
+	case "logout":
+		err = a.logout()
+	case "decks":
+		err = a.decks(args[1:])
+	case "presets":
+		err = a.presets(args[1:])
+	case "push":
+		err = a.push(args[1:])
+	case "pull":
+		err = a.pull(args[1:])
+	case "sync":
+		err = a.sync(args[1:])
+	case "study":
+		err = a.study(args[1:])
+	case "plan":
+		err = a.plan(args[1:])
+	case "leeches":
+		err = a.leeches(args[1:])
+	case "stats":
+		err = a.stats(args[1:])
+	case "goal":
+		err = a.goal(args[1:])
+	case "streak":
+		err = a.streak(args[1:])
+	case "quiz":
+		err = a.quiz(args[1:])
+	case "play":
+		err = a.play(args[1:])
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(a.stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(a.stderr, "flashcards: unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+
+	if errors.Is(err, errUsage) {
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(a.stderr, "flashcards:", err)
+		return 1
+	}
+	return 0
+}
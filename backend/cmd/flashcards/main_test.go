@@ -0,0 +1,658 @@
+package main
+
+import (
+	"api/src/grading"
+	"api/src/models"
+	"api/src/quiz"
+	"api/src/scheduler"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testApp returns an app with scripted stdin, captured output and a temporary config file
+func testApp(t *testing.T, stdin string, env map[string]string) (*app, *bytes.Buffer, *bytes.Buffer) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	a := &app{
+		stdin:      bufio.NewReader(strings.NewReader(stdin)),
+		stdout:     &stdout,
+		stderr:     &stderr,
+		configPath: filepath.Join(t.TempDir(), "flashcards", "config.json"),
+		getenv:     func(key string) string { return env[key] },
+	}
+	return a, &stdout, &stderr
+}
+
+// fakeServer serves a single deck with two cards and records created flashcards
+type fakeServer struct {
+	*httptest.Server
+	deckID  uuid.UUID
+	cards   []models.Flashcard
+	created []models.Flashcard
+	synced  []models.DeckSync
+	quizzes []models.QuizRequest
+	games   []models.GameRequest
+	// sessions are the study sessions started and answers the grades given in them
+	sessions []models.StudySessionRequest
+	filtered []models.FilteredStudyRequest
+	answers  []scheduler.Grade
+	// unsuspended are the flashcards brought back to study
+	unsuspended []string
+	presets     []models.DeckPreset
+	goals       []models.StudyGoal
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	t.Helper()
+	s := &fakeServer{deckID: uuid.New()}
+	starred := true
+	s.cards = []models.Flashcard{
+		{ID: uuid.New(), ParentDeck: s.deckID, Front: "France", Back: "Paris", Starred: &starred},
+		{ID: uuid.New(), ParentDeck: s.deckID, Front: "Italy", Back: "Rome"},
+	}
+	deck := models.Deck{ID: s.deckID, Title: "Capitals", Labels: []string{"geo"}}
+	deckPath := "/api/go/decks/" + s.deckID.String()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/go/decks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]models.Deck{deck})
+	})
+	mux.HandleFunc("POST /api/go/decks", func(w http.ResponseWriter, r *http.Request) {
+		var d models.Deck
+		json.NewDecoder(r.Body).Decode(&d)
+		d.ID = s.deckID
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(d)
+	})
+	mux.HandleFunc("GET "+deckPath+"/flashcards", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(s.cards)
+	})
+	mux.HandleFunc("POST "+deckPath+"/flashcards", func(w http.ResponseWriter, r *http.Request) {
+		var f models.Flashcard
+		json.NewDecoder(r.Body).Decode(&f)
+		s.created = append(s.created, f)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(f)
+	})
+	mux.HandleFunc("GET "+deckPath+"/export", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"version": 1, "deck": deck, "flashcards": s.cards})
+	})
+
+	mux.HandleFunc("POST "+deckPath+"/sync", func(w http.ResponseWriter, r *http.Request) {
+		var req models.DeckSync
+		json.NewDecoder(r.Body).Decode(&req)
+		s.synced = append(s.synced, req)
+		result := models.DeckSyncResult{
+			DryRun:   req.DryRun,
+			Created:  []models.Flashcard{{Front: "Spain", Back: "Madrid"}},
+			Document: req.Document + "<!-- synced -->\n",
+		}
+		// France and Italy are missing from the synced files
+		if req.DeleteMissing {
+			result.Deleted = []uuid.UUID{s.cards[0].ID, s.cards[1].ID}
+		} else {
+			result.Missing = []uuid.UUID{s.cards[0].ID, s.cards[1].ID}
+		}
+		json.NewEncoder(w).Encode(result)
+	})
+
+	quizID := uuid.New()
+	mux.HandleFunc("POST "+deckPath+"/quiz", func(w http.ResponseWriter, r *http.Request) {
+		var req models.QuizRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		s.quizzes = append(s.quizzes, req)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(models.Quiz{ID: quizID, DeckID: s.deckID})
+	})
+	mux.HandleFunc("GET /api/go/quizzes/"+quizID.String(), func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("# Capitals\n\nanswers=" + r.URL.Query().Get("answers") + "\n"))
+	})
+
+	mux.HandleFunc("GET "+deckPath+"/plan", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(models.CramPlan{DeckID: s.deckID, ExamDate: "2026-06-03", DaysLeft: 2, NewPerDay: 1, Days: []models.CramPlanDay{
+			{Date: "2026-06-01", NewCards: 1},
+			{Date: "2026-06-02", NewCards: 1, Reviews: 1},
+		}})
+	})
+
+	stats := func(w http.ResponseWriter, r *http.Request) {
+		days, _ := strconv.Atoi(r.URL.Query().Get("days"))
+		week, young := 6, 20
+		json.NewEncoder(w).Encode(models.Stats{
+			Days:         days,
+			Reviews:      []models.DayStats{{Date: "2026-06-01", Reviews: 4, Correct: 3, TimeMs: 90000}},
+			TotalReviews: 4,
+			TimeSpentMs:  90000,
+			Retention: []models.RetentionStats{
+				{MinInterval: 1, MaxInterval: &week, Reviews: 4, Recalled: 3, Rate: 0.75},
+				{MinInterval: 7, MaxInterval: &young},
+				{MinInterval: 21},
+			},
+			Cards:    models.CardCounts{New: 1, Young: 1},
+			Forecast: []models.ForecastDay{{Date: "2026-06-01", Due: 1}, {Date: "2026-06-02", Due: 0}},
+		})
+	}
+	mux.HandleFunc("GET /api/go/stats", stats)
+	mux.HandleFunc("GET "+deckPath+"/stats", stats)
+
+	mux.HandleFunc("GET /api/go/goal", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(models.DefaultStudyGoal())
+	})
+	mux.HandleFunc("PUT /api/go/goal", func(w http.ResponseWriter, r *http.Request) {
+		var goal models.StudyGoal
+		json.NewDecoder(r.Body).Decode(&goal)
+		s.goals = append(s.goals, goal)
+		json.NewEncoder(w).Encode(goal)
+	})
+	mux.HandleFunc("GET /api/go/streak", func(w http.ResponseWriter, r *http.Request) {
+		heatmap := make([]models.StudyDay, models.HeatmapDays)
+		heatmap[len(heatmap)-4] = models.StudyDay{Cards: 5}
+		heatmap[len(heatmap)-3] = models.StudyDay{Cards: 20, GoalMet: true}
+		heatmap[len(heatmap)-2] = models.StudyDay{Frozen: true}
+		heatmap[len(heatmap)-1] = models.StudyDay{Cards: 25, GoalMet: true}
+		json.NewEncoder(w).Encode(models.Streak{Goal: models.DefaultStudyGoal(), Today: heatmap[len(heatmap)-1],
+			Current: 2, Longest: 9, Freezes: 1, Heatmap: heatmap})
+	})
+
+	// Game rounds are built from the cards with the real generators
+	var round models.GameRound
+	mux.HandleFunc("POST "+deckPath+"/games", func(w http.ResponseWriter, r *http.Request) {
+		var req models.GameRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		s.games = append(s.games, req)
+		if req.Kind == models.GameMatch {
+			round, _ = quiz.Match(s.cards, 1, req.Size)
+		} else {
+			round, _ = quiz.TrueFalse(s.cards, 1, req.Size)
+		}
+		round.ID, round.Seeded = uuid.New(), req.Seed != nil
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(round)
+	})
+	mux.HandleFunc("POST /api/go/game-rounds/{id}/submit", func(w http.ResponseWriter, r *http.Request) {
+		var submission models.GameSubmission
+		json.NewDecoder(r.Body).Decode(&submission)
+		result := models.GameResult{RoundID: round.ID, Kind: round.Kind, Total: len(round.Key), Key: round.Key, DurationMs: 12345}
+		result.Correct, result.Mistakes = round.Grade(submission)
+		best := int64(9000)
+		result.BestMs = &best
+		json.NewEncoder(w).Encode(result)
+	})
+
+	mux.HandleFunc("GET /api/go/deck-presets", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(s.presets)
+	})
+	mux.HandleFunc("POST /api/go/deck-presets", func(w http.ResponseWriter, r *http.Request) {
+		var p models.DeckPreset
+		json.NewDecoder(r.Body).Decode(&p)
+		p.ID = uuid.New()
+		s.presets = append(s.presets, p)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(p)
+	})
+
+	mux.HandleFunc("GET /api/go/leeches", func(w http.ResponseWriter, r *http.Request) {
+		suspended := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+		json.NewEncoder(w).Encode([]models.Leech{{Flashcard: s.cards[1], DeckTitle: deck.Title, Lapses: 9, SuspendedAt: &suspended}})
+	})
+	mux.HandleFunc("POST /api/go/flashcards/{id}/unsuspend", func(w http.ResponseWriter, r *http.Request) {
+		s.unsuspended = append(s.unsuspended, r.PathValue("id"))
+		json.NewEncoder(w).Encode(models.CardState{State: scheduler.StateReview, Lapses: 9, Leech: true})
+	})
+
+	// Study sessions go through the cards once, again answers at the end
+	sessionID := uuid.New()
+	var queue []models.Flashcard
+	mux.HandleFunc("POST /api/go/study-sessions", func(w http.ResponseWriter, r *http.Request) {
+		var req models.StudySessionRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		s.sessions = append(s.sessions, req)
+		queue = append([]models.Flashcard{}, s.cards...)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(models.StudySession{ID: sessionID, Status: models.StudySessionActive, Cards: queue})
+	})
+	mux.HandleFunc("POST /api/go/filtered-decks/{id}/study", func(w http.ResponseWriter, r *http.Request) {
+		var req models.FilteredStudyRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		s.filtered = append(s.filtered, req)
+		queue = append([]models.Flashcard{}, s.cards...)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(models.StudySession{ID: sessionID, Status: models.StudySessionActive, Cards: queue})
+	})
+	mux.HandleFunc("POST /api/go/study-sessions/"+sessionID.String()+"/answer", func(w http.ResponseWriter, r *http.Request) {
+		var answer models.StudyAnswer
+		json.NewDecoder(r.Body).Decode(&answer)
+		card := queue[0]
+		queue = queue[1:]
+		var check *grading.Result
+		if answer.Typed != nil {
+			result := grading.Check(*answer.Typed, card.Back)
+			check = &result
+			answer.Grade = result.Grade
+		}
+		s.answers = append(s.answers, answer.Grade)
+		if answer.Grade == scheduler.Again {
+			queue = append(queue, card)
+		}
+		result := models.StudyAnswerResult{Check: check, Remaining: len(queue)}
+		if len(queue) > 0 {
+			result.Next = &queue[0]
+		}
+		json.NewEncoder(w).Encode(result)
+	})
+	mux.HandleFunc("POST /api/go/study-sessions/"+sessionID.String()+"/finish", func(w http.ResponseWriter, r *http.Request) {
+		summary := models.StudySummary{Answers: len(s.answers), CardsStudied: len(s.cards), StudyTimeMs: 65000}
+		for _, g := range s.answers {
+			if g > scheduler.Again {
+				summary.Correct++
+			}
+		}
+		summary.Accuracy = float64(summary.Correct) / float64(summary.Answers)
+		summary.CardsLearned = summary.Correct
+		json.NewEncoder(w).Encode(models.StudySession{ID: sessionID, Status: models.StudySessionFinished, Summary: &summary})
+	})
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer fcp_test" {
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"title":"Unauthorized","status":401,"code":"unauthorized"}`))
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestUsage(t *testing.T) {
+	a, _, stderr := testApp(t, "", nil)
+
+	assert.Equal(t, 2, a.run(nil))
+	assert.Equal(t, 2, a.run([]string{"frobnicate"}))
+	assert.Equal(t, 2, a.run([]string{"pull", "only-one-arg"}))
+	assert.Contains(t, stderr.String(), "Usage: flashcards")
+}
+
+func TestLogin(t *testing.T) {
+	server := newFakeServer(t)
+
+	t.Run("token from stdin", func(t *testing.T) {
+		a, stdout, _ := testApp(t, "fcp_test\n", nil)
+
+		assert.Equal(t, 0, a.run([]string{"login", "-server", server.URL}))
+		assert.Contains(t, stdout.String(), "Logged in to "+server.URL)
+
+		cfg, err := a.loadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, config{Server: server.URL, Token: "fcp_test"}, cfg)
+
+		info, err := os.Stat(a.configPath)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+		assert.Equal(t, 0, a.run([]string{"logout"}))
+		cfg, err = a.loadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, config{Server: server.URL}, cfg)
+	})
+
+	t.Run("rejected token", func(t *testing.T) {
+		a, _, stderr := testApp(t, "", nil)
+
+		assert.Equal(t, 1, a.run([]string{"login", "-server", server.URL, "-token", "wrong"}))
+		assert.Contains(t, stderr.String(), "checking the token")
+		_, err := os.Stat(a.configPath)
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestNotLoggedIn(t *testing.T) {
+	a, _, stderr := testApp(t, "", nil)
+
+	assert.Equal(t, 1, a.run([]string{"decks", "list"}))
+	assert.Contains(t, stderr.String(), "not logged in")
+}
+
+func TestDecksList(t *testing.T) {
+	server := newFakeServer(t)
+	a, stdout, _ := testApp(t, "", map[string]string{"FLASHCARDS_SERVER": server.URL, "FLASHCARDS_TOKEN": "fcp_test"})
+
+	assert.Equal(t, 0, a.run([]string{"decks", "list"}))
+	assert.Contains(t, stdout.String(), server.deckID.String())
+	assert.Contains(t, stdout.String(), "Capitals")
+	assert.Contains(t, stdout.String(), "geo")
+}
+
+func TestPresets(t *testing.T) {
+	server := newFakeServer(t)
+	env := map[string]string{"FLASHCARDS_SERVER": server.URL, "FLASHCARDS_TOKEN": "fcp_test"}
+	a, stdout, _ := testApp(t, "", env)
+
+	assert.Equal(t, 0, a.run([]string{"presets", "create", "-name", "Languages", "-default", "-new", "50", "-steps", "5, 30", "-random", "-scheduler", "leitner"}))
+	require.Len(t, server.presets, 1)
+	preset := server.presets[0]
+	assert.Equal(t, preset.ID.String()+"\n", stdout.String())
+	assert.True(t, preset.IsDefault)
+	assert.Equal(t, 50, preset.NewPerDay)
+	assert.Equal(t, models.DefaultReviewLimit, preset.ReviewsPerDay)
+	assert.Equal(t, []int{5, 30}, preset.LearningSteps)
+	assert.Equal(t, models.NewOrderRandom, preset.NewOrder)
+	assert.Equal(t, scheduler.Leitner, preset.Scheduler)
+
+	a, stdout, _ = testApp(t, "", env)
+	assert.Equal(t, 0, a.run([]string{"presets", "list"}))
+	assert.Equal(t, "ID                                    NAME       DEFAULT  NEW/DAY  REVIEWS/DAY  STEPS   SCHEDULER\n"+
+		preset.ID.String()+"  Languages  yes      50       200          5m 30m  leitner\n", stdout.String())
+
+	a, _, stderr := testApp(t, "", env)
+	assert.Equal(t, 1, a.run([]string{"presets", "create", "-name", "Slow", "-steps", "5m"}))
+	assert.Contains(t, stderr.String(), "not a number of minutes")
+}
+
+func TestPush(t *testing.T) {
+	server := newFakeServer(t)
+	a, stdout, _ := testApp(t, "", map[string]string{"FLASHCARDS_SERVER": server.URL, "FLASHCARDS_TOKEN": "fcp_test"})
+
+	path := filepath.Join(t.TempDir(), "capitals.md")
+	require.NoError(t, os.WriteFile(path, []byte("# Capitals\n\n## France\n\nParis\n\n## Spain\n\nMadrid\n"), 0o644))
+
+	assert.Equal(t, 0, a.run([]string{"push", path}))
+	assert.Contains(t, stdout.String(), "Created deck Capitals")
+	assert.Contains(t, stdout.String(), "Pushed 2 cards")
+	if assert.Len(t, server.created, 2) {
+		assert.Equal(t, "Spain", server.created[1].Front)
+		assert.Equal(t, "Madrid", server.created[1].Back)
+	}
+}
+
+func TestPull(t *testing.T) {
+	server := newFakeServer(t)
+	a, stdout, _ := testApp(t, "", map[string]string{"FLASHCARDS_SERVER": server.URL, "FLASHCARDS_TOKEN": "fcp_test"})
+
+	assert.Equal(t, 0, a.run([]string{"pull", "-format", "csv", server.deckID.String(), "-"}))
+	assert.Equal(t, "front,back,starred\nFrance,Paris,true\nItaly,Rome,false\n", stdout.String())
+
+	path := filepath.Join(t.TempDir(), "capitals.md")
+	assert.Equal(t, 0, a.run([]string{"pull", server.deckID.String(), path}))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "# Capitals\n\n## France\n<!-- card: "+server.cards[0].ID.String()+" -->\n\nParis\n\n## Italy\n<!-- card: "+server.cards[1].ID.String()+" -->\n\nRome\n", string(data))
+}
+
+func TestSync(t *testing.T) {
+	server := newFakeServer(t)
+	a, stdout, stderr := testApp(t, "", map[string]string{"FLASHCARDS_SERVER": server.URL, "FLASHCARDS_TOKEN": "fcp_test"})
+
+	path := filepath.Join(t.TempDir(), "capitals.md")
+	require.NoError(t, os.WriteFile(path, []byte("Q: Spain?\nA: Madrid\n"), 0o644))
+
+	assert.Equal(t, 0, a.run([]string{"sync", "-dry-run", server.deckID.String(), path}))
+	assert.Contains(t, stdout.String(), "Would sync "+path+": 1 created, 0 updated, 0 deleted, 0 unchanged")
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Q: Spain?\nA: Madrid\n", string(data))
+
+	assert.Equal(t, 0, a.run([]string{"sync", server.deckID.String(), path}))
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Q: Spain?\nA: Madrid\n<!-- synced -->\n", string(data))
+	if assert.Len(t, server.synced, 2) {
+		assert.True(t, server.synced[0].DryRun)
+		assert.False(t, server.synced[1].DryRun)
+		assert.False(t, server.synced[1].DeleteMissing)
+	}
+	assert.Contains(t, stdout.String(), "Kept 2 flashcards missing from "+path+", sync with -delete to delete them.")
+
+	assert.Equal(t, 1, a.run([]string{"sync", server.deckID.String(), "capitals.csv"}))
+	assert.Contains(t, stderr.String(), "only Markdown files can be synced")
+}
+
+func TestSyncDelete(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capitals.md")
+	require.NoError(t, os.WriteFile(path, []byte("Q: Spain?\nA: Madrid\n"), 0o644))
+
+	t.Run("declined", func(t *testing.T) {
+		server := newFakeServer(t)
+		a, stdout, _ := testApp(t, "\n", map[string]string{"FLASHCARDS_SERVER": server.URL, "FLASHCARDS_TOKEN": "fcp_test"})
+
+		assert.Equal(t, 0, a.run([]string{"sync", "-delete", server.deckID.String(), path}))
+		assert.Contains(t, stdout.String(), "Would sync "+path+": 1 created, 0 updated, 2 deleted, 0 unchanged")
+		assert.Contains(t, stdout.String(), "Delete 2 flashcards missing from "+path+"? [y/N] Nothing synced.")
+		if assert.Len(t, server.synced, 1) {
+			assert.True(t, server.synced[0].DryRun)
+			assert.True(t, server.synced[0].DeleteMissing)
+		}
+	})
+
+	t.Run("confirmed", func(t *testing.T) {
+		server := newFakeServer(t)
+		a, stdout, _ := testApp(t, "y\n", map[string]string{"FLASHCARDS_SERVER": server.URL, "FLASHCARDS_TOKEN": "fcp_test"})
+
+		assert.Equal(t, 0, a.run([]string{"sync", "-delete", server.deckID.String(), path}))
+		assert.Contains(t, stdout.String(), "Synced "+path+": 1 created, 0 updated, 2 deleted, 0 unchanged")
+		if assert.Len(t, server.synced, 2) {
+			assert.False(t, server.synced[1].DryRun)
+			assert.True(t, server.synced[1].DeleteMissing)
+		}
+	})
+
+	t.Run("yes", func(t *testing.T) {
+		server := newFakeServer(t)
+		a, _, _ := testApp(t, "", map[string]string{"FLASHCARDS_SERVER": server.URL, "FLASHCARDS_TOKEN": "fcp_test"})
+
+		assert.Equal(t, 0, a.run([]string{"sync", "-delete", "-yes", server.deckID.String(), path}))
+		if assert.Len(t, server.synced, 1) {
+			assert.False(t, server.synced[0].DryRun)
+			assert.True(t, server.synced[0].DeleteMissing)
+		}
+	})
+}
+
+func TestStudy(t *testing.T) {
+	server := newFakeServer(t)
+	// France good by default, Italy again, then Italy easy
+	a, stdout, _ := testApp(t, "\n\n\n1\n\n4\n", map[string]string{"FLASHCARDS_SERVER": server.URL, "FLASHCARDS_TOKEN": "fcp_test"})
+
+	assert.Equal(t, 0, a.run([]string{"study", server.deckID.String()}))
+	out := stdout.String()
+	assert.Equal(t, 2, strings.Count(out, "Rome\n"))
+	assert.Equal(t, []scheduler.Grade{scheduler.Good, scheduler.Again, scheduler.Easy}, server.answers)
+	assert.Contains(t, out, "Studied 2 cards with 3 answers, 67% correct, in 1m5s.")
+	assert.Contains(t, out, "2 learned, 0 lapsed.")
+	// The daily limit of the deck applies unless -new is given
+	if assert.Len(t, server.sessions, 1) {
+		assert.Nil(t, server.sessions[0].NewLimit)
+	}
+
+	a, _, _ = testApp(t, "q\n", map[string]string{"FLASHCARDS_SERVER": server.URL, "FLASHCARDS_TOKEN": "fcp_test"})
+	assert.Equal(t, 0, a.run([]string{"study", "-new", "0", server.deckID.String()}))
+	if assert.Len(t, server.sessions, 2) && assert.NotNil(t, server.sessions[1].NewLimit) {
+		assert.Equal(t, 0, *server.sessions[1].NewLimit)
+	}
+}
+
+func TestStudyTyped(t *testing.T) {
+	server := newFakeServer(t)
+	// France right, Italy wrong then nearly right
+	a, stdout, _ := testApp(t, "paris\nMilan\nRom\n", map[string]string{"FLASHCARDS_SERVER": server.URL, "FLASHCARDS_TOKEN": "fcp_test"})
+
+	assert.Equal(t, 0, a.run([]string{"study", "-type", server.deckID.String()}))
+	out := stdout.String()
+	assert.Contains(t, out, "Correct!")
+	assert.Contains(t, out, "Wrong: Rome")
+	assert.Contains(t, out, "Almost: rom{+e+}")
+	assert.Equal(t, []scheduler.Grade{scheduler.Good, scheduler.Again, scheduler.Hard}, server.answers)
+}
+
+func TestStudyCram(t *testing.T) {
+	server := newFakeServer(t)
+	env := map[string]string{"FLASHCARDS_SERVER": server.URL, "FLASHCARDS_TOKEN": "fcp_test"}
+	a, _, _ := testApp(t, "\n\nq\n", env)
+
+	assert.Equal(t, 0, a.run([]string{"study", "-cram", server.deckID.String()}))
+	if assert.Len(t, server.sessions, 1) {
+		assert.Equal(t, models.StudyModeCram, server.sessions[0].Mode)
+	}
+
+	a, _, stderr := testApp(t, "", env)
+	assert.Equal(t, 2, a.run([]string{"study", "-cram", "-practice", server.deckID.String()}))
+	assert.Contains(t, stderr.String(), "can't be combined")
+}
+
+func TestStudyFiltered(t *testing.T) {
+	server := newFakeServer(t)
+	a, stdout, _ := testApp(t, "\n\nq\n", map[string]string{"FLASHCARDS_SERVER": server.URL, "FLASHCARDS_TOKEN": "fcp_test"})
+
+	assert.Equal(t, 0, a.run([]string{"study", "-filtered", "-practice", "-limit", "5", uuid.New().String()}))
+	assert.Empty(t, server.sessions)
+	if assert.Len(t, server.filtered, 1) {
+		assert.Equal(t, models.FilteredStudyRequest{Mode: models.StudyModePractice, Limit: 5}, server.filtered[0])
+	}
+	assert.Contains(t, stdout.String(), "Studied 2 cards with 1 answers")
+}
+
+func TestPlan(t *testing.T) {
+	server := newFakeServer(t)
+	a, stdout, _ := testApp(t, "", map[string]string{"FLASHCARDS_SERVER": server.URL, "FLASHCARDS_TOKEN": "fcp_test"})
+
+	assert.Equal(t, 0, a.run([]string{"plan", server.deckID.String()}))
+	assert.Equal(t, "Exam on 2026-06-03, 2 days left: learn 1 new cards a day.\n\n"+
+		"DATE        NEW  REVIEWS\n"+
+		"2026-06-01  1    0\n"+
+		"2026-06-02  1    1\n", stdout.String())
+}
+
+func TestStats(t *testing.T) {
+	server := newFakeServer(t)
+	env := map[string]string{"FLASHCARDS_SERVER": server.URL, "FLASHCARDS_TOKEN": "fcp_test"}
+	a, stdout, _ := testApp(t, "", env)
+
+	assert.Equal(t, 0, a.run([]string{"stats", "-days", "7"}))
+	assert.Equal(t, "Last 7 days: 4 reviews, 75% correct, 1m30s of study.\n"+
+		"Cards: 1 new, 0 learning, 1 young, 0 mature, 0 suspended.\n\n"+
+		"INTERVAL   REVIEWS  RETENTION\n"+
+		"1-6 days   4        75%\n"+
+		"7-20 days  0        0%\n"+
+		"21+ days   0        0%\n\n"+
+		"Due in the next 2 days: 1 0\n", stdout.String())
+
+	a, stdout, _ = testApp(t, "", env)
+	assert.Equal(t, 0, a.run([]string{"stats", "-deck", server.deckID.String()}))
+	assert.Contains(t, stdout.String(), "Last 30 days")
+
+	a, _, stderr := testApp(t, "", env)
+	assert.Equal(t, 1, a.run([]string{"stats", "-deck", "capitals"}))
+	assert.Contains(t, stderr.String(), "not a deck ID")
+}
+
+func TestGoal(t *testing.T) {
+	server := newFakeServer(t)
+	env := map[string]string{"FLASHCARDS_SERVER": server.URL, "FLASHCARDS_TOKEN": "fcp_test"}
+	a, stdout, _ := testApp(t, "", env)
+
+	assert.Equal(t, 0, a.run([]string{"goal"}))
+	assert.Equal(t, "Daily goal: 20 cards, days start at 04:00.\n", stdout.String())
+	assert.Empty(t, server.goals)
+
+	a, stdout, _ = testApp(t, "", env)
+	assert.Equal(t, 0, a.run([]string{"goal", "-minutes", "15", "-rollover", "0"}))
+	assert.Equal(t, []models.StudyGoal{{Unit: models.GoalMinutes, Target: 15}}, server.goals)
+	assert.Equal(t, "Daily goal: 15 minutes, days start at 00:00.\n", stdout.String())
+
+	a, _, stderr := testApp(t, "", env)
+	assert.Equal(t, 1, a.run([]string{"goal", "-minutes", "15", "-cards", "10"}))
+	assert.Contains(t, stderr.String(), "can't be used together")
+}
+
+func TestStreak(t *testing.T) {
+	server := newFakeServer(t)
+	a, stdout, _ := testApp(t, "", map[string]string{"FLASHCARDS_SERVER": server.URL, "FLASHCARDS_TOKEN": "fcp_test"})
+
+	assert.Equal(t, 0, a.run([]string{"streak"}))
+	assert.Equal(t, "Current streak: 2 days, longest 9 days, 1 freezes held.\n"+
+		"Today: 25/20 cards.\n"+
+		"Last 4 weeks: ........................+#*#\n", stdout.String())
+}
+
+func TestLeeches(t *testing.T) {
+	server := newFakeServer(t)
+	env := map[string]string{"FLASHCARDS_SERVER": server.URL, "FLASHCARDS_TOKEN": "fcp_test"}
+	a, stdout, _ := testApp(t, "", env)
+
+	assert.Equal(t, 0, a.run([]string{"leeches"}))
+	assert.Equal(t, "ID                                    DECK      LAPSES  SUSPENDED  FRONT\n"+
+		server.cards[1].ID.String()+"  Capitals  9       yes        Italy\n", stdout.String())
+
+	a, _, _ = testApp(t, "", env)
+	assert.Equal(t, 0, a.run([]string{"leeches", "-unsuspend", server.cards[1].ID.String()}))
+	assert.Equal(t, []string{server.cards[1].ID.String()}, server.unsuspended)
+
+	a, _, stderr := testApp(t, "", env)
+	assert.Equal(t, 1, a.run([]string{"leeches", "-unsuspend", "italy"}))
+	assert.Contains(t, stderr.String(), "not a flashcard ID")
+}
+
+func TestQuiz(t *testing.T) {
+	server := newFakeServer(t)
+	a, stdout, _ := testApp(t, "", map[string]string{"FLASHCARDS_SERVER": server.URL, "FLASHCARDS_TOKEN": "fcp_test"})
+
+	assert.Equal(t, 0, a.run([]string{"quiz", "-seed", "0", "-answers", server.deckID.String()}))
+	assert.Equal(t, "# Capitals\n\nanswers=true\n", stdout.String())
+	assert.Equal(t, 0, a.run([]string{"quiz", "-choices", "3", server.deckID.String()}))
+
+	if assert.Len(t, server.quizzes, 2) {
+		if assert.NotNil(t, server.quizzes[0].Seed) {
+			assert.Equal(t, int64(0), *server.quizzes[0].Seed)
+		}
+		assert.Nil(t, server.quizzes[1].Seed)
+		assert.Equal(t, 3, server.quizzes[1].Choices)
+	}
+}
+
+func TestPlay(t *testing.T) {
+	server := newFakeServer(t)
+	env := map[string]string{"FLASHCARDS_SERVER": server.URL, "FLASHCARDS_TOKEN": "fcp_test"}
+
+	// The fake server builds the same round: answer with the letter of every back
+	round, _ := quiz.Match(server.cards, 1, 0)
+	var input strings.Builder
+	for _, back := range round.Key {
+		input.WriteString(string(rune('a'+back)) + "\n")
+	}
+	a, stdout, _ := testApp(t, input.String(), env)
+	assert.Equal(t, 0, a.run([]string{"play", "-match", "-seed", "5", server.deckID.String()}))
+	assert.Contains(t, stdout.String(), "2/2 correct in 12.3s.\nRounds played again with -seed don't count towards best times.\n")
+
+	// Answering true to every statement gets the false ones wrong
+	a, stdout, _ = testApp(t, "t\nt\n", env)
+	assert.Equal(t, 0, a.run([]string{"play", server.deckID.String()}))
+	tf, _ := quiz.TrueFalse(server.cards, 1, 0)
+	correct := 0
+	for _, k := range tf.Key {
+		correct += k
+	}
+	assert.Contains(t, stdout.String(), fmt.Sprintf("%d/2 correct", correct))
+	assert.Contains(t, stdout.String(), "Best time: 9.0s.\n")
+
+	if assert.Len(t, server.games, 2) {
+		assert.Equal(t, models.GameMatch, server.games[0].Kind)
+		assert.Equal(t, int64(5), *server.games[0].Seed)
+		assert.Equal(t, models.GameTrueFalse, server.games[1].Kind)
+		assert.Nil(t, server.games[1].Seed)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"api/src/apierror"
+	"api/src/client"
+	"api/src/models"
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+)
+
+// play times a round of a quick game on a deck: true/false statements by
+// default, or matching fronts with backs with -match. The server keeps the
+// clock and the best times.
+func (a *app) play(args []string) error {
+	fs := a.newFlagSet("play")
+	match := fs.Bool("match", false, "match fronts with backs instead of answering true/false statements")
+	size := fs.Int("size", 0, "number of pairs or statements, 0 for the default")
+	seed := fs.Int64("seed", 0, "play the round of this seed again, without counting towards best times")
+	if err := a.parse(fs, args, 1); err != nil {
+		return err
+	}
+	id, err := parseDeckID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	req := models.GameRequest{Kind: models.GameTrueFalse, Size: *size}
+	if *match {
+		req.Kind = models.GameMatch
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			req.Seed = seed
+		}
+	})
+	ctx := context.Background()
+	round, err := c.StartGame(ctx, id, req)
+	if client.ErrorCode(err) == apierror.CodeConflict {
+		fmt.Fprintln(a.stdout, "Not enough cards to play with this deck.")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var submission models.GameSubmission
+	if round.Kind == models.GameMatch {
+		submission.Matches, err = a.playMatch(round)
+	} else {
+		submission.Answers, err = a.playTrueFalse(round)
+	}
+	if err != nil {
+		return err
+	}
+
+	result, err := c.SubmitGame(ctx, round.ID, submission)
+	if err != nil {
+		return err
+	}
+	for _, i := range result.Mistakes {
+		if round.Kind == models.GameMatch {
+			fmt.Fprintf(a.stdout, "Wrong: %s is %s\n", round.Fronts[i], round.Backs[result.Key[i]])
+		} else {
+			s := round.Statements[i]
+			fmt.Fprintf(a.stdout, "Wrong: %s / %s is %t\n", s.Front, s.Back, result.Key[i] == 1)
+		}
+	}
+	fmt.Fprintf(a.stdout, "\n%d/%d correct in %s.\n", result.Correct, result.Total, formatMs(result.DurationMs))
+	switch {
+	case round.Seeded:
+		fmt.Fprintln(a.stdout, "Rounds played again with -seed don't count towards best times.")
+	case result.NewBest:
+		fmt.Fprintln(a.stdout, "New best time!")
+	case result.BestMs != nil:
+		fmt.Fprintf(a.stdout, "Best time: %s.\n", formatMs(*result.BestMs))
+	}
+	return nil
+}
+
+// playMatch shows the lettered backs and asks for the letter of the back of
+// every front. A letter can only be picked once.
+func (a *app) playMatch(round *models.GameRound) ([]int, error) {
+	fmt.Fprintln(a.stdout)
+	for i, back := range round.Backs {
+		fmt.Fprintf(a.stdout, "  %c) %s\n", 'a'+i, back)
+	}
+	used := map[int]bool{}
+	matches := make([]int, len(round.Fronts))
+	for i, front := range round.Fronts {
+		var letters []string
+		for j := range round.Backs {
+			if !used[j] {
+				letters = append(letters, string(rune('a'+j)))
+			}
+		}
+		choice, ok := a.ask(fmt.Sprintf("\n%s: ", front), letters...)
+		if !ok {
+			return nil, fmt.Errorf("round left unfinished")
+		}
+		matches[i] = int(choice[0] - 'a')
+		used[matches[i]] = true
+	}
+	return matches, nil
+}
+
+// playTrueFalse asks whether every statement is true
+func (a *app) playTrueFalse(round *models.GameRound) ([]bool, error) {
+	answers := make([]bool, len(round.Statements))
+	for i, s := range round.Statements {
+		fmt.Fprintf(a.stdout, "\n[%d/%d] %s\n        %s\n", i+1, len(round.Statements), s.Front, s.Back)
+		choice, ok := a.ask("[t]rue or [f]alse: ", "t", "f")
+		if !ok {
+			return nil, fmt.Errorf("round left unfinished")
+		}
+		answers[i] = choice == "t"
+	}
+	return answers, nil
+}
+
+// formatMs formats a duration in milliseconds to the tenth of a second
+func formatMs(ms int64) string {
+	return strconv.FormatFloat(float64(ms)/1000, 'f', 1, 64) + "s"
+}
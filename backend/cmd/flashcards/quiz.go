@@ -0,0 +1,40 @@
+package main
+
+import (
+	"api/src/models"
+	"context"
+	"flag"
+)
+
+// quiz generates a multiple-choice quiz from a deck and prints it as Markdown
+func (a *app) quiz(args []string) error {
+	fs := a.newFlagSet("quiz")
+	seed := fs.Int64("seed", 0, "generate the quiz of this seed again, random when not given")
+	questions := fs.Int("questions", 0, "maximum number of questions, 0 for one per card")
+	choices := fs.Int("choices", models.DefaultQuizChoices, "number of choices per question")
+	answers := fs.Bool("answers", false, "print the answer key after the questions")
+	if err := a.parse(fs, args, 1); err != nil {
+		return err
+	}
+	id, err := parseDeckID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	req := models.QuizRequest{Questions: *questions, Choices: *choices}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			req.Seed = seed
+		}
+	})
+	ctx := context.Background()
+	q, err := c.CreateQuiz(ctx, id, req)
+	if err != nil {
+		return err
+	}
+	return c.PrintQuiz(ctx, q.ID, *answers, a.stdout)
+}
@@ -0,0 +1,406 @@
+package main
+
+import (
+	"api/src/apierror"
+	"api/src/client"
+	"api/src/models"
+	"api/src/scheduler"
+	"api/src/textdiff"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// study goes through a study session of a deck, one card at a time. The
+// server picks the cards and reschedules them from the grades given, or
+// grades typed answers with -type; cards answered Again come back at the end
+// of the session. -cram paces the cards to the exam date of the deck, and
+// -filtered studies the cards of a filtered deck instead.
+func (a *app) study(args []string) error {
+	fs := a.newFlagSet("study")
+	shuffle := fs.Bool("shuffle", false, "study the cards in random order")
+	practice := fs.Bool("practice", false, "study every card without changing when cards are due")
+	cram := fs.Bool("cram", false, "cram for the exam of the deck, weak and starred cards first")
+	newLimit := fs.Int("new", 0, "maximum number of new cards, the daily limit of the deck when not given")
+	limit := fs.Int("limit", 0, "maximum number of cards, 0 for no limit")
+	typed := fs.Bool("type", false, "type the answers and let the server grade them")
+	filtered := fs.Bool("filtered", false, "DECK_ID is a filtered deck")
+	if err := a.parse(fs, args, 1); err != nil {
+		return err
+	}
+	if *cram && (*practice || *filtered) {
+		fmt.Fprintln(a.stderr, "flashcards: -cram can't be combined with -practice or -filtered")
+		return errUsage
+	}
+	id, err := parseDeckID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	var session *models.StudySession
+	if *filtered {
+		req := models.FilteredStudyRequest{Limit: *limit, Shuffle: *shuffle}
+		if *practice {
+			req.Mode = models.StudyModePractice
+		}
+		session, err = c.StudyFilteredDeck(ctx, id, req)
+	} else {
+		req := models.StudySessionRequest{DeckIDs: []uuid.UUID{id}, Limit: *limit, Shuffle: *shuffle}
+		fs.Visit(func(f *flag.Flag) {
+			if f.Name == "new" {
+				req.NewLimit = newLimit
+			}
+		})
+		switch {
+		case *practice:
+			req.Mode = models.StudyModePractice
+		case *cram:
+			req.Mode = models.StudyModeCram
+		}
+		session, err = c.StartStudySession(ctx, req)
+	}
+	// Cram sessions also conflict on decks without an exam, the server tells which
+	if client.ErrorCode(err) == apierror.CodeConflict && !*cram {
+		fmt.Fprintln(a.stdout, "Nothing to study in this deck.")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var next *models.Flashcard
+	if len(session.Cards) > 0 {
+		next = &session.Cards[0]
+	}
+	left := len(session.Cards)
+	for next != nil {
+		card := *next
+		shown := time.Now()
+		fmt.Fprintf(a.stdout, "\n[%d left] %s\n", left, card.Front)
+		answer := models.StudyAnswer{FlashcardID: card.ID}
+		if *typed {
+			fmt.Fprint(a.stdout, "Answer (empty line to quit): ")
+			line, _ := a.stdin.ReadString('\n')
+			line = strings.TrimSpace(line)
+			if line == "" {
+				break
+			}
+			answer.Typed = &line
+		} else {
+			if !a.prompt("Press Enter to show the answer, q to quit: ") {
+				break
+			}
+			fmt.Fprintf(a.stdout, "%s\n", card.Back)
+
+			// Enter picks the first choice, Good
+			choice, ok := a.ask("[1] again, [2] hard, [3] good, [4] easy, [q]uit: ", "3", "1", "2", "4", "q")
+			if !ok || choice == "q" {
+				break
+			}
+			grade, _ := strconv.Atoi(choice)
+			answer.Grade = scheduler.Grade(grade)
+		}
+		answer.DurationMs = int(time.Since(shown).Milliseconds())
+
+		result, err := c.AnswerStudyCard(ctx, session.ID, answer)
+		if err != nil {
+			return err
+		}
+		if check := result.Check; check != nil {
+			switch {
+			case check.Correct && check.Distance == 0:
+				fmt.Fprintln(a.stdout, "Correct!")
+			case check.Correct:
+				fmt.Fprintf(a.stdout, "Almost: %s\n", renderDiff(check.Diff))
+			default:
+				fmt.Fprintf(a.stdout, "Wrong: %s\n", card.Back)
+			}
+		}
+		next, left = result.Next, result.Remaining
+	}
+
+	session, err = c.FinishStudySession(ctx, session.ID)
+	if err != nil {
+		return err
+	}
+	sum := session.Summary
+	fmt.Fprintf(a.stdout, "\nStudied %d cards with %d answers, %.0f%% correct, in %s.\n",
+		sum.CardsStudied, sum.Answers, sum.Accuracy*100, (time.Duration(sum.StudyTimeMs) * time.Millisecond).Round(time.Second))
+	if !*practice {
+		fmt.Fprintf(a.stdout, "%d learned, %d lapsed.\n", sum.CardsLearned, sum.CardsLapsed)
+	}
+	return nil
+}
+
+// plan prints the day-by-day cram plan of a deck with an upcoming exam
+func (a *app) plan(args []string) error {
+	fs := a.newFlagSet("plan")
+	if err := a.parse(fs, args, 1); err != nil {
+		return err
+	}
+	id, err := parseDeckID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	plan, err := c.DeckPlan(context.Background(), id)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "Exam on %s, %d days left: learn %d new cards a day.\n\n", plan.ExamDate, plan.DaysLeft, plan.NewPerDay)
+	tw := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "DATE\tNEW\tREVIEWS")
+	for _, d := range plan.Days {
+		fmt.Fprintf(tw, "%s\t%d\t%d\n", d.Date, d.NewCards, d.Reviews)
+	}
+	return tw.Flush()
+}
+
+// stats prints the learning statistics of the user, or of a deck with -deck
+func (a *app) stats(args []string) error {
+	fs := a.newFlagSet("stats")
+	days := fs.Int("days", models.DefaultStatsDays, "days of review history")
+	deck := fs.String("deck", "", "print the statistics of this deck")
+	if err := a.parse(fs, args, 0); err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	var stats *models.Stats
+	if *deck != "" {
+		id, err := parseDeckID(*deck)
+		if err != nil {
+			return err
+		}
+		stats, err = c.DeckStats(ctx, id, *days)
+		if err != nil {
+			return err
+		}
+	} else if stats, err = c.Stats(ctx, *days); err != nil {
+		return err
+	}
+
+	correct := 0
+	for _, d := range stats.Reviews {
+		correct += d.Correct
+	}
+	spent := (time.Duration(stats.TimeSpentMs) * time.Millisecond).Round(time.Second)
+	fmt.Fprintf(a.stdout, "Last %d days: %d reviews, %.0f%% correct, %s of study.\n", stats.Days, stats.TotalReviews,
+		100*models.SuccessRate(correct, stats.TotalReviews), spent)
+	fmt.Fprintf(a.stdout, "Cards: %d new, %d learning, %d young, %d mature, %d suspended.\n\n",
+		stats.Cards.New, stats.Cards.Learning, stats.Cards.Young, stats.Cards.Mature, stats.Cards.Suspended)
+
+	tw := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "INTERVAL\tREVIEWS\tRETENTION")
+	for _, r := range stats.Retention {
+		interval := fmt.Sprintf("%d+ days", r.MinInterval)
+		if r.MaxInterval != nil {
+			interval = fmt.Sprintf("%d-%d days", r.MinInterval, *r.MaxInterval)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%.0f%%\n", interval, r.Reviews, 100*r.Rate)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	due := make([]string, 0, 7)
+	for _, d := range stats.Forecast[:min(7, len(stats.Forecast))] {
+		due = append(due, strconv.Itoa(d.Due))
+	}
+	fmt.Fprintf(a.stdout, "\nDue in the next %d days: %s\n", len(due), strings.Join(due, " "))
+	return nil
+}
+
+// goal prints the daily goal of the user, or sets it with -cards or -minutes
+// and -rollover
+func (a *app) goal(args []string) error {
+	fs := a.newFlagSet("goal")
+	cards := fs.Int("cards", 0, "answer this many cards a day")
+	minutes := fs.Int("minutes", 0, "study this many minutes a day")
+	rollover := fs.Int("rollover", 0, "hour at which a new study day starts")
+	if err := a.parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *cards != 0 && *minutes != 0 {
+		return errors.New("-cards and -minutes can't be used together")
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	goal, err := c.StudyGoal(ctx)
+	if err != nil {
+		return err
+	}
+	changed := false
+	fs.Visit(func(f *flag.Flag) {
+		changed = true
+		switch f.Name {
+		case "cards":
+			goal.Unit, goal.Target = models.GoalCards, *cards
+		case "minutes":
+			goal.Unit, goal.Target = models.GoalMinutes, *minutes
+		case "rollover":
+			goal.DayRollover = *rollover
+		}
+	})
+	if changed {
+		if goal, err = c.UpdateStudyGoal(ctx, *goal); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(a.stdout, "Daily goal: %d %s, days start at %02d:00.\n", goal.Target, goal.Unit, goal.DayRollover)
+	return nil
+}
+
+// streak prints the study streak of the user and their last four weeks
+func (a *app) streak(args []string) error {
+	fs := a.newFlagSet("streak")
+	if err := a.parse(fs, args, 0); err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	s, err := c.Streak(context.Background())
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "Current streak: %d days, longest %d days, %d freezes held.\n", s.Current, s.Longest, s.Freezes)
+	progress := s.Today.Cards
+	if s.Goal.Unit == models.GoalMinutes {
+		progress = int(s.Today.TimeMs / 60000)
+	}
+	fmt.Fprintf(a.stdout, "Today: %d/%d %s.\n", progress, s.Goal.Target, s.Goal.Unit)
+
+	// # goal met, * frozen, + studied, . not studied
+	var last strings.Builder
+	for _, d := range s.Heatmap[max(0, len(s.Heatmap)-28):] {
+		switch {
+		case d.GoalMet:
+			last.WriteByte('#')
+		case d.Frozen:
+			last.WriteByte('*')
+		case d.Cards > 0:
+			last.WriteByte('+')
+		default:
+			last.WriteByte('.')
+		}
+	}
+	fmt.Fprintf(a.stdout, "Last 4 weeks: %s\n", last.String())
+	return nil
+}
+
+// leeches lists the cards the user keeps forgetting, across decks, with
+// -unsuspend to bring one back to study
+func (a *app) leeches(args []string) error {
+	fs := a.newFlagSet("leeches")
+	unsuspend := fs.String("unsuspend", "", "unsuspend the leech with this flashcard ID")
+	if err := a.parse(fs, args, 0); err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	if *unsuspend != "" {
+		id, err := uuid.Parse(*unsuspend)
+		if err != nil {
+			return fmt.Errorf("%q is not a flashcard ID", *unsuspend)
+		}
+		_, err = c.UnsuspendFlashcard(ctx, id)
+		return err
+	}
+
+	leeches, err := c.Leeches(ctx)
+	if err != nil {
+		return err
+	}
+	if len(leeches) == 0 {
+		fmt.Fprintln(a.stdout, "No leeches.")
+		return nil
+	}
+	tw := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tDECK\tLAPSES\tSUSPENDED\tFRONT")
+	for _, l := range leeches {
+		suspended := "no"
+		if l.SuspendedAt != nil {
+			suspended = "yes"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n", l.Flashcard.ID, l.DeckTitle, l.Lapses, suspended, l.Flashcard.Front)
+	}
+	return tw.Flush()
+}
+
+// renderDiff shows the corrections of a typed answer: [-removed-]{+added+}
+func renderDiff(segments []textdiff.Segment) string {
+	var b strings.Builder
+	for _, seg := range segments {
+		switch seg.Op {
+		case textdiff.Delete:
+			b.WriteString("[-" + seg.Text + "-]")
+		case textdiff.Insert:
+			b.WriteString("{+" + seg.Text + "+}")
+		default:
+			b.WriteString(seg.Text)
+		}
+	}
+	return b.String()
+}
+
+// prompt waits for a line and reports false on q or end of input
+func (a *app) prompt(text string) bool {
+	fmt.Fprint(a.stdout, text)
+	line, err := a.stdin.ReadString('\n')
+	if err != nil && line == "" {
+		return false
+	}
+	return strings.TrimSpace(strings.ToLower(line)) != "q"
+}
+
+// ask reads lines until one is among choices; an empty line picks the first choice.
+// It reports false at the end of input.
+func (a *app) ask(text string, choices ...string) (string, bool) {
+	for {
+		fmt.Fprint(a.stdout, text)
+		line, err := a.stdin.ReadString('\n')
+		if err != nil && line == "" {
+			return "", false
+		}
+		answer := strings.TrimSpace(strings.ToLower(line))
+		if answer == "" {
+			return choices[0], true
+		}
+		for _, c := range choices {
+			if answer == c {
+				return c, true
+			}
+		}
+	}
+}
@@ -2,17 +2,69 @@
 CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
 
 -- Drop existing tables to ensure clean state
+DROP TABLE IF EXISTS study_goals;
+DROP TABLE IF EXISTS filtered_decks;
+DROP TABLE IF EXISTS game_rounds;
+DROP TABLE IF EXISTS quiz_attempts;
+DROP TABLE IF EXISTS quizzes;
+DROP TABLE IF EXISTS review_logs;
+DROP TABLE IF EXISTS study_sessions;
+DROP TABLE IF EXISTS card_states;
+DROP TABLE IF EXISTS flashcard_revisions;
+DROP TABLE IF EXISTS deck_revisions;
 DROP TABLE IF EXISTS flashcards;
+DROP TABLE IF EXISTS personal_access_tokens;
+DROP TABLE IF EXISTS export_jobs;
 DROP TABLE IF EXISTS decks;
+DROP TABLE IF EXISTS deck_presets;
 DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS webhook_events;
+DROP TABLE IF EXISTS audit_log;
 
 -- Create users table
 CREATE TABLE users (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     clerk_id TEXT UNIQUE NOT NULL,  -- Clerk's user ID
     name TEXT NOT NULL,
-    email TEXT UNIQUE NOT NULL
-); 
+    email TEXT UNIQUE NOT NULL,
+    role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'admin')), -- Promote the first admin with UPDATE users SET role = 'admin'
+    suspended_at TIMESTAMPTZ, -- Suspended users are rejected on every authenticated route
+    deletion_requested_at TIMESTAMPTZ,
+    deletion_scheduled_for TIMESTAMPTZ, -- The account is locked until then and purged after
+    timezone TEXT NOT NULL DEFAULT 'UTC' -- IANA name; study days and streaks follow it
+);
+
+-- Create the 'study_goals' table
+-- Daily goal of a user; users without a row have the default goal
+CREATE TABLE study_goals (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    unit TEXT NOT NULL CHECK (unit IN ('cards', 'minutes')),
+    target INTEGER NOT NULL CHECK (target > 0),
+    day_rollover INTEGER NOT NULL CHECK (day_rollover BETWEEN 0 AND 23), -- Local hour at which a new study day starts
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create the 'deck_presets' table
+-- Study options shared by decks; decks without a preset use the default preset of their owner
+CREATE TABLE deck_presets (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    new_per_day INTEGER NOT NULL CHECK (new_per_day >= 0), -- Daily limits per deck
+    reviews_per_day INTEGER NOT NULL CHECK (reviews_per_day >= 0),
+    learning_steps INTEGER[] NOT NULL, -- Minutes between the first answers to a new card
+    graduating_interval INTEGER NOT NULL CHECK (graduating_interval > 0), -- Days
+    maximum_interval INTEGER NOT NULL CHECK (maximum_interval >= graduating_interval),
+    new_order TEXT NOT NULL CHECK (new_order IN ('sequential', 'random')),
+    show_timer BOOLEAN NOT NULL,
+    max_answer_seconds INTEGER NOT NULL CHECK (max_answer_seconds > 0), -- Caps the time recorded for an answer
+    scheduler TEXT NOT NULL CHECK (scheduler IN ('sm2', 'leitner')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- A user has at most one default preset
+CREATE UNIQUE INDEX deck_presets_default_idx ON deck_presets (owner_id) WHERE is_default;
 
 -- Create the 'decks' table
 CREATE TABLE decks (
@@ -20,7 +72,11 @@ CREATE TABLE decks (
     owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- Foreign key to the 'users' table
     labels TEXT[], -- An array of text for labels (e.g. ["math", "science"])
     title TEXT NOT NULL,
-    description TEXT
+    description TEXT,
+    exam_date DATE, -- Cram sessions pace the cards up to this date, normal scheduling resumes after it
+    leech_threshold INTEGER NOT NULL DEFAULT 8 CHECK (leech_threshold > 0), -- Lapses that make a card a leech
+    leech_action TEXT NOT NULL DEFAULT 'tag' CHECK (leech_action IN ('tag', 'suspend')), -- Whether leeches are also suspended
+    preset_id UUID REFERENCES deck_presets(id) ON DELETE SET NULL -- NULL for the default preset of the owner
 );
 
 -- Create the 'flashcards' table
@@ -32,5 +88,202 @@ CREATE TABLE flashcards (
     back TEXT NOT NULL
 );
 
+-- Create the 'flashcard_revisions' table
+-- Each row is the content a flashcard had before an edit replaced it
+CREATE TABLE flashcard_revisions (
+    flashcard_id UUID NOT NULL REFERENCES flashcards(id) ON DELETE CASCADE,
+    rev INTEGER NOT NULL, -- 1-based, increasing per flashcard
+    starred BOOLEAN NOT NULL,
+    front TEXT NOT NULL,
+    back TEXT NOT NULL,
+    edited_by UUID REFERENCES users(id) ON DELETE SET NULL, -- Who made the edit that replaced this revision
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (flashcard_id, rev)
+);
+
+-- Create the 'deck_revisions' table
+-- Each row is the content a deck had before an edit replaced it
+CREATE TABLE deck_revisions (
+    deck_id UUID NOT NULL REFERENCES decks(id) ON DELETE CASCADE,
+    rev INTEGER NOT NULL, -- 1-based, increasing per deck
+    labels TEXT[],
+    title TEXT NOT NULL,
+    description TEXT,
+    edited_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (deck_id, rev)
+);
+
+-- Create the 'card_states' table
+-- Scheduling state of a flashcard; flashcards without a row have never been studied
+CREATE TABLE card_states (
+    flashcard_id UUID PRIMARY KEY REFERENCES flashcards(id) ON DELETE CASCADE,
+    state TEXT NOT NULL DEFAULT 'new' CHECK (state IN ('new', 'learning', 'review', 'relearning')),
+    step INTEGER NOT NULL DEFAULT 0, -- Current learning or relearning step
+    ease DOUBLE PRECISION NOT NULL DEFAULT 2.5,
+    interval_days INTEGER NOT NULL DEFAULT 0,
+    due_at TIMESTAMPTZ,
+    reps INTEGER NOT NULL DEFAULT 0,
+    lapses INTEGER NOT NULL DEFAULT 0, -- Times the card was forgotten once in review
+    last_reviewed_at TIMESTAMPTZ,
+    leech BOOLEAN NOT NULL DEFAULT FALSE, -- Set once the lapses reach the leech threshold of the deck
+    suspended_at TIMESTAMPTZ, -- Suspended cards are left out of study until unsuspended
+    buried_until TIMESTAMPTZ -- Buried cards are left out of study until then
+);
+
+CREATE INDEX card_states_due_at_idx ON card_states (due_at);
+CREATE INDEX card_states_leech_idx ON card_states (flashcard_id) WHERE leech;
+
+-- Create the 'study_sessions' table
+CREATE TABLE study_sessions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    deck_ids UUID[] NOT NULL,
+    mode TEXT NOT NULL CHECK (mode IN ('scheduled', 'practice', 'cram')),
+    new_limit INTEGER NOT NULL,
+    review_limit INTEGER NOT NULL,
+    card_limit INTEGER NOT NULL DEFAULT 0, -- 0 means no limit
+    card_ids UUID[] NOT NULL, -- The card sequence; cards answered Again or still learning are appended
+    position INTEGER NOT NULL DEFAULT 0, -- Cards of the sequence already answered or skipped
+    status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'finished', 'abandoned')),
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_activity_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX study_sessions_active_idx ON study_sessions (last_activity_at) WHERE status = 'active';
+
+-- Create the 'review_logs' table
+-- Every answer given to a card, the basis of statistics
+CREATE TABLE review_logs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    flashcard_id UUID NOT NULL REFERENCES flashcards(id) ON DELETE CASCADE,
+    session_id UUID REFERENCES study_sessions(id) ON DELETE SET NULL,
+    grade INTEGER NOT NULL CHECK (grade BETWEEN 1 AND 4), -- 1 again, 2 hard, 3 good, 4 easy
+    typed_answer TEXT, -- The answer typed by the learner, graded by the server
+    scheduled BOOLEAN NOT NULL, -- FALSE for practice answers that didn't change the schedule
+    state_before TEXT NOT NULL,
+    state_after TEXT NOT NULL,
+    interval_before INTEGER NOT NULL,
+    interval_after INTEGER NOT NULL,
+    ease DOUBLE PRECISION NOT NULL,
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    reviewed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX review_logs_user_id_idx ON review_logs (user_id, reviewed_at);
+CREATE INDEX review_logs_flashcard_id_idx ON review_logs (flashcard_id);
+CREATE INDEX review_logs_session_id_idx ON review_logs (session_id);
+
+-- Create the 'quizzes' table
+-- A generated multiple-choice quiz, kept so submissions are graded against the answers it was generated with
+CREATE TABLE quizzes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    deck_id UUID NOT NULL REFERENCES decks(id) ON DELETE CASCADE,
+    seed BIGINT NOT NULL, -- Generating again with the same seed on an unchanged deck gives the same quiz
+    choices INTEGER NOT NULL,
+    questions JSONB NOT NULL, -- Prompts, choices and the index of the correct choice
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX quizzes_deck_id_idx ON quizzes (deck_id);
+
+-- Create the 'quiz_attempts' table
+CREATE TABLE quiz_attempts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    quiz_id UUID NOT NULL REFERENCES quizzes(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    answers INTEGER[] NOT NULL, -- Index of the choice picked for every question, -1 when unanswered
+    score INTEGER NOT NULL,
+    total INTEGER NOT NULL,
+    submitted_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX quiz_attempts_quiz_id_idx ON quiz_attempts (quiz_id);
+
+-- Create the 'game_rounds' table
+-- A round of the match or true/false game, timed by the server from its start to its submission
+CREATE TABLE game_rounds (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    deck_id UUID NOT NULL REFERENCES decks(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('match', 'true_false')),
+    seed BIGINT NOT NULL,
+    seeded BOOLEAN NOT NULL DEFAULT FALSE, -- The seed came from the client, so the round can be replayed and doesn't count towards best times
+    items JSONB NOT NULL, -- Fronts and backs of a match round, statements of a true/false round
+    answer_key INTEGER[] NOT NULL, -- Index of the back of every front, or 1 for true statements and 0 for false ones
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    submitted_at TIMESTAMPTZ,
+    correct INTEGER,
+    total INTEGER NOT NULL,
+    duration_ms BIGINT -- Set on submission
+);
+
+-- Best times are the fastest submitted rounds without mistakes and without a seed from the client
+CREATE INDEX game_rounds_best_idx ON game_rounds (user_id, deck_id, kind, duration_ms) WHERE correct = total AND NOT seeded;
+
+-- Create the 'filtered_decks' table
+-- Virtual decks gathering the flashcards matching a filter across the decks of a user; the cards stay in their decks
+CREATE TABLE filtered_decks (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    filter JSONB NOT NULL, -- Starred, deck labels, due within N days, lapses, failed today, text search
+    card_ids UUID[] NOT NULL, -- The matching cards when the deck was last built
+    built_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX filtered_decks_owner_id_idx ON filtered_decks (owner_id);
+
+-- Create the 'personal_access_tokens' table
+CREATE TABLE personal_access_tokens (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    token_prefix TEXT NOT NULL, -- First characters of the token, shown in listings
+    token_hash TEXT UNIQUE NOT NULL, -- SHA-256 of the token, the token itself is never stored
+    scopes TEXT[] NOT NULL, -- e.g. ["decks:read", "decks:write", "study"]
+    expires_at TIMESTAMPTZ, -- NULL means the token never expires
+    last_used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMPTZ
+);
+
+-- Create the 'export_jobs' table
+-- Background builds of account archives (GDPR-style takeout)
+CREATE TABLE export_jobs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'done', 'failed')),
+    error TEXT,
+    file_path TEXT, -- Location of the archive on disk once done
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ -- The archive is deleted after this
+);
+
+-- Create the 'webhook_events' table
+-- Records processed webhook deliveries so retries are only applied once
+CREATE TABLE webhook_events (
+    id TEXT PRIMARY KEY, -- svix-id of the delivery
+    type TEXT NOT NULL,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create the 'audit_log' table
+-- Actions taken on accounts. There is no foreign key so entries survive an account purge.
+CREATE TABLE audit_log (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL, -- The account the action was taken on
+    actor_id UUID, -- NULL for background jobs
+    action TEXT NOT NULL, -- e.g. 'account.deletion_requested'
+    details JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX audit_log_user_id_idx ON audit_log (user_id, created_at);
+
 -- Enable the extension for using UUIDs
 CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
\ No newline at end of file
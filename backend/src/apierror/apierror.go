@@ -0,0 +1,258 @@
+// Package apierror defines the errors returned by the API and renders them
+// as RFC 7807 problem details.
+package apierror
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/lib/pq"
+)
+
+// Machine-readable error codes. Clients should branch on these, not on messages.
+const (
+	CodeBadRequest        = "bad_request"
+	CodeInvalidID         = "invalid_id"
+	CodeValidation        = "validation_failed"
+	CodeUnauthorized      = "unauthorized"
+	CodeForbidden         = "forbidden"
+	CodeNotFound          = "not_found"
+	CodeMethodNotAllowed  = "method_not_allowed"
+	CodeConflict          = "conflict"
+	CodeTooLarge          = "payload_too_large"
+	CodeDuplicate         = "duplicate"
+	CodeInvalidReference  = "invalid_reference"
+	CodeInternal          = "internal"
+	CodeUnavailable       = "unavailable"
+	CodeAccountSuspended  = "account_suspended"
+	CodeAccountDeleting   = "account_pending_deletion"
+	CodeUserNotRegistered = "user_not_registered"
+	CodeSessionRequired   = "session_required"
+	CodeInsufficientScope = "insufficient_scope"
+	CodeAdminRequired     = "admin_required"
+)
+
+// Production hides the causes of internal errors from responses. It is set from the configuration at startup.
+var Production = true
+
+// FieldError describes why a single request field was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is an error with everything needed to render it as a response
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Fields  []FieldError
+	// Err is the underlying cause. It is logged but only rendered outside production.
+	Err error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New returns an error with the given status, code and message
+func New(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+// BadRequest returns a 400 error
+func BadRequest(message string) *Error {
+	return New(http.StatusBadRequest, CodeBadRequest, message)
+}
+
+// InvalidID returns the 400 error for a malformed UUID in the path.
+// what names the kind of resource and may be empty.
+func InvalidID(what string) *Error {
+	if what == "" {
+		return New(http.StatusBadRequest, CodeInvalidID, "Invalid UUID format")
+	}
+	return New(http.StatusBadRequest, CodeInvalidID, "Invalid "+what+" UUID format")
+}
+
+// Unauthorized returns a 401 error
+func Unauthorized(message string) *Error {
+	return New(http.StatusUnauthorized, CodeUnauthorized, message)
+}
+
+// Forbidden returns a 403 error with a code saying why access was denied
+func Forbidden(code, message string) *Error {
+	return New(http.StatusForbidden, code, message)
+}
+
+// NotFound returns a 404 error
+func NotFound(message string) *Error {
+	return New(http.StatusNotFound, CodeNotFound, message)
+}
+
+// Conflict returns a 409 error
+func Conflict(message string) *Error {
+	return New(http.StatusConflict, CodeConflict, message)
+}
+
+// TooLarge returns a 413 error for a request body over its limit
+func TooLarge(message string) *Error {
+	return New(http.StatusRequestEntityTooLarge, CodeTooLarge, message)
+}
+
+// Unavailable returns a 503 error
+func Unavailable(message string) *Error {
+	return New(http.StatusServiceUnavailable, CodeUnavailable, message)
+}
+
+// Internal wraps an unexpected error as a 500 error
+func Internal(err error) *Error {
+	return &Error{Status: http.StatusInternalServerError, Code: CodeInternal, Message: "Internal server error", Err: err}
+}
+
+// Invalid returns the 400 error for a request body that failed to bind or validate.
+// Binding validation failures are reported per field, and bodies cut off by
+// http.MaxBytesReader are reported as too large.
+func Invalid(err error) *Error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return TooLarge("Request body too large")
+	}
+	var verrs validator.ValidationErrors
+	if errors.As(err, &verrs) {
+		e := New(http.StatusBadRequest, CodeValidation, "Request validation failed")
+		for _, fe := range verrs {
+			e.Fields = append(e.Fields, FieldError{Field: fe.Field(), Message: validationMessage(fe)})
+		}
+		return e
+	}
+	return New(http.StatusBadRequest, CodeValidation, err.Error())
+}
+
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "oneof":
+		return "must be one of " + fe.Param()
+	case "min", "gte":
+		return "must be at least " + fe.Param()
+	case "max", "lte":
+		return "must be at most " + fe.Param()
+	}
+	return "failed the " + fe.Tag() + " check"
+}
+
+// constraintFields maps unique constraints from setup.sql to the request field they guard
+var constraintFields = map[string]string{
+	"users_email_key":    "email",
+	"users_clerk_id_key": "clerk_id",
+}
+
+// From converts any error to an *Error. Errors that already are one are returned as is,
+// Postgres constraint violations become client errors and anything else is internal.
+func From(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "23505": // unique_violation
+			e := &Error{Status: http.StatusConflict, Code: CodeDuplicate, Message: "A resource with these values already exists", Err: err}
+			if field, ok := constraintFields[pqErr.Constraint]; ok {
+				e.Message = "The " + field + " is already in use"
+				e.Fields = []FieldError{{Field: field, Message: "is already in use"}}
+			}
+			return e
+		case "23503": // foreign_key_violation
+			return &Error{Status: http.StatusUnprocessableEntity, Code: CodeInvalidReference, Message: "The request references a resource that does not exist", Err: err}
+		case "23514", "23502": // check_violation, not_null_violation
+			return &Error{Status: http.StatusUnprocessableEntity, Code: CodeValidation, Message: "The request violates a data constraint", Err: err}
+		case "22P02": // invalid_text_representation
+			return &Error{Status: http.StatusBadRequest, Code: CodeBadRequest, Message: "The request contains a malformed value", Err: err}
+		}
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return &Error{Status: http.StatusNotFound, Code: CodeNotFound, Message: "Resource not found", Err: err}
+	}
+
+	return Internal(err)
+}
+
+// Problem is the RFC 7807 representation of an Error
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code"`
+	Errors   []FieldError `json:"errors,omitempty"`
+	// Debug holds the underlying cause outside production
+	Debug string `json:"debug,omitempty"`
+}
+
+// ContentType is the media type of problem responses
+const ContentType = "application/problem+json"
+
+// ProblemFor returns the problem details of err for a request to path
+func ProblemFor(err error, path string) Problem {
+	e := From(err)
+	p := Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(e.Status),
+		Status:   e.Status,
+		Detail:   e.Message,
+		Instance: path,
+		Code:     e.Code,
+		Errors:   e.Fields,
+	}
+	if !Production && e.Err != nil {
+		p.Debug = e.Err.Error()
+	}
+	return p
+}
+
+// Abort records err on the context, writes it as a problem response and stops the handler chain
+func Abort(c *gin.Context, err error) {
+	_ = c.Error(err)
+	path := ""
+	if c.Request != nil && c.Request.URL != nil {
+		path = c.Request.URL.Path
+	}
+	p := ProblemFor(err, path)
+	c.Header("Content-Type", ContentType)
+	c.AbortWithStatusJSON(p.Status, p)
+}
+
+// init reports binding failures with the JSON names of fields instead of Go struct field names
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return f.Name
+			}
+			return name
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package apierror
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFrom(t *testing.T) {
+	t.Run("unique violation on a known constraint", func(t *testing.T) {
+		err := fmt.Errorf("insert user: %w", &pq.Error{Code: "23505", Constraint: "users_email_key"})
+
+		e := From(err)
+		assert.Equal(t, http.StatusConflict, e.Status)
+		assert.Equal(t, CodeDuplicate, e.Code)
+		assert.Equal(t, []FieldError{{Field: "email", Message: "is already in use"}}, e.Fields)
+	})
+
+	t.Run("foreign key violation", func(t *testing.T) {
+		e := From(&pq.Error{Code: "23503"})
+		assert.Equal(t, http.StatusUnprocessableEntity, e.Status)
+		assert.Equal(t, CodeInvalidReference, e.Code)
+	})
+
+	t.Run("no rows", func(t *testing.T) {
+		e := From(sql.ErrNoRows)
+		assert.Equal(t, http.StatusNotFound, e.Status)
+	})
+
+	t.Run("api errors pass through", func(t *testing.T) {
+		original := Conflict("already done")
+		assert.Same(t, original, From(fmt.Errorf("wrapped: %w", original)))
+	})
+
+	t.Run("anything else is internal", func(t *testing.T) {
+		e := From(errors.New("connection refused"))
+		assert.Equal(t, http.StatusInternalServerError, e.Status)
+		assert.Equal(t, "Internal server error", e.Message)
+	})
+}
+
+func TestInvalid(t *testing.T) {
+	var body struct {
+		Title string `json:"title" binding:"required"`
+	}
+	err := binding.Validator.ValidateStruct(&body)
+
+	e := Invalid(err)
+	assert.Equal(t, http.StatusBadRequest, e.Status)
+	assert.Equal(t, CodeValidation, e.Code)
+	assert.Equal(t, []FieldError{{Field: "title", Message: "is required"}}, e.Fields)
+
+	e = Invalid(errors.New("front is required"))
+	assert.Equal(t, "front is required", e.Message)
+	assert.Empty(t, e.Fields)
+
+	e = Invalid(fmt.Errorf("read body: %w", &http.MaxBytesError{Limit: 10}))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, e.Status)
+	assert.Equal(t, CodeTooLarge, e.Code)
+}
+
+func TestAbort(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cause := errors.New("pq: password authentication failed")
+	for _, production := range []bool{true, false} {
+		t.Run(fmt.Sprintf("production=%v", production), func(t *testing.T) {
+			original := Production
+			Production = production
+			defer func() { Production = original }()
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", "/api/go/decks", nil)
+
+			Abort(c, cause)
+
+			assert.Equal(t, http.StatusInternalServerError, w.Code)
+			assert.Equal(t, ContentType, w.Header().Get("Content-Type"))
+			assert.True(t, c.IsAborted())
+
+			var p Problem
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+			assert.Equal(t, CodeInternal, p.Code)
+			assert.Equal(t, "/api/go/decks", p.Instance)
+			if production {
+				assert.NotContains(t, w.Body.String(), "password")
+			} else {
+				assert.Equal(t, cause.Error(), p.Debug)
+			}
+		})
+	}
+}
@@ -0,0 +1,422 @@
+// Package cardfile reads and writes flashcards kept in plain files, so decks
+// can live in version control next to notes.
+//
+// Two formats are supported:
+//
+// CSV files have a front,back,starred header row followed by one card per row,
+// the same layout as a CSV deck export. The starred column is optional.
+//
+// Markdown files hold one card per level-two heading. The heading text is the
+// front and everything up to the next level-two heading is the back:
+//
+//	# Capitals
+//
+//	## What is the capital of France?
+//	<!-- card: 0b7c6a52-5f0e-4a43-9a2c-6f1c1e1d6c3e -->
+//
+//	Paris
+//
+//	## What is the capital of Italy?
+//
+//	Rome, since 1871.
+//
+// A level-one heading names the deck and is otherwise ignored.
+//
+// Notes can instead mark cards with Q: and A: lines. A file with any Q: line is
+// read as notes: only Q:/A: pairs become cards and all other text, headings
+// included, is ignored. The front runs from Q: to A: and the back from A: to
+// the next blank line:
+//
+//	## Geography
+//
+//	Some notes about Europe.
+//
+//	<!-- card: 0b7c6a52-5f0e-4a43-9a2c-6f1c1e1d6c3e -->
+//	Q: What is the capital of France?
+//	A: Paris
+//
+// The card comments hold the ID of the flashcard a card was synced to, so an
+// edited file can be applied to its deck again without duplicating cards.
+// A comment belongs to the card it is in, or in notes to the Q: line below it.
+// Written files always use headings. Markdown files do not record whether a
+// card is starred.
+package cardfile
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// File formats
+const (
+	FormatCSV      = "csv"
+	FormatMarkdown = "markdown"
+)
+
+// Card is a flashcard as stored in a file
+type Card struct {
+	// ID is the flashcard the card was synced to, from its card comment. Empty for new cards.
+	ID      string
+	Front   string
+	Back    string
+	Starred bool
+}
+
+// Deck is the content of a card file
+type Deck struct {
+	// Title is the level-one heading of a Markdown file, empty for CSV
+	Title string
+	Cards []Card
+}
+
+// FormatOf returns the format of a file from its extension
+func FormatOf(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".md", ".markdown":
+		return FormatMarkdown, nil
+	}
+	return "", fmt.Errorf("cannot tell the format of %s, use a .csv or .md file", path)
+}
+
+// Read parses a card file in format
+func Read(r io.Reader, format string) (*Deck, error) {
+	switch format {
+	case FormatCSV:
+		return readCSV(r)
+	case FormatMarkdown:
+		return readMarkdown(r)
+	}
+	return nil, fmt.Errorf("unknown card file format %q", format)
+}
+
+// Write writes a deck as a card file in format
+func Write(w io.Writer, format string, deck *Deck) error {
+	switch format {
+	case FormatCSV:
+		return writeCSV(w, deck)
+	case FormatMarkdown:
+		return writeMarkdown(w, deck)
+	}
+	return fmt.Errorf("unknown card file format %q", format)
+}
+
+func readCSV(r io.Reader) (*Deck, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	deck := &Deck{}
+	for i, record := range records {
+		if i == 0 && len(record) >= 2 && strings.EqualFold(record[0], "front") && strings.EqualFold(record[1], "back") {
+			continue
+		}
+		if len(record) < 2 || len(record) > 3 {
+			return nil, fmt.Errorf("line %d: expected front,back[,starred], got %d fields", i+1, len(record))
+		}
+		card := Card{Front: record[0], Back: record[1]}
+		if len(record) == 3 && record[2] != "" {
+			if card.Starred, err = strconv.ParseBool(record[2]); err != nil {
+				return nil, fmt.Errorf("line %d: starred must be true or false", i+1)
+			}
+		}
+		if err := card.validate(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		deck.Cards = append(deck.Cards, card)
+	}
+	return deck, nil
+}
+
+func writeCSV(w io.Writer, deck *Deck) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"front", "back", "starred"}); err != nil {
+		return err
+	}
+	for _, card := range deck.Cards {
+		if err := cw.Write([]string{card.Front, card.Back, strconv.FormatBool(card.Starred)}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// cardComment matches the comment holding the ID of a card
+var cardComment = regexp.MustCompile(`^\s*<!--\s*card:\s*(\S+)\s*-->\s*$`)
+
+// mdLine is a line of a Markdown file outside code fences,
+// or inside one when fenced is true
+type mdLine struct {
+	number int
+	text   string
+	fenced bool
+}
+
+func readMarkdown(r io.Reader) (*Deck, error) {
+	deck, _, _, err := parseMarkdown(r)
+	return deck, err
+}
+
+// parseMarkdown reads a Markdown file and also returns the line each card
+// starts on and whether the file holds notes
+func parseMarkdown(r io.Reader) (*Deck, []int, bool, error) {
+	var lines []mdLine
+	notes := false
+	scanner := bufio.NewScanner(r)
+	inFence := false
+	for n := 1; scanner.Scan(); n++ {
+		text := scanner.Text()
+		fence := strings.HasPrefix(strings.TrimSpace(text), "```")
+		if fence {
+			inFence = !inFence
+		}
+		line := mdLine{number: n, text: text, fenced: inFence || fence}
+		if !line.fenced && strings.HasPrefix(text, "Q:") {
+			notes = true
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, false, err
+	}
+
+	deck := &Deck{}
+	for _, line := range lines {
+		if !line.fenced && strings.HasPrefix(line.text, "# ") {
+			deck.Title = strings.TrimSpace(line.text[2:])
+			break
+		}
+		if !line.fenced && strings.HasPrefix(line.text, "## ") {
+			break
+		}
+	}
+
+	var starts []int
+	var err error
+	if notes {
+		deck.Cards, starts, err = readNotes(lines)
+	} else {
+		deck.Cards, starts, err = readHeadings(lines)
+	}
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return deck, starts, notes, nil
+}
+
+// readHeadings reads a card for each level-two heading
+func readHeadings(lines []mdLine) ([]Card, []int, error) {
+	var cards []Card
+	var starts []int
+	var card *Card
+	var body []string
+	var headingLine int
+
+	finish := func() error {
+		if card == nil {
+			return nil
+		}
+		card.Back = strings.TrimSpace(strings.Join(body, "\n"))
+		if err := card.validate(); err != nil {
+			return fmt.Errorf("line %d: %w", headingLine, err)
+		}
+		cards = append(cards, *card)
+		starts = append(starts, headingLine)
+		card, body = nil, nil
+		return nil
+	}
+
+	for _, line := range lines {
+		switch {
+		case !line.fenced && strings.HasPrefix(line.text, "## "):
+			if err := finish(); err != nil {
+				return nil, nil, err
+			}
+			card = &Card{Front: strings.TrimSpace(line.text[3:])}
+			headingLine = line.number
+		case card == nil:
+		case !line.fenced && cardComment.MatchString(line.text):
+			if err := card.setID(cardComment.FindStringSubmatch(line.text)[1], line.number); err != nil {
+				return nil, nil, err
+			}
+		default:
+			body = append(body, line.text)
+		}
+	}
+	if err := finish(); err != nil {
+		return nil, nil, err
+	}
+	return cards, starts, nil
+}
+
+// readNotes reads a card for each Q:/A: pair and ignores everything else
+func readNotes(lines []mdLine) ([]Card, []int, error) {
+	var cards []Card
+	var starts []int
+	var card *Card
+	var front, back []string
+	var inBack bool
+	var pendingID string
+	var cardLine, idLine int
+
+	finish := func() error {
+		if card == nil {
+			return nil
+		}
+		if !inBack {
+			return fmt.Errorf("line %d: Q: without an A:", cardLine)
+		}
+		card.Front = strings.TrimSpace(strings.Join(front, "\n"))
+		card.Back = strings.TrimSpace(strings.Join(back, "\n"))
+		if err := card.validate(); err != nil {
+			return fmt.Errorf("line %d: %w", cardLine, err)
+		}
+		cards = append(cards, *card)
+		starts = append(starts, cardLine)
+		card, front, back, inBack = nil, nil, nil, false
+		return nil
+	}
+
+	for _, line := range lines {
+		text := line.text
+		switch {
+		case !line.fenced && strings.HasPrefix(text, "Q:"):
+			if err := finish(); err != nil {
+				return nil, nil, err
+			}
+			card = &Card{}
+			cardLine = line.number
+			front = []string{strings.TrimSpace(text[2:])}
+			if pendingID != "" {
+				if err := card.setID(pendingID, idLine); err != nil {
+					return nil, nil, err
+				}
+				pendingID = ""
+			}
+		case !line.fenced && cardComment.MatchString(text):
+			id := cardComment.FindStringSubmatch(text)[1]
+			if card == nil {
+				pendingID, idLine = id, line.number
+			} else if err := card.setID(id, line.number); err != nil {
+				return nil, nil, err
+			}
+		case card == nil:
+			// Notes between cards; a card comment only applies to the Q: right below it
+			if strings.TrimSpace(text) != "" {
+				pendingID = ""
+			}
+		case !line.fenced && !inBack && strings.HasPrefix(text, "A:"):
+			inBack = true
+			back = []string{strings.TrimSpace(text[2:])}
+		case !line.fenced && inBack && strings.TrimSpace(text) == "":
+			if err := finish(); err != nil {
+				return nil, nil, err
+			}
+		case inBack:
+			back = append(back, text)
+		default:
+			front = append(front, text)
+		}
+	}
+	if err := finish(); err != nil {
+		return nil, nil, err
+	}
+	return cards, starts, nil
+}
+
+// Annotate adds a card comment with ids[i] to the i-th card of a Markdown file
+// that has none, leaving the rest of the file untouched. Empty ids are skipped.
+// Comments go below the heading of a card, or above the Q: line in notes.
+func Annotate(document string, ids []string) (string, error) {
+	deck, starts, notes, err := parseMarkdown(strings.NewReader(document))
+	if err != nil {
+		return "", err
+	}
+	if len(ids) != len(deck.Cards) {
+		return "", fmt.Errorf("got %d ids for %d cards", len(ids), len(deck.Cards))
+	}
+
+	insert := map[int]string{}
+	for i, card := range deck.Cards {
+		if card.ID == "" && ids[i] != "" {
+			insert[starts[i]] = ids[i]
+		}
+	}
+
+	var b strings.Builder
+	for n, line := range strings.SplitAfter(document, "\n") {
+		id, ok := insert[n+1]
+		if !ok {
+			b.WriteString(line)
+			continue
+		}
+		eol := "\n"
+		if strings.HasSuffix(line, "\r\n") {
+			eol = "\r\n"
+		}
+		comment := fmt.Sprintf("<!-- card: %s -->%s", id, eol)
+		if notes {
+			b.WriteString(comment + line)
+		} else {
+			if !strings.HasSuffix(line, "\n") {
+				line += eol
+			}
+			b.WriteString(line + comment)
+		}
+	}
+	return b.String(), nil
+}
+
+// setID sets the ID of a card from the card comment on line
+func (c *Card) setID(id string, line int) error {
+	if c.ID != "" && c.ID != id {
+		return fmt.Errorf("line %d: card has two card comments", line)
+	}
+	c.ID = id
+	return nil
+}
+
+func writeMarkdown(w io.Writer, deck *Deck) error {
+	bw := bufio.NewWriter(w)
+	if deck.Title != "" {
+		fmt.Fprintf(bw, "# %s\n\n", deck.Title)
+	}
+	for i, card := range deck.Cards {
+		if i > 0 {
+			bw.WriteString("\n")
+		}
+		fmt.Fprintf(bw, "## %s\n", oneLine(card.Front))
+		if card.ID != "" {
+			fmt.Fprintf(bw, "<!-- card: %s -->\n", card.ID)
+		}
+		fmt.Fprintf(bw, "\n%s\n", card.Back)
+	}
+	return bw.Flush()
+}
+
+// oneLine joins the lines of a front so it fits in a heading
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func (c Card) validate() error {
+	if strings.TrimSpace(c.Front) == "" {
+		return errors.New("card has an empty front")
+	}
+	if strings.TrimSpace(c.Back) == "" {
+		return fmt.Errorf("card %q has an empty back", c.Front)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package cardfile
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatOf(t *testing.T) {
+	for path, want := range map[string]string{"deck.csv": FormatCSV, "notes/Capitals.MD": FormatMarkdown, "a.markdown": FormatMarkdown} {
+		format, err := FormatOf(path)
+		assert.NoError(t, err, path)
+		assert.Equal(t, want, format, path)
+	}
+
+	_, err := FormatOf("deck.txt")
+	assert.Error(t, err)
+}
+
+func TestCSV(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		deck := &Deck{Cards: []Card{
+			{Front: "2 + 2", Back: "4", Starred: true},
+			{Front: "Multi\nline", Back: "with, comma"},
+		}}
+
+		var buf bytes.Buffer
+		require.NoError(t, Write(&buf, FormatCSV, deck))
+		assert.True(t, strings.HasPrefix(buf.String(), "front,back,starred\n"))
+
+		read, err := Read(&buf, FormatCSV)
+		require.NoError(t, err)
+		assert.Equal(t, deck, read)
+	})
+
+	t.Run("without header or starred column", func(t *testing.T) {
+		deck, err := Read(strings.NewReader("a,b\nc,d,\n"), FormatCSV)
+		require.NoError(t, err)
+		assert.Equal(t, []Card{{Front: "a", Back: "b"}, {Front: "c", Back: "d"}}, deck.Cards)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for input, want := range map[string]string{
+			"front,back\nonly\n":      "line 2: expected front,back[,starred]",
+			"front,back\na,b,maybe\n": "line 2: starred must be true or false",
+			"front,back\n,b\n":        "line 2: card has an empty front",
+			"front,back\na, \n":       `line 2: card "a" has an empty back`,
+		} {
+			_, err := Read(strings.NewReader(input), FormatCSV)
+			if assert.Error(t, err, input) {
+				assert.Contains(t, err.Error(), want)
+			}
+		}
+	})
+}
+
+func TestMarkdown(t *testing.T) {
+	t.Run("read", func(t *testing.T) {
+		input := "# Capitals\n\nIntro text is ignored.\n\n## What is the capital of France?\n\nParis\n\n" +
+			"## Show a heading in code\n\n```md\n## not a card\n```\n"
+
+		deck, err := Read(strings.NewReader(input), FormatMarkdown)
+		require.NoError(t, err)
+		assert.Equal(t, "Capitals", deck.Title)
+		assert.Equal(t, []Card{
+			{Front: "What is the capital of France?", Back: "Paris"},
+			{Front: "Show a heading in code", Back: "```md\n## not a card\n```"},
+		}, deck.Cards)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		deck := &Deck{Title: "Capitals", Cards: []Card{
+			{Front: "France", Back: "Paris"},
+			{Front: "Italy", Back: "Rome,\nsince 1871."},
+		}}
+
+		var buf bytes.Buffer
+		require.NoError(t, Write(&buf, FormatMarkdown, deck))
+		assert.Equal(t, "# Capitals\n\n## France\n\nParis\n\n## Italy\n\nRome,\nsince 1871.\n", buf.String())
+
+		read, err := Read(&buf, FormatMarkdown)
+		require.NoError(t, err)
+		assert.Equal(t, deck, read)
+	})
+
+	t.Run("card comments", func(t *testing.T) {
+		deck := &Deck{Title: "Capitals", Cards: []Card{
+			{ID: "0b7c6a52-5f0e-4a43-9a2c-6f1c1e1d6c3e", Front: "France", Back: "Paris"},
+			{Front: "Italy", Back: "Rome"},
+		}}
+
+		var buf bytes.Buffer
+		require.NoError(t, Write(&buf, FormatMarkdown, deck))
+		assert.Equal(t, "# Capitals\n\n## France\n<!-- card: 0b7c6a52-5f0e-4a43-9a2c-6f1c1e1d6c3e -->\n\nParis\n\n## Italy\n\nRome\n", buf.String())
+
+		read, err := Read(&buf, FormatMarkdown)
+		require.NoError(t, err)
+		assert.Equal(t, deck, read)
+
+		_, err = Read(strings.NewReader("## A\n<!-- card: 1 -->\nB\n<!-- card: 2 -->\n"), FormatMarkdown)
+		if assert.Error(t, err) {
+			assert.Equal(t, "line 4: card has two card comments", err.Error())
+		}
+	})
+
+	t.Run("empty back", func(t *testing.T) {
+		_, err := Read(strings.NewReader("## One\n\nA\n\n## Two\n\n"), FormatMarkdown)
+		if assert.Error(t, err) {
+			assert.Equal(t, `line 5: card "Two" has an empty back`, err.Error())
+		}
+	})
+}
+
+func TestNotes(t *testing.T) {
+	t.Run("read", func(t *testing.T) {
+		input := "# Europe\n\n## Geography\n\nSome notes.\n\n<!-- card: abc -->\nQ: What is the capital of France?\nA: Paris\n\n" +
+			"More notes.\nQ: Name two rivers\nof Italy\nA: Po\nTiber\n\n<!-- card: stale -->\nNot a card.\n\nQ: Show code\nA: Like this:\n```\nQ: not a card\n\n```\n"
+
+		deck, err := Read(strings.NewReader(input), FormatMarkdown)
+		require.NoError(t, err)
+		assert.Equal(t, "Europe", deck.Title)
+		assert.Equal(t, []Card{
+			{ID: "abc", Front: "What is the capital of France?", Back: "Paris"},
+			{Front: "Name two rivers\nof Italy", Back: "Po\nTiber"},
+			{Front: "Show code", Back: "Like this:\n```\nQ: not a card\n\n```"},
+		}, deck.Cards)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for input, want := range map[string]string{
+			"Q: front\n\nQ: next\nA: back\n": "line 1: Q: without an A:",
+			"Q: front\nA:\n":                 `line 1: card "front" has an empty back`,
+		} {
+			_, err := Read(strings.NewReader(input), FormatMarkdown)
+			if assert.Error(t, err, input) {
+				assert.Equal(t, want, err.Error())
+			}
+		}
+	})
+}
+
+func TestAnnotate(t *testing.T) {
+	t.Run("headings", func(t *testing.T) {
+		doc := "# Capitals\r\n\r\n## France\r\n<!-- card: a -->\r\n\r\nParis\r\n\r\n## Italy\r\nRome"
+
+		annotated, err := Annotate(doc, []string{"a", "b"})
+		require.NoError(t, err)
+		assert.Equal(t, "# Capitals\r\n\r\n## France\r\n<!-- card: a -->\r\n\r\nParis\r\n\r\n## Italy\r\n<!-- card: b -->\r\nRome", annotated)
+	})
+
+	t.Run("notes", func(t *testing.T) {
+		doc := "## Geography\n\nSome notes.\nQ: France?\nA: Paris\n\nQ: Italy?\nA: Rome\n"
+
+		annotated, err := Annotate(doc, []string{"a", ""})
+		require.NoError(t, err)
+		assert.Equal(t, "## Geography\n\nSome notes.\n<!-- card: a -->\nQ: France?\nA: Paris\n\nQ: Italy?\nA: Rome\n", annotated)
+
+		deck, err := Read(strings.NewReader(annotated), FormatMarkdown)
+		require.NoError(t, err)
+		assert.Equal(t, "a", deck.Cards[0].ID)
+	})
+
+	t.Run("wrong number of ids", func(t *testing.T) {
+		_, err := Annotate("## A\n\nB\n", nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestUnknownFormat(t *testing.T) {
+	_, err := Read(strings.NewReader(""), "xml")
+	assert.Error(t, err)
+	assert.Error(t, Write(&bytes.Buffer{}, "xml", &Deck{}))
+}
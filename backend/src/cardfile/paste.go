@@ -0,0 +1,140 @@
+package cardfile
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Separators of pasted text, as offered by Quizlet exports and word lists
+const (
+	SeparatorTab       = "tab"
+	SeparatorComma     = "comma"
+	SeparatorDash      = "dash"
+	SeparatorNewline   = "newline"
+	SeparatorSemicolon = "semicolon"
+	SeparatorBlankLine = "blank_line"
+	SeparatorCustom    = "custom"
+)
+
+// PasteOptions says how pasted text is split into cards
+type PasteOptions struct {
+	// Term separates a front from its back: tab (the default), comma, dash or custom.
+	// A dash is a hyphen or en or em dash with spaces around it.
+	Term string
+	// Card separates cards: newline (the default), semicolon, blank_line or custom
+	Card string
+	// CustomTerm and CustomCard are the separators used for custom
+	CustomTerm string
+	CustomCard string
+}
+
+// Warning is a problem with an entry of pasted text
+type Warning struct {
+	// Entry is the 1-based position of the entry among the non-empty entries of the text
+	Entry   int
+	Text    string
+	Message string
+	// Skipped is set when no card was made from the entry
+	Skipped bool
+}
+
+var (
+	blankLine = regexp.MustCompile(`\n[ \t]*\n`)
+	dash      = regexp.MustCompile(` [-–—] `)
+)
+
+// Validate checks that the separators are known and custom ones are given
+func (o PasteOptions) Validate() error {
+	switch o.Term {
+	case "", SeparatorTab, SeparatorComma, SeparatorDash:
+	case SeparatorCustom:
+		if o.CustomTerm == "" {
+			return fmt.Errorf("custom_term_separator is required for a custom term separator")
+		}
+	default:
+		return fmt.Errorf("term_separator must be tab, comma, dash or custom")
+	}
+	switch o.Card {
+	case "", SeparatorNewline, SeparatorSemicolon, SeparatorBlankLine:
+	case SeparatorCustom:
+		if o.CustomCard == "" {
+			return fmt.Errorf("custom_card_separator is required for a custom card separator")
+		}
+	default:
+		return fmt.Errorf("card_separator must be newline, semicolon, blank_line or custom")
+	}
+	return nil
+}
+
+// ParsePaste splits pasted text into cards. Entries that can't be made into a
+// card are skipped with a warning; suspicious ones are kept with a warning.
+func ParsePaste(text string, opts PasteOptions) ([]Card, []Warning, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, nil, err
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	var entries []string
+	switch opts.Card {
+	case SeparatorSemicolon:
+		entries = strings.Split(text, ";")
+	case SeparatorBlankLine:
+		entries = blankLine.Split(text, -1)
+	case SeparatorCustom:
+		entries = strings.Split(text, opts.CustomCard)
+	default:
+		entries = strings.Split(text, "\n")
+	}
+
+	cards := []Card{}
+	warnings := []Warning{}
+	fronts := map[string]int{}
+	n := 0
+	for _, entry := range entries {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		n++
+		warn := func(skipped bool, format string, args ...any) {
+			warnings = append(warnings, Warning{Entry: n, Text: strings.TrimSpace(entry), Message: fmt.Sprintf(format, args...), Skipped: skipped})
+		}
+
+		parts := splitTerm(entry, opts)
+		if len(parts) != 2 {
+			warn(true, "no term separator found")
+			continue
+		}
+		card := Card{Front: strings.TrimSpace(parts[0]), Back: strings.TrimSpace(parts[1])}
+		if err := card.validate(); err != nil {
+			warn(true, "%s", err.Error())
+			continue
+		}
+		// The rest stays in the back, since definitions often contain commas
+		if len(splitTerm(parts[1], opts)) == 2 {
+			warn(false, "more than one term separator, split at the first")
+		}
+
+		key := strings.ToLower(strings.Join(strings.Fields(card.Front), " "))
+		if first, ok := fronts[key]; ok {
+			warn(false, "same front as entry %d", first)
+		} else {
+			fronts[key] = n
+		}
+		cards = append(cards, card)
+	}
+	return cards, warnings, nil
+}
+
+// splitTerm splits an entry at its first term separator
+func splitTerm(entry string, opts PasteOptions) []string {
+	switch opts.Term {
+	case SeparatorComma:
+		return strings.SplitN(entry, ",", 2)
+	case SeparatorDash:
+		return dash.Split(entry, 2)
+	case SeparatorCustom:
+		return strings.SplitN(entry, opts.CustomTerm, 2)
+	}
+	return strings.SplitN(entry, "\t", 2)
+}
@@ -0,0 +1,56 @@
+package cardfile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePaste(t *testing.T) {
+	t.Run("separators", func(t *testing.T) {
+		want := []Card{{Front: "hola", Back: "hello"}, {Front: "adiós", Back: "goodbye"}}
+		for name, tc := range map[string]struct {
+			text string
+			opts PasteOptions
+		}{
+			"defaults":         {"hola\thello\r\nadiós\tgoodbye\n", PasteOptions{}},
+			"comma, semicolon": {"hola,hello;adiós,goodbye;", PasteOptions{Term: SeparatorComma, Card: SeparatorSemicolon}},
+			"dash, blank line": {"hola - hello\n  \nadiós — goodbye", PasteOptions{Term: SeparatorDash, Card: SeparatorBlankLine}},
+			"custom":           {"hola=>hello||adiós=>goodbye", PasteOptions{Term: SeparatorCustom, CustomTerm: "=>", Card: SeparatorCustom, CustomCard: "||"}},
+		} {
+			cards, warnings, err := ParsePaste(tc.text, tc.opts)
+			require.NoError(t, err, name)
+			assert.Equal(t, want, cards, name)
+			assert.Empty(t, warnings, name)
+		}
+	})
+
+	t.Run("blank line keeps multi-line backs", func(t *testing.T) {
+		cards, _, err := ParsePaste("cell - the unit of life,\nfound in all organisms\n\natom - smallest unit", PasteOptions{Term: SeparatorDash, Card: SeparatorBlankLine})
+		require.NoError(t, err)
+		assert.Equal(t, "the unit of life,\nfound in all organisms", cards[0].Back)
+	})
+
+	t.Run("warnings", func(t *testing.T) {
+		text := "dog,a pet, often loyal\n\ncat\n,missing front\nDog ,perro\nbird,\n"
+
+		cards, warnings, err := ParsePaste(text, PasteOptions{Term: SeparatorComma})
+		require.NoError(t, err)
+		assert.Equal(t, []Card{{Front: "dog", Back: "a pet, often loyal"}, {Front: "Dog", Back: "perro"}}, cards)
+		assert.Equal(t, []Warning{
+			{Entry: 1, Text: "dog,a pet, often loyal", Message: "more than one term separator, split at the first"},
+			{Entry: 2, Text: "cat", Message: "no term separator found", Skipped: true},
+			{Entry: 3, Text: ",missing front", Message: "card has an empty front", Skipped: true},
+			{Entry: 4, Text: "Dog ,perro", Message: "same front as entry 1"},
+			{Entry: 5, Text: "bird,", Message: `card "bird" has an empty back`, Skipped: true},
+		}, warnings)
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		for _, opts := range []PasteOptions{{Term: "pipe"}, {Card: "tab"}, {Term: SeparatorCustom}, {Card: SeparatorCustom}} {
+			_, _, err := ParsePaste("a\tb", opts)
+			assert.Error(t, err, opts)
+		}
+	})
+}
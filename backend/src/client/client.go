@@ -0,0 +1,209 @@
+// Package client is a typed Go client for the flashcard API.
+//
+//	c := client.New("http://localhost:8000", os.Getenv("FLASHCARDS_TOKEN"))
+//	decks, err := c.ListDecks(ctx)
+//
+// Tokens are either session tokens or personal access tokens (fcp_...).
+// Failed requests return an *Error carrying the server's problem details.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// apiPrefix is the path under which the API is served
+const apiPrefix = "/api/go"
+
+// Client calls the flashcard API. Its fields may be changed before the first request.
+type Client struct {
+	// BaseURL is the server's origin, e.g. http://localhost:8000
+	BaseURL string
+	// Token is sent as a bearer token
+	Token string
+	// HTTPClient sends the requests, http.DefaultClient when nil
+	HTTPClient *http.Client
+	// MaxRetries is how often idempotent requests are retried after
+	// network errors and 429, 502, 503 and 504 responses
+	MaxRetries int
+	// RetryBackoff is the delay before the first retry, doubled for each further one.
+	// A Retry-After response header takes precedence.
+	RetryBackoff time.Duration
+}
+
+// New returns a client for the server at baseURL authenticating with token
+func New(baseURL, token string) *Client {
+	return &Client{
+		BaseURL:      strings.TrimRight(baseURL, "/"),
+		Token:        token,
+		MaxRetries:   3,
+		RetryBackoff: 200 * time.Millisecond,
+	}
+}
+
+// FieldError describes why a single request field was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is a failed API request, decoded from the server's problem details
+type Error struct {
+	StatusCode int          `json:"status"`
+	Code       string       `json:"code"` // Machine-readable, e.g. not_found or duplicate
+	Title      string       `json:"title"`
+	Detail     string       `json:"detail"`
+	Instance   string       `json:"instance"`
+	Fields     []FieldError `json:"errors"`
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("flashcards: %d %s", e.StatusCode, e.Code)
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	for _, f := range e.Fields {
+		msg += "; " + f.Field + " " + f.Message
+	}
+	return msg
+}
+
+// ErrorCode returns the code of an *Error in err's chain, or "" if there is none
+func ErrorCode(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return ""
+}
+
+// idempotent reports whether a request with method can safely be sent again
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// retryable reports whether a response status is worth retrying
+func retryable(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// do sends a request to path, encoding in as the JSON body when it is not nil and
+// decoding a successful JSON response into out when it is not nil
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out any) error {
+	resp, err := c.send(ctx, method, path, query, in)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		_, err = io.Copy(io.Discard, resp.Body)
+		return err
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("flashcards: decoding %s %s response: %w", method, path, err)
+	}
+	return nil
+}
+
+// send sends a request, retrying idempotent ones, and returns the successful response.
+// The caller closes the body.
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, in any) (*http.Response, error) {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return nil, err
+		}
+	}
+
+	u := c.BaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	attempts := 1
+	if idempotent(method) {
+		attempts += c.MaxRetries
+	}
+	backoff := c.RetryBackoff
+
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		if in != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.Header.Set("Accept", "application/json, application/problem+json")
+		if c.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.Token)
+		}
+
+		resp, err := c.httpClient().Do(req)
+		wait := backoff
+		switch {
+		case err != nil:
+			if ctx.Err() != nil || attempt >= attempts {
+				return nil, err
+			}
+		case resp.StatusCode < 400:
+			return resp, nil
+		case !retryable(resp.StatusCode) || attempt >= attempts:
+			defer resp.Body.Close()
+			return nil, decodeError(resp)
+		default:
+			if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+				wait = time.Duration(s) * time.Second
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+		backoff *= 2
+	}
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// decodeError reads the problem details of a failed response
+func decodeError(resp *http.Response) error {
+	e := &Error{StatusCode: resp.StatusCode}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil || json.Unmarshal(data, e) != nil || e.Code == "" {
+		// Not a problem document, e.g. from a proxy in front of the server
+		e.Code = "http_" + strconv.Itoa(resp.StatusCode)
+		e.Title = http.StatusText(resp.StatusCode)
+		e.Detail = strings.TrimSpace(string(data))
+	}
+	e.StatusCode = resp.StatusCode
+	return e
+}
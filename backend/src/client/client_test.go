@@ -0,0 +1,130 @@
+package client
+
+import (
+	"api/src/apierror"
+	"api/src/database"
+	"api/src/middleware"
+	"api/src/routes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestClient serves the real router backed by a mock database and returns a client
+// authenticated as a fresh user, whose lookup is the first expected query
+func newTestClient(t *testing.T) (*Client, sqlmock.Sqlmock, uuid.UUID) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	t.Cleanup(func() { mockDB.Close() })
+	database.DB = mockDB
+
+	userID := uuid.New()
+	subject := "client_" + userID.String()
+	mock.ExpectQuery(`SELECT id, role, suspended_at, deletion_scheduled_for FROM users WHERE clerk_id = \$1`).
+		WithArgs(subject).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "role", "suspended_at", "deletion_scheduled_for"}).AddRow(userID, "user", nil, nil))
+
+	auth := middleware.StaticTokenAuthenticator{Tokens: map[string]middleware.Principal{
+		"test-token": {Subject: subject},
+	}}
+	server := httptest.NewServer(routes.SetupRouter(auth))
+	t.Cleanup(server.Close)
+
+	c := New(server.URL, "test-token")
+	c.RetryBackoff = time.Millisecond
+	return c, mock, userID
+}
+
+func TestUnauthenticated(t *testing.T) {
+	c, _, _ := newTestClient(t)
+	c.Token = ""
+
+	_, err := c.ListDecks(context.Background())
+
+	var apiErr *Error
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+		assert.Equal(t, apierror.CodeUnauthorized, apiErr.Code)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"status":503,"code":"unavailable","detail":"Try again later"}`))
+			return
+		}
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "token")
+	c.RetryBackoff = time.Millisecond
+
+	t.Run("idempotent requests are retried", func(t *testing.T) {
+		calls.Store(0)
+		decks, err := c.ListDecks(context.Background())
+		assert.NoError(t, err)
+		assert.Empty(t, decks)
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("other requests are not", func(t *testing.T) {
+		calls.Store(0)
+		_, err := c.RequestAccountExport(context.Background())
+		assert.Equal(t, apierror.CodeUnavailable, ErrorCode(err))
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("retries are bounded", func(t *testing.T) {
+		calls.Store(-10)
+		c.MaxRetries = 1
+		defer func() { c.MaxRetries = 3 }()
+
+		_, err := c.ListDecks(context.Background())
+		assert.Equal(t, apierror.CodeUnavailable, ErrorCode(err))
+		assert.Equal(t, int32(-8), calls.Load())
+	})
+
+	t.Run("context cancels waiting", func(t *testing.T) {
+		calls.Store(-10)
+		c.RetryBackoff = time.Hour
+		defer func() { c.RetryBackoff = time.Millisecond }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err := c.ListDecks(ctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestErrorWithoutProblemDetails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upstream exploded", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	_, err := New(server.URL, "token").Me(context.Background())
+
+	var apiErr *Error
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, "http_500", apiErr.Code)
+		assert.Equal(t, "upstream exploded", apiErr.Detail)
+	}
+}
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"api/src/models"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// DeckPresets lists the deck presets of the user, the default one first
+func (c *Client) DeckPresets(ctx context.Context) ([]models.DeckPreset, error) {
+	var presets []models.DeckPreset
+	err := c.do(ctx, "GET", apiPrefix+"/deck-presets", nil, nil, &presets)
+	return presets, err
+}
+
+// DeckPreset returns a deck preset
+func (c *Client) DeckPreset(ctx context.Context, id uuid.UUID) (*models.DeckPreset, error) {
+	var p models.DeckPreset
+	if err := c.do(ctx, "GET", apiPrefix+"/deck-presets/"+id.String(), nil, nil, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// CreateDeckPreset creates a deck preset. Start from models.DefaultDeckPreset
+// to keep the default options.
+func (c *Client) CreateDeckPreset(ctx context.Context, p models.DeckPreset) (*models.DeckPreset, error) {
+	var created models.DeckPreset
+	if err := c.do(ctx, "POST", apiPrefix+"/deck-presets", nil, p, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// UpdateDeckPreset replaces the options of a deck preset
+func (c *Client) UpdateDeckPreset(ctx context.Context, id uuid.UUID, p models.DeckPreset) (*models.DeckPreset, error) {
+	var updated models.DeckPreset
+	if err := c.do(ctx, "PUT", apiPrefix+"/deck-presets/"+id.String(), nil, p, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteDeckPreset deletes a deck preset; its decks go back to the default preset
+func (c *Client) DeleteDeckPreset(ctx context.Context, id uuid.UUID) error {
+	return c.do(ctx, "DELETE", apiPrefix+"/deck-presets/"+id.String(), nil, nil, nil)
+}
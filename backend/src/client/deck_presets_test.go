@@ -0,0 +1,48 @@
+package client
+
+import (
+	"api/src/models"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeckPresets(t *testing.T) {
+	c, mock, userID := newTestClient(t)
+	ctx := context.Background()
+	presetID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO deck_presets`).
+		WithArgs(userID, "Languages", false, 50, models.DefaultReviewLimit, "{1,10}", 1, 36500, models.NewOrderSequential, false, 60, "sm2").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(presetID, time.Now()))
+	mock.ExpectCommit()
+	preset := models.DefaultDeckPreset()
+	preset.Name, preset.NewPerDay = "Languages", 50
+	created, err := c.CreateDeckPreset(ctx, preset)
+	require.NoError(t, err)
+	assert.Equal(t, presetID, created.ID)
+	assert.Equal(t, []int{1, 10}, created.LearningSteps)
+
+	mock.ExpectQuery(`FROM deck_presets WHERE owner_id = \$1`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id", "name", "is_default", "new_per_day", "reviews_per_day", "learning_steps", "graduating_interval",
+			"maximum_interval", "new_order", "show_timer", "max_answer_seconds", "scheduler", "created_at"}).
+			AddRow(presetID, userID, "Languages", true, 50, 200, "{1,10}", 1, 36500, "sequential", false, 60, "sm2", time.Now()))
+	presets, err := c.DeckPresets(ctx)
+	if assert.NoError(t, err) && assert.Len(t, presets, 1) {
+		assert.True(t, presets[0].IsDefault)
+	}
+
+	mock.ExpectExec(`DELETE FROM deck_presets`).
+		WithArgs(presetID, userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, c.DeleteDeckPreset(ctx, presetID))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
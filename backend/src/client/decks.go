@@ -0,0 +1,98 @@
+package client
+
+import (
+	"api/src/models"
+	"context"
+	"net/url"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// ListDecks returns the decks of the authenticated user
+func (c *Client) ListDecks(ctx context.Context) ([]models.Deck, error) {
+	var decks []models.Deck
+	err := c.do(ctx, "GET", apiPrefix+"/decks", nil, nil, &decks)
+	return decks, err
+}
+
+// GetDeck returns a deck
+func (c *Client) GetDeck(ctx context.Context, id uuid.UUID) (*models.Deck, error) {
+	var deck models.Deck
+	if err := c.do(ctx, "GET", apiPrefix+"/decks/"+id.String(), nil, nil, &deck); err != nil {
+		return nil, err
+	}
+	return &deck, nil
+}
+
+// CreateDeck creates a deck from the title, description, labels and exam date of deck
+func (c *Client) CreateDeck(ctx context.Context, deck models.Deck) (*models.Deck, error) {
+	var created models.Deck
+	if err := c.do(ctx, "POST", apiPrefix+"/decks", nil, deck, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// UpdateDeck replaces the title, description, labels and exam date of a deck
+func (c *Client) UpdateDeck(ctx context.Context, id uuid.UUID, deck models.Deck) (*models.Deck, error) {
+	var updated models.Deck
+	if err := c.do(ctx, "PUT", apiPrefix+"/decks/"+id.String(), nil, deck, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteDeck deletes a deck and its flashcards
+func (c *Client) DeleteDeck(ctx context.Context, id uuid.UUID) error {
+	return c.do(ctx, "DELETE", apiPrefix+"/decks/"+id.String(), nil, nil, nil)
+}
+
+// DeckRevisions returns the earlier versions of a deck, oldest first
+func (c *Client) DeckRevisions(ctx context.Context, id uuid.UUID) ([]models.DeckRevision, error) {
+	var revisions []models.DeckRevision
+	err := c.do(ctx, "GET", apiPrefix+"/decks/"+id.String()+"/revisions", nil, nil, &revisions)
+	return revisions, err
+}
+
+// RevertDeck restores a deck to revision rev
+func (c *Client) RevertDeck(ctx context.Context, id uuid.UUID, rev int) (*models.Deck, error) {
+	var deck models.Deck
+	path := apiPrefix + "/decks/" + id.String() + "/revisions/" + strconv.Itoa(rev) + "/revert"
+	if err := c.do(ctx, "POST", path, nil, nil, &deck); err != nil {
+		return nil, err
+	}
+	return &deck, nil
+}
+
+// SyncDeck applies a Markdown card file to a deck. The result holds the file
+// with card comments added to its new cards, to be saved in place of the original.
+func (c *Client) SyncDeck(ctx context.Context, id uuid.UUID, in models.DeckSync) (*models.DeckSyncResult, error) {
+	var result models.DeckSyncResult
+	if err := c.do(ctx, "POST", apiPrefix+"/decks/"+id.String()+"/sync", nil, in, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// DeckDuplicates returns the groups of duplicate flashcards of a deck.
+// A threshold of 0 uses the server's default.
+func (c *Client) DeckDuplicates(ctx context.Context, id uuid.UUID, threshold float64) ([]models.DuplicateGroup, error) {
+	var query url.Values
+	if threshold > 0 {
+		query = url.Values{"threshold": {strconv.FormatFloat(threshold, 'f', -1, 64)}}
+	}
+	var groups []models.DuplicateGroup
+	err := c.do(ctx, "GET", apiPrefix+"/decks/"+id.String()+"/duplicates", query, nil, &groups)
+	return groups, err
+}
+
+// MergeDuplicates keeps one flashcard of a deck and deletes the others merged into it
+func (c *Client) MergeDuplicates(ctx context.Context, id, keep uuid.UUID, merge ...uuid.UUID) (*models.Flashcard, error) {
+	var kept models.Flashcard
+	in := models.DuplicateMerge{Keep: keep, Merge: merge}
+	if err := c.do(ctx, "POST", apiPrefix+"/decks/"+id.String()+"/duplicates/merge", nil, in, &kept); err != nil {
+		return nil, err
+	}
+	return &kept, nil
+}
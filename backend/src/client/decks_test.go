@@ -0,0 +1,121 @@
+package client
+
+import (
+	"api/src/apierror"
+	"api/src/models"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecks(t *testing.T) {
+	c, mock, userID := newTestClient(t)
+	ctx := context.Background()
+	deckID := uuid.New()
+
+	mock.ExpectQuery(`INSERT INTO decks \(owner_id, labels, title, description, exam_date, leech_threshold, leech_action, preset_id\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8\) RETURNING id`).
+		WithArgs(userID, pq.StringArray([]string{"geo"}), "Capitals", "", nil, 8, "tag", nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(deckID))
+	created, err := c.CreateDeck(ctx, models.Deck{Title: "Capitals", Labels: []string{"geo"}})
+	if assert.NoError(t, err) {
+		assert.Equal(t, deckID, created.ID)
+		assert.Equal(t, userID, created.OwnerID)
+	}
+
+	mock.ExpectQuery(`SELECT id, owner_id, labels, title, description, to_char\(exam_date, 'YYYY-MM-DD'\), leech_threshold, leech_action, preset_id FROM decks WHERE owner_id = \$1`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id", "labels", "title", "description", "exam_date", "leech_threshold", "leech_action", "preset_id"}).
+			AddRow(deckID, userID, pq.Array([]string{"geo"}), "Capitals", "", nil, 8, "tag", nil))
+	decks, err := c.ListDecks(ctx)
+	if assert.NoError(t, err) && assert.Len(t, decks, 1) {
+		assert.Equal(t, "Capitals", decks[0].Title)
+	}
+
+	_, err = c.CreateDeck(ctx, models.Deck{})
+	assert.Equal(t, apierror.CodeValidation, ErrorCode(err))
+
+	mock.ExpectQuery(`SELECT id, owner_id, labels, title, description, to_char\(exam_date, 'YYYY-MM-DD'\), leech_threshold, leech_action, preset_id FROM decks WHERE id = \$1 AND owner_id = \$2`).
+		WithArgs(deckID, userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id", "labels", "title", "description", "exam_date", "leech_threshold", "leech_action", "preset_id"}))
+	_, err = c.GetDeck(ctx, deckID)
+	assert.Equal(t, apierror.CodeNotFound, ErrorCode(err))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeckRevisions(t *testing.T) {
+	c, mock, userID := newTestClient(t)
+	deckID := uuid.New()
+	columns := []string{"deck_id", "rev", "labels", "title", "description", "edited_by", "created_at"}
+
+	mock.ExpectQuery(`SELECT labels, title, COALESCE\(description, ''\) FROM decks WHERE id = \$1 AND owner_id = \$2`).
+		WithArgs(deckID, userID).
+		WillReturnRows(sqlmock.NewRows([]string{"labels", "title", "description"}).AddRow(pq.Array([]string{"geo"}), "European capitals", ""))
+	mock.ExpectQuery(`FROM deck_revisions WHERE deck_id = \$1 ORDER BY rev`).
+		WithArgs(deckID).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(deckID, 1, pq.Array([]string{}), "Capitals", "", userID, time.Now().Add(-time.Hour)).
+			AddRow(deckID, 2, pq.Array([]string{"geo"}), "Capitals", "", userID, time.Now()))
+	revisions, err := c.DeckRevisions(context.Background(), deckID)
+	if assert.NoError(t, err) && assert.Len(t, revisions, 2) {
+		assert.Equal(t, 1, revisions[0].Rev)
+		assert.Equal(t, []string{"geo"}, revisions[0].Diff.LabelsAdded)
+		// The newest revision is diffed against the current deck
+		assert.Equal(t, 2, revisions[1].Rev)
+		assert.Empty(t, revisions[1].Diff.LabelsAdded)
+		assert.NotEmpty(t, revisions[1].Diff.Title)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSyncDeck(t *testing.T) {
+	c, mock, userID := newTestClient(t)
+	deckID := uuid.New()
+	cardID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM decks WHERE id = \$1 AND owner_id = \$2 FOR UPDATE`).
+		WithArgs(deckID, userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(deckID))
+	mock.ExpectQuery(`SELECT id, parent_deck, starred, front, back FROM flashcards WHERE parent_deck = \$1 ORDER BY id FOR UPDATE`).
+		WithArgs(deckID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "parent_deck", "starred", "front", "back"}).AddRow(cardID, deckID, true, "France", "Paris"))
+	mock.ExpectRollback()
+
+	result, err := c.SyncDeck(context.Background(), deckID, models.DeckSync{Document: "## France\n\nParis\n\n## Italy\n\nRome\n", DryRun: true})
+	if assert.NoError(t, err) {
+		assert.True(t, result.DryRun)
+		assert.Equal(t, 1, result.Unchanged)
+		assert.Len(t, result.Created, 1)
+		assert.Contains(t, result.Document, "<!-- card: "+cardID.String()+" -->")
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeckDuplicates(t *testing.T) {
+	c, mock, userID := newTestClient(t)
+	deckID := uuid.New()
+
+	mock.ExpectQuery(`SELECT id FROM decks WHERE id = \$1 AND owner_id = \$2`).
+		WithArgs(deckID, userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(deckID))
+	mock.ExpectQuery(`SELECT f.id, f.parent_deck, f.starred, f.front, f.back FROM flashcards f`).
+		WithArgs(deckID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "parent_deck", "starred", "front", "back"}).
+			AddRow(uuid.New(), deckID, false, "Photosynthesis", "Light to sugar").
+			AddRow(uuid.New(), deckID, false, "Photosyntesis", "Light to sugar"))
+
+	groups, err := c.DeckDuplicates(context.Background(), deckID, 0.9)
+	if assert.NoError(t, err) && assert.Len(t, groups, 1) {
+		assert.Equal(t, models.DuplicateNear, groups[0].Kind)
+	}
+
+	_, err = c.DeckDuplicates(context.Background(), deckID, 3)
+	assert.Equal(t, apierror.CodeBadRequest, ErrorCode(err))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
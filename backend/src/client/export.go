@@ -0,0 +1,93 @@
+package client
+
+import (
+	"api/src/export"
+	"api/src/models"
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExportDeck returns a deck with its flashcards in the JSON export format
+func (c *Client) ExportDeck(ctx context.Context, id uuid.UUID) (*export.DeckDocument, error) {
+	var doc export.DeckDocument
+	err := c.do(ctx, "GET", apiPrefix+"/decks/"+id.String()+"/export", url.Values{"format": {export.FormatJSON}}, nil, &doc)
+	if err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// DownloadDeck writes a deck export in format (export.FormatJSON or export.FormatCSV) to w
+func (c *Client) DownloadDeck(ctx context.Context, id uuid.UUID, format string, w io.Writer) error {
+	resp, err := c.send(ctx, "GET", apiPrefix+"/decks/"+id.String()+"/export", url.Values{"format": {format}}, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// RequestAccountExport starts building an archive of everything stored about the authenticated user
+func (c *Client) RequestAccountExport(ctx context.Context) (*models.ExportJob, error) {
+	var job models.ExportJob
+	if err := c.do(ctx, "POST", apiPrefix+"/me/export", nil, nil, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// AccountExport returns the status of an account export
+func (c *Client) AccountExport(ctx context.Context, id uuid.UUID) (*models.ExportJob, error) {
+	var job models.ExportJob
+	if err := c.do(ctx, "GET", apiPrefix+"/me/exports/"+id.String(), nil, nil, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// WaitForAccountExport polls an account export every interval until it is done or failed
+func (c *Client) WaitForAccountExport(ctx context.Context, id uuid.UUID, interval time.Duration) (*models.ExportJob, error) {
+	for {
+		job, err := c.AccountExport(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		switch job.Status {
+		case models.ExportDone:
+			return job, nil
+		case models.ExportFailed:
+			return job, errors.New("flashcards: account export failed: " + job.Error)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// DownloadAccountExport writes the archive of a finished export to w
+func (c *Client) DownloadAccountExport(ctx context.Context, job *models.ExportJob, w io.Writer) error {
+	if job.DownloadURL == "" {
+		return errors.New("flashcards: account export has no download link")
+	}
+	link, err := url.Parse(job.DownloadURL)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.send(ctx, "GET", link.Path, link.Query(), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
@@ -0,0 +1,42 @@
+package client
+
+import (
+	"api/src/export"
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportDeck(t *testing.T) {
+	c, mock, userID := newTestClient(t)
+	ctx := context.Background()
+	deckID := uuid.New()
+
+	for i := 0; i < 2; i++ {
+		mock.ExpectQuery(`SELECT id, owner_id, labels, title, description, .* FROM decks WHERE id = \$1 AND owner_id = \$2`).
+			WithArgs(deckID, userID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id", "labels", "title", "description", "exam_date", "leech_threshold", "leech_action", "preset_id"}).
+				AddRow(deckID, userID, pq.Array([]string{}), "Capitals", "", nil, 8, "tag", nil))
+		mock.ExpectQuery(`FROM flashcards f JOIN decks d ON f.parent_deck = d.id WHERE f.parent_deck = \$1`).
+			WithArgs(deckID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "parent_deck", "starred", "front", "back"}).
+				AddRow(uuid.New(), deckID, true, "France", "Paris"))
+	}
+
+	doc, err := c.ExportDeck(ctx, deckID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Capitals", doc.Deck.Title)
+		assert.Len(t, doc.Flashcards, 1)
+	}
+
+	var csv bytes.Buffer
+	assert.NoError(t, c.DownloadDeck(ctx, deckID, export.FormatCSV, &csv))
+	assert.Equal(t, "front,back,starred\nFrance,Paris,true\n", csv.String())
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
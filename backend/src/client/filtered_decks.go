@@ -0,0 +1,65 @@
+package client
+
+import (
+	"api/src/models"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// FilteredDecks lists the filtered decks of the user, without their cards
+func (c *Client) FilteredDecks(ctx context.Context) ([]models.FilteredDeck, error) {
+	var decks []models.FilteredDeck
+	err := c.do(ctx, "GET", apiPrefix+"/filtered-decks", nil, nil, &decks)
+	return decks, err
+}
+
+// FilteredDeck returns a filtered deck with its cards
+func (c *Client) FilteredDeck(ctx context.Context, id uuid.UUID) (*models.FilteredDeck, error) {
+	var d models.FilteredDeck
+	if err := c.do(ctx, "GET", apiPrefix+"/filtered-decks/"+id.String(), nil, nil, &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// CreateFilteredDeck creates a filtered deck from a title and filter and returns it with its cards
+func (c *Client) CreateFilteredDeck(ctx context.Context, d models.FilteredDeck) (*models.FilteredDeck, error) {
+	var created models.FilteredDeck
+	if err := c.do(ctx, "POST", apiPrefix+"/filtered-decks", nil, d, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// UpdateFilteredDeck replaces the title and filter of a filtered deck and rebuilds it
+func (c *Client) UpdateFilteredDeck(ctx context.Context, id uuid.UUID, d models.FilteredDeck) (*models.FilteredDeck, error) {
+	var updated models.FilteredDeck
+	if err := c.do(ctx, "PUT", apiPrefix+"/filtered-decks/"+id.String(), nil, d, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteFilteredDeck deletes a filtered deck; its cards stay in their decks
+func (c *Client) DeleteFilteredDeck(ctx context.Context, id uuid.UUID) error {
+	return c.do(ctx, "DELETE", apiPrefix+"/filtered-decks/"+id.String(), nil, nil, nil)
+}
+
+// RebuildFilteredDeck gathers the cards now matching the filter of a filtered deck
+func (c *Client) RebuildFilteredDeck(ctx context.Context, id uuid.UUID) (*models.FilteredDeck, error) {
+	var d models.FilteredDeck
+	if err := c.do(ctx, "POST", apiPrefix+"/filtered-decks/"+id.String()+"/rebuild", nil, nil, &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// StudyFilteredDeck starts a study session on the cards of a filtered deck
+func (c *Client) StudyFilteredDeck(ctx context.Context, id uuid.UUID, req models.FilteredStudyRequest) (*models.StudySession, error) {
+	var s models.StudySession
+	if err := c.do(ctx, "POST", apiPrefix+"/filtered-decks/"+id.String()+"/study", nil, req, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"api/src/models"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilteredDecks(t *testing.T) {
+	c, mock, userID := newTestClient(t)
+	ctx := context.Background()
+	deckID, cardID, filteredID, sessionID := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	mock.ExpectQuery(`SELECT f.id FROM flashcards f JOIN decks d .* WHERE d.owner_id = \$1 AND f.starred`).
+		WithArgs(userID, models.DefaultFilterLimit).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(cardID))
+	mock.ExpectQuery(`INSERT INTO filtered_decks`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "built_at", "created_at"}).AddRow(filteredID, time.Now(), time.Now()))
+	mock.ExpectQuery(`SELECT id, parent_deck, starred, front, back FROM flashcards`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "parent_deck", "starred", "front", "back"}).
+			AddRow(cardID, deckID, true, "France", "Paris"))
+	created, err := c.CreateFilteredDeck(ctx, models.FilteredDeck{Title: "Starred", Filter: models.CardFilter{Starred: true}})
+	require.NoError(t, err)
+	assert.Equal(t, filteredID, created.ID)
+	assert.Len(t, created.Cards, 1)
+
+	filter, _ := json.Marshal(models.CardFilter{Starred: true})
+	mock.ExpectQuery(`FROM filtered_decks WHERE id = \$1 AND owner_id = \$2`).
+		WithArgs(filteredID, userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id", "title", "filter", "card_ids", "built_at", "created_at"}).
+			AddRow(filteredID, userID, "Starred", filter, "{"+cardID.String()+"}", time.Now(), time.Now()))
+	mock.ExpectQuery(`SELECT f.id, f.parent_deck FROM flashcards f LEFT JOIN card_states s ON s.flashcard_id = f.id WHERE f.id = ANY\(\$1\) AND s.suspended_at IS NULL`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "parent_deck"}).AddRow(cardID, deckID))
+	mock.ExpectQuery(`INSERT INTO study_sessions`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "position", "status", "started_at", "last_activity_at"}).
+			AddRow(sessionID, 0, models.StudySessionActive, time.Now(), time.Now()))
+	mock.ExpectQuery(`SELECT id, parent_deck, starred, front, back FROM flashcards`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "parent_deck", "starred", "front", "back"}).
+			AddRow(cardID, deckID, true, "France", "Paris"))
+	session, err := c.StudyFilteredDeck(ctx, filteredID, models.FilteredStudyRequest{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, sessionID, session.ID)
+		assert.Equal(t, []uuid.UUID{deckID}, session.DeckIDs)
+	}
+
+	mock.ExpectExec(`DELETE FROM filtered_decks`).
+		WithArgs(filteredID, userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, c.DeleteFilteredDeck(ctx, filteredID))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
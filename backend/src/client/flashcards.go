@@ -0,0 +1,61 @@
+package client
+
+import (
+	"api/src/models"
+	"context"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// ListFlashcards returns the flashcards of a deck
+func (c *Client) ListFlashcards(ctx context.Context, deckID uuid.UUID) ([]models.Flashcard, error) {
+	var flashcards []models.Flashcard
+	err := c.do(ctx, "GET", apiPrefix+"/decks/"+deckID.String()+"/flashcards", nil, nil, &flashcards)
+	return flashcards, err
+}
+
+// GetFlashcard returns a flashcard
+func (c *Client) GetFlashcard(ctx context.Context, id uuid.UUID) (*models.Flashcard, error) {
+	var flashcard models.Flashcard
+	if err := c.do(ctx, "GET", apiPrefix+"/flashcards/"+id.String(), nil, nil, &flashcard); err != nil {
+		return nil, err
+	}
+	return &flashcard, nil
+}
+
+// CreateFlashcard adds a flashcard to a deck
+func (c *Client) CreateFlashcard(ctx context.Context, deckID uuid.UUID, flashcard models.Flashcard) (*models.Flashcard, error) {
+	var created models.Flashcard
+	if err := c.do(ctx, "POST", apiPrefix+"/decks/"+deckID.String()+"/flashcards", nil, flashcard, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// UpdateFlashcard replaces the content of a flashcard
+func (c *Client) UpdateFlashcard(ctx context.Context, id uuid.UUID, flashcard models.Flashcard) error {
+	return c.do(ctx, "PUT", apiPrefix+"/flashcards/"+id.String(), nil, flashcard, nil)
+}
+
+// DeleteFlashcard deletes a flashcard
+func (c *Client) DeleteFlashcard(ctx context.Context, id uuid.UUID) error {
+	return c.do(ctx, "DELETE", apiPrefix+"/flashcards/"+id.String(), nil, nil, nil)
+}
+
+// FlashcardRevisions returns the earlier versions of a flashcard, oldest first
+func (c *Client) FlashcardRevisions(ctx context.Context, id uuid.UUID) ([]models.FlashcardRevision, error) {
+	var revisions []models.FlashcardRevision
+	err := c.do(ctx, "GET", apiPrefix+"/flashcards/"+id.String()+"/revisions", nil, nil, &revisions)
+	return revisions, err
+}
+
+// RevertFlashcard restores a flashcard to revision rev
+func (c *Client) RevertFlashcard(ctx context.Context, id uuid.UUID, rev int) (*models.Flashcard, error) {
+	var flashcard models.Flashcard
+	path := apiPrefix + "/flashcards/" + id.String() + "/revisions/" + strconv.Itoa(rev) + "/revert"
+	if err := c.do(ctx, "POST", path, nil, nil, &flashcard); err != nil {
+		return nil, err
+	}
+	return &flashcard, nil
+}
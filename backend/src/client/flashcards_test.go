@@ -0,0 +1,72 @@
+package client
+
+import (
+	"api/src/models"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlashcards(t *testing.T) {
+	c, mock, userID := newTestClient(t)
+	ctx := context.Background()
+	deckID := uuid.New()
+	flashcardID := uuid.New()
+	starred := false
+
+	mock.ExpectQuery(`SELECT owner_id FROM decks WHERE id = \$1`).
+		WithArgs(deckID).
+		WillReturnRows(sqlmock.NewRows([]string{"owner_id"}).AddRow(userID))
+	mock.ExpectQuery(`SELECT id, front FROM flashcards WHERE parent_deck = \$1 ORDER BY id`).
+		WithArgs(deckID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "front"}))
+	mock.ExpectQuery(`INSERT INTO flashcards \(parent_deck, starred, front, back\) VALUES \(\$1, \$2, \$3, \$4\) RETURNING id`).
+		WithArgs(deckID, false, "France", "Paris").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(flashcardID))
+	created, err := c.CreateFlashcard(ctx, deckID, models.Flashcard{Starred: &starred, Front: "France", Back: "Paris"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, flashcardID, created.ID)
+		assert.Equal(t, deckID, created.ParentDeck)
+	}
+
+	mock.ExpectQuery(`SELECT f.id, f.parent_deck, f.starred, f.front, f.back`).
+		WithArgs(deckID, userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "parent_deck", "starred", "front", "back"}).
+			AddRow(flashcardID, deckID, false, "France", "Paris"))
+	flashcards, err := c.ListFlashcards(ctx, deckID)
+	if assert.NoError(t, err) && assert.Len(t, flashcards, 1) {
+		assert.Equal(t, "Paris", flashcards[0].Back)
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFlashcardRevisions(t *testing.T) {
+	c, mock, userID := newTestClient(t)
+	cardID := uuid.New()
+	columns := []string{"flashcard_id", "rev", "starred", "front", "back", "edited_by", "created_at"}
+
+	mock.ExpectQuery(`SELECT f.starred, f.front, f.back FROM flashcards f JOIN decks d ON f.parent_deck = d.id WHERE f.id = \$1 AND d.owner_id = \$2`).
+		WithArgs(cardID, userID).
+		WillReturnRows(sqlmock.NewRows([]string{"starred", "front", "back"}).AddRow(true, "France", "Paris"))
+	mock.ExpectQuery(`FROM flashcard_revisions WHERE flashcard_id = \$1 ORDER BY rev`).
+		WithArgs(cardID).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(cardID, 1, false, "France", "Pariss", userID, time.Now().Add(-time.Hour)).
+			AddRow(cardID, 2, false, "France", "Paris", userID, time.Now()))
+	revisions, err := c.FlashcardRevisions(context.Background(), cardID)
+	if assert.NoError(t, err) && assert.Len(t, revisions, 2) {
+		assert.Equal(t, 1, revisions[0].Rev)
+		assert.NotEmpty(t, revisions[0].Diff.Back)
+		assert.Nil(t, revisions[0].Diff.Starred)
+		// The newest revision is diffed against the current flashcard
+		assert.Equal(t, 2, revisions[1].Rev)
+		assert.Empty(t, revisions[1].Diff.Back)
+		assert.NotNil(t, revisions[1].Diff.Starred)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,35 @@
+package client
+
+import (
+	"api/src/models"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// StartGame starts a match or true/false round on a deck. The clock starts on the server.
+func (c *Client) StartGame(ctx context.Context, deckID uuid.UUID, req models.GameRequest) (*models.GameRound, error) {
+	var round models.GameRound
+	if err := c.do(ctx, "POST", apiPrefix+"/decks/"+deckID.String()+"/games", nil, req, &round); err != nil {
+		return nil, err
+	}
+	return &round, nil
+}
+
+// SubmitGame submits the answers to a round and returns its result and time
+func (c *Client) SubmitGame(ctx context.Context, roundID uuid.UUID, submission models.GameSubmission) (*models.GameResult, error) {
+	var result models.GameResult
+	if err := c.do(ctx, "POST", apiPrefix+"/game-rounds/"+roundID.String()+"/submit", nil, submission, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GameBests returns the best times of the user on a deck
+func (c *Client) GameBests(ctx context.Context, deckID uuid.UUID) ([]models.GameBest, error) {
+	var bests []models.GameBest
+	if err := c.do(ctx, "GET", apiPrefix+"/decks/"+deckID.String()+"/games/best", nil, nil, &bests); err != nil {
+		return nil, err
+	}
+	return bests, nil
+}
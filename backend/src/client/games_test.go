@@ -0,0 +1,66 @@
+package client
+
+import (
+	"api/src/models"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGames(t *testing.T) {
+	c, mock, userID := newTestClient(t)
+	ctx := context.Background()
+	deckID, roundID := uuid.New(), uuid.New()
+
+	mock.ExpectQuery(`SELECT id FROM decks WHERE id = \$1 AND owner_id = \$2`).
+		WithArgs(deckID, userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(deckID))
+	mock.ExpectQuery(`SELECT f.id, f.parent_deck, f.starred, f.front, f.back`).
+		WithArgs(deckID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "parent_deck", "starred", "front", "back"}).
+			AddRow(uuid.New(), deckID, false, "France", "Paris").
+			AddRow(uuid.New(), deckID, false, "Italy", "Rome"))
+	mock.ExpectQuery(`INSERT INTO game_rounds`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "started_at"}).AddRow(roundID, time.Now()))
+	round, err := c.StartGame(ctx, deckID, models.GameRequest{Kind: models.GameTrueFalse})
+	require.NoError(t, err)
+	assert.Equal(t, roundID, round.ID)
+	assert.Len(t, round.Statements, 2)
+
+	items, _ := json.Marshal(map[string]any{"statements": []models.GameStatement{{Front: "France", Back: "Paris"}}})
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM game_rounds WHERE id = \$1 AND user_id = \$2 FOR UPDATE`).
+		WithArgs(roundID, userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "deck_id", "kind", "seed", "seeded", "items", "answer_key", "started_at", "submitted"}).
+			AddRow(roundID, deckID, models.GameTrueFalse, 1, false, items, "{1}", time.Now(), false))
+	mock.ExpectQuery(`SELECT MIN\(duration_ms\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow(nil))
+	mock.ExpectQuery(`UPDATE game_rounds`).
+		WillReturnRows(sqlmock.NewRows([]string{"duration_ms"}).AddRow(4200))
+	mock.ExpectCommit()
+	result, err := c.SubmitGame(ctx, roundID, models.GameSubmission{Answers: []bool{true}})
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, result.Correct)
+		assert.True(t, result.NewBest)
+	}
+
+	mock.ExpectQuery(`SELECT id FROM decks`).
+		WithArgs(deckID, userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(deckID))
+	mock.ExpectQuery(`SELECT DISTINCT ON \(kind\)`).
+		WithArgs(userID, deckID).
+		WillReturnRows(sqlmock.NewRows([]string{"kind", "perfect", "duration_ms", "submitted_at", "count"}).
+			AddRow(models.GameTrueFalse, true, 4200, time.Now(), 1))
+	bests, err := c.GameBests(ctx, deckID)
+	if assert.NoError(t, err) && assert.Len(t, bests, 1) {
+		assert.Equal(t, int64(4200), *bests[0].BestMs)
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"api/src/models"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Leeches lists the cards the user keeps forgetting, across decks
+func (c *Client) Leeches(ctx context.Context) ([]models.Leech, error) {
+	var leeches []models.Leech
+	err := c.do(ctx, "GET", apiPrefix+"/leeches", nil, nil, &leeches)
+	return leeches, err
+}
+
+// SuspendFlashcard leaves a flashcard out of study until it is unsuspended
+func (c *Client) SuspendFlashcard(ctx context.Context, id uuid.UUID) (*models.CardState, error) {
+	return c.setCardState(ctx, id, "suspend")
+}
+
+// UnsuspendFlashcard brings a suspended or buried flashcard back to study
+func (c *Client) UnsuspendFlashcard(ctx context.Context, id uuid.UUID) (*models.CardState, error) {
+	return c.setCardState(ctx, id, "unsuspend")
+}
+
+// BuryFlashcard leaves a flashcard out of study until the next day
+func (c *Client) BuryFlashcard(ctx context.Context, id uuid.UUID) (*models.CardState, error) {
+	return c.setCardState(ctx, id, "bury")
+}
+
+func (c *Client) setCardState(ctx context.Context, id uuid.UUID, action string) (*models.CardState, error) {
+	var state models.CardState
+	if err := c.do(ctx, "POST", apiPrefix+"/flashcards/"+id.String()+"/"+action, nil, nil, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
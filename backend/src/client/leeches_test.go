@@ -0,0 +1,47 @@
+package client
+
+import (
+	"api/src/apierror"
+	"api/src/scheduler"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLeeches(t *testing.T) {
+	c, mock, userID := newTestClient(t)
+	ctx := context.Background()
+	cardID, deckID := uuid.New(), uuid.New()
+	columns := []string{"flashcard_id", "state", "step", "ease", "interval_days", "due_at", "reps", "lapses", "leech", "suspended_at", "buried_until"}
+
+	mock.ExpectQuery(`FROM card_states s .* WHERE d.owner_id = \$1 AND s.leech`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "parent_deck", "starred", "front", "back", "title", "lapses", "suspended_at"}).
+			AddRow(cardID, deckID, false, "Ubiquitous", "found everywhere", "Vocabulary", 9, time.Now()))
+	leeches, err := c.Leeches(ctx)
+	if assert.NoError(t, err) && assert.Len(t, leeches, 1) {
+		assert.Equal(t, cardID, leeches[0].Flashcard.ID)
+		assert.Equal(t, 9, leeches[0].Lapses)
+	}
+
+	mock.ExpectQuery(`INSERT INTO card_states \(flashcard_id\) SELECT f.id`).
+		WithArgs(cardID, userID).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(cardID, scheduler.StateReview, 0, 1.3, 1, time.Now(), 20, 9, true, nil, nil))
+	state, err := c.UnsuspendFlashcard(ctx, cardID)
+	if assert.NoError(t, err) {
+		assert.Nil(t, state.SuspendedAt)
+		assert.True(t, state.Leech)
+	}
+
+	mock.ExpectQuery(`INSERT INTO card_states \(flashcard_id, suspended_at\)`).
+		WithArgs(cardID, userID).
+		WillReturnRows(sqlmock.NewRows(columns))
+	_, err = c.SuspendFlashcard(ctx, cardID)
+	assert.Equal(t, apierror.CodeNotFound, ErrorCode(err))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
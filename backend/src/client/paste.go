@@ -0,0 +1,24 @@
+package client
+
+import (
+	"api/src/models"
+	"context"
+)
+
+// PreviewPaste returns the cards pasted text would be imported as
+func (c *Client) PreviewPaste(ctx context.Context, paste models.PasteImport) (*models.PastePreview, error) {
+	var preview models.PastePreview
+	if err := c.do(ctx, "POST", apiPrefix+"/import/paste/preview", nil, paste, &preview); err != nil {
+		return nil, err
+	}
+	return &preview, nil
+}
+
+// ImportPaste imports pasted text into paste.DeckID, or into a new deck named paste.Title
+func (c *Client) ImportPaste(ctx context.Context, paste models.PasteImport) (*models.PasteImportResult, error) {
+	var result models.PasteImportResult
+	if err := c.do(ctx, "POST", apiPrefix+"/import/paste", nil, paste, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
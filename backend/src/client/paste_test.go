@@ -0,0 +1,46 @@
+package client
+
+import (
+	"api/src/models"
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaste(t *testing.T) {
+	c, mock, userID := newTestClient(t)
+	ctx := context.Background()
+	paste := models.PasteImport{Text: "hola\thello\nadiós\tgoodbye\nnope", Title: "Spanish"}
+
+	preview, err := c.PreviewPaste(ctx, paste)
+	if assert.NoError(t, err) {
+		assert.Len(t, preview.Cards, 2)
+		if assert.Len(t, preview.Warnings, 1) {
+			assert.True(t, preview.Warnings[0].Skipped)
+		}
+	}
+
+	deckID := uuid.New()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO decks \(owner_id, labels, title, description\) VALUES \(\$1, \$2, \$3, \$4\) RETURNING id, owner_id, labels`).
+		WithArgs(userID, pq.StringArray{}, "Spanish", "").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id", "labels", "title", "description", "exam_date", "leech_threshold", "leech_action", "preset_id"}).
+			AddRow(deckID, userID, pq.Array([]string{}), "Spanish", "", nil, 8, "tag", nil))
+	for _, front := range []string{"hola", "adiós"} {
+		mock.ExpectQuery(`INSERT INTO flashcards`).
+			WithArgs(deckID, false, front, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	}
+	mock.ExpectCommit()
+
+	result, err := c.ImportPaste(ctx, paste)
+	if assert.NoError(t, err) {
+		assert.Equal(t, deckID, result.Deck.ID)
+		assert.Len(t, result.Flashcards, 2)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
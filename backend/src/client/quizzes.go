@@ -0,0 +1,51 @@
+package client
+
+import (
+	"api/src/models"
+	"context"
+	"io"
+	"net/url"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// CreateQuiz generates a multiple-choice quiz from a deck. The questions come without their answers.
+func (c *Client) CreateQuiz(ctx context.Context, deckID uuid.UUID, req models.QuizRequest) (*models.Quiz, error) {
+	var q models.Quiz
+	if err := c.do(ctx, "POST", apiPrefix+"/decks/"+deckID.String()+"/quiz", nil, req, &q); err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+// Quiz returns a quiz, with the answer key when answers is set
+func (c *Client) Quiz(ctx context.Context, id uuid.UUID, answers bool) (*models.Quiz, error) {
+	var q models.Quiz
+	query := url.Values{"answers": {strconv.FormatBool(answers)}}
+	if err := c.do(ctx, "GET", apiPrefix+"/quizzes/"+id.String(), query, nil, &q); err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+// PrintQuiz writes a printable Markdown quiz to w, followed by the answer key when answers is set
+func (c *Client) PrintQuiz(ctx context.Context, id uuid.UUID, answers bool, w io.Writer) error {
+	query := url.Values{"format": {"markdown"}, "answers": {strconv.FormatBool(answers)}}
+	resp, err := c.send(ctx, "GET", apiPrefix+"/quizzes/"+id.String(), query, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// SubmitQuiz submits the index of the choice picked for every question, -1 to leave one unanswered
+func (c *Client) SubmitQuiz(ctx context.Context, id uuid.UUID, answers []int) (*models.QuizAttempt, error) {
+	var attempt models.QuizAttempt
+	if err := c.do(ctx, "POST", apiPrefix+"/quizzes/"+id.String()+"/submit", nil, models.QuizSubmission{Answers: answers}, &attempt); err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"api/src/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuizzes(t *testing.T) {
+	c, mock, userID := newTestClient(t)
+	ctx := context.Background()
+	deckID, quizID := uuid.New(), uuid.New()
+	seed := int64(7)
+
+	mock.ExpectQuery(`SELECT id FROM decks WHERE id = \$1 AND owner_id = \$2`).
+		WithArgs(deckID, userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(deckID))
+	mock.ExpectQuery(`SELECT f.id, f.parent_deck, f.starred, f.front, f.back`).
+		WithArgs(deckID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "parent_deck", "starred", "front", "back"}).
+			AddRow(uuid.New(), deckID, false, "France", "Paris").
+			AddRow(uuid.New(), deckID, false, "Italy", "Rome"))
+	mock.ExpectQuery(`INSERT INTO quizzes`).
+		WithArgs(deckID, seed, 2, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(quizID, time.Now()))
+	created, err := c.CreateQuiz(ctx, deckID, models.QuizRequest{Seed: &seed, Choices: 2})
+	require.NoError(t, err)
+	require.Len(t, created.Questions, 2)
+	assert.Nil(t, created.Questions[0].Answer)
+
+	answer := 0
+	questions, _ := json.Marshal([]models.QuizQuestion{{Prompt: "France", Choices: []string{"Paris", "Rome"}, Answer: &answer}})
+	quizRow := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "deck_id", "seed", "choices", "questions", "created_at", "title"}).
+			AddRow(quizID, deckID, seed, 2, questions, time.Now(), "Capitals")
+	}
+
+	mock.ExpectQuery(`FROM quizzes q JOIN decks d`).WithArgs(quizID, userID).WillReturnRows(quizRow())
+	key, err := c.Quiz(ctx, quizID, true)
+	if assert.NoError(t, err) && assert.NotNil(t, key.Questions[0].Answer) {
+		assert.Equal(t, 0, *key.Questions[0].Answer)
+	}
+
+	mock.ExpectQuery(`FROM quizzes q JOIN decks d`).WithArgs(quizID, userID).WillReturnRows(quizRow())
+	var buf bytes.Buffer
+	if assert.NoError(t, c.PrintQuiz(ctx, quizID, false, &buf)) {
+		assert.Contains(t, buf.String(), "1. France\n\n   A) Paris\n   B) Rome\n")
+	}
+
+	mock.ExpectQuery(`FROM quizzes q JOIN decks d`).WithArgs(quizID, userID).WillReturnRows(quizRow())
+	mock.ExpectQuery(`INSERT INTO quiz_attempts`).
+		WithArgs(quizID, userID, pq.Array([]int{0}), 1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "submitted_at"}).AddRow(uuid.New(), time.Now()))
+	attempt, err := c.SubmitQuiz(ctx, quizID, []int{0})
+	if assert.NoError(t, err) {
+		assert.Equal(t, 100.0, attempt.Percent)
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,34 @@
+package client
+
+import (
+	"api/src/models"
+	"context"
+	"net/url"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// Stats returns the learning statistics of the user over the last days,
+// models.DefaultStatsDays when days is 0
+func (c *Client) Stats(ctx context.Context, days int) (*models.Stats, error) {
+	return c.stats(ctx, apiPrefix+"/stats", days)
+}
+
+// DeckStats returns the learning statistics of a deck over the last days,
+// models.DefaultStatsDays when days is 0
+func (c *Client) DeckStats(ctx context.Context, deckID uuid.UUID, days int) (*models.Stats, error) {
+	return c.stats(ctx, apiPrefix+"/decks/"+deckID.String()+"/stats", days)
+}
+
+func (c *Client) stats(ctx context.Context, path string, days int) (*models.Stats, error) {
+	var query url.Values
+	if days > 0 {
+		query = url.Values{"days": {strconv.Itoa(days)}}
+	}
+	var stats models.Stats
+	if err := c.do(ctx, "GET", path, query, nil, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"api/src/apierror"
+	"api/src/models"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats(t *testing.T) {
+	c, mock, userID := newTestClient(t)
+	ctx := context.Background()
+	today := time.Now().UTC().Format(time.DateOnly)
+
+	mock.ExpectQuery(`FROM users u LEFT JOIN study_goals g`).
+		WillReturnRows(sqlmock.NewRows([]string{"timezone", "unit", "target", "day_rollover"}).AddRow("UTC", models.GoalCards, 20, 0))
+	mock.ExpectQuery(`AS day, COUNT\(\*\), .* FROM review_logs r`).
+		WithArgs(userID, nil, sqlmock.AnyArg(), "UTC", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"day", "count", "correct", "duration"}).AddRow(today, 5, 4, 30000))
+	mock.ExpectQuery(`width_bucket`).
+		WillReturnRows(sqlmock.NewRows([]string{"bucket", "count", "recalled"}))
+	mock.ExpectQuery(`EXTRACT\(HOUR`).
+		WillReturnRows(sqlmock.NewRows([]string{"hour", "count", "correct"}))
+	mock.ExpectQuery(`FROM flashcards f JOIN decks d`).
+		WillReturnRows(sqlmock.NewRows([]string{"new", "learning", "young", "mature", "suspended"}).AddRow(7, 0, 0, 0, 0))
+	mock.ExpectQuery(`GREATEST\(s.due_at, \$3\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"day", "due"}))
+	stats, err := c.Stats(ctx, 7)
+	if assert.NoError(t, err) {
+		assert.Len(t, stats.Reviews, 7)
+		assert.Equal(t, 5, stats.TotalReviews)
+		assert.Equal(t, 7, stats.Cards.New)
+	}
+
+	deckID := uuid.New()
+	mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM decks`).
+		WithArgs(deckID, userID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	_, err = c.DeckStats(ctx, deckID, 0)
+	assert.Equal(t, apierror.CodeNotFound, ErrorCode(err))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,33 @@
+package client
+
+import (
+	"api/src/models"
+	"context"
+)
+
+// StudyGoal returns the daily goal of the user
+func (c *Client) StudyGoal(ctx context.Context) (*models.StudyGoal, error) {
+	var goal models.StudyGoal
+	if err := c.do(ctx, "GET", apiPrefix+"/goal", nil, nil, &goal); err != nil {
+		return nil, err
+	}
+	return &goal, nil
+}
+
+// UpdateStudyGoal sets the daily goal of the user
+func (c *Client) UpdateStudyGoal(ctx context.Context, goal models.StudyGoal) (*models.StudyGoal, error) {
+	var updated models.StudyGoal
+	if err := c.do(ctx, "PUT", apiPrefix+"/goal", nil, goal, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// Streak returns the study streak of the user and the heatmap of their last year
+func (c *Client) Streak(ctx context.Context) (*models.Streak, error) {
+	var s models.Streak
+	if err := c.do(ctx, "GET", apiPrefix+"/streak", nil, nil, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
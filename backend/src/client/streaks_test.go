@@ -0,0 +1,51 @@
+package client
+
+import (
+	"api/src/apierror"
+	"api/src/models"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreak(t *testing.T) {
+	c, mock, userID := newTestClient(t)
+	ctx := context.Background()
+	goalColumns := []string{"timezone", "unit", "target", "day_rollover"}
+
+	mock.ExpectExec(`INSERT INTO study_goals`).
+		WithArgs(userID, models.GoalMinutes, 10, 0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	goal, err := c.UpdateStudyGoal(ctx, models.StudyGoal{Unit: models.GoalMinutes, Target: 10})
+	if assert.NoError(t, err) {
+		assert.Equal(t, 10, goal.Target)
+	}
+
+	_, err = c.UpdateStudyGoal(ctx, models.StudyGoal{Unit: "pages", Target: 10})
+	assert.Equal(t, apierror.CodeValidation, ErrorCode(err))
+
+	mock.ExpectQuery(`FROM users u LEFT JOIN study_goals g`).
+		WithArgs(userID, models.GoalCards, 20, 4).
+		WillReturnRows(sqlmock.NewRows(goalColumns).AddRow("UTC", models.GoalMinutes, 10, 0))
+	goal, err = c.StudyGoal(ctx)
+	if assert.NoError(t, err) {
+		assert.Equal(t, models.GoalMinutes, goal.Unit)
+	}
+
+	mock.ExpectQuery(`FROM users u LEFT JOIN study_goals g`).
+		WillReturnRows(sqlmock.NewRows(goalColumns).AddRow("UTC", models.GoalMinutes, 10, 0))
+	mock.ExpectQuery(`FROM review_logs r WHERE r.user_id = \$1 GROUP BY day`).
+		WithArgs(userID, "UTC", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"day", "cards", "duration"}).
+			AddRow(time.Now().UTC().Format(time.DateOnly), 30, 11*60000))
+	s, err := c.Streak(ctx)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, s.Current)
+		assert.True(t, s.Today.GoalMet)
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
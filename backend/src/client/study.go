@@ -0,0 +1,53 @@
+package client
+
+import (
+	"api/src/models"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// StartStudySession starts a study session over one or more decks
+func (c *Client) StartStudySession(ctx context.Context, req models.StudySessionRequest) (*models.StudySession, error) {
+	var session models.StudySession
+	if err := c.do(ctx, "POST", apiPrefix+"/study-sessions", nil, req, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// StudySession returns a study session with the cards left to study, or its summary once closed
+func (c *Client) StudySession(ctx context.Context, id uuid.UUID) (*models.StudySession, error) {
+	var session models.StudySession
+	if err := c.do(ctx, "GET", apiPrefix+"/study-sessions/"+id.String(), nil, nil, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// AnswerStudyCard answers the next card of a study session
+func (c *Client) AnswerStudyCard(ctx context.Context, id uuid.UUID, answer models.StudyAnswer) (*models.StudyAnswerResult, error) {
+	var result models.StudyAnswerResult
+	if err := c.do(ctx, "POST", apiPrefix+"/study-sessions/"+id.String()+"/answer", nil, answer, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// FinishStudySession closes a study session and returns it with its summary
+func (c *Client) FinishStudySession(ctx context.Context, id uuid.UUID) (*models.StudySession, error) {
+	var session models.StudySession
+	if err := c.do(ctx, "POST", apiPrefix+"/study-sessions/"+id.String()+"/finish", nil, nil, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// DeckPlan returns the day-by-day cram plan of a deck with an upcoming exam
+func (c *Client) DeckPlan(ctx context.Context, deckID uuid.UUID) (*models.CramPlan, error) {
+	var plan models.CramPlan
+	if err := c.do(ctx, "GET", apiPrefix+"/decks/"+deckID.String()+"/plan", nil, nil, &plan); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
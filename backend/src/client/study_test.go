@@ -0,0 +1,114 @@
+package client
+
+import (
+	"api/src/models"
+	"api/src/scheduler"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStudySession(t *testing.T) {
+	c, mock, userID := newTestClient(t)
+	ctx := context.Background()
+	deckID, cardID, sessionID := uuid.New(), uuid.New(), uuid.New()
+	decks, cards := pq.Array([]uuid.UUID{deckID}), pq.Array([]uuid.UUID{cardID})
+	started := time.Now()
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM decks`).
+		WithArgs(decks, userID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(`SELECT f.id FROM flashcards f LEFT JOIN card_states s ON s.flashcard_id = f.id WHERE f.parent_deck = ANY\(\$1\)`).
+		WithArgs(decks).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(cardID))
+	mock.ExpectQuery(`INSERT INTO study_sessions`).
+		WithArgs(userID, decks, models.StudyModePractice, models.DefaultNewLimit, models.DefaultReviewLimit, 0, cards).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "position", "status", "started_at", "last_activity_at"}).
+			AddRow(sessionID, 0, models.StudySessionActive, started, started))
+	mock.ExpectQuery(`SELECT id, parent_deck, starred, front, back FROM flashcards WHERE id = ANY\(\$1\)`).
+		WithArgs(cards).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "parent_deck", "starred", "front", "back"}).AddRow(cardID, deckID, false, "France", "Paris"))
+	session, err := c.StartStudySession(ctx, models.StudySessionRequest{DeckIDs: []uuid.UUID{deckID}, Mode: models.StudyModePractice})
+	require.NoError(t, err)
+	assert.Equal(t, sessionID, session.ID)
+	require.Len(t, session.Cards, 1)
+
+	sessionRow := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "user_id", "deck_ids", "mode", "new_limit", "review_limit", "card_limit", "card_ids", "position", "status", "started_at", "last_activity_at", "finished_at"}).
+			AddRow(sessionID, userID, "{"+deckID.String()+"}", models.StudyModePractice, 20, 200, 0, "{"+cardID.String()+"}", 0, models.StudySessionActive, started, started, nil)
+	}
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM study_sessions WHERE id = \$1 AND user_id = \$2 FOR UPDATE`).
+		WithArgs(sessionID, userID).
+		WillReturnRows(sessionRow())
+	mock.ExpectQuery(`SELECT id, parent_deck, starred, front, back FROM flashcards WHERE id = ANY\(\$1\)`).
+		WithArgs(cards).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "parent_deck", "starred", "front", "back"}).AddRow(cardID, deckID, false, "France", "Paris"))
+	mock.ExpectQuery(`FROM flashcards f LEFT JOIN card_states s`).
+		WithArgs(cardID).
+		WillReturnRows(sqlmock.NewRows([]string{"state", "step", "ease", "interval_days", "due_at", "reps", "lapses", "leech", "suspended_at", "buried_until"}).AddRow("new", 0, 0.0, 0, nil, 0, 0, false, nil, nil))
+	mock.ExpectQuery(`FROM decks d JOIN deck_presets p`).
+		WithArgs(decks).
+		WillReturnRows(sqlmock.NewRows([]string{"deck_id"}))
+	mock.ExpectQuery(`INSERT INTO review_logs`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	mock.ExpectExec(`UPDATE study_sessions SET card_ids`).
+		WithArgs(cards, 1, sqlmock.AnyArg(), sessionID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	result, err := c.AnswerStudyCard(ctx, sessionID, models.StudyAnswer{FlashcardID: cardID, Grade: scheduler.Good, DurationMs: 1500})
+	if assert.NoError(t, err) {
+		assert.Nil(t, result.Next)
+		assert.Equal(t, scheduler.Good, result.Review.Grade)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM study_sessions WHERE id = \$1 AND user_id = \$2 FOR UPDATE`).
+		WithArgs(sessionID, userID).
+		WillReturnRows(sessionRow())
+	mock.ExpectQuery(`UPDATE study_sessions SET status = 'finished'`).
+		WithArgs(sessionID).
+		WillReturnRows(sqlmock.NewRows([]string{"finished_at"}).AddRow(started.Add(time.Minute)))
+	mock.ExpectQuery(`FROM review_logs WHERE session_id = \$1`).
+		WithArgs(sessionID).
+		WillReturnRows(sqlmock.NewRows([]string{"answers", "correct", "study_time", "studied", "learned", "lapsed"}).AddRow(1, 1, 1500, 1, 0, 0))
+	mock.ExpectCommit()
+	finished, err := c.FinishStudySession(ctx, sessionID)
+	if assert.NoError(t, err) && assert.NotNil(t, finished.Summary) {
+		assert.Equal(t, 1.0, finished.Summary.Accuracy)
+		assert.Equal(t, int64(60000), finished.Summary.ElapsedMs)
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeckPlan(t *testing.T) {
+	c, mock, userID := newTestClient(t)
+	deckID := uuid.New()
+	exam := time.Now().UTC().Add(48 * time.Hour).Format(time.DateOnly)
+
+	mock.ExpectQuery(`SELECT to_char\(exam_date, 'YYYY-MM-DD'\) FROM decks`).
+		WithArgs(deckID, userID).
+		WillReturnRows(sqlmock.NewRows([]string{"exam_date"}).AddRow(exam))
+	mock.ExpectQuery(`FROM users u LEFT JOIN study_goals g`).
+		WillReturnRows(sqlmock.NewRows([]string{"timezone", "unit", "target", "day_rollover"}).AddRow("UTC", models.GoalCards, 20, 0))
+	mock.ExpectQuery(`FROM flashcards f LEFT JOIN card_states s`).
+		WithArgs(deckID).
+		WillReturnRows(sqlmock.NewRows([]string{"state", "step", "ease", "interval_days", "due_at", "reps", "lapses"}).
+			AddRow(scheduler.StateNew, 0, 0.0, 0, nil, 0, 0))
+	mock.ExpectQuery(`FROM decks d JOIN deck_presets p`).
+		WillReturnRows(sqlmock.NewRows([]string{"deck_id"}))
+	plan, err := c.DeckPlan(context.Background(), deckID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, exam, plan.ExamDate)
+		assert.Len(t, plan.Days, plan.DaysLeft)
+		assert.Equal(t, 1, plan.Days[0].NewCards)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
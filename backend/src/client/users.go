@@ -0,0 +1,69 @@
+package client
+
+import (
+	"api/src/models"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Me returns the authenticated user
+func (c *Client) Me(ctx context.Context) (*models.User, error) {
+	var user models.User
+	if err := c.do(ctx, "GET", apiPrefix+"/me", nil, nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetUser returns a user. Only admins can get users other than themselves.
+func (c *Client) GetUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	var user models.User
+	if err := c.do(ctx, "GET", apiPrefix+"/users/"+id.String(), nil, nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Register creates the application user of the authenticated session
+func (c *Client) Register(ctx context.Context, name, email string) (*models.User, error) {
+	var user models.User
+	err := c.do(ctx, "POST", apiPrefix+"/users", nil, models.User{Name: name, Email: email}, &user)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateUser changes the name and email of the authenticated user
+func (c *Client) UpdateUser(ctx context.Context, id uuid.UUID, name, email string) (*models.User, error) {
+	var user models.User
+	err := c.do(ctx, "PUT", apiPrefix+"/users/"+id.String(), nil, models.User{Name: name, Email: email}, &user)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// DeleteUser schedules the deletion of the authenticated user's account
+func (c *Client) DeleteUser(ctx context.Context, id uuid.UUID) (*models.AccountDeletion, error) {
+	var deletion models.AccountDeletion
+	if err := c.do(ctx, "DELETE", apiPrefix+"/users/"+id.String(), nil, nil, &deletion); err != nil {
+		return nil, err
+	}
+	return &deletion, nil
+}
+
+// AccountDeletion returns the deletion status of the authenticated user's account
+func (c *Client) AccountDeletion(ctx context.Context) (*models.AccountDeletion, error) {
+	var deletion models.AccountDeletion
+	if err := c.do(ctx, "GET", apiPrefix+"/me/deletion", nil, nil, &deletion); err != nil {
+		return nil, err
+	}
+	return &deletion, nil
+}
+
+// CancelAccountDeletion restores an account scheduled for deletion
+func (c *Client) CancelAccountDeletion(ctx context.Context) error {
+	return c.do(ctx, "DELETE", apiPrefix+"/me/deletion", nil, nil, nil)
+}
@@ -0,0 +1,25 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMe(t *testing.T) {
+	c, mock, userID := newTestClient(t)
+
+	mock.ExpectQuery(`SELECT id, clerk_id, name, email, role, suspended_at, timezone FROM users WHERE id = \$1`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "clerk_id", "name", "email", "role", "suspended_at", "timezone"}).
+			AddRow(userID, "client_user", "Ada", "ada@example.com", "user", nil, "UTC"))
+
+	me, err := c.Me(context.Background())
+	if assert.NoError(t, err) {
+		assert.Equal(t, userID, me.ID)
+		assert.Equal(t, "ada@example.com", me.Email)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
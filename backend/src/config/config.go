@@ -0,0 +1,80 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Provisioning modes for application users
+const (
+	// ProvisionWebhook only creates users through POST /users or the Clerk webhook
+	ProvisionWebhook = "webhook"
+	// ProvisionJIT additionally creates users on their first authenticated request
+	ProvisionJIT = "jit"
+)
+
+// Authentication providers
+const (
+	// AuthClerk verifies Clerk session JWTs
+	AuthClerk = "clerk"
+	// AuthLocal verifies HS256 JWTs signed with AUTH_JWT_SECRET
+	AuthLocal = "local"
+	// AuthStatic accepts the fixed tokens listed in AUTH_STATIC_TOKENS
+	AuthStatic = "static"
+)
+
+// Environments
+const (
+	// EnvProduction hides the causes of internal errors from responses
+	EnvProduction = "production"
+	// EnvDevelopment includes them in a debug member of error responses
+	EnvDevelopment = "development"
+)
+
+// Config holds the settings read from the environment at startup
+type Config struct {
+	// Env is EnvProduction unless APP_ENV is development
+	Env              string
+	Port             string
+	ClerkSecretKey   string
+	UserProvisioning string
+	AuthProvider     string
+	AuthJWTSecret    string
+	AuthStaticTokens string
+	// ExportDir is where account archives are written
+	ExportDir string
+	// ExportSigningSecret signs archive download links; a random one is used when empty
+	ExportSigningSecret string
+}
+
+// Load reads the configuration from environment variables, applying defaults
+func Load() Config {
+	cfg := Config{
+		Env:                 strings.ToLower(os.Getenv("APP_ENV")),
+		Port:                os.Getenv("PORT"),
+		ClerkSecretKey:      os.Getenv("CLERK_SECRET_KEY"),
+		UserProvisioning:    strings.ToLower(os.Getenv("USER_PROVISIONING")),
+		AuthProvider:        strings.ToLower(os.Getenv("AUTH_PROVIDER")),
+		AuthJWTSecret:       os.Getenv("AUTH_JWT_SECRET"),
+		AuthStaticTokens:    os.Getenv("AUTH_STATIC_TOKENS"),
+		ExportDir:           os.Getenv("EXPORT_DIR"),
+		ExportSigningSecret: os.Getenv("EXPORT_SIGNING_SECRET"),
+	}
+	if cfg.Env != EnvDevelopment {
+		cfg.Env = EnvProduction
+	}
+	if cfg.Port == "" {
+		cfg.Port = "8080"
+	}
+	if cfg.UserProvisioning != ProvisionJIT {
+		cfg.UserProvisioning = ProvisionWebhook
+	}
+	if cfg.AuthProvider == "" {
+		cfg.AuthProvider = AuthClerk
+	}
+	if cfg.ExportDir == "" {
+		cfg.ExportDir = filepath.Join(os.TempDir(), "flashcard-exports")
+	}
+	return cfg
+}
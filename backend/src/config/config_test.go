@@ -0,0 +1,52 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		t.Setenv("PORT", "")
+		t.Setenv("USER_PROVISIONING", "")
+		t.Setenv("AUTH_PROVIDER", "")
+		t.Setenv("APP_ENV", "")
+
+		cfg := Load()
+		assert.Equal(t, EnvProduction, cfg.Env)
+		assert.Equal(t, "8080", cfg.Port)
+		assert.Equal(t, ProvisionWebhook, cfg.UserProvisioning)
+		assert.Equal(t, AuthClerk, cfg.AuthProvider)
+	})
+
+	t.Run("local auth provider", func(t *testing.T) {
+		t.Setenv("AUTH_PROVIDER", "Local")
+		t.Setenv("AUTH_JWT_SECRET", "dev-secret")
+
+		cfg := Load()
+		assert.Equal(t, AuthLocal, cfg.AuthProvider)
+		assert.Equal(t, "dev-secret", cfg.AuthJWTSecret)
+	})
+
+	t.Run("development environment", func(t *testing.T) {
+		t.Setenv("APP_ENV", "Development")
+
+		cfg := Load()
+		assert.Equal(t, EnvDevelopment, cfg.Env)
+	})
+
+	t.Run("just-in-time provisioning", func(t *testing.T) {
+		t.Setenv("USER_PROVISIONING", "JIT")
+
+		cfg := Load()
+		assert.Equal(t, ProvisionJIT, cfg.UserProvisioning)
+	})
+
+	t.Run("unknown provisioning mode", func(t *testing.T) {
+		t.Setenv("USER_PROVISIONING", "sometimes")
+
+		cfg := Load()
+		assert.Equal(t, ProvisionWebhook, cfg.UserProvisioning)
+	})
+}
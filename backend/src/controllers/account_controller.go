@@ -0,0 +1,296 @@
+package controllers
+
+import (
+	"api/src/apierror"
+	"api/src/database"
+	"api/src/models"
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// purgeBatchSize bounds how many accounts one run of PurgeDeletedAccounts purges
+const purgeBatchSize = 100
+
+var errDeletionPending = errors.New("account deletion already scheduled")
+
+// GetAccountDeletion returns the deletion status of the authenticated user's account.
+// It stays reachable while the account is locked for deletion.
+func GetAccountDeletion(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	deletion := models.AccountDeletion{UserID: userID}
+	err := database.DB.QueryRow(
+		"SELECT deletion_requested_at, deletion_scheduled_for FROM users WHERE id = $1",
+		userID,
+	).Scan(&deletion.RequestedAt, &deletion.ScheduledFor)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Abort(c, apierror.NotFound("User not found"))
+			return
+		}
+		apierror.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deletion)
+}
+
+// scheduleAccountDeletion locks the account of userID and schedules its purge
+// after the grace period. It returns sql.ErrNoRows when no such account
+// belongs to clerkID and errDeletionPending when one is already scheduled.
+func scheduleAccountDeletion(ctx context.Context, userID uuid.UUID, clerkID string) (models.AccountDeletion, error) {
+	deletion := models.AccountDeletion{UserID: userID}
+
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return deletion, err
+	}
+	defer tx.Rollback()
+
+	var scheduledFor *time.Time
+	err = tx.QueryRowContext(ctx,
+		"SELECT deletion_scheduled_for FROM users WHERE id = $1 AND clerk_id = $2 FOR UPDATE",
+		userID, clerkID,
+	).Scan(&scheduledFor)
+	if err != nil {
+		return deletion, err
+	}
+	if scheduledFor != nil {
+		return deletion, errDeletionPending
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`UPDATE users SET deletion_requested_at = NOW(), deletion_scheduled_for = $2
+		 WHERE id = $1 RETURNING deletion_requested_at, deletion_scheduled_for`,
+		userID, time.Now().Add(models.AccountDeletionGracePeriod),
+	).Scan(&deletion.RequestedAt, &deletion.ScheduledFor)
+	if err != nil {
+		return deletion, err
+	}
+
+	err = recordAudit(ctx, tx, userID, &userID, models.AuditDeletionRequested, map[string]any{
+		"scheduled_for": deletion.ScheduledFor,
+	})
+	if err != nil {
+		return deletion, err
+	}
+
+	return deletion, tx.Commit()
+}
+
+// CancelAccountDeletion restores an account during the grace period of its deletion
+func CancelAccountDeletion(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+	clerkID, _ := getClerkID(c)
+	ctx := c.Request.Context()
+
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	defer tx.Rollback()
+
+	// The purge job may already be running once the grace period is over
+	result, err := tx.ExecContext(ctx,
+		`UPDATE users SET deletion_requested_at = NULL, deletion_scheduled_for = NULL
+		 WHERE id = $1 AND deletion_scheduled_for > NOW()`,
+		userID,
+	)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	if rowsAffected == 0 {
+		apierror.Abort(c, apierror.Conflict("No account deletion to cancel"))
+		return
+	}
+
+	if err := recordAudit(ctx, tx, userID, &userID, models.AuditDeletionCancelled, nil); err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	userIDs.forget(clerkID)
+
+	c.JSON(http.StatusOK, models.AccountDeletion{UserID: userID})
+}
+
+// PurgeDeletedAccounts permanently deletes the accounts whose deletion grace period is over.
+// It runs periodically as a background job.
+func PurgeDeletedAccounts(ctx context.Context) error {
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT id FROM users WHERE deletion_scheduled_for <= NOW() ORDER BY deletion_scheduled_for LIMIT $1",
+		purgeBatchSize,
+	)
+	if err != nil {
+		return err
+	}
+	var due []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		due = append(due, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// A failed account is retried on the next run, it doesn't hold up the others
+	for _, id := range due {
+		if err := purgeAccount(ctx, id); err != nil {
+			log.Printf("Failed to purge account %s: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// DeleteIdentity deletes a purged user at the authentication provider, so
+// signing in again can't provision the account anew. It is set from the
+// configuration at startup; only Clerk keeps identities of its own.
+var DeleteIdentity = func(ctx context.Context, clerkID string) error { return nil }
+
+// purgeAccount deletes a user whose deletion is due along with everything they own.
+func purgeAccount(ctx context.Context, userID uuid.UUID) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Re-check under the lock so a deletion cancelled meanwhile is not purged
+	var clerkID string
+	err = tx.QueryRowContext(ctx,
+		"SELECT clerk_id FROM users WHERE id = $1 AND deletion_scheduled_for <= NOW() FOR UPDATE",
+		userID,
+	).Scan(&clerkID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Deleted before committing so a failure leaves the account to the next run
+	exportFiles, err := purgeUser(ctx, tx, userID, func() error {
+		return DeleteIdentity(ctx, clerkID)
+	})
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	userIDs.forget(clerkID)
+	removeExportFiles(exportFiles)
+	return nil
+}
+
+// purgeUser deletes a user locked in tx along with everything they own,
+// recording each step in the audit log. Decks cascade to their flashcards and
+// revisions, the user row to tokens and export jobs. Edits the user made
+// elsewhere keep their revisions with the editor cleared. Decks have a single
+// owner and no collaborators, so there is nothing to hand over.
+// deleteIdentity, when set, runs once the user row is gone. The export files
+// to remove after committing are returned.
+func purgeUser(ctx context.Context, tx *sql.Tx, userID uuid.UUID, deleteIdentity func() error) ([]string, error) {
+	if err := recordAudit(ctx, tx, userID, nil, models.AuditPurgeStarted, nil); err != nil {
+		return nil, err
+	}
+
+	var flashcards int64
+	err := tx.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM flashcards f JOIN decks d ON f.parent_deck = d.id WHERE d.owner_id = $1",
+		userID,
+	).Scan(&flashcards)
+	if err != nil {
+		return nil, err
+	}
+	result, err := tx.ExecContext(ctx, "DELETE FROM decks WHERE owner_id = $1", userID)
+	if err != nil {
+		return nil, err
+	}
+	decks, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	err = recordAudit(ctx, tx, userID, nil, models.AuditContentPurged, map[string]any{
+		"decks":      decks,
+		"flashcards": flashcards,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	exportRows, err := tx.QueryContext(ctx,
+		"SELECT file_path FROM export_jobs WHERE user_id = $1 AND file_path IS NOT NULL",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	var exportFiles []string
+	for exportRows.Next() {
+		var path string
+		if err := exportRows.Scan(&path); err != nil {
+			exportRows.Close()
+			return nil, err
+		}
+		exportFiles = append(exportFiles, path)
+	}
+	exportRows.Close()
+	if err := exportRows.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM users WHERE id = $1", userID); err != nil {
+		return nil, err
+	}
+	if deleteIdentity != nil {
+		if err := deleteIdentity(); err != nil {
+			return nil, err
+		}
+	}
+	err = recordAudit(ctx, tx, userID, nil, models.AuditAccountPurged, map[string]any{
+		"export_files": len(exportFiles),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return exportFiles, nil
+}
+
+// removeExportFiles deletes the export files of a purged user
+func removeExportFiles(paths []string) {
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Printf("Failed to delete export %s: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package controllers
+
+import (
+	"api/src/database"
+	"api/src/models"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAccountDeletion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("pending", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		testUserID := uuid.New()
+		requestedAt := time.Now()
+
+		mock.ExpectQuery(`SELECT deletion_requested_at, deletion_scheduled_for FROM users WHERE id = \$1`).
+			WithArgs(testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"deletion_requested_at", "deletion_scheduled_for"}).
+				AddRow(requestedAt, requestedAt.Add(models.AccountDeletionGracePeriod)))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		originalGetUserID := GetUserIDFromClerkID
+		GetUserIDFromClerkID = func(c *gin.Context) (uuid.UUID, bool) {
+			return testUserID, true
+		}
+		defer func() { GetUserIDFromClerkID = originalGetUserID }()
+
+		GetAccountDeletion(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), testUserID.String())
+		assert.NotContains(t, w.Body.String(), `"scheduled_for":null`)
+	})
+}
+
+func TestCancelAccountDeletion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		testUserID := uuid.New()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE users SET deletion_requested_at = NULL, deletion_scheduled_for = NULL WHERE id = \$1 AND deletion_scheduled_for > NOW\(\)`).
+			WithArgs(testUserID).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`INSERT INTO audit_log \(user_id, actor_id, action, details\)`).
+			WithArgs(testUserID, &testUserID, models.AuditDeletionCancelled, []byte("{}")).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("DELETE", "/", nil)
+
+		originalGetUserID := GetUserIDFromClerkID
+		GetUserIDFromClerkID = func(c *gin.Context) (uuid.UUID, bool) {
+			return testUserID, true
+		}
+		defer func() { GetUserIDFromClerkID = originalGetUserID }()
+
+		CancelAccountDeletion(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"scheduled_for":null`)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("nothing to cancel", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		testUserID := uuid.New()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE users SET deletion_requested_at = NULL`).
+			WithArgs(testUserID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("DELETE", "/", nil)
+
+		originalGetUserID := GetUserIDFromClerkID
+		GetUserIDFromClerkID = func(c *gin.Context) (uuid.UUID, bool) {
+			return testUserID, true
+		}
+		defer func() { GetUserIDFromClerkID = originalGetUserID }()
+
+		CancelAccountDeletion(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPurgeDeletedAccounts(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	database.DB = mockDB
+
+	testUserID := uuid.New()
+	exportFile := filepath.Join(t.TempDir(), "export.zip")
+	assert.NoError(t, os.WriteFile(exportFile, []byte("zip bytes"), 0o600))
+
+	mock.ExpectQuery(`SELECT id FROM users WHERE deletion_scheduled_for <= NOW\(\) ORDER BY deletion_scheduled_for LIMIT \$1`).
+		WithArgs(purgeBatchSize).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testUserID))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT clerk_id FROM users WHERE id = \$1 AND deletion_scheduled_for <= NOW\(\) FOR UPDATE`).
+		WithArgs(testUserID).
+		WillReturnRows(sqlmock.NewRows([]string{"clerk_id"}).AddRow("user_leaving"))
+	mock.ExpectExec(`INSERT INTO audit_log`).
+		WithArgs(testUserID, nil, models.AuditPurgeStarted, []byte("{}")).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM flashcards f JOIN decks d ON f.parent_deck = d.id WHERE d.owner_id = \$1`).
+		WithArgs(testUserID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(12))
+	mock.ExpectExec(`DELETE FROM decks WHERE owner_id = \$1`).
+		WithArgs(testUserID).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`INSERT INTO audit_log`).
+		WithArgs(testUserID, nil, models.AuditContentPurged, []byte(`{"decks":2,"flashcards":12}`)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`SELECT file_path FROM export_jobs WHERE user_id = \$1 AND file_path IS NOT NULL`).
+		WithArgs(testUserID).
+		WillReturnRows(sqlmock.NewRows([]string{"file_path"}).AddRow(exportFile))
+	mock.ExpectExec(`DELETE FROM users WHERE id = \$1`).
+		WithArgs(testUserID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO audit_log`).
+		WithArgs(testUserID, nil, models.AuditAccountPurged, []byte(`{"export_files":1}`)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	var deleted []string
+	originalDeleteIdentity := DeleteIdentity
+	DeleteIdentity = func(ctx context.Context, clerkID string) error {
+		deleted = append(deleted, clerkID)
+		return nil
+	}
+	defer func() { DeleteIdentity = originalDeleteIdentity }()
+
+	assert.NoError(t, PurgeDeletedAccounts(context.Background()))
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, []string{"user_leaving"}, deleted)
+
+	_, err = os.Stat(exportFile)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestPurgeAccountCancelledMeanwhile(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	database.DB = mockDB
+
+	testUserID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT clerk_id FROM users WHERE id = \$1 AND deletion_scheduled_for <= NOW\(\) FOR UPDATE`).
+		WithArgs(testUserID).
+		WillReturnRows(sqlmock.NewRows([]string{"clerk_id"}))
+	mock.ExpectRollback()
+
+	assert.NoError(t, purgeAccount(context.Background(), testUserID))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPurgeAccountIdentityFailure(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	database.DB = mockDB
+
+	testUserID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT clerk_id FROM users WHERE id = \$1`).
+		WithArgs(testUserID).
+		WillReturnRows(sqlmock.NewRows([]string{"clerk_id"}).AddRow("user_leaving"))
+	mock.ExpectExec(`INSERT INTO audit_log`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM flashcards`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec(`DELETE FROM decks`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO audit_log`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`SELECT file_path FROM export_jobs`).WillReturnRows(sqlmock.NewRows([]string{"file_path"}))
+	mock.ExpectExec(`DELETE FROM users WHERE id = \$1`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	originalDeleteIdentity := DeleteIdentity
+	DeleteIdentity = func(ctx context.Context, clerkID string) error {
+		return errors.New("clerk unavailable")
+	}
+	defer func() { DeleteIdentity = originalDeleteIdentity }()
+
+	assert.Error(t, purgeAccount(context.Background(), testUserID))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"api/src/apierror"
+	"api/src/database"
+	"api/src/models"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// defaultSearchLimit and maxSearchLimit bound the page size of SearchUsers
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 200
+)
+
+// SearchUsers lists users whose name or email contains the q query parameter,
+// paginated with limit and offset
+func SearchUsers(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultSearchLimit)))
+	if err != nil || limit < 1 || limit > maxSearchLimit {
+		apierror.Abort(c, apierror.BadRequest("limit must be between 1 and "+strconv.Itoa(maxSearchLimit)))
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		apierror.Abort(c, apierror.BadRequest("offset must be a non-negative integer"))
+		return
+	}
+
+	// Escape LIKE wildcards so the query is matched literally
+	q := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(c.Query("q"))
+
+	rows, err := database.DB.Query(
+		`SELECT `+userColumns+` FROM users
+		 WHERE name ILIKE '%' || $1 || '%' OR email ILIKE '%' || $1 || '%'
+		 ORDER BY email LIMIT $2 OFFSET $3`,
+		q, limit, offset,
+	)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		var u models.User
+		if err := scanUser(rows, &u); err != nil {
+			apierror.Abort(c, err)
+			return
+		}
+		users = append(users, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+// setSuspended suspends or reinstates the user in the id path parameter
+func setSuspended(c *gin.Context, suspend bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidID(""))
+		return
+	}
+
+	adminID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+	if suspend && adminID == id {
+		apierror.Abort(c, apierror.BadRequest("You cannot suspend your own account"))
+		return
+	}
+
+	query := "UPDATE users SET suspended_at = COALESCE(suspended_at, NOW()) WHERE id = $1 RETURNING " + userColumns
+	if !suspend {
+		query = "UPDATE users SET suspended_at = NULL WHERE id = $1 RETURNING " + userColumns
+	}
+
+	var user models.User
+	if err := scanUser(database.DB.QueryRow(query, id), &user); err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Abort(c, apierror.NotFound("User not found"))
+			return
+		}
+		apierror.Abort(c, err)
+		return
+	}
+	userIDs.forget(user.ClerkID)
+
+	c.JSON(http.StatusOK, user)
+}
+
+// SuspendUser suspends a user, who is then rejected on every authenticated route
+func SuspendUser(c *gin.Context) {
+	setSuspended(c, true)
+}
+
+// UnsuspendUser lifts a user's suspension
+func UnsuspendUser(c *gin.Context) {
+	setSuspended(c, false)
+}
+
+// SetUserRole changes a user's role
+func SetUserRole(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidID(""))
+		return
+	}
+
+	var body struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		apierror.Abort(c, apierror.Invalid(err))
+		return
+	}
+	if body.Role != models.RoleUser && body.Role != models.RoleAdmin {
+		apierror.Abort(c, apierror.BadRequest("role must be user or admin"))
+		return
+	}
+
+	var user models.User
+	err = scanUser(database.DB.QueryRow(
+		"UPDATE users SET role = $1 WHERE id = $2 RETURNING "+userColumns,
+		body.Role, id,
+	), &user)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Abort(c, apierror.NotFound("User not found"))
+			return
+		}
+		apierror.Abort(c, err)
+		return
+	}
+	userIDs.forget(user.ClerkID)
+
+	c.JSON(http.StatusOK, user)
+}
+
+// GetUserUsage returns how many decks, flashcards and tokens a user has
+func GetUserUsage(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidID(""))
+		return
+	}
+
+	usage := models.UserUsage{UserID: id}
+	err = database.DB.QueryRow(
+		`SELECT
+		   (SELECT COUNT(*) FROM decks WHERE owner_id = u.id),
+		   (SELECT COUNT(*) FROM flashcards f JOIN decks d ON f.parent_deck = d.id WHERE d.owner_id = u.id),
+		   (SELECT COUNT(*) FROM personal_access_tokens t
+		    WHERE t.user_id = u.id AND t.revoked_at IS NULL AND (t.expires_at IS NULL OR t.expires_at > NOW())),
+		   (SELECT MAX(last_used_at) FROM personal_access_tokens WHERE user_id = u.id)
+		 FROM users u WHERE u.id = $1`,
+		id,
+	).Scan(&usage.Decks, &usage.Flashcards, &usage.ActiveTokens, &usage.LastTokenUse)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Abort(c, apierror.NotFound("User not found"))
+			return
+		}
+		apierror.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"api/src/database"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchUsers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		rows := sqlmock.NewRows([]string{"id", "clerk_id", "name", "email", "role", "suspended_at", "timezone"}).
+			AddRow(uuid.New(), "clerk1", "Ada Lovelace", "ada@example.com", "user", nil, "UTC")
+		mock.ExpectQuery(`SELECT id, clerk_id, name, email, role, suspended_at, timezone FROM users WHERE name ILIKE`).
+			WithArgs(`ada\_l`, 10, 0).
+			WillReturnRows(rows)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/?q=ada_l&limit=10", nil)
+
+		SearchUsers(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "ada@example.com")
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/?limit=0", nil)
+
+		SearchUsers(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestSuspendUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		testUserID := uuid.New()
+		userIDs.set("clerk_suspended", currentUser{ID: testUserID, Role: "user"})
+
+		rows := sqlmock.NewRows([]string{"id", "clerk_id", "name", "email", "role", "suspended_at", "timezone"}).
+			AddRow(testUserID, "clerk_suspended", "Spammer", "spam@example.com", "user", time.Now(), "UTC")
+		mock.ExpectQuery(`UPDATE users SET suspended_at = COALESCE\(suspended_at, NOW\(\)\) WHERE id = \$1 RETURNING`).
+			WithArgs(testUserID).
+			WillReturnRows(rows)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "id", Value: testUserID.String()}}
+
+		originalGetUserID := GetUserIDFromClerkID
+		GetUserIDFromClerkID = func(c *gin.Context) (uuid.UUID, bool) {
+			return uuid.New(), true
+		}
+		defer func() { GetUserIDFromClerkID = originalGetUserID }()
+
+		SuspendUser(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		_, cached := userIDs.get("clerk_suspended")
+		assert.False(t, cached)
+	})
+
+	t.Run("own account", func(t *testing.T) {
+		testUserID := uuid.New()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "id", Value: testUserID.String()}}
+
+		originalGetUserID := GetUserIDFromClerkID
+		GetUserIDFromClerkID = func(c *gin.Context) (uuid.UUID, bool) {
+			return testUserID, true
+		}
+		defer func() { GetUserIDFromClerkID = originalGetUserID }()
+
+		SuspendUser(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestGetUserUsage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		testUserID := uuid.New()
+		mock.ExpectQuery(`SELECT \(SELECT COUNT\(\*\) FROM decks WHERE owner_id = u.id\)`).
+			WithArgs(testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"decks", "flashcards", "tokens", "last_used"}).AddRow(3, 120, 1, nil))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "id", Value: testUserID.String()}}
+
+		GetUserUsage(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"flashcards":120`)
+	})
+}
@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"api/src/apierror"
+	"api/src/database"
+	"api/src/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// recordAudit appends an entry to the audit log. actorID is nil for background jobs.
+func recordAudit(ctx context.Context, db execer, userID uuid.UUID, actorID *uuid.UUID, action string, details map[string]any) error {
+	if details == nil {
+		details = map[string]any{}
+	}
+	encoded, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx,
+		"INSERT INTO audit_log (user_id, actor_id, action, details) VALUES ($1, $2, $3, $4)",
+		userID, actorID, action, encoded,
+	)
+	return err
+}
+
+// GetUserAudit returns the audit log of the user in the id path parameter, oldest first.
+// It also works for accounts that have been purged.
+func GetUserAudit(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidID(""))
+		return
+	}
+
+	rows, err := database.DB.Query(
+		"SELECT id, user_id, actor_id, action, details, created_at FROM audit_log WHERE user_id = $1 ORDER BY created_at",
+		id,
+	)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var e models.AuditEntry
+		var details []byte
+		if err := rows.Scan(&e.ID, &e.UserID, &e.ActorID, &e.Action, &details, &e.CreatedAt); err != nil {
+			apierror.Abort(c, err)
+			return
+		}
+		e.Details = details
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
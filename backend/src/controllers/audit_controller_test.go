@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"api/src/database"
+	"api/src/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetUserAudit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		testUserID := uuid.New()
+		rows := sqlmock.NewRows([]string{"id", "user_id", "actor_id", "action", "details", "created_at"}).
+			AddRow(uuid.New(), testUserID, testUserID, models.AuditDeletionRequested, []byte(`{"scheduled_for":"2026-11-02T00:00:00Z"}`), time.Now()).
+			AddRow(uuid.New(), testUserID, nil, models.AuditPurgeStarted, []byte(`{}`), time.Now())
+
+		mock.ExpectQuery(`SELECT id, user_id, actor_id, action, details, created_at FROM audit_log WHERE user_id = \$1 ORDER BY created_at`).
+			WithArgs(testUserID).
+			WillReturnRows(rows)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "id", Value: testUserID.String()}}
+
+		GetUserAudit(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"actor_id":null`)
+		assert.Contains(t, w.Body.String(), `"scheduled_for":"2026-11-02T00:00:00Z"`)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"api/src/apierror"
 	"api/src/database"
 	"api/src/models"
 	"database/sql"
@@ -11,6 +12,14 @@ import (
 	"github.com/lib/pq"
 )
 
+// deckColumns are the columns scanned by scanDeck
+const deckColumns = "id, owner_id, labels, title, description, to_char(exam_date, 'YYYY-MM-DD'), leech_threshold, leech_action, preset_id"
+
+// scanDeck scans a row selected with deckColumns
+func scanDeck(row interface{ Scan(...any) error }, d *models.Deck) error {
+	return row.Scan(&d.ID, &d.OwnerID, pq.Array(&d.Labels), &d.Title, &d.Description, &d.ExamDate, &d.LeechThreshold, &d.LeechAction, &d.PresetID)
+}
+
 // GetDecks returns all decks for a single user by ID
 // Takes in the users ID as a parameter
 func GetDecks(c *gin.Context) {
@@ -20,11 +29,11 @@ func GetDecks(c *gin.Context) {
 	}
 
 	rows, err := database.DB.Query(
-		"SELECT id, owner_id, labels, title, description FROM decks WHERE owner_id = $1",
+		"SELECT "+deckColumns+" FROM decks WHERE owner_id = $1",
 		userID,
 	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apierror.Abort(c, err)
 		return
 	}
 	defer rows.Close()
@@ -32,15 +41,15 @@ func GetDecks(c *gin.Context) {
 	var decks []models.Deck
 	for rows.Next() {
 		var d models.Deck
-		if err := rows.Scan(&d.ID, &d.OwnerID, pq.Array(&d.Labels), &d.Title, &d.Description); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if err := scanDeck(rows, &d); err != nil {
+			apierror.Abort(c, err)
 			return
 		}
 		decks = append(decks, d)
 	}
 
 	if err := rows.Err(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apierror.Abort(c, err)
 		return
 	}
 
@@ -51,7 +60,7 @@ func GetDecks(c *gin.Context) {
 func GetDeck(c *gin.Context) {
 	deckID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		apierror.Abort(c, apierror.InvalidID(""))
 		return
 	}
 
@@ -61,16 +70,16 @@ func GetDeck(c *gin.Context) {
 	}
 
 	var deck models.Deck
-	err = database.DB.QueryRow(
-		"SELECT id, owner_id, labels, title, description FROM decks WHERE id = $1 AND owner_id = $2",
+	err = scanDeck(database.DB.QueryRow(
+		"SELECT "+deckColumns+" FROM decks WHERE id = $1 AND owner_id = $2",
 		deckID, userID,
-	).Scan(&deck.ID, &deck.OwnerID, pq.Array(&deck.Labels), &deck.Title, &deck.Description)
+	), &deck)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Deck not found or access denied"})
+			apierror.Abort(c, apierror.NotFound("Deck not found or access denied"))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apierror.Abort(c, err)
 		return
 	}
 
@@ -88,24 +97,29 @@ func CreateDeck(c *gin.Context) {
 
 	var deck models.Deck
 	if err := c.ShouldBindJSON(&deck); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Abort(c, apierror.Invalid(err))
 		return
 	}
 
 	deck.OwnerID = userID
 
 	if err := deck.Validate(); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Abort(c, apierror.Invalid(err))
+		return
+	}
+
+	if !checkDeckPreset(c, deck.PresetID, userID) {
 		return
 	}
 
 	err := database.DB.QueryRow(
-		"INSERT INTO decks (owner_id, labels, title, description) VALUES ($1, $2, $3, $4) RETURNING id",
-		deck.OwnerID, pq.StringArray(deck.Labels), deck.Title, deck.Description,
+		`INSERT INTO decks (owner_id, labels, title, description, exam_date, leech_threshold, leech_action, preset_id)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`,
+		deck.OwnerID, pq.StringArray(deck.Labels), deck.Title, deck.Description, deck.ExamDate, deck.LeechThreshold, deck.LeechAction, deck.PresetID,
 	).Scan(&deck.ID)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apierror.Abort(c, err)
 		return
 	}
 
@@ -121,50 +135,69 @@ func UpdateDeck(c *gin.Context) {
 
 	deckID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		apierror.Abort(c, apierror.InvalidID(""))
 		return
 	}
 
 	var deck models.Deck
 	if err := c.ShouldBindJSON(&deck); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Abort(c, apierror.Invalid(err))
 		return
 	}
 
 	deck.OwnerID = userID
 
 	if err := deck.Validate(); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Abort(c, apierror.Invalid(err))
 		return
 	}
 
-	result, err := database.DB.Exec(
-		"UPDATE decks SET labels = $1, title = $2, description = $3 WHERE id = $4 AND owner_id = $5",
-		pq.StringArray(deck.Labels), deck.Title, deck.Description, deckID, userID,
-	)
+	if !checkDeckPreset(c, deck.PresetID, userID) {
+		return
+	}
 
+	tx, err := database.DB.Begin()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apierror.Abort(c, err)
 		return
 	}
+	defer tx.Rollback()
 
-	rowsAffected, err := result.RowsAffected()
+	if err := lockDeck(tx, deckID, userID); err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Abort(c, apierror.NotFound("Deck not found"))
+			return
+		}
+		apierror.Abort(c, err)
+		return
+	}
+
+	// Keep the previous content so the edit can be reviewed or reverted
+	if err := saveDeckRevision(tx, deckID, userID); err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	_, err = tx.Exec(
+		"UPDATE decks SET labels = $1, title = $2, description = $3, exam_date = $4, leech_threshold = $5, leech_action = $6, preset_id = $7 WHERE id = $8",
+		pq.StringArray(deck.Labels), deck.Title, deck.Description, deck.ExamDate, deck.LeechThreshold, deck.LeechAction, deck.PresetID, deckID,
+	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apierror.Abort(c, err)
 		return
 	}
 
-	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Deck not found"})
+	if err := tx.Commit(); err != nil {
+		apierror.Abort(c, err)
 		return
 	}
 
-	err = database.DB.QueryRow(
-		"SELECT id, owner_id, labels, title, description FROM decks WHERE id = $1",
+	err = scanDeck(database.DB.QueryRow(
+		"SELECT "+deckColumns+" FROM decks WHERE id = $1",
 		deckID,
-	).Scan(&deck.ID, &deck.OwnerID, pq.Array(&deck.Labels), &deck.Title, &deck.Description)
+	), &deck)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apierror.Abort(c, err)
 		return
 	}
 
@@ -180,24 +213,24 @@ func DeleteDeck(c *gin.Context) {
 
 	deckID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		apierror.Abort(c, apierror.InvalidID(""))
 		return
 	}
 
 	result, err := database.DB.Exec("DELETE FROM decks WHERE id = $1 AND owner_id = $2", deckID, userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apierror.Abort(c, err)
 		return
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apierror.Abort(c, err)
 		return
 	}
 
 	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Deck not found"})
+		apierror.Abort(c, apierror.NotFound("Deck not found"))
 		return
 	}
 
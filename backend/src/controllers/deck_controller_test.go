@@ -26,11 +26,11 @@ func TestGetDecks(t *testing.T) {
 		database.DB = mockDB
 
 		testUserID := uuid.New()
-		rows := sqlmock.NewRows([]string{"id", "owner_id", "labels", "title", "description"}).
-			AddRow(uuid.New(), testUserID, pq.Array([]string{"label1"}), "Deck One", "Description One").
-			AddRow(uuid.New(), testUserID, pq.Array([]string{"label2"}), "Deck Two", "Description Two")
+		rows := sqlmock.NewRows([]string{"id", "owner_id", "labels", "title", "description", "exam_date", "leech_threshold", "leech_action", "preset_id"}).
+			AddRow(uuid.New(), testUserID, pq.Array([]string{"label1"}), "Deck One", "Description One", nil, 8, "tag", nil).
+			AddRow(uuid.New(), testUserID, pq.Array([]string{"label2"}), "Deck Two", "Description Two", nil, 8, "tag", nil)
 
-		mock.ExpectQuery("SELECT id, owner_id, labels, title, description FROM decks WHERE owner_id = \\$1").
+		mock.ExpectQuery("SELECT id, owner_id, labels, title, description, to_char\\(exam_date, 'YYYY-MM-DD'\\), leech_threshold, leech_action, preset_id FROM decks WHERE owner_id = \\$1").
 			WithArgs(testUserID).
 			WillReturnRows(rows)
 
@@ -64,10 +64,10 @@ func TestGetDeck(t *testing.T) {
 
 		testUserID := uuid.New()
 		testDeckID := uuid.New()
-		rows := sqlmock.NewRows([]string{"id", "owner_id", "labels", "title", "description"}).
-			AddRow(testDeckID, testUserID, pq.Array([]string{"label1"}), "Deck One", "Description One")
+		rows := sqlmock.NewRows([]string{"id", "owner_id", "labels", "title", "description", "exam_date", "leech_threshold", "leech_action", "preset_id"}).
+			AddRow(testDeckID, testUserID, pq.Array([]string{"label1"}), "Deck One", "Description One", nil, 8, "tag", nil)
 
-		mock.ExpectQuery("SELECT id, owner_id, labels, title, description FROM decks WHERE id = \\$1 AND owner_id = \\$2").
+		mock.ExpectQuery("SELECT id, owner_id, labels, title, description, to_char\\(exam_date, 'YYYY-MM-DD'\\), leech_threshold, leech_action, preset_id FROM decks WHERE id = \\$1 AND owner_id = \\$2").
 			WithArgs(testDeckID, testUserID).
 			WillReturnRows(rows)
 
@@ -103,8 +103,8 @@ func TestCreateDeck(t *testing.T) {
 		newDeckID := uuid.New()
 		deckJSON := `{"title":"New Deck","description":"New Description","labels":["new-label"]}`
 
-		mock.ExpectQuery("INSERT INTO decks \\(owner_id, labels, title, description\\) VALUES \\(\\$1, \\$2, \\$3, \\$4\\) RETURNING id").
-			WithArgs(testUserID, pq.StringArray([]string{"new-label"}), "New Deck", "New Description").
+		mock.ExpectQuery("INSERT INTO decks \\(owner_id, labels, title, description, exam_date, leech_threshold, leech_action, preset_id\\) VALUES \\(\\$1, \\$2, \\$3, \\$4, \\$5, \\$6, \\$7, \\$8\\) RETURNING id").
+			WithArgs(testUserID, pq.StringArray([]string{"new-label"}), "New Deck", "New Description", nil, 8, "tag", nil).
 			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(newDeckID))
 
 		w := httptest.NewRecorder()
@@ -140,13 +140,21 @@ func TestUpdateDeck(t *testing.T) {
 		testDeckID := uuid.New()
 		deckJSON := `{"title":"Updated Deck","description":"Updated Description","labels":["updated-label"]}`
 
-		mock.ExpectExec("UPDATE decks SET labels = \\$1, title = \\$2, description = \\$3 WHERE id = \\$4 AND owner_id = \\$5").
-			WithArgs(pq.StringArray([]string{"updated-label"}), "Updated Deck", "Updated Description", testDeckID, testUserID).
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT id FROM decks WHERE id = \\$1 AND owner_id = \\$2 FOR UPDATE").
+			WithArgs(testDeckID, testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testDeckID))
+		mock.ExpectExec("INSERT INTO deck_revisions").
+			WithArgs(testDeckID, testUserID).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("UPDATE decks SET labels = \\$1, title = \\$2, description = \\$3, exam_date = \\$4, leech_threshold = \\$5, leech_action = \\$6, preset_id = \\$7 WHERE id = \\$8").
+			WithArgs(pq.StringArray([]string{"updated-label"}), "Updated Deck", "Updated Description", nil, 8, "tag", nil, testDeckID).
 			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
 
-		rows := sqlmock.NewRows([]string{"id", "owner_id", "labels", "title", "description"}).
-			AddRow(testDeckID, testUserID, pq.Array([]string{"updated-label"}), "Updated Deck", "Updated Description")
-		mock.ExpectQuery("SELECT id, owner_id, labels, title, description FROM decks WHERE id = \\$1").
+		rows := sqlmock.NewRows([]string{"id", "owner_id", "labels", "title", "description", "exam_date", "leech_threshold", "leech_action", "preset_id"}).
+			AddRow(testDeckID, testUserID, pq.Array([]string{"updated-label"}), "Updated Deck", "Updated Description", nil, 8, "tag", nil)
+		mock.ExpectQuery("SELECT id, owner_id, labels, title, description, to_char\\(exam_date, 'YYYY-MM-DD'\\), leech_threshold, leech_action, preset_id FROM decks WHERE id = \\$1").
 			WithArgs(testDeckID).
 			WillReturnRows(rows)
 
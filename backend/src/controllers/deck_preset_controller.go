@@ -0,0 +1,316 @@
+package controllers
+
+import (
+	"api/src/apierror"
+	"api/src/database"
+	"api/src/models"
+	"context"
+	"database/sql"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// deckPresetColumns are the columns scanned by scanDeckPreset
+const deckPresetColumns = "id, owner_id, name, is_default, new_per_day, reviews_per_day, learning_steps, graduating_interval, maximum_interval, new_order, show_timer, max_answer_seconds, scheduler, created_at"
+
+// scanDeckPreset scans a row selected with deckPresetColumns, after the
+// leading destinations dest
+func scanDeckPreset(row interface{ Scan(...any) error }, p *models.DeckPreset, dest ...any) error {
+	var steps pq.Int64Array
+	err := row.Scan(append(dest,
+		&p.ID, &p.OwnerID, &p.Name, &p.IsDefault, &p.NewPerDay, &p.ReviewsPerDay, &steps, &p.GraduatingInterval,
+		&p.MaximumInterval, &p.NewOrder, &p.ShowTimer, &p.MaxAnswerSeconds, &p.Scheduler, &p.CreatedAt,
+	)...)
+	if err != nil {
+		return err
+	}
+	p.LearningSteps = make([]int, len(steps))
+	for i, step := range steps {
+		p.LearningSteps[i] = int(step)
+	}
+	return nil
+}
+
+// loadDeckPresets returns the preset of each deck: its own, the default
+// preset of its owner, or models.DefaultDeckPreset
+func loadDeckPresets(ctx context.Context, q querier, deckIDs []uuid.UUID) (map[uuid.UUID]models.DeckPreset, error) {
+	rows, err := q.QueryContext(ctx,
+		`SELECT d.id, p.`+strings.ReplaceAll(deckPresetColumns, ", ", ", p.")+`
+		 FROM decks d
+		 JOIN deck_presets p ON p.id = COALESCE(d.preset_id, (SELECT dp.id FROM deck_presets dp WHERE dp.owner_id = d.owner_id AND dp.is_default))
+		 WHERE d.id = ANY($1)`,
+		pq.Array(deckIDs),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	presets := map[uuid.UUID]models.DeckPreset{}
+	for rows.Next() {
+		var deckID uuid.UUID
+		var p models.DeckPreset
+		if err := scanDeckPreset(rows, &p, &deckID); err != nil {
+			return nil, err
+		}
+		presets[deckID] = p
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, id := range deckIDs {
+		if _, ok := presets[id]; !ok {
+			presets[id] = models.DefaultDeckPreset()
+		}
+	}
+	return presets, nil
+}
+
+// checkDeckPreset checks that a preset given to a deck belongs to the user,
+// aborting with 404 otherwise. A nil preset is the default one.
+func checkDeckPreset(c *gin.Context, presetID *uuid.UUID, userID uuid.UUID) bool {
+	if presetID == nil {
+		return true
+	}
+	var exists bool
+	err := database.DB.QueryRowContext(c.Request.Context(),
+		"SELECT EXISTS (SELECT 1 FROM deck_presets WHERE id = $1 AND owner_id = $2)",
+		*presetID, userID,
+	).Scan(&exists)
+	if err != nil {
+		apierror.Abort(c, err)
+		return false
+	}
+	if !exists {
+		apierror.Abort(c, apierror.NotFound("Deck preset not found"))
+		return false
+	}
+	return true
+}
+
+// bindDeckPreset reads a preset from the request body. Options left out get
+// the values of models.DefaultDeckPreset.
+func bindDeckPreset(c *gin.Context) (models.DeckPreset, bool) {
+	p := models.DefaultDeckPreset()
+	p.Name = ""
+	if err := c.ShouldBindJSON(&p); err != nil {
+		apierror.Abort(c, apierror.Invalid(err))
+		return p, false
+	}
+	if err := p.Validate(); err != nil {
+		apierror.Abort(c, apierror.Invalid(err))
+		return p, false
+	}
+	return p, true
+}
+
+// clearDefaultDeckPreset unsets the default preset of a user before another one becomes the default
+func clearDefaultDeckPreset(ctx context.Context, tx *sql.Tx, userID uuid.UUID) error {
+	_, err := tx.ExecContext(ctx, "UPDATE deck_presets SET is_default = FALSE WHERE owner_id = $1 AND is_default", userID)
+	return err
+}
+
+// GetDeckPresets returns the deck presets of the authenticated user
+func GetDeckPresets(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	rows, err := database.DB.QueryContext(c.Request.Context(),
+		"SELECT "+deckPresetColumns+" FROM deck_presets WHERE owner_id = $1 ORDER BY is_default DESC, name",
+		userID,
+	)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	defer rows.Close()
+
+	presets := []models.DeckPreset{}
+	for rows.Next() {
+		var p models.DeckPreset
+		if err := scanDeckPreset(rows, &p); err != nil {
+			apierror.Abort(c, err)
+			return
+		}
+		presets = append(presets, p)
+	}
+	if err := rows.Err(); err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, presets)
+}
+
+// GetDeckPreset returns a deck preset
+func GetDeckPreset(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidID("Deck preset"))
+		return
+	}
+
+	var p models.DeckPreset
+	err = scanDeckPreset(database.DB.QueryRowContext(c.Request.Context(),
+		"SELECT "+deckPresetColumns+" FROM deck_presets WHERE id = $1 AND owner_id = $2",
+		id, userID,
+	), &p)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Abort(c, apierror.NotFound("Deck preset not found"))
+			return
+		}
+		apierror.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, p)
+}
+
+// CreateDeckPreset creates a deck preset. A default preset replaces the
+// previous default one.
+func CreateDeckPreset(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	p, ok := bindDeckPreset(c)
+	if !ok {
+		return
+	}
+	p.OwnerID = userID
+
+	ctx := c.Request.Context()
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	defer tx.Rollback()
+
+	if p.IsDefault {
+		if err := clearDefaultDeckPreset(ctx, tx, userID); err != nil {
+			apierror.Abort(c, err)
+			return
+		}
+	}
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO deck_presets (owner_id, name, is_default, new_per_day, reviews_per_day, learning_steps, graduating_interval,
+		                           maximum_interval, new_order, show_timer, max_answer_seconds, scheduler)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id, created_at`,
+		userID, p.Name, p.IsDefault, p.NewPerDay, p.ReviewsPerDay, pq.Array(p.LearningSteps), p.GraduatingInterval,
+		p.MaximumInterval, p.NewOrder, p.ShowTimer, p.MaxAnswerSeconds, p.Scheduler,
+	).Scan(&p.ID, &p.CreatedAt)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, p)
+}
+
+// UpdateDeckPreset replaces the options of a deck preset; they apply to the
+// next answers in its decks
+func UpdateDeckPreset(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidID("Deck preset"))
+		return
+	}
+	p, ok := bindDeckPreset(c)
+	if !ok {
+		return
+	}
+	p.ID, p.OwnerID = id, userID
+
+	ctx := c.Request.Context()
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	defer tx.Rollback()
+
+	if p.IsDefault {
+		if err := clearDefaultDeckPreset(ctx, tx, userID); err != nil {
+			apierror.Abort(c, err)
+			return
+		}
+	}
+	err = tx.QueryRowContext(ctx,
+		`UPDATE deck_presets SET name = $1, is_default = $2, new_per_day = $3, reviews_per_day = $4, learning_steps = $5,
+		     graduating_interval = $6, maximum_interval = $7, new_order = $8, show_timer = $9, max_answer_seconds = $10, scheduler = $11
+		 WHERE id = $12 AND owner_id = $13 RETURNING created_at`,
+		p.Name, p.IsDefault, p.NewPerDay, p.ReviewsPerDay, pq.Array(p.LearningSteps), p.GraduatingInterval,
+		p.MaximumInterval, p.NewOrder, p.ShowTimer, p.MaxAnswerSeconds, p.Scheduler, id, userID,
+	).Scan(&p.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Abort(c, apierror.NotFound("Deck preset not found"))
+			return
+		}
+		apierror.Abort(c, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, p)
+}
+
+// DeleteDeckPreset deletes a deck preset; its decks go back to the default preset
+func DeleteDeckPreset(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidID("Deck preset"))
+		return
+	}
+
+	result, err := database.DB.ExecContext(c.Request.Context(),
+		"DELETE FROM deck_presets WHERE id = $1 AND owner_id = $2",
+		id, userID,
+	)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	if rowsAffected == 0 {
+		apierror.Abort(c, apierror.NotFound("Deck preset not found"))
+		return
+	}
+
+	c.AbortWithStatus(http.StatusNoContent)
+}
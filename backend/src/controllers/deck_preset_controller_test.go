@@ -0,0 +1,255 @@
+package controllers
+
+import (
+	"api/src/database"
+	"api/src/models"
+	"api/src/scheduler"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func deckPresetValues(p models.DeckPreset) []driver.Value {
+	steps, _ := pq.Array(p.LearningSteps).Value()
+	return []driver.Value{
+		p.ID, p.OwnerID, p.Name, p.IsDefault, p.NewPerDay, p.ReviewsPerDay, steps, p.GraduatingInterval,
+		p.MaximumInterval, p.NewOrder, p.ShowTimer, p.MaxAnswerSeconds, p.Scheduler, time.Now(),
+	}
+}
+
+// presetRows returns rows selected with deckPresetColumns
+func presetRows(presets ...models.DeckPreset) *sqlmock.Rows {
+	rows := sqlmock.NewRows(strings.Split(deckPresetColumns, ", "))
+	for _, p := range presets {
+		rows.AddRow(deckPresetValues(p)...)
+	}
+	return rows
+}
+
+// deckPresetRows returns the rows loadDeckPresets selects for a deck, none
+// for a deck on the built-in defaults
+func deckPresetRows(deckID uuid.UUID, presets ...models.DeckPreset) *sqlmock.Rows {
+	rows := sqlmock.NewRows(append([]string{"deck_id"}, strings.Split(deckPresetColumns, ", ")...))
+	for _, p := range presets {
+		rows.AddRow(append([]driver.Value{deckID}, deckPresetValues(p)...)...)
+	}
+	return rows
+}
+
+func TestLoadDeckPresets(t *testing.T) {
+	_, _, mock, testUserID, _ := setupDuplicates(t, "GET", "/", "")
+	ownDeck, defaultDeck := uuid.New(), uuid.New()
+	preset := models.DefaultDeckPreset()
+	preset.ID, preset.OwnerID, preset.Name, preset.NewPerDay = uuid.New(), testUserID, "Languages", 50
+
+	mock.ExpectQuery(`SELECT d.id, p.id, p.owner_id, p.name, .* p.scheduler, p.created_at FROM decks d JOIN deck_presets p ` +
+		`ON p.id = COALESCE\(d.preset_id, \(SELECT dp.id FROM deck_presets dp WHERE dp.owner_id = d.owner_id AND dp.is_default\)\) WHERE d.id = ANY\(\$1\)`).
+		WithArgs(pq.Array([]uuid.UUID{ownDeck, defaultDeck})).
+		WillReturnRows(deckPresetRows(ownDeck, preset))
+
+	presets, err := loadDeckPresets(t.Context(), database.DB, []uuid.UUID{ownDeck, defaultDeck})
+	require.NoError(t, err)
+	assert.Equal(t, "Languages", presets[ownDeck].Name)
+	assert.Equal(t, 50, presets[ownDeck].NewPerDay)
+	assert.Equal(t, models.DefaultDeckPreset(), presets[defaultDeck])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetDeckPresets(t *testing.T) {
+	w, c, mock, testUserID, _ := setupDuplicates(t, "GET", "/", "")
+	preset := models.DefaultDeckPreset()
+	preset.ID, preset.OwnerID, preset.IsDefault = uuid.New(), testUserID, true
+
+	mock.ExpectQuery(`SELECT ` + deckPresetColumns + ` FROM deck_presets WHERE owner_id = \$1 ORDER BY is_default DESC, name`).
+		WithArgs(testUserID).
+		WillReturnRows(presetRows(preset))
+
+	GetDeckPresets(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var presets []models.DeckPreset
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &presets))
+	if assert.Len(t, presets, 1) {
+		assert.Equal(t, preset.ID, presets[0].ID)
+		assert.Equal(t, []int{1, 10}, presets[0].LearningSteps)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetDeckPreset(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		w, c, mock, testUserID, presetID := setupDuplicates(t, "GET", "/", "")
+		preset := models.DefaultDeckPreset()
+		preset.ID, preset.OwnerID = presetID, testUserID
+
+		mock.ExpectQuery(`FROM deck_presets WHERE id = \$1 AND owner_id = \$2`).
+			WithArgs(presetID, testUserID).
+			WillReturnRows(presetRows(preset))
+
+		GetDeckPreset(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		w, c, mock, testUserID, presetID := setupDuplicates(t, "GET", "/", "")
+
+		mock.ExpectQuery(`FROM deck_presets WHERE id = \$1 AND owner_id = \$2`).
+			WithArgs(presetID, testUserID).
+			WillReturnError(sql.ErrNoRows)
+
+		GetDeckPreset(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestCreateDeckPreset(t *testing.T) {
+	t.Run("default preset", func(t *testing.T) {
+		w, c, mock, testUserID, _ := setupDuplicates(t, "POST", "/",
+			`{"name":"Languages","is_default":true,"new_per_day":50,"learning_steps":[5,30],"new_order":"random","scheduler":"leitner"}`)
+		presetID := uuid.New()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE deck_presets SET is_default = FALSE WHERE owner_id = \$1 AND is_default`).
+			WithArgs(testUserID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`INSERT INTO deck_presets \(owner_id, name, is_default, new_per_day, reviews_per_day, learning_steps, graduating_interval, `+
+			`maximum_interval, new_order, show_timer, max_answer_seconds, scheduler\) VALUES .* RETURNING id, created_at`).
+			WithArgs(testUserID, "Languages", true, 50, models.DefaultReviewLimit, pq.Array([]int{5, 30}), 1,
+				scheduler.DefaultSettings.MaximumInterval, models.NewOrderRandom, false, 60, scheduler.Leitner).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(presetID, time.Now()))
+		mock.ExpectCommit()
+
+		CreateDeckPreset(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var created models.DeckPreset
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		assert.Equal(t, presetID, created.ID)
+		assert.Equal(t, testUserID, created.OwnerID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("missing name", func(t *testing.T) {
+		w, c, _, _, _ := setupDuplicates(t, "POST", "/", `{"new_per_day":10}`)
+
+		CreateDeckPreset(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("unknown scheduler", func(t *testing.T) {
+		w, c, _, _, _ := setupDuplicates(t, "POST", "/", `{"name":"Fancy","scheduler":"fsrs"}`)
+
+		CreateDeckPreset(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "unknown scheduler")
+	})
+}
+
+func TestUpdateDeckPreset(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		w, c, mock, testUserID, presetID := setupDuplicates(t, "PUT", "/", `{"name":"Slow","new_per_day":5,"show_timer":true}`)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`UPDATE deck_presets SET name = \$1, .* WHERE id = \$12 AND owner_id = \$13 RETURNING created_at`).
+			WithArgs("Slow", false, 5, models.DefaultReviewLimit, pq.Array([]int{1, 10}), 1,
+				scheduler.DefaultSettings.MaximumInterval, models.NewOrderSequential, true, 60, scheduler.SM2, presetID, testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(time.Now()))
+		mock.ExpectCommit()
+
+		UpdateDeckPreset(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		w, c, mock, _, _ := setupDuplicates(t, "PUT", "/", `{"name":"Slow"}`)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`UPDATE deck_presets`).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		UpdateDeckPreset(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestDeleteDeckPreset(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		w, c, mock, testUserID, presetID := setupDuplicates(t, "DELETE", "/", "")
+
+		mock.ExpectExec(`DELETE FROM deck_presets WHERE id = \$1 AND owner_id = \$2`).
+			WithArgs(presetID, testUserID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		DeleteDeckPreset(c)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		w, c, mock, _, _ := setupDuplicates(t, "DELETE", "/", "")
+
+		mock.ExpectExec(`DELETE FROM deck_presets`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		DeleteDeckPreset(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestCreateDeckWithPreset(t *testing.T) {
+	t.Run("own preset", func(t *testing.T) {
+		presetID := uuid.New()
+		w, c, mock, testUserID, _ := setupDuplicates(t, "POST", "/", `{"title":"Verbs","preset_id":"`+presetID.String()+`"}`)
+
+		mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM deck_presets WHERE id = \$1 AND owner_id = \$2\)`).
+			WithArgs(presetID, testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectQuery(`INSERT INTO decks`).
+			WithArgs(testUserID, sqlmock.AnyArg(), "Verbs", "", nil, 8, "tag", presetID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+
+		CreateDeck(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("preset of another user", func(t *testing.T) {
+		presetID := uuid.New()
+		w, c, mock, testUserID, _ := setupDuplicates(t, "POST", "/", `{"title":"Verbs","preset_id":"`+presetID.String()+`"}`)
+
+		mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM deck_presets`).
+			WithArgs(presetID, testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+		CreateDeck(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), "Deck preset not found")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,211 @@
+package controllers
+
+import (
+	"api/src/apierror"
+	"api/src/database"
+	"api/src/models"
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// deckFronts returns the ID and front of every flashcard of a deck
+func deckFronts(deckID uuid.UUID) ([]models.Flashcard, error) {
+	rows, err := database.DB.Query("SELECT id, front FROM flashcards WHERE parent_deck = $1 ORDER BY id", deckID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var flashcards []models.Flashcard
+	for rows.Next() {
+		var f models.Flashcard
+		if err := rows.Scan(&f.ID, &f.Front); err != nil {
+			return nil, err
+		}
+		flashcards = append(flashcards, f)
+	}
+	return flashcards, rows.Err()
+}
+
+// GetDuplicates returns the groups of flashcards of a deck whose fronts are
+// duplicates: equal once case, punctuation and whitespace are ignored, or at
+// least as similar as the threshold query parameter.
+func GetDuplicates(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	deckID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidID("Deck"))
+		return
+	}
+
+	threshold := models.DefaultDuplicateThreshold
+	if s := c.Query("threshold"); s != "" {
+		threshold, err = strconv.ParseFloat(s, 64)
+		if err != nil || threshold <= 0 || threshold > 1 {
+			apierror.Abort(c, apierror.BadRequest("threshold must be a number above 0 and at most 1"))
+			return
+		}
+	}
+
+	var id uuid.UUID
+	err = database.DB.QueryRow("SELECT id FROM decks WHERE id = $1 AND owner_id = $2", deckID, userID).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Abort(c, apierror.NotFound("Deck not found or access denied"))
+			return
+		}
+		apierror.Abort(c, err)
+		return
+	}
+
+	flashcards, err := queryFlashcards(c.Request.Context(), "f.parent_deck = $1", deckID)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	groups := models.FindDuplicates(flashcards[deckID], threshold)
+	if groups == nil {
+		groups = []models.DuplicateGroup{}
+	}
+	c.JSON(http.StatusOK, groups)
+}
+
+// MergeDuplicates keeps one flashcard of a deck and deletes the duplicates
+// merged into it. The kept flashcard is starred if any of them was, and takes
+// over their review history and the most advanced schedule among them.
+func MergeDuplicates(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	deckID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidID("Deck"))
+		return
+	}
+
+	var merge models.DuplicateMerge
+	if err := c.ShouldBindJSON(&merge); err != nil {
+		apierror.Abort(c, apierror.Invalid(err))
+		return
+	}
+	if err := merge.Validate(); err != nil {
+		apierror.Abort(c, apierror.Invalid(err))
+		return
+	}
+
+	ids := map[uuid.UUID]bool{merge.Keep: true}
+	for _, id := range merge.Merge {
+		ids[id] = true
+	}
+	all := append([]uuid.UUID{merge.Keep}, merge.Merge...)
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	defer tx.Rollback()
+
+	if err := lockDeck(tx, deckID, userID); err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Abort(c, apierror.NotFound("Deck not found or access denied"))
+			return
+		}
+		apierror.Abort(c, err)
+		return
+	}
+
+	rows, err := tx.Query(
+		"SELECT id, parent_deck, starred, front, back FROM flashcards WHERE parent_deck = $1 AND id = ANY($2) FOR UPDATE",
+		deckID, pq.Array(all),
+	)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	var kept models.Flashcard
+	found, starred := 0, false
+	for rows.Next() {
+		var f models.Flashcard
+		if err := rows.Scan(&f.ID, &f.ParentDeck, &f.Starred, &f.Front, &f.Back); err != nil {
+			rows.Close()
+			apierror.Abort(c, err)
+			return
+		}
+		found++
+		starred = starred || (f.Starred != nil && *f.Starred)
+		if f.ID == merge.Keep {
+			kept = f
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	if found != len(ids) {
+		apierror.Abort(c, apierror.NotFound("Flashcards not found in this deck"))
+		return
+	}
+
+	if starred && (kept.Starred == nil || !*kept.Starred) {
+		if err := saveFlashcardRevision(tx, kept.ID, userID); err != nil {
+			apierror.Abort(c, err)
+			return
+		}
+		if _, err := tx.Exec("UPDATE flashcards SET starred = TRUE WHERE id = $1", kept.ID); err != nil {
+			apierror.Abort(c, err)
+			return
+		}
+		kept.Starred = &starred
+	}
+
+	// The kept flashcard inherits the review history of the merged ones
+	if _, err := tx.Exec("UPDATE review_logs SET flashcard_id = $1 WHERE flashcard_id = ANY($2)", kept.ID, pq.Array(merge.Merge)); err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	// and the schedule with the longest interval, with the most reps and
+	// lapses of them all. A suspension or burial of the kept flashcard stays;
+	// those of the merged ones don't carry over.
+	_, err = tx.Exec(
+		`INSERT INTO card_states (flashcard_id, state, step, ease, interval_days, due_at, reps, lapses, last_reviewed_at, leech, suspended_at, buried_until)
+		 SELECT $1, s.state, s.step, s.ease, s.interval_days, s.due_at, m.reps, m.lapses, s.last_reviewed_at, m.leech, NULL, NULL
+		 FROM card_states s,
+		      (SELECT MAX(reps) AS reps, MAX(lapses) AS lapses, bool_or(leech) AS leech FROM card_states WHERE flashcard_id = ANY($2)) m
+		 WHERE s.flashcard_id = ANY($2)
+		 ORDER BY s.interval_days DESC, s.reps DESC
+		 LIMIT 1
+		 ON CONFLICT (flashcard_id) DO UPDATE SET state = EXCLUDED.state, step = EXCLUDED.step, ease = EXCLUDED.ease,
+		     interval_days = EXCLUDED.interval_days, due_at = EXCLUDED.due_at, reps = EXCLUDED.reps, lapses = EXCLUDED.lapses,
+		     last_reviewed_at = EXCLUDED.last_reviewed_at, leech = EXCLUDED.leech`,
+		kept.ID, pq.Array(all),
+	)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	if _, err := tx.Exec("DELETE FROM flashcards WHERE id = ANY($1)", pq.Array(merge.Merge)); err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, kept)
+}
@@ -0,0 +1,188 @@
+package controllers
+
+import (
+	"api/src/database"
+	"api/src/models"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupDuplicates returns a test context for a request on a deck, or any
+// resource identified by the id parameter, by a fresh user
+func setupDuplicates(t *testing.T, method, target, body string) (*httptest.ResponseRecorder, *gin.Context, sqlmock.Sqlmock, uuid.UUID, uuid.UUID) {
+	gin.SetMode(gin.TestMode)
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	t.Cleanup(func() { mockDB.Close() })
+	database.DB = mockDB
+
+	testUserID := uuid.New()
+	testDeckID := uuid.New()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{gin.Param{Key: "id", Value: testDeckID.String()}}
+	c.Request = httptest.NewRequest(method, target, strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	originalGetUserID := GetUserIDFromClerkID
+	GetUserIDFromClerkID = func(c *gin.Context) (uuid.UUID, bool) {
+		return testUserID, true
+	}
+	t.Cleanup(func() { GetUserIDFromClerkID = originalGetUserID })
+
+	return w, c, mock, testUserID, testDeckID
+}
+
+func TestGetDuplicates(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		w, c, mock, testUserID, testDeckID := setupDuplicates(t, "GET", "/?threshold=0.9", "")
+		first, second := uuid.New(), uuid.New()
+
+		mock.ExpectQuery(`SELECT id FROM decks WHERE id = \$1 AND owner_id = \$2`).
+			WithArgs(testDeckID, testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testDeckID))
+		mock.ExpectQuery(`SELECT f.id, f.parent_deck, f.starred, f.front, f.back FROM flashcards f JOIN decks d ON f.parent_deck = d.id WHERE f.parent_deck = \$1 ORDER BY f.parent_deck, f.id`).
+			WithArgs(testDeckID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "parent_deck", "starred", "front", "back"}).
+				AddRow(first, testDeckID, false, "Capital of France?", "Paris").
+				AddRow(uuid.New(), testDeckID, false, "Capital of Italy?", "Rome").
+				AddRow(second, testDeckID, true, "capital of  France", "Paris"))
+
+		GetDuplicates(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var groups []models.DuplicateGroup
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &groups))
+		if assert.Len(t, groups, 1) {
+			assert.Equal(t, models.DuplicateExact, groups[0].Kind)
+			assert.Equal(t, first, groups[0].Flashcards[0].ID)
+			assert.Equal(t, second, groups[0].Flashcards[1].ID)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("invalid threshold", func(t *testing.T) {
+		w, c, _, _, _ := setupDuplicates(t, "GET", "/?threshold=2", "")
+
+		GetDuplicates(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("deck not found", func(t *testing.T) {
+		w, c, mock, testUserID, testDeckID := setupDuplicates(t, "GET", "/", "")
+
+		mock.ExpectQuery(`SELECT id FROM decks WHERE id = \$1 AND owner_id = \$2`).
+			WithArgs(testDeckID, testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		GetDuplicates(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestMergeDuplicates(t *testing.T) {
+	keep, merged := uuid.New(), uuid.New()
+	body := `{"keep":"` + keep.String() + `","merge":["` + merged.String() + `"]}`
+
+	t.Run("success", func(t *testing.T) {
+		w, c, mock, testUserID, testDeckID := setupDuplicates(t, "POST", "/", body)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT id FROM decks WHERE id = \$1 AND owner_id = \$2 FOR UPDATE`).
+			WithArgs(testDeckID, testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testDeckID))
+		mock.ExpectQuery(`SELECT id, parent_deck, starred, front, back FROM flashcards WHERE parent_deck = \$1 AND id = ANY\(\$2\) FOR UPDATE`).
+			WithArgs(testDeckID, pq.Array([]uuid.UUID{keep, merged})).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "parent_deck", "starred", "front", "back"}).
+				AddRow(keep, testDeckID, false, "Capital of France?", "Paris").
+				AddRow(merged, testDeckID, true, "capital of france", "Paris"))
+		mock.ExpectExec(`INSERT INTO flashcard_revisions`).
+			WithArgs(keep, testUserID).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`UPDATE flashcards SET starred = TRUE WHERE id = \$1`).
+			WithArgs(keep).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE review_logs SET flashcard_id = \$1 WHERE flashcard_id = ANY\(\$2\)`).
+			WithArgs(keep, pq.Array([]uuid.UUID{merged})).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec(`INSERT INTO card_states .* SELECT \$1, s.state, s.step, s.ease, s.interval_days, s.due_at, m.reps, m.lapses, s.last_reviewed_at, m.leech, NULL, NULL FROM card_states s, \(SELECT MAX\(reps\) AS reps, MAX\(lapses\) AS lapses, bool_or\(leech\) AS leech `+
+			`FROM card_states WHERE flashcard_id = ANY\(\$2\)\) m WHERE s.flashcard_id = ANY\(\$2\) ORDER BY s.interval_days DESC, s.reps DESC LIMIT 1 ON CONFLICT`).
+			WithArgs(keep, pq.Array([]uuid.UUID{keep, merged})).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`DELETE FROM flashcards WHERE id = ANY\(\$1\)`).
+			WithArgs(pq.Array([]uuid.UUID{merged})).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		MergeDuplicates(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"starred":true`)
+		assert.Contains(t, w.Body.String(), keep.String())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("flashcard from another deck", func(t *testing.T) {
+		w, c, mock, testUserID, testDeckID := setupDuplicates(t, "POST", "/", body)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT id FROM decks WHERE id = \$1 AND owner_id = \$2 FOR UPDATE`).
+			WithArgs(testDeckID, testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testDeckID))
+		mock.ExpectQuery(`SELECT id, parent_deck, starred, front, back FROM flashcards`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "parent_deck", "starred", "front", "back"}).
+				AddRow(keep, testDeckID, false, "Capital of France?", "Paris"))
+		mock.ExpectRollback()
+
+		MergeDuplicates(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("keep merged into itself", func(t *testing.T) {
+		w, c, _, _, _ := setupDuplicates(t, "POST", "/", `{"keep":"`+keep.String()+`","merge":["`+keep.String()+`"]}`)
+
+		MergeDuplicates(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "keep can't also be merged")
+	})
+}
+
+func TestCreateFlashcardDuplicates(t *testing.T) {
+	w, c, mock, testUserID, testDeckID := setupDuplicates(t, "POST", "/", `{"front":"capital of FRANCE","back":"Paris","starred":false}`)
+	existing := uuid.New()
+
+	mock.ExpectQuery(`SELECT owner_id FROM decks WHERE id = \$1`).
+		WithArgs(testDeckID).
+		WillReturnRows(sqlmock.NewRows([]string{"owner_id"}).AddRow(testUserID))
+	mock.ExpectQuery(`SELECT id, front FROM flashcards WHERE parent_deck = \$1 ORDER BY id`).
+		WithArgs(testDeckID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "front"}).AddRow(existing, "Capital of France?"))
+	mock.ExpectQuery(`INSERT INTO flashcards`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+
+	CreateFlashcard(c)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created models.Flashcard
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, []models.DuplicateMatch{{FlashcardID: existing, Front: "Capital of France?", Kind: models.DuplicateExact, Similarity: 1}}, created.Duplicates)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
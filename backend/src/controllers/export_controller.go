@@ -0,0 +1,604 @@
+package controllers
+
+import (
+	"api/src/apierror"
+	"api/src/database"
+	"api/src/export"
+	"api/src/jobs"
+	"api/src/models"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ExportDir is where account archives are written. Set from the configuration at startup.
+var ExportDir = filepath.Join(os.TempDir(), "flashcard-exports")
+
+// ExportSigningKey signs archive download links. Set from the configuration at startup.
+var ExportSigningKey []byte
+
+const (
+	// exportRetention is how long a finished archive is kept
+	exportRetention = 7 * 24 * time.Hour
+	// exportLinkTTL is how long a download link stays valid
+	exportLinkTTL = time.Hour
+)
+
+// Errors recorded on failed export jobs. The causes are logged rather than
+// stored, as they may reveal internals to the user polling the job.
+const (
+	exportQueueBusy = "Export queue is busy, try again later"
+	exportFailed    = "Export failed, try again later"
+)
+
+// enqueueJob schedules background work; replaced in tests to run jobs inline
+var enqueueJob = func(name string, fn jobs.Func) error {
+	return jobs.Default.Enqueue(name, fn)
+}
+
+// ExportDeck downloads a deck in the format given by the format query parameter (json or csv)
+func ExportDeck(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	deckID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidID(""))
+		return
+	}
+
+	format := c.DefaultQuery("format", export.FormatJSON)
+	if format != export.FormatJSON && format != export.FormatCSV {
+		apierror.Abort(c, apierror.BadRequest("format must be json or csv"))
+		return
+	}
+
+	var deck models.Deck
+	err = scanDeck(database.DB.QueryRow(
+		"SELECT "+deckColumns+" FROM decks WHERE id = $1 AND owner_id = $2",
+		deckID, userID,
+	), &deck)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Abort(c, apierror.NotFound("Deck not found or access denied"))
+			return
+		}
+		apierror.Abort(c, err)
+		return
+	}
+
+	flashcards, err := queryFlashcards(c.Request.Context(), "f.parent_deck = $1", deckID)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="deck-%s.%s"`, deck.ID, format))
+	c.Header("Content-Type", export.ContentType(format))
+	c.Status(http.StatusOK)
+	if err := export.WriteDeck(c.Writer, format, deck, flashcards[deck.ID]); err != nil {
+		log.Printf("Failed to export deck %s: %v", deck.ID, err)
+	}
+}
+
+// RequestAccountExport starts building an archive of everything stored about
+// the authenticated user. The archive is built in the background; poll
+// GetAccountExport for its status and download link.
+func RequestAccountExport(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	job := models.ExportJob{UserID: userID, Status: models.ExportPending}
+	err := database.DB.QueryRow(
+		"INSERT INTO export_jobs (user_id) VALUES ($1) RETURNING id, created_at",
+		userID,
+	).Scan(&job.ID, &job.CreatedAt)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	jobID := job.ID
+	err = enqueueJob("account-export", func(ctx context.Context) error {
+		return runAccountExport(ctx, jobID, userID)
+	})
+	if err != nil {
+		log.Printf("Failed to enqueue export %s: %v", jobID, err)
+		database.DB.Exec("UPDATE export_jobs SET status = 'failed', error = $1, finished_at = NOW() WHERE id = $2", exportQueueBusy, jobID)
+		apierror.Abort(c, apierror.Unavailable(exportQueueBusy))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GetAccountExport returns the status of an export job, with a signed
+// download link once the archive is ready
+func GetAccountExport(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	jobID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidID(""))
+		return
+	}
+
+	var job models.ExportJob
+	var jobError sql.NullString
+	err = database.DB.QueryRow(
+		`SELECT id, user_id, status, error, created_at, finished_at, expires_at
+		 FROM export_jobs WHERE id = $1 AND user_id = $2`,
+		jobID, userID,
+	).Scan(&job.ID, &job.UserID, &job.Status, &jobError, &job.CreatedAt, &job.FinishedAt, &job.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Abort(c, apierror.NotFound("Export not found"))
+			return
+		}
+		apierror.Abort(c, err)
+		return
+	}
+	job.Error = jobError.String
+
+	if job.Status == models.ExportDone && job.ExpiresAt != nil && time.Now().Before(*job.ExpiresAt) {
+		expires := time.Now().Add(exportLinkTTL)
+		if expires.After(*job.ExpiresAt) {
+			expires = *job.ExpiresAt
+		}
+		job.DownloadURL = fmt.Sprintf("/api/go/exports/%s/download?expires=%d&signature=%s",
+			job.ID, expires.Unix(), signExportLink(job.ID, expires.Unix()))
+		job.DownloadExpiresAt = &expires
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// DownloadAccountExport serves an archive to anyone holding a valid signed link.
+// It is not behind authentication so the link can be opened directly in a browser.
+func DownloadAccountExport(c *gin.Context) {
+	jobID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidID(""))
+		return
+	}
+
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil || time.Now().Unix() > expires ||
+		!hmac.Equal([]byte(signExportLink(jobID, expires)), []byte(c.Query("signature"))) {
+		apierror.Abort(c, apierror.Forbidden(apierror.CodeForbidden, "Download link is invalid or has expired"))
+		return
+	}
+
+	var path string
+	err = database.DB.QueryRow(
+		"SELECT file_path FROM export_jobs WHERE id = $1 AND status = 'done' AND expires_at > NOW()",
+		jobID,
+	).Scan(&path)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Abort(c, apierror.NotFound("Export not found"))
+			return
+		}
+		apierror.Abort(c, err)
+		return
+	}
+
+	c.FileAttachment(path, "flashcards-export-"+jobID.String()+".zip")
+}
+
+// signExportLink returns the hex HMAC of a download link
+func signExportLink(jobID uuid.UUID, expires int64) string {
+	mac := hmac.New(sha256.New, ExportSigningKey)
+	fmt.Fprintf(mac, "%s.%d", jobID, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// runAccountExport builds the archive for an export job and records the outcome
+func runAccountExport(ctx context.Context, jobID, userID uuid.UUID) error {
+	if _, err := database.DB.ExecContext(ctx, "UPDATE export_jobs SET status = 'running' WHERE id = $1", jobID); err != nil {
+		return err
+	}
+
+	path, err := buildAccountArchive(ctx, jobID, userID)
+	if err != nil {
+		log.Printf("Failed to build export %s: %v", jobID, err)
+		database.DB.ExecContext(ctx,
+			"UPDATE export_jobs SET status = 'failed', error = $1, finished_at = NOW() WHERE id = $2",
+			exportFailed, jobID,
+		)
+		return err
+	}
+
+	_, err = database.DB.ExecContext(ctx,
+		"UPDATE export_jobs SET status = 'done', file_path = $1, finished_at = NOW(), expires_at = $2 WHERE id = $3",
+		path, time.Now().Add(exportRetention), jobID,
+	)
+	return err
+}
+
+// buildAccountArchive writes the archive to a temporary file and moves it
+// into place once complete, so a half-written archive is never served
+func buildAccountArchive(ctx context.Context, jobID, userID uuid.UUID) (string, error) {
+	account, err := loadAccount(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(ExportDir, 0o700); err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(ExportDir, jobID.String()+"-*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := export.WriteAccountArchive(tmp, account, time.Now().UTC()); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(ExportDir, jobID.String()+".zip")
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// PurgeExpiredExports deletes archives past their retention period
+func PurgeExpiredExports(ctx context.Context) error {
+	rows, err := database.DB.QueryContext(ctx,
+		"DELETE FROM export_jobs WHERE expires_at < NOW() RETURNING file_path",
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var path sql.NullString
+		if err := rows.Scan(&path); err != nil {
+			return err
+		}
+		if path.Valid {
+			if err := os.Remove(path.String); err != nil && !os.IsNotExist(err) {
+				log.Printf("Failed to delete export %s: %v", path.String, err)
+			}
+		}
+	}
+	return rows.Err()
+}
+
+// loadAccount gathers everything stored about a user
+func loadAccount(ctx context.Context, userID uuid.UUID) (export.Account, error) {
+	var account export.Account
+	err := scanUser(database.DB.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = $1", userID), &account.User)
+	if err != nil {
+		return account, err
+	}
+
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT "+tokenColumns+" FROM personal_access_tokens WHERE user_id = $1 ORDER BY created_at",
+		userID,
+	)
+	if err != nil {
+		return account, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var t models.PersonalAccessToken
+		if err := scanToken(rows, &t); err != nil {
+			return account, err
+		}
+		account.Tokens = append(account.Tokens, t)
+	}
+	if err := rows.Err(); err != nil {
+		return account, err
+	}
+
+	deckRows, err := database.DB.QueryContext(ctx,
+		"SELECT "+deckColumns+" FROM decks WHERE owner_id = $1 ORDER BY title",
+		userID,
+	)
+	if err != nil {
+		return account, err
+	}
+	defer deckRows.Close()
+	for deckRows.Next() {
+		var d export.DeckExport
+		if err := scanDeck(deckRows, &d.Deck); err != nil {
+			return account, err
+		}
+		account.Decks = append(account.Decks, d)
+	}
+	if err := deckRows.Err(); err != nil {
+		return account, err
+	}
+
+	flashcards, err := queryFlashcards(ctx, "d.owner_id = $1", userID)
+	if err != nil {
+		return account, err
+	}
+
+	deckRevisions := map[uuid.UUID][]models.DeckRevision{}
+	revRows, err := database.DB.QueryContext(ctx,
+		`SELECT r.deck_id, r.rev, r.labels, r.title, r.description, r.edited_by, r.created_at
+		 FROM deck_revisions r JOIN decks d ON r.deck_id = d.id
+		 WHERE d.owner_id = $1 ORDER BY r.deck_id, r.rev`,
+		userID,
+	)
+	if err != nil {
+		return account, err
+	}
+	defer revRows.Close()
+	for revRows.Next() {
+		var r models.DeckRevision
+		if err := revRows.Scan(&r.DeckID, &r.Rev, pq.Array(&r.Labels), &r.Title, &r.Description, &r.EditedBy, &r.CreatedAt); err != nil {
+			return account, err
+		}
+		deckRevisions[r.DeckID] = append(deckRevisions[r.DeckID], r)
+	}
+	if err := revRows.Err(); err != nil {
+		return account, err
+	}
+
+	flashcardRevisions := map[uuid.UUID][]models.FlashcardRevision{}
+	cardRevRows, err := database.DB.QueryContext(ctx,
+		`SELECT f.parent_deck, r.flashcard_id, r.rev, r.starred, r.front, r.back, r.edited_by, r.created_at
+		 FROM flashcard_revisions r
+		 JOIN flashcards f ON r.flashcard_id = f.id
+		 JOIN decks d ON f.parent_deck = d.id
+		 WHERE d.owner_id = $1 ORDER BY r.flashcard_id, r.rev`,
+		userID,
+	)
+	if err != nil {
+		return account, err
+	}
+	defer cardRevRows.Close()
+	for cardRevRows.Next() {
+		var deckID uuid.UUID
+		var r models.FlashcardRevision
+		if err := cardRevRows.Scan(&deckID, &r.FlashcardID, &r.Rev, &r.Starred, &r.Front, &r.Back, &r.EditedBy, &r.CreatedAt); err != nil {
+			return account, err
+		}
+		flashcardRevisions[deckID] = append(flashcardRevisions[deckID], r)
+	}
+	if err := cardRevRows.Err(); err != nil {
+		return account, err
+	}
+
+	logRows, err := database.DB.QueryContext(ctx,
+		`SELECT id, user_id, flashcard_id, session_id, grade, typed_answer, scheduled, state_before, state_after,
+		        interval_before, interval_after, ease, duration_ms, reviewed_at
+		 FROM review_logs WHERE user_id = $1 ORDER BY reviewed_at`,
+		userID,
+	)
+	if err != nil {
+		return account, err
+	}
+	defer logRows.Close()
+	for logRows.Next() {
+		var r models.ReviewLog
+		err := logRows.Scan(&r.ID, &r.UserID, &r.FlashcardID, &r.SessionID, &r.Grade, &r.TypedAnswer, &r.Scheduled, &r.StateBefore, &r.StateAfter,
+			&r.IntervalBefore, &r.IntervalAfter, &r.Ease, &r.DurationMs, &r.ReviewedAt)
+		if err != nil {
+			return account, err
+		}
+		account.ReviewLogs = append(account.ReviewLogs, r)
+	}
+	if err := logRows.Err(); err != nil {
+		return account, err
+	}
+	if err := loadStudyData(ctx, userID, &account); err != nil {
+		return account, err
+	}
+
+	for i := range account.Decks {
+		id := account.Decks[i].Deck.ID
+		account.Decks[i].Flashcards = flashcards[id]
+		account.Decks[i].DeckRevisions = deckRevisions[id]
+		account.Decks[i].FlashcardRevisions = flashcardRevisions[id]
+	}
+	return account, nil
+}
+
+// loadStudyData adds the study options, schedules and activity of a user to their account
+func loadStudyData(ctx context.Context, userID uuid.UUID, account *export.Account) error {
+	var goal models.StudyGoal
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT unit, target, day_rollover FROM study_goals WHERE user_id = $1",
+		userID,
+	).Scan(&goal.Unit, &goal.Target, &goal.DayRollover)
+	if err == nil {
+		account.StudyGoal = &goal
+	} else if err != sql.ErrNoRows {
+		return err
+	}
+
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT "+deckPresetColumns+" FROM deck_presets WHERE owner_id = $1 ORDER BY created_at",
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+	err = scanRows(rows, func() error {
+		var p models.DeckPreset
+		if err := scanDeckPreset(rows, &p); err != nil {
+			return err
+		}
+		account.DeckPresets = append(account.DeckPresets, p)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	rows, err = database.DB.QueryContext(ctx,
+		"SELECT "+filteredDeckColumns+" FROM filtered_decks WHERE owner_id = $1 ORDER BY created_at",
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+	err = scanRows(rows, func() error {
+		var d models.FilteredDeck
+		if err := scanFilteredDeck(rows, &d); err != nil {
+			return err
+		}
+		account.FilteredDecks = append(account.FilteredDecks, d)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	rows, err = database.DB.QueryContext(ctx,
+		`SELECT s.flashcard_id, s.state, s.step, s.ease, s.interval_days, s.due_at, s.reps, s.lapses, s.leech, s.suspended_at, s.buried_until
+		 FROM card_states s
+		 JOIN flashcards f ON f.id = s.flashcard_id
+		 JOIN decks d ON d.id = f.parent_deck
+		 WHERE d.owner_id = $1 ORDER BY s.flashcard_id`,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+	err = scanRows(rows, func() error {
+		var s models.CardState
+		if err := scanCardState(rows, &s); err != nil {
+			return err
+		}
+		account.CardStates = append(account.CardStates, s)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	rows, err = database.DB.QueryContext(ctx,
+		"SELECT "+studySessionColumns+" FROM study_sessions WHERE user_id = $1 ORDER BY started_at",
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+	err = scanRows(rows, func() error {
+		var s models.StudySession
+		if err := scanStudySession(rows, &s); err != nil {
+			return err
+		}
+		account.StudySessions = append(account.StudySessions, s)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Attempts are graded again against their quiz to export the answer keys
+	rows, err = database.DB.QueryContext(ctx,
+		`SELECT a.id, a.answers, a.submitted_at, q.id, q.deck_id, q.seed, q.choices, q.questions, q.created_at
+		 FROM quiz_attempts a
+		 JOIN quizzes q ON q.id = a.quiz_id
+		 WHERE a.user_id = $1 ORDER BY a.submitted_at`,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+	err = scanRows(rows, func() error {
+		var id uuid.UUID
+		var submittedAt time.Time
+		var answers pq.Int64Array
+		var quiz models.Quiz
+		var questions []byte
+		err := rows.Scan(&id, &answers, &submittedAt, &quiz.ID, &quiz.DeckID, &quiz.Seed, &quiz.Choices, &questions, &quiz.CreatedAt)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(questions, &quiz.Questions); err != nil {
+			return err
+		}
+		picked := make([]int, len(quiz.Questions))
+		for i := range picked {
+			picked[i] = -1
+			if i < len(answers) {
+				picked[i] = int(answers[i])
+			}
+		}
+		attempt := quiz.Grade(picked)
+		attempt.ID, attempt.SubmittedAt = id, submittedAt
+		account.QuizAttempts = append(account.QuizAttempts, attempt)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	rows, err = database.DB.QueryContext(ctx,
+		`SELECT id, deck_id, kind, seed, seeded, started_at, submitted_at, correct, total, duration_ms
+		 FROM game_rounds WHERE user_id = $1 ORDER BY started_at`,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+	return scanRows(rows, func() error {
+		var r export.GameRound
+		if err := rows.Scan(&r.ID, &r.DeckID, &r.Kind, &r.Seed, &r.Seeded, &r.StartedAt, &r.SubmittedAt, &r.Correct, &r.Total, &r.DurationMs); err != nil {
+			return err
+		}
+		account.GameRounds = append(account.GameRounds, r)
+		return nil
+	})
+}
+
+// queryFlashcards returns flashcards matching a condition on the flashcard (f)
+// and its deck (d), grouped by deck
+func queryFlashcards(ctx context.Context, where string, args ...any) (map[uuid.UUID][]models.Flashcard, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		`SELECT f.id, f.parent_deck, f.starred, f.front, f.back
+		 FROM flashcards f
+		 JOIN decks d ON f.parent_deck = d.id
+		 WHERE `+where+` ORDER BY f.parent_deck, f.id`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	flashcards := map[uuid.UUID][]models.Flashcard{}
+	for rows.Next() {
+		var f models.Flashcard
+		if err := rows.Scan(&f.ID, &f.ParentDeck, &f.Starred, &f.Front, &f.Back); err != nil {
+			return nil, err
+		}
+		flashcards[f.ParentDeck] = append(flashcards[f.ParentDeck], f)
+	}
+	return flashcards, rows.Err()
+}
@@ -0,0 +1,330 @@
+package controllers
+
+import (
+	"api/src/database"
+	"api/src/export"
+	"api/src/jobs"
+	"api/src/models"
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportDeck(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("csv", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		testUserID := uuid.New()
+		testDeckID := uuid.New()
+
+		mock.ExpectQuery(`SELECT id, owner_id, labels, title, description, to_char\(exam_date, 'YYYY-MM-DD'\), leech_threshold, leech_action, preset_id FROM decks WHERE id = \$1 AND owner_id = \$2`).
+			WithArgs(testDeckID, testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id", "labels", "title", "description", "exam_date", "leech_threshold", "leech_action", "preset_id"}).
+				AddRow(testDeckID, testUserID, pq.Array([]string{}), "Capitals", "", nil, 8, "tag", nil))
+		mock.ExpectQuery(`SELECT f.id, f.parent_deck, f.starred, f.front, f.back FROM flashcards f JOIN decks d ON f.parent_deck = d.id WHERE f.parent_deck = \$1`).
+			WithArgs(testDeckID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "parent_deck", "starred", "front", "back"}).
+				AddRow(uuid.New(), testDeckID, true, "France", "Paris"))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "id", Value: testDeckID.String()}}
+		c.Request, _ = http.NewRequest("GET", "/?format=csv", nil)
+
+		originalGetUserID := GetUserIDFromClerkID
+		GetUserIDFromClerkID = func(c *gin.Context) (uuid.UUID, bool) {
+			return testUserID, true
+		}
+		defer func() { GetUserIDFromClerkID = originalGetUserID }()
+
+		ExportDeck(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "front,back,starred\nFrance,Paris,true\n", w.Body.String())
+		assert.Contains(t, w.Header().Get("Content-Disposition"), ".csv")
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "id", Value: uuid.New().String()}}
+		c.Request, _ = http.NewRequest("GET", "/?format=xml", nil)
+
+		originalGetUserID := GetUserIDFromClerkID
+		GetUserIDFromClerkID = func(c *gin.Context) (uuid.UUID, bool) {
+			return uuid.New(), true
+		}
+		defer func() { GetUserIDFromClerkID = originalGetUserID }()
+
+		ExportDeck(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestRequestAccountExport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		testUserID := uuid.New()
+		testJobID := uuid.New()
+
+		mock.ExpectQuery(`INSERT INTO export_jobs \(user_id\) VALUES \(\$1\) RETURNING id, created_at`).
+			WithArgs(testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(testJobID, time.Now()))
+
+		var enqueued []string
+		originalEnqueue := enqueueJob
+		enqueueJob = func(name string, fn jobs.Func) error {
+			enqueued = append(enqueued, name)
+			return nil
+		}
+		defer func() { enqueueJob = originalEnqueue }()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/", nil)
+
+		originalGetUserID := GetUserIDFromClerkID
+		GetUserIDFromClerkID = func(c *gin.Context) (uuid.UUID, bool) {
+			return testUserID, true
+		}
+		defer func() { GetUserIDFromClerkID = originalGetUserID }()
+
+		RequestAccountExport(c)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		assert.Contains(t, w.Body.String(), `"status":"pending"`)
+		assert.Equal(t, []string{"account-export"}, enqueued)
+	})
+}
+
+func TestRunAccountExport(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	database.DB = mockDB
+
+	originalExportDir := ExportDir
+	ExportDir = t.TempDir()
+	defer func() { ExportDir = originalExportDir }()
+
+	testUserID := uuid.New()
+	testDeckID := uuid.New()
+	testJobID := uuid.New()
+
+	mock.ExpectExec(`UPDATE export_jobs SET status = 'running' WHERE id = \$1`).
+		WithArgs(testJobID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`SELECT id, clerk_id, name, email, role, suspended_at, timezone FROM users WHERE id = \$1`).
+		WithArgs(testUserID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "clerk_id", "name", "email", "role", "suspended_at", "timezone"}).
+			AddRow(testUserID, "clerk1", "Ada", "ada@example.com", "user", nil, "UTC"))
+	mock.ExpectQuery(`FROM personal_access_tokens WHERE user_id = \$1`).
+		WithArgs(testUserID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "token_prefix", "scopes", "expires_at", "last_used_at", "created_at", "revoked_at"}))
+	mock.ExpectQuery(`SELECT id, owner_id, labels, title, description, .* FROM decks WHERE owner_id = \$1 ORDER BY title`).
+		WithArgs(testUserID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id", "labels", "title", "description", "exam_date", "leech_threshold", "leech_action", "preset_id"}).
+			AddRow(testDeckID, testUserID, pq.Array([]string{"geo"}), "Capitals", "", "2026-06-15", 8, "tag", nil))
+	mock.ExpectQuery(`FROM flashcards f JOIN decks d ON f.parent_deck = d.id WHERE d.owner_id = \$1`).
+		WithArgs(testUserID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "parent_deck", "starred", "front", "back"}).
+			AddRow(uuid.New(), testDeckID, false, "France", "Paris"))
+	mock.ExpectQuery(`FROM deck_revisions r`).
+		WithArgs(testUserID).
+		WillReturnRows(sqlmock.NewRows([]string{"deck_id", "rev", "labels", "title", "description", "edited_by", "created_at"}))
+	mock.ExpectQuery(`FROM flashcard_revisions r`).
+		WithArgs(testUserID).
+		WillReturnRows(sqlmock.NewRows([]string{"parent_deck", "flashcard_id", "rev", "starred", "front", "back", "edited_by", "created_at"}))
+	mock.ExpectQuery(`FROM review_logs WHERE user_id = \$1 ORDER BY reviewed_at`).
+		WithArgs(testUserID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "flashcard_id", "session_id", "grade", "typed_answer", "scheduled", "state_before", "state_after",
+			"interval_before", "interval_after", "ease", "duration_ms", "reviewed_at"}).
+			AddRow(uuid.New(), testUserID, uuid.New(), nil, 3, nil, true, "new", "learning", 0, 0, 2.5, 4000, time.Now()))
+	mock.ExpectQuery(`SELECT unit, target, day_rollover FROM study_goals WHERE user_id = \$1`).
+		WithArgs(testUserID).
+		WillReturnRows(sqlmock.NewRows([]string{"unit", "target", "day_rollover"}).AddRow("minutes", 15, 4))
+	mock.ExpectQuery(`FROM deck_presets WHERE owner_id = \$1 ORDER BY created_at`).
+		WithArgs(testUserID).
+		WillReturnRows(sqlmock.NewRows(nil))
+	mock.ExpectQuery(`FROM filtered_decks WHERE owner_id = \$1 ORDER BY created_at`).
+		WithArgs(testUserID).
+		WillReturnRows(sqlmock.NewRows(nil))
+	mock.ExpectQuery(`FROM card_states s JOIN flashcards f ON f.id = s.flashcard_id JOIN decks d ON d.id = f.parent_deck WHERE d.owner_id = \$1`).
+		WithArgs(testUserID).
+		WillReturnRows(sqlmock.NewRows([]string{"flashcard_id", "state", "step", "ease", "interval_days", "due_at", "reps", "lapses", "leech", "suspended_at", "buried_until"}).
+			AddRow(uuid.New(), "learning", 1, 2.5, 0, time.Now(), 1, 0, false, nil, nil))
+	mock.ExpectQuery(`FROM study_sessions WHERE user_id = \$1 ORDER BY started_at`).
+		WithArgs(testUserID).
+		WillReturnRows(sqlmock.NewRows(nil))
+	answer := 1
+	questions, err := json.Marshal([]models.QuizQuestion{{FlashcardID: uuid.New(), Prompt: "France", Choices: []string{"Rome", "Paris"}, Answer: &answer}})
+	require.NoError(t, err)
+	mock.ExpectQuery(`FROM quiz_attempts a JOIN quizzes q ON q.id = a.quiz_id WHERE a.user_id = \$1`).
+		WithArgs(testUserID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "answers", "submitted_at", "quiz_id", "deck_id", "seed", "choices", "questions", "created_at"}).
+			AddRow(uuid.New(), pq.Array([]int{1}), time.Now(), uuid.New(), testDeckID, 7, 2, questions, time.Now()))
+	mock.ExpectQuery(`FROM game_rounds WHERE user_id = \$1 ORDER BY started_at`).
+		WithArgs(testUserID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "deck_id", "kind", "seed", "seeded", "started_at", "submitted_at", "correct", "total", "duration_ms"}).
+			AddRow(uuid.New(), testDeckID, models.GameMatch, 7, false, time.Now(), time.Now(), 6, 6, 21000))
+	archivePath := filepath.Join(ExportDir, testJobID.String()+".zip")
+	mock.ExpectExec(`UPDATE export_jobs SET status = 'done', file_path = \$1, finished_at = NOW\(\), expires_at = \$2 WHERE id = \$3`).
+		WithArgs(archivePath, sqlmock.AnyArg(), testJobID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	assert.NoError(t, runAccountExport(context.Background(), testJobID, testUserID))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	zr, err := zip.OpenReader(archivePath)
+	if assert.NoError(t, err) {
+		defer zr.Close()
+		_, err := zr.Open("decks/" + testDeckID.String() + "/cards.csv")
+		assert.NoError(t, err)
+		f, err := zr.Open("review_logs.json")
+		if assert.NoError(t, err) {
+			var logs []models.ReviewLog
+			assert.NoError(t, json.NewDecoder(f).Decode(&logs))
+			assert.Len(t, logs, 1)
+		}
+		f, err = zr.Open("quiz_attempts.json")
+		if assert.NoError(t, err) {
+			var attempts []models.QuizAttempt
+			assert.NoError(t, json.NewDecoder(f).Decode(&attempts))
+			if assert.Len(t, attempts, 1) {
+				assert.Equal(t, 1, attempts[0].Score)
+				assert.Equal(t, []int{1}, attempts[0].Key)
+			}
+		}
+		f, err = zr.Open("decks/" + testDeckID.String() + "/deck.json")
+		if assert.NoError(t, err) {
+			var doc export.DeckDocument
+			assert.NoError(t, json.NewDecoder(f).Decode(&doc))
+			assert.Equal(t, "2026-06-15", *doc.Deck.ExamDate)
+		}
+		for _, name := range []string{"study_goal.json", "deck_presets.json", "filtered_decks.json", "card_states.json", "study_sessions.json", "game_rounds.json"} {
+			_, err := zr.Open(name)
+			assert.NoError(t, err, name)
+		}
+	}
+}
+
+func TestRunAccountExportFailure(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	database.DB = mockDB
+
+	testUserID := uuid.New()
+	testJobID := uuid.New()
+
+	mock.ExpectExec(`UPDATE export_jobs SET status = 'running'`).
+		WithArgs(testJobID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`FROM users WHERE id = \$1`).
+		WithArgs(testUserID).
+		WillReturnError(errors.New(`pq: relation "users" does not exist`))
+	mock.ExpectExec(`UPDATE export_jobs SET status = 'failed', error = \$1`).
+		WithArgs(exportFailed, testJobID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	assert.Error(t, runAccountExport(context.Background(), testJobID, testUserID))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDownloadAccountExport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	originalKey := ExportSigningKey
+	ExportSigningKey = []byte("test-signing-key")
+	defer func() { ExportSigningKey = originalKey }()
+
+	t.Run("valid link", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		testJobID := uuid.New()
+		path := filepath.Join(t.TempDir(), "export.zip")
+		assert.NoError(t, os.WriteFile(path, []byte("zip bytes"), 0o600))
+
+		mock.ExpectQuery(`SELECT file_path FROM export_jobs WHERE id = \$1 AND status = 'done' AND expires_at > NOW\(\)`).
+			WithArgs(testJobID).
+			WillReturnRows(sqlmock.NewRows([]string{"file_path"}).AddRow(path))
+
+		expires := time.Now().Add(time.Minute).Unix()
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "id", Value: testJobID.String()}}
+		c.Request, _ = http.NewRequest("GET", fmt.Sprintf("/?expires=%d&signature=%s", expires, signExportLink(testJobID, expires)), nil)
+
+		DownloadAccountExport(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "zip bytes", w.Body.String())
+	})
+
+	t.Run("expired link", func(t *testing.T) {
+		testJobID := uuid.New()
+		expires := time.Now().Add(-time.Minute).Unix()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "id", Value: testJobID.String()}}
+		c.Request, _ = http.NewRequest("GET", fmt.Sprintf("/?expires=%d&signature=%s", expires, signExportLink(testJobID, expires)), nil)
+
+		DownloadAccountExport(c)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("forged signature", func(t *testing.T) {
+		expires := time.Now().Add(time.Minute).Unix()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "id", Value: uuid.New().String()}}
+		c.Request, _ = http.NewRequest("GET", fmt.Sprintf("/?expires=%d&signature=%s", expires, signExportLink(uuid.New(), expires)), nil)
+
+		DownloadAccountExport(c)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
@@ -0,0 +1,399 @@
+package controllers
+
+import (
+	"api/src/apierror"
+	"api/src/database"
+	"api/src/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// filteredDeckColumns are the columns scanned by scanFilteredDeck
+const filteredDeckColumns = "id, owner_id, title, filter, card_ids, built_at, created_at"
+
+// scanFilteredDeck scans a row selected with filteredDeckColumns
+func scanFilteredDeck(row interface{ Scan(...any) error }, d *models.FilteredDeck) error {
+	var filter []byte
+	if err := row.Scan(&d.ID, &d.OwnerID, &d.Title, &filter, pq.Array(&d.CardIDs), &d.BuiltAt, &d.CreatedAt); err != nil {
+		return err
+	}
+	return json.Unmarshal(filter, &d.Filter)
+}
+
+// filterCards returns the flashcards of a user matching a filter, the soonest
+// due first and never studied ones last. Today is the study day of the user.
+func filterCards(ctx context.Context, q querier, userID uuid.UUID, f models.CardFilter) ([]uuid.UUID, error) {
+	args := []any{userID}
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	where := []string{"d.owner_id = $1"}
+	if len(f.DeckIDs) > 0 {
+		where = append(where, "f.parent_deck = ANY("+arg(pq.Array(f.DeckIDs))+")")
+	}
+	if f.Starred {
+		where = append(where, "f.starred")
+	}
+	if len(f.Labels) > 0 {
+		where = append(where, "d.labels && "+arg(pq.StringArray(f.Labels)))
+	}
+	if f.DueWithinDays != nil {
+		where = append(where, "s.state <> 'new' AND s.due_at <= NOW() + make_interval(days => "+arg(*f.DueWithinDays)+")")
+	}
+	if f.MinLapses != nil {
+		where = append(where, "COALESCE(s.lapses, 0) >= "+arg(*f.MinLapses))
+	}
+	if f.FailedToday {
+		clock, err := loadStudyClock(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		today := clock.start(clock.day(time.Now()))
+		where = append(where,
+			"EXISTS (SELECT 1 FROM review_logs r WHERE r.flashcard_id = f.id AND r.grade = 1 AND r.reviewed_at >= "+arg(today)+")")
+	}
+	if f.Search != "" {
+		// Escape LIKE wildcards so the search text matches literally
+		p := arg(strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(f.Search))
+		where = append(where, "(f.front ILIKE '%' || "+p+" || '%' OR f.back ILIKE '%' || "+p+" || '%')")
+	}
+
+	return queryIDs(ctx, q,
+		`SELECT f.id FROM flashcards f
+		 JOIN decks d ON f.parent_deck = d.id
+		 LEFT JOIN card_states s ON s.flashcard_id = f.id
+		 WHERE `+strings.Join(where, " AND ")+`
+		 ORDER BY s.due_at NULLS LAST, f.parent_deck, f.id LIMIT `+arg(f.Limit),
+		args...,
+	)
+}
+
+// getFilteredDeck loads a filtered deck of a user, aborting with 404 when it doesn't exist
+func getFilteredDeck(c *gin.Context, userID uuid.UUID) (models.FilteredDeck, bool) {
+	var d models.FilteredDeck
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidID("Filtered deck"))
+		return d, false
+	}
+	err = scanFilteredDeck(database.DB.QueryRowContext(c.Request.Context(),
+		"SELECT "+filteredDeckColumns+" FROM filtered_decks WHERE id = $1 AND owner_id = $2",
+		id, userID,
+	), &d)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Abort(c, apierror.NotFound("Filtered deck not found"))
+			return d, false
+		}
+		apierror.Abort(c, err)
+		return d, false
+	}
+	return d, true
+}
+
+// GetFilteredDecks returns the filtered decks of the authenticated user, without their cards
+func GetFilteredDecks(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	rows, err := database.DB.QueryContext(c.Request.Context(),
+		"SELECT "+filteredDeckColumns+" FROM filtered_decks WHERE owner_id = $1 ORDER BY created_at DESC",
+		userID,
+	)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	defer rows.Close()
+
+	decks := []models.FilteredDeck{}
+	for rows.Next() {
+		var d models.FilteredDeck
+		if err := scanFilteredDeck(rows, &d); err != nil {
+			apierror.Abort(c, err)
+			return
+		}
+		decks = append(decks, d)
+	}
+	if err := rows.Err(); err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, decks)
+}
+
+// GetFilteredDeck returns a filtered deck with its cards
+func GetFilteredDeck(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	d, ok := getFilteredDeck(c, userID)
+	if !ok {
+		return
+	}
+	var err error
+	d.Cards, err = studyCards(c.Request.Context(), database.DB, d.CardIDs)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, d)
+}
+
+// CreateFilteredDeck creates a filtered deck and gathers the cards matching its filter
+func CreateFilteredDeck(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	var d models.FilteredDeck
+	if err := c.ShouldBindJSON(&d); err != nil {
+		apierror.Abort(c, apierror.Invalid(err))
+		return
+	}
+	if err := d.Validate(); err != nil {
+		apierror.Abort(c, apierror.Invalid(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	var err error
+	d.OwnerID = userID
+	d.CardIDs, err = filterCards(ctx, database.DB, userID, d.Filter)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	filter, err := json.Marshal(d.Filter)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	err = database.DB.QueryRowContext(ctx,
+		`INSERT INTO filtered_decks (owner_id, title, filter, card_ids)
+		 VALUES ($1, $2, $3, $4) RETURNING id, built_at, created_at`,
+		userID, d.Title, filter, pq.Array(d.CardIDs),
+	).Scan(&d.ID, &d.BuiltAt, &d.CreatedAt)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	d.Cards, err = studyCards(ctx, database.DB, d.CardIDs)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, d)
+}
+
+// UpdateFilteredDeck changes the title and filter of a filtered deck and rebuilds it
+func UpdateFilteredDeck(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	d, ok := getFilteredDeck(c, userID)
+	if !ok {
+		return
+	}
+	var update models.FilteredDeck
+	if err := c.ShouldBindJSON(&update); err != nil {
+		apierror.Abort(c, apierror.Invalid(err))
+		return
+	}
+	if err := update.Validate(); err != nil {
+		apierror.Abort(c, apierror.Invalid(err))
+		return
+	}
+	d.Title, d.Filter = update.Title, update.Filter
+	rebuildFilteredDeck(c, d)
+}
+
+// RebuildFilteredDeck gathers the cards matching the filter of a filtered deck again
+func RebuildFilteredDeck(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	d, ok := getFilteredDeck(c, userID)
+	if !ok {
+		return
+	}
+	rebuildFilteredDeck(c, d)
+}
+
+// rebuildFilteredDeck saves a filtered deck with the cards now matching its
+// filter and responds with it
+func rebuildFilteredDeck(c *gin.Context, d models.FilteredDeck) {
+	ctx := c.Request.Context()
+	var err error
+	d.CardIDs, err = filterCards(ctx, database.DB, d.OwnerID, d.Filter)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	filter, err := json.Marshal(d.Filter)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	err = database.DB.QueryRowContext(ctx,
+		`UPDATE filtered_decks SET title = $1, filter = $2, card_ids = $3, built_at = NOW()
+		 WHERE id = $4 RETURNING built_at`,
+		d.Title, filter, pq.Array(d.CardIDs), d.ID,
+	).Scan(&d.BuiltAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Abort(c, apierror.NotFound("Filtered deck not found"))
+			return
+		}
+		apierror.Abort(c, err)
+		return
+	}
+
+	d.Cards, err = studyCards(ctx, database.DB, d.CardIDs)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, d)
+}
+
+// DeleteFilteredDeck deletes a filtered deck; its cards stay in their decks
+func DeleteFilteredDeck(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidID("Filtered deck"))
+		return
+	}
+
+	result, err := database.DB.ExecContext(c.Request.Context(),
+		"DELETE FROM filtered_decks WHERE id = $1 AND owner_id = $2",
+		id, userID,
+	)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	if rowsAffected == 0 {
+		apierror.Abort(c, apierror.NotFound("Filtered deck not found"))
+		return
+	}
+
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
+// StudyFilteredDeck starts a study session on the cards of a filtered deck.
+// Answers count toward the cards in their own decks.
+func StudyFilteredDeck(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	var req models.FilteredStudyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Invalid(err))
+		return
+	}
+	if err := req.Validate(); err != nil {
+		apierror.Abort(c, apierror.Invalid(err))
+		return
+	}
+
+	d, ok := getFilteredDeck(c, userID)
+	if !ok {
+		return
+	}
+
+	// Cards deleted since the deck was built are left out, like suspended and buried ones
+	rows, err := database.DB.QueryContext(c.Request.Context(),
+		`SELECT f.id, f.parent_deck FROM flashcards f
+		 LEFT JOIN card_states s ON s.flashcard_id = f.id
+		 WHERE f.id = ANY($1) AND `+studyable,
+		pq.Array(d.CardIDs),
+	)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	defer rows.Close()
+	deckOf := map[uuid.UUID]uuid.UUID{}
+	for rows.Next() {
+		var id, deckID uuid.UUID
+		if err := rows.Scan(&id, &deckID); err != nil {
+			apierror.Abort(c, err)
+			return
+		}
+		deckOf[id] = deckID
+	}
+	if err := rows.Err(); err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	var cardIDs, deckIDs []uuid.UUID
+	seen := map[uuid.UUID]bool{}
+	for _, id := range d.CardIDs {
+		deckID, ok := deckOf[id]
+		if !ok {
+			continue
+		}
+		cardIDs = append(cardIDs, id)
+		if !seen[deckID] {
+			seen[deckID] = true
+			deckIDs = append(deckIDs, deckID)
+		}
+	}
+	if req.Shuffle {
+		rand.Shuffle(len(cardIDs), func(i, j int) { cardIDs[i], cardIDs[j] = cardIDs[j], cardIDs[i] })
+	}
+	if req.Limit > 0 && len(cardIDs) > req.Limit {
+		cardIDs = cardIDs[:req.Limit]
+	}
+	if len(cardIDs) == 0 {
+		apierror.Abort(c, apierror.Conflict("No cards to study in this filtered deck"))
+		return
+	}
+
+	createStudySession(c, models.StudySession{
+		UserID:      userID,
+		DeckIDs:     deckIDs,
+		Mode:        req.Mode,
+		NewLimit:    len(cardIDs),
+		ReviewLimit: len(cardIDs),
+		Limit:       req.Limit,
+		CardIDs:     cardIDs,
+	})
+}
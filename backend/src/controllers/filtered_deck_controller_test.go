@@ -0,0 +1,272 @@
+package controllers
+
+import (
+	"api/src/models"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func filteredDeckRow(d models.FilteredDeck) *sqlmock.Rows {
+	filter, _ := json.Marshal(d.Filter)
+	cardIDs, _ := pq.Array(d.CardIDs).Value()
+	return sqlmock.NewRows([]string{"id", "owner_id", "title", "filter", "card_ids", "built_at", "created_at"}).
+		AddRow(d.ID, d.OwnerID, d.Title, filter, cardIDs, time.Now(), time.Now())
+}
+
+func TestCreateFilteredDeck(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		deckID := uuid.New()
+		w, c, mock, testUserID, _ := setupDuplicates(t, "POST", "/",
+			`{"title":"Hard verbs","filter":{"deck_ids":["`+deckID.String()+`"],"starred":true,"labels":["verbs"],"due_within_days":3,"min_lapses":2,"failed_today":true,"search":"50%_off"}}`)
+		first, second, filteredID := uuid.New(), uuid.New(), uuid.New()
+
+		expectStudyGoal(mock, testUserID, "UTC", models.StudyGoal{Unit: models.GoalCards, Target: 20, DayRollover: 0})
+		mock.ExpectQuery(`SELECT f.id FROM flashcards f JOIN decks d ON f.parent_deck = d.id LEFT JOIN card_states s ON s.flashcard_id = f.id `+
+			`WHERE d.owner_id = \$1 AND f.parent_deck = ANY\(\$2\) AND f.starred AND d.labels && \$3 `+
+			`AND s.state <> 'new' AND s.due_at <= NOW\(\) \+ make_interval\(days => \$4\) AND COALESCE\(s.lapses, 0\) >= \$5 `+
+			`AND EXISTS \(SELECT 1 FROM review_logs r WHERE r.flashcard_id = f.id AND r.grade = 1 AND r.reviewed_at >= \$6\) `+
+			`AND \(f.front ILIKE '%' \|\| \$7 \|\| '%' OR f.back ILIKE '%' \|\| \$7 \|\| '%'\) `+
+			`ORDER BY s.due_at NULLS LAST, f.parent_deck, f.id LIMIT \$8`).
+			WithArgs(testUserID, pq.Array([]uuid.UUID{deckID}), pq.StringArray{"verbs"}, 3, 2, time.Now().UTC().Truncate(24*time.Hour), `50\%\_off`, models.DefaultFilterLimit).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(first).AddRow(second))
+		mock.ExpectQuery(`INSERT INTO filtered_decks \(owner_id, title, filter, card_ids\) VALUES \(\$1, \$2, \$3, \$4\) RETURNING id, built_at, created_at`).
+			WithArgs(testUserID, "Hard verbs", sqlmock.AnyArg(), pq.Array([]uuid.UUID{first, second})).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "built_at", "created_at"}).AddRow(filteredID, time.Now(), time.Now()))
+		mock.ExpectQuery(`SELECT id, parent_deck, starred, front, back FROM flashcards WHERE id = ANY\(\$1\)`).
+			WithArgs(pq.Array([]uuid.UUID{first, second})).
+			WillReturnRows(flashcardRows(deckID, second, first))
+
+		CreateFilteredDeck(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var created models.FilteredDeck
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		assert.Equal(t, filteredID, created.ID)
+		assert.Equal(t, []uuid.UUID{first, second}, created.CardIDs)
+		if assert.Len(t, created.Cards, 2) {
+			assert.Equal(t, first, created.Cards[0].ID)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("every card of the user", func(t *testing.T) {
+		w, c, mock, testUserID, _ := setupDuplicates(t, "POST", "/", `{"title":"Everything","filter":{"limit":10}}`)
+
+		mock.ExpectQuery(`WHERE d.owner_id = \$1 ORDER BY s.due_at NULLS LAST, f.parent_deck, f.id LIMIT \$2`).
+			WithArgs(testUserID, 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery(`INSERT INTO filtered_decks`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "built_at", "created_at"}).AddRow(uuid.New(), time.Now(), time.Now()))
+
+		CreateFilteredDeck(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("invalid filter", func(t *testing.T) {
+		w, c, _, _, _ := setupDuplicates(t, "POST", "/", `{"title":"Leeches","filter":{"min_lapses":-1}}`)
+
+		CreateFilteredDeck(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestGetFilteredDeck(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		w, c, mock, testUserID, filteredID := setupDuplicates(t, "GET", "/", "")
+		deckID, card := uuid.New(), uuid.New()
+
+		mock.ExpectQuery(`SELECT id, owner_id, title, filter, card_ids, built_at, created_at FROM filtered_decks WHERE id = \$1 AND owner_id = \$2`).
+			WithArgs(filteredID, testUserID).
+			WillReturnRows(filteredDeckRow(models.FilteredDeck{ID: filteredID, OwnerID: testUserID, Title: "Starred", Filter: models.CardFilter{Starred: true, Limit: 100}, CardIDs: []uuid.UUID{card}}))
+		mock.ExpectQuery(`SELECT id, parent_deck, starred, front, back FROM flashcards WHERE id = ANY\(\$1\)`).
+			WithArgs(pq.Array([]uuid.UUID{card})).
+			WillReturnRows(flashcardRows(deckID, card))
+
+		GetFilteredDeck(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var d models.FilteredDeck
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &d))
+		assert.True(t, d.Filter.Starred)
+		assert.Len(t, d.Cards, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		w, c, mock, testUserID, filteredID := setupDuplicates(t, "GET", "/", "")
+
+		mock.ExpectQuery(`FROM filtered_decks WHERE id = \$1 AND owner_id = \$2`).
+			WithArgs(filteredID, testUserID).
+			WillReturnError(sql.ErrNoRows)
+
+		GetFilteredDeck(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetFilteredDecks(t *testing.T) {
+	w, c, mock, testUserID, _ := setupDuplicates(t, "GET", "/", "")
+
+	mock.ExpectQuery(`FROM filtered_decks WHERE owner_id = \$1 ORDER BY created_at DESC`).
+		WithArgs(testUserID).
+		WillReturnRows(filteredDeckRow(models.FilteredDeck{ID: uuid.New(), OwnerID: testUserID, Title: "Starred"}))
+
+	GetFilteredDecks(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var decks []models.FilteredDeck
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &decks))
+	if assert.Len(t, decks, 1) {
+		assert.Equal(t, "Starred", decks[0].Title)
+		assert.Nil(t, decks[0].Cards)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateFilteredDeck(t *testing.T) {
+	w, c, mock, testUserID, filteredID := setupDuplicates(t, "PUT", "/", `{"title":"Failed today","filter":{"failed_today":true}}`)
+	card := uuid.New()
+
+	mock.ExpectQuery(`FROM filtered_decks WHERE id = \$1 AND owner_id = \$2`).
+		WithArgs(filteredID, testUserID).
+		WillReturnRows(filteredDeckRow(models.FilteredDeck{ID: filteredID, OwnerID: testUserID, Title: "Starred", Filter: models.CardFilter{Starred: true}}))
+	expectStudyGoal(mock, testUserID, "UTC", models.DefaultStudyGoal())
+	mock.ExpectQuery(`WHERE d.owner_id = \$1 AND EXISTS \(SELECT 1 FROM review_logs`).
+		WithArgs(testUserID, sqlmock.AnyArg(), models.DefaultFilterLimit).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(card))
+	mock.ExpectQuery(`UPDATE filtered_decks SET title = \$1, filter = \$2, card_ids = \$3, built_at = NOW\(\) WHERE id = \$4 RETURNING built_at`).
+		WithArgs("Failed today", sqlmock.AnyArg(), pq.Array([]uuid.UUID{card}), filteredID).
+		WillReturnRows(sqlmock.NewRows([]string{"built_at"}).AddRow(time.Now()))
+	mock.ExpectQuery(`SELECT id, parent_deck, starred, front, back FROM flashcards WHERE id = ANY\(\$1\)`).
+		WillReturnRows(flashcardRows(uuid.New(), card))
+
+	UpdateFilteredDeck(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var d models.FilteredDeck
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &d))
+	assert.Equal(t, "Failed today", d.Title)
+	assert.False(t, d.Filter.Starred)
+	assert.True(t, d.Filter.FailedToday)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRebuildFilteredDeck(t *testing.T) {
+	w, c, mock, testUserID, filteredID := setupDuplicates(t, "POST", "/", "")
+	stale, fresh := uuid.New(), uuid.New()
+
+	mock.ExpectQuery(`FROM filtered_decks WHERE id = \$1 AND owner_id = \$2`).
+		WithArgs(filteredID, testUserID).
+		WillReturnRows(filteredDeckRow(models.FilteredDeck{ID: filteredID, OwnerID: testUserID, Title: "Starred", Filter: models.CardFilter{Starred: true, Limit: 50}, CardIDs: []uuid.UUID{stale}}))
+	mock.ExpectQuery(`WHERE d.owner_id = \$1 AND f.starred ORDER BY`).
+		WithArgs(testUserID, 50).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(fresh))
+	mock.ExpectQuery(`UPDATE filtered_decks SET`).
+		WithArgs("Starred", sqlmock.AnyArg(), pq.Array([]uuid.UUID{fresh}), filteredID).
+		WillReturnRows(sqlmock.NewRows([]string{"built_at"}).AddRow(time.Now()))
+	mock.ExpectQuery(`SELECT id, parent_deck, starred, front, back FROM flashcards`).
+		WillReturnRows(flashcardRows(uuid.New(), fresh))
+
+	RebuildFilteredDeck(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var d models.FilteredDeck
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &d))
+	assert.Equal(t, []uuid.UUID{fresh}, d.CardIDs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteFilteredDeck(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		w, c, mock, testUserID, filteredID := setupDuplicates(t, "DELETE", "/", "")
+
+		mock.ExpectExec(`DELETE FROM filtered_decks WHERE id = \$1 AND owner_id = \$2`).
+			WithArgs(filteredID, testUserID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		DeleteFilteredDeck(c)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		w, c, mock, _, _ := setupDuplicates(t, "DELETE", "/", "")
+
+		mock.ExpectExec(`DELETE FROM filtered_decks`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		DeleteFilteredDeck(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestStudyFilteredDeck(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		w, c, mock, testUserID, filteredID := setupDuplicates(t, "POST", "/", `{"mode":"practice"}`)
+		french, spanish := uuid.New(), uuid.New()
+		first, deleted, second, sessionID := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+		mock.ExpectQuery(`FROM filtered_decks WHERE id = \$1 AND owner_id = \$2`).
+			WithArgs(filteredID, testUserID).
+			WillReturnRows(filteredDeckRow(models.FilteredDeck{ID: filteredID, OwnerID: testUserID, Title: "Starred", CardIDs: []uuid.UUID{first, deleted, second}}))
+		mock.ExpectQuery(`SELECT f.id, f.parent_deck FROM flashcards f LEFT JOIN card_states s ON s.flashcard_id = f.id WHERE f.id = ANY\(\$1\) AND s.suspended_at IS NULL`).
+			WithArgs(pq.Array([]uuid.UUID{first, deleted, second})).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "parent_deck"}).AddRow(second, spanish).AddRow(first, french))
+		mock.ExpectQuery(`INSERT INTO study_sessions \(user_id, deck_ids, mode, new_limit, review_limit, card_limit, card_ids\)`).
+			WithArgs(testUserID, pq.Array([]uuid.UUID{french, spanish}), models.StudyModePractice, 2, 2, 0, pq.Array([]uuid.UUID{first, second})).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "position", "status", "started_at", "last_activity_at"}).
+				AddRow(sessionID, 0, models.StudySessionActive, time.Now(), time.Now()))
+		mock.ExpectQuery(`SELECT id, parent_deck, starred, front, back FROM flashcards WHERE id = ANY\(\$1\)`).
+			WithArgs(pq.Array([]uuid.UUID{first, second})).
+			WillReturnRows(flashcardRows(french, first, second))
+
+		StudyFilteredDeck(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var s models.StudySession
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &s))
+		assert.Equal(t, sessionID, s.ID)
+		assert.Len(t, s.Cards, 2)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		w, c, mock, testUserID, filteredID := setupDuplicates(t, "POST", "/", `{}`)
+
+		mock.ExpectQuery(`FROM filtered_decks WHERE id = \$1 AND owner_id = \$2`).
+			WithArgs(filteredID, testUserID).
+			WillReturnRows(filteredDeckRow(models.FilteredDeck{ID: filteredID, OwnerID: testUserID, Title: "Starred"}))
+		mock.ExpectQuery(`SELECT f.id, f.parent_deck FROM flashcards f`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "parent_deck"}))
+
+		StudyFilteredDeck(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("cram", func(t *testing.T) {
+		w, c, _, _, _ := setupDuplicates(t, "POST", "/", `{"mode":"cram"}`)
+
+		StudyFilteredDeck(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
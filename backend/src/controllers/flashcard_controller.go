@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"api/src/apierror"
 	"api/src/database"
 	"api/src/models"
 	"database/sql"
@@ -19,7 +20,7 @@ func GetFlashcards(c *gin.Context) {
 
 	deckID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Deck UUID format"})
+		apierror.Abort(c, apierror.InvalidID("Deck"))
 		return
 	}
 
@@ -32,7 +33,7 @@ func GetFlashcards(c *gin.Context) {
 		userID,
 	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apierror.Abort(c, err)
 		return
 	}
 	defer rows.Close()
@@ -41,14 +42,14 @@ func GetFlashcards(c *gin.Context) {
 	for rows.Next() {
 		var f models.Flashcard
 		if err := rows.Scan(&f.ID, &f.ParentDeck, &f.Starred, &f.Front, &f.Back); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			apierror.Abort(c, err)
 			return
 		}
 		flashcards = append(flashcards, f)
 	}
 
 	if err := rows.Err(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apierror.Abort(c, err)
 		return
 	}
 
@@ -64,7 +65,7 @@ func GetFlashcard(c *gin.Context) {
 
 	flashcardID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Flashcard UUID format"})
+		apierror.Abort(c, apierror.InvalidID("Flashcard"))
 		return
 	}
 
@@ -80,10 +81,10 @@ func GetFlashcard(c *gin.Context) {
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Flashcard not found or access denied"})
+			apierror.Abort(c, apierror.NotFound("Flashcard not found or access denied"))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve flashcard"})
+		apierror.Abort(c, err)
 		return
 	}
 
@@ -91,6 +92,7 @@ func GetFlashcard(c *gin.Context) {
 }
 
 // CreateFlashcard creates a new flashcard in a specific deck.
+// The response lists the flashcards of the deck it duplicates, if any.
 func CreateFlashcard(c *gin.Context) {
 	userID, ok := GetUserIDFromClerkID(c)
 	if !ok {
@@ -99,36 +101,44 @@ func CreateFlashcard(c *gin.Context) {
 
 	deckID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Deck UUID format"})
+		apierror.Abort(c, apierror.InvalidID("Deck"))
 		return
 	}
 
 	var ownerID uuid.UUID
 	err = database.DB.QueryRow("SELECT owner_id FROM decks WHERE id = $1", deckID).Scan(&ownerID)
 	if err != nil || ownerID != userID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Access to deck denied"})
+		apierror.Abort(c, apierror.Forbidden(apierror.CodeForbidden, "Access to deck denied"))
 		return
 	}
 
 	var flashcard models.Flashcard
 	if err := c.ShouldBindJSON(&flashcard); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Abort(c, apierror.Invalid(err))
 		return
 	}
 	flashcard.ParentDeck = deckID
 
 	if err := flashcard.Validate(); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Abort(c, apierror.Invalid(err))
 		return
 	}
 
+	// The card is created anyway; the duplicates are a warning for the user
+	others, err := deckFronts(deckID)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	flashcard.Duplicates = models.MatchDuplicates(flashcard.Front, others, models.DefaultDuplicateThreshold)
+
 	err = database.DB.QueryRow(
 		"INSERT INTO flashcards (parent_deck, starred, front, back) VALUES ($1, $2, $3, $4) RETURNING id",
 		flashcard.ParentDeck, flashcard.Starred, flashcard.Front, flashcard.Back,
 	).Scan(&flashcard.ID)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create flashcard"})
+		apierror.Abort(c, err)
 		return
 	}
 
@@ -144,33 +154,49 @@ func UpdateFlashcard(c *gin.Context) {
 
 	flashcardID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Flashcard UUID format"})
+		apierror.Abort(c, apierror.InvalidID("Flashcard"))
 		return
 	}
 
 	var flashcard models.Flashcard
 	if err := c.ShouldBindJSON(&flashcard); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Abort(c, apierror.Invalid(err))
 		return
 	}
 
-	result, err := database.DB.Exec(
-		`UPDATE flashcards SET starred = $1, front = $2, back = $3
-		 WHERE id = $4 AND parent_deck IN (SELECT id FROM decks WHERE owner_id = $5)`,
-		flashcard.Starred, flashcard.Front, flashcard.Back, flashcardID, userID,
-	)
+	tx, err := database.DB.Begin()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update flashcard"})
+		apierror.Abort(c, err)
 		return
 	}
+	defer tx.Rollback()
 
-	rowsAffected, err := result.RowsAffected()
+	if err := lockFlashcard(tx, flashcardID, userID); err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Abort(c, apierror.NotFound("Flashcard not found or access denied"))
+			return
+		}
+		apierror.Abort(c, err)
+		return
+	}
+
+	// Keep the previous content so the edit can be reviewed or reverted
+	if err := saveFlashcardRevision(tx, flashcardID, userID); err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	_, err = tx.Exec(
+		"UPDATE flashcards SET starred = $1, front = $2, back = $3 WHERE id = $4",
+		flashcard.Starred, flashcard.Front, flashcard.Back, flashcardID,
+	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apierror.Abort(c, err)
 		return
 	}
-	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Flashcard not found or access denied"})
+
+	if err := tx.Commit(); err != nil {
+		apierror.Abort(c, err)
 		return
 	}
 
@@ -186,7 +212,7 @@ func DeleteFlashcard(c *gin.Context) {
 
 	flashcardID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Flashcard UUID format"})
+		apierror.Abort(c, apierror.InvalidID("Flashcard"))
 		return
 	}
 
@@ -195,17 +221,17 @@ func DeleteFlashcard(c *gin.Context) {
 		flashcardID, userID,
 	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete flashcard"})
+		apierror.Abort(c, err)
 		return
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apierror.Abort(c, err)
 		return
 	}
 	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Flashcard not found or access denied"})
+		apierror.Abort(c, apierror.NotFound("Flashcard not found or access denied"))
 		return
 	}
 
@@ -113,6 +113,10 @@ func TestCreateFlashcard(t *testing.T) {
 			WithArgs(testDeckID).
 			WillReturnRows(sqlmock.NewRows([]string{"owner_id"}).AddRow(testUserID))
 
+		mock.ExpectQuery("SELECT id, front FROM flashcards WHERE parent_deck = \\$1 ORDER BY id").
+			WithArgs(testDeckID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "front"}))
+
 		mock.ExpectQuery("INSERT INTO flashcards \\(parent_deck, starred, front, back\\) VALUES \\(\\$1, \\$2, \\$3, \\$4\\) RETURNING id").
 			WithArgs(testDeckID, &starred, "New Front", "New Back").
 			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(newFlashcardID))
@@ -152,9 +156,17 @@ func TestUpdateFlashcard(t *testing.T) {
 		starred := true
 		flashcardJSON := `{"front":"Updated Front","back":"Updated Back","starred":true}`
 
-		mock.ExpectExec(`UPDATE flashcards SET starred = \$1, front = \$2, back = \$3 WHERE id = \$4 AND parent_deck IN \(SELECT id FROM decks WHERE owner_id = \$5\)`).
-			WithArgs(&starred, "Updated Front", "Updated Back", testFlashcardID, testUserID).
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT f.id FROM flashcards f JOIN decks d ON f.parent_deck = d.id WHERE f.id = \$1 AND d.owner_id = \$2 FOR UPDATE OF f`).
+			WithArgs(testFlashcardID, testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testFlashcardID))
+		mock.ExpectExec(`INSERT INTO flashcard_revisions`).
+			WithArgs(testFlashcardID, testUserID).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`UPDATE flashcards SET starred = \$1, front = \$2, back = \$3 WHERE id = \$4`).
+			WithArgs(&starred, "Updated Front", "Updated Back", testFlashcardID).
 			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
 
 		w := httptest.NewRecorder()
 		c, _ := gin.CreateTestContext(w)
@@ -172,6 +184,7 @@ func TestUpdateFlashcard(t *testing.T) {
 
 		assert.Equal(t, http.StatusOK, w.Code)
 		assert.Contains(t, w.Body.String(), "Flashcard updated successfully")
+		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
 
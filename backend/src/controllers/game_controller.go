@@ -0,0 +1,272 @@
+package controllers
+
+import (
+	"api/src/apierror"
+	"api/src/database"
+	"api/src/models"
+	"api/src/quiz"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// gameItems is what a round shows, stored as JSON
+type gameItems struct {
+	Fronts     []string               `json:"fronts,omitempty"`
+	Backs      []string               `json:"backs,omitempty"`
+	Statements []models.GameStatement `json:"statements,omitempty"`
+}
+
+// StartGame builds a match or true/false round from the flashcards of a deck.
+// The clock of the round starts on the server when it is created. Rounds
+// started with a seed of the request are seeded: the same seed builds the same
+// round, so they are left out of best times.
+func StartGame(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	deckID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidID("Deck"))
+		return
+	}
+
+	var req models.GameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Invalid(err))
+		return
+	}
+	if err := req.Validate(); err != nil {
+		apierror.Abort(c, apierror.Invalid(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	var id uuid.UUID
+	err = database.DB.QueryRowContext(ctx, "SELECT id FROM decks WHERE id = $1 AND owner_id = $2", deckID, userID).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Abort(c, apierror.NotFound("Deck not found or access denied"))
+			return
+		}
+		apierror.Abort(c, err)
+		return
+	}
+
+	flashcards, err := queryFlashcards(ctx, "f.parent_deck = $1", deckID)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	seed := rand.Int63n(1 << 31)
+	if req.Seed != nil {
+		seed = *req.Seed
+	}
+	seeded := req.Seed != nil
+	var round models.GameRound
+	if req.Kind == models.GameMatch {
+		round, err = quiz.Match(flashcards[deckID], seed, req.Size)
+	} else {
+		round, err = quiz.TrueFalse(flashcards[deckID], seed, req.Size)
+	}
+	if err != nil {
+		if errors.Is(err, quiz.ErrTooFewPairs) {
+			apierror.Abort(c, apierror.Conflict("A game needs at least two flashcards with different fronts and backs"))
+			return
+		}
+		apierror.Abort(c, err)
+		return
+	}
+	round.DeckID, round.Seeded = deckID, seeded
+
+	items, err := json.Marshal(gameItems{Fronts: round.Fronts, Backs: round.Backs, Statements: round.Statements})
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	err = database.DB.QueryRowContext(ctx,
+		`INSERT INTO game_rounds (deck_id, user_id, kind, seed, seeded, items, answer_key, total)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, started_at`,
+		deckID, userID, round.Kind, round.Seed, round.Seeded, items, pq.Array(round.Key), len(round.Key),
+	).Scan(&round.ID, &round.StartedAt)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, round)
+}
+
+// SubmitGame grades a round and records its time. The time counts towards the
+// best time of the user on the deck when every answer is correct and the round
+// isn't seeded.
+func SubmitGame(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	roundID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidID("Game round"))
+		return
+	}
+
+	var submission models.GameSubmission
+	if err := c.ShouldBindJSON(&submission); err != nil {
+		apierror.Abort(c, apierror.Invalid(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	defer tx.Rollback()
+
+	var round models.GameRound
+	var items []byte
+	var key pq.Int64Array
+	var submitted bool
+	err = tx.QueryRowContext(ctx,
+		`SELECT id, deck_id, kind, seed, seeded, items, answer_key, started_at, submitted_at IS NOT NULL
+		 FROM game_rounds WHERE id = $1 AND user_id = $2 FOR UPDATE`,
+		roundID, userID,
+	).Scan(&round.ID, &round.DeckID, &round.Kind, &round.Seed, &round.Seeded, &items, &key, &round.StartedAt, &submitted)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Abort(c, apierror.NotFound("Game round not found"))
+			return
+		}
+		apierror.Abort(c, err)
+		return
+	}
+	if submitted {
+		apierror.Abort(c, apierror.Conflict("Game round was already submitted"))
+		return
+	}
+	var shown gameItems
+	if err := json.Unmarshal(items, &shown); err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	round.Fronts, round.Backs, round.Statements = shown.Fronts, shown.Backs, shown.Statements
+	for _, k := range key {
+		round.Key = append(round.Key, int(k))
+	}
+
+	if err := submission.Validate(round); err != nil {
+		apierror.Abort(c, apierror.Invalid(err))
+		return
+	}
+
+	result := models.GameResult{RoundID: round.ID, Kind: round.Kind, Total: len(round.Key), Key: round.Key}
+	result.Correct, result.Mistakes = round.Grade(submission)
+
+	var best sql.NullInt64
+	err = tx.QueryRowContext(ctx,
+		"SELECT MIN(duration_ms) FROM game_rounds WHERE user_id = $1 AND deck_id = $2 AND kind = $3 AND correct = total AND NOT seeded",
+		userID, round.DeckID, round.Kind,
+	).Scan(&best)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`UPDATE game_rounds
+		 SET submitted_at = NOW(), correct = $2, duration_ms = (EXTRACT(EPOCH FROM NOW() - started_at) * 1000)::BIGINT
+		 WHERE id = $1 RETURNING duration_ms`,
+		round.ID, result.Correct,
+	).Scan(&result.DurationMs)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	if best.Valid {
+		result.BestMs = &best.Int64
+	}
+	if result.Correct == result.Total && !round.Seeded && (result.BestMs == nil || result.DurationMs < *result.BestMs) {
+		result.BestMs = &result.DurationMs
+		result.NewBest = true
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// GetGameBests returns the best times of the user on a deck, one per game played
+func GetGameBests(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	deckID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidID("Deck"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	var id uuid.UUID
+	err = database.DB.QueryRowContext(ctx, "SELECT id FROM decks WHERE id = $1 AND owner_id = $2", deckID, userID).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Abort(c, apierror.NotFound("Deck not found or access denied"))
+			return
+		}
+		apierror.Abort(c, err)
+		return
+	}
+
+	// The first row of every kind is its fastest round without mistakes nor
+	// seed, if any
+	rows, err := database.DB.QueryContext(ctx,
+		`SELECT DISTINCT ON (kind) kind, correct = total AND NOT seeded AS best, duration_ms, submitted_at,
+		        COUNT(*) OVER (PARTITION BY kind)
+		 FROM game_rounds
+		 WHERE user_id = $1 AND deck_id = $2 AND submitted_at IS NOT NULL
+		 ORDER BY kind, best DESC, duration_ms`,
+		userID, deckID,
+	)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	defer rows.Close()
+
+	bests := []models.GameBest{}
+	for rows.Next() {
+		var b models.GameBest
+		var perfect bool
+		var durationMs int64
+		var submittedAt sql.NullTime
+		if err := rows.Scan(&b.Kind, &perfect, &durationMs, &submittedAt, &b.Rounds); err != nil {
+			apierror.Abort(c, err)
+			return
+		}
+		if perfect {
+			b.BestMs, b.AchievedAt = &durationMs, &submittedAt.Time
+		}
+		bests = append(bests, b)
+	}
+	if err := rows.Err(); err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, bests)
+}
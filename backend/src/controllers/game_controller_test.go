@@ -0,0 +1,216 @@
+package controllers
+
+import (
+	"api/src/models"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gameRoundRow(roundID, deckID uuid.UUID, seeded, submitted bool) *sqlmock.Rows {
+	items, _ := json.Marshal(gameItems{Fronts: []string{"Capital of France", "Capital of Italy"}, Backs: []string{"Rome", "Paris"}})
+	return sqlmock.NewRows([]string{"id", "deck_id", "kind", "seed", "seeded", "items", "answer_key", "started_at", "submitted"}).
+		AddRow(roundID, deckID, models.GameMatch, 42, seeded, items, "{1,0}", time.Now(), submitted)
+}
+
+func TestStartGame(t *testing.T) {
+	t.Run("match", func(t *testing.T) {
+		w, c, mock, testUserID, testDeckID := setupDuplicates(t, "POST", "/", `{"kind":"match","seed":42}`)
+		roundID := uuid.New()
+
+		mock.ExpectQuery(`SELECT id FROM decks WHERE id = \$1 AND owner_id = \$2`).
+			WithArgs(testDeckID, testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testDeckID))
+		mock.ExpectQuery(`SELECT f.id, f.parent_deck, f.starred, f.front, f.back FROM flashcards f JOIN decks d ON f.parent_deck = d.id WHERE f.parent_deck = \$1`).
+			WithArgs(testDeckID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "parent_deck", "starred", "front", "back"}).
+				AddRow(uuid.New(), testDeckID, false, "Capital of France", "Paris").
+				AddRow(uuid.New(), testDeckID, false, "Capital of Italy", "Rome"))
+		mock.ExpectQuery(`INSERT INTO game_rounds \(deck_id, user_id, kind, seed, seeded, items, answer_key, total\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8\) RETURNING id, started_at`).
+			WithArgs(testDeckID, testUserID, models.GameMatch, int64(42), true, sqlmock.AnyArg(), sqlmock.AnyArg(), 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "started_at"}).AddRow(roundID, time.Now()))
+
+		StartGame(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var round models.GameRound
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &round))
+		assert.Equal(t, roundID, round.ID)
+		assert.True(t, round.Seeded)
+		assert.ElementsMatch(t, []string{"Capital of France", "Capital of Italy"}, round.Fronts)
+		assert.ElementsMatch(t, []string{"Paris", "Rome"}, round.Backs)
+		assert.NotContains(t, w.Body.String(), "key")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("too few cards", func(t *testing.T) {
+		w, c, mock, testUserID, testDeckID := setupDuplicates(t, "POST", "/", `{"kind":"true_false"}`)
+
+		mock.ExpectQuery(`SELECT id FROM decks`).
+			WithArgs(testDeckID, testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testDeckID))
+		mock.ExpectQuery(`SELECT f.id, f.parent_deck`).
+			WithArgs(testDeckID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "parent_deck", "starred", "front", "back"}).
+				AddRow(uuid.New(), testDeckID, false, "Capital of France", "Paris"))
+
+		StartGame(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unknown game", func(t *testing.T) {
+		w, c, _, _, _ := setupDuplicates(t, "POST", "/", `{"kind":"hangman"}`)
+
+		StartGame(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestSubmitGame(t *testing.T) {
+	t.Run("new best time", func(t *testing.T) {
+		w, c, mock, testUserID, roundID := setupDuplicates(t, "POST", "/", `{"matches":[1,0]}`)
+		deckID := uuid.New()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT id, deck_id, kind, seed, seeded, items, answer_key, started_at, submitted_at IS NOT NULL FROM game_rounds WHERE id = \$1 AND user_id = \$2 FOR UPDATE`).
+			WithArgs(roundID, testUserID).
+			WillReturnRows(gameRoundRow(roundID, deckID, false, false))
+		mock.ExpectQuery(`SELECT MIN\(duration_ms\) FROM game_rounds WHERE user_id = \$1 AND deck_id = \$2 AND kind = \$3 AND correct = total AND NOT seeded`).
+			WithArgs(testUserID, deckID, models.GameMatch).
+			WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow(9000))
+		mock.ExpectQuery(`UPDATE game_rounds SET submitted_at = NOW\(\), correct = \$2`).
+			WithArgs(roundID, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"duration_ms"}).AddRow(7500))
+		mock.ExpectCommit()
+
+		SubmitGame(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var result models.GameResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		assert.Equal(t, 2, result.Correct)
+		assert.Empty(t, result.Mistakes)
+		assert.Equal(t, int64(7500), *result.BestMs)
+		assert.True(t, result.NewBest)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("seeded rounds don't count", func(t *testing.T) {
+		w, c, mock, testUserID, roundID := setupDuplicates(t, "POST", "/", `{"matches":[1,0]}`)
+		deckID := uuid.New()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM game_rounds WHERE id = \$1 AND user_id = \$2 FOR UPDATE`).
+			WithArgs(roundID, testUserID).
+			WillReturnRows(gameRoundRow(roundID, deckID, true, false))
+		mock.ExpectQuery(`SELECT MIN\(duration_ms\)`).
+			WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow(9000))
+		mock.ExpectQuery(`UPDATE game_rounds`).
+			WithArgs(roundID, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"duration_ms"}).AddRow(800))
+		mock.ExpectCommit()
+
+		SubmitGame(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var result models.GameResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		assert.Equal(t, 2, result.Correct)
+		assert.Equal(t, int64(9000), *result.BestMs)
+		assert.False(t, result.NewBest)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("mistakes don't count", func(t *testing.T) {
+		w, c, mock, testUserID, roundID := setupDuplicates(t, "POST", "/", `{"matches":[0,1]}`)
+		deckID := uuid.New()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM game_rounds WHERE id = \$1 AND user_id = \$2 FOR UPDATE`).
+			WithArgs(roundID, testUserID).
+			WillReturnRows(gameRoundRow(roundID, deckID, false, false))
+		mock.ExpectQuery(`SELECT MIN\(duration_ms\)`).
+			WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow(nil))
+		mock.ExpectQuery(`UPDATE game_rounds`).
+			WithArgs(roundID, 0).
+			WillReturnRows(sqlmock.NewRows([]string{"duration_ms"}).AddRow(3000))
+		mock.ExpectCommit()
+
+		SubmitGame(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var result models.GameResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		assert.Equal(t, []int{0, 1}, result.Mistakes)
+		assert.Equal(t, []int{1, 0}, result.Key)
+		assert.Nil(t, result.BestMs)
+		assert.False(t, result.NewBest)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("already submitted", func(t *testing.T) {
+		w, c, mock, testUserID, roundID := setupDuplicates(t, "POST", "/", `{"matches":[1,0]}`)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM game_rounds`).
+			WithArgs(roundID, testUserID).
+			WillReturnRows(gameRoundRow(roundID, uuid.New(), false, true))
+		mock.ExpectRollback()
+
+		SubmitGame(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("back matched twice", func(t *testing.T) {
+		w, c, mock, testUserID, roundID := setupDuplicates(t, "POST", "/", `{"matches":[1,1]}`)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM game_rounds`).
+			WithArgs(roundID, testUserID).
+			WillReturnRows(gameRoundRow(roundID, uuid.New(), false, false))
+		mock.ExpectRollback()
+
+		SubmitGame(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetGameBests(t *testing.T) {
+	w, c, mock, testUserID, testDeckID := setupDuplicates(t, "GET", "/", "")
+	achieved := time.Now()
+
+	mock.ExpectQuery(`SELECT id FROM decks WHERE id = \$1 AND owner_id = \$2`).
+		WithArgs(testDeckID, testUserID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testDeckID))
+	mock.ExpectQuery(`SELECT DISTINCT ON \(kind\) kind, correct = total AND NOT seeded AS best, duration_ms, submitted_at, COUNT\(\*\) OVER \(PARTITION BY kind\) FROM game_rounds`).
+		WithArgs(testUserID, testDeckID).
+		WillReturnRows(sqlmock.NewRows([]string{"kind", "perfect", "duration_ms", "submitted_at", "count"}).
+			AddRow(models.GameMatch, true, 7500, achieved, 4).
+			AddRow(models.GameTrueFalse, false, 12000, achieved, 1))
+
+	GetGameBests(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var bests []models.GameBest
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &bests))
+	if assert.Len(t, bests, 2) {
+		assert.Equal(t, int64(7500), *bests[0].BestMs)
+		assert.Equal(t, 4, bests[0].Rounds)
+		assert.Nil(t, bests[1].BestMs)
+		assert.Nil(t, bests[1].AchievedAt)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -1,42 +1,280 @@
 package controllers
 
 import (
+	"api/src/apierror"
+	"api/src/config"
 	"api/src/database"
+	"api/src/middleware"
+	"api/src/models"
 	"database/sql"
-	"net/http"
+	"errors"
+	"strings"
+	"sync"
+	"time"
 
-	"github.com/clerk/clerk-sdk-go/v2"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
+// UserProvisioning selects how application users are created, see config.ProvisionWebhook
+// and config.ProvisionJIT. It is set from the configuration at startup.
+var UserProvisioning = config.ProvisionWebhook
+
 var getClerkID = func(c *gin.Context) (string, bool) {
-	claims, ok := clerk.SessionClaimsFromContext(c.Request.Context())
+	principal, ok := middleware.PrincipalFromContext(c.Request.Context())
 	if !ok {
 		return "", false
 	}
-	return claims.Subject, true
+	return principal.Subject, true
+}
+
+// hasScope reports whether the principal of a request was granted scope, for
+// handlers that only hold back part of a response from narrower tokens
+func hasScope(c *gin.Context, scope string) bool {
+	principal, ok := middleware.PrincipalFromContext(c.Request.Context())
+	return ok && principal.HasScope(scope)
+}
+
+// currentUser is the application user behind the principal of a request
+type currentUser struct {
+	ID        uuid.UUID
+	Role      string
+	Suspended bool
+	// PendingDeletion is set during the grace period of an account deletion
+	PendingDeletion bool
+}
+
+// userCacheTTL bounds how long a cached user is trusted without a query.
+// Role and suspension changes made on another instance take up to this long to apply.
+const userCacheTTL = 5 * time.Minute
+
+type userCacheEntry struct {
+	user    currentUser
+	expires time.Time
+}
+
+// userCache maps Clerk user IDs to application users. Expired entries are
+// dropped when read and swept every userCacheTTL when a user is cached, so
+// users who stop calling the API don't stay in memory.
+type userCache struct {
+	mu        sync.RWMutex
+	entries   map[string]userCacheEntry
+	lastSweep time.Time
+}
+
+var userIDs = &userCache{entries: map[string]userCacheEntry{}}
+
+func (uc *userCache) get(clerkID string) (currentUser, bool) {
+	uc.mu.RLock()
+	entry, ok := uc.entries[clerkID]
+	uc.mu.RUnlock()
+	if !ok {
+		return currentUser{}, false
+	}
+	if time.Now().After(entry.expires) {
+		uc.mu.Lock()
+		// Another request may have cached the user again meanwhile
+		if e, ok := uc.entries[clerkID]; ok && time.Now().After(e.expires) {
+			delete(uc.entries, clerkID)
+		}
+		uc.mu.Unlock()
+		return currentUser{}, false
+	}
+	return entry.user, true
+}
+
+func (uc *userCache) set(clerkID string, user currentUser) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	now := time.Now()
+	if now.Sub(uc.lastSweep) >= userCacheTTL {
+		for id, e := range uc.entries {
+			if now.After(e.expires) {
+				delete(uc.entries, id)
+			}
+		}
+		uc.lastSweep = now
+	}
+	uc.entries[clerkID] = userCacheEntry{user: user, expires: now.Add(userCacheTTL)}
+}
+
+// forget drops a cached user, e.g. after it has been deleted or suspended
+func (uc *userCache) forget(clerkID string) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	delete(uc.entries, clerkID)
+}
+
+var (
+	errNoSession    = errors.New("no session found")
+	errUserNotFound = errors.New("user not found in application database")
+	// errNoEmailClaim is returned when a user can't be provisioned from the session
+	errNoEmailClaim = errors.New("session token has no email claim")
+)
+
+// provisionUser creates the application user for a session from its email and name claims.
+// Concurrent first requests race on the clerk_id unique constraint, so the insert
+// is an upsert that returns the existing row when another request won.
+func provisionUser(c *gin.Context, clerkID string) (currentUser, error) {
+	principal, ok := middleware.PrincipalFromContext(c.Request.Context())
+	if !ok || principal.Email == "" {
+		return currentUser{}, errNoEmailClaim
+	}
+	name := strings.TrimSpace(principal.Name)
+	if name == "" {
+		name = principal.Email
+	}
+
+	return scanCurrentUser(database.DB.QueryRow(
+		`INSERT INTO users (clerk_id, name, email) VALUES ($1, $2, $3)
+		 ON CONFLICT (clerk_id) DO UPDATE SET clerk_id = EXCLUDED.clerk_id
+		 RETURNING `+currentUserColumns,
+		clerkID, name, principal.Email,
+	))
+}
+
+// currentUserColumns are the columns scanned by scanCurrentUser
+const currentUserColumns = "id, role, suspended_at, deletion_scheduled_for"
+
+// scanCurrentUser scans a row selected with currentUserColumns
+func scanCurrentUser(row *sql.Row) (currentUser, error) {
+	var user currentUser
+	var suspendedAt, deletionScheduledFor *time.Time
+	err := row.Scan(&user.ID, &user.Role, &suspendedAt, &deletionScheduledFor)
+	user.Suspended = suspendedAt != nil
+	user.PendingDeletion = deletionScheduledFor != nil
+	return user, err
+}
+
+// resolveCurrentUser finds the application user of the request's principal,
+// provisioning it when just-in-time provisioning is enabled
+func resolveCurrentUser(c *gin.Context) (currentUser, error) {
+	clerkID, ok := getClerkID(c)
+	if !ok {
+		return currentUser{}, errNoSession
+	}
+
+	if user, ok := userIDs.get(clerkID); ok {
+		return user, nil
+	}
+
+	user, err := scanCurrentUser(database.DB.QueryRow(
+		"SELECT "+currentUserColumns+" FROM users WHERE clerk_id = $1",
+		clerkID,
+	))
+	if err == sql.ErrNoRows && UserProvisioning == config.ProvisionJIT {
+		user, err = provisionUser(c, clerkID)
+	}
+	if err == sql.ErrNoRows {
+		return currentUser{}, errUserNotFound
+	}
+	if err != nil {
+		return currentUser{}, err
+	}
+
+	userIDs.set(clerkID, user)
+	return user, nil
+}
+
+// respondUserError writes the response for an error from resolveCurrentUser
+func respondUserError(c *gin.Context, err error) {
+	switch err {
+	case errNoSession:
+		apierror.Abort(c, apierror.Unauthorized("Unauthorized: No session found"))
+	case errUserNotFound:
+		apierror.Abort(c, apierror.Forbidden(apierror.CodeUserNotRegistered, "User not found in application database"))
+	case errNoEmailClaim:
+		apierror.Abort(c, apierror.Forbidden(apierror.CodeUserNotRegistered, "User not found in application database and session has no email claim"))
+	default:
+		apierror.Abort(c, err)
+	}
+}
+
+// getCurrentUser returns the application user of the request, writing an error response if there is none
+var getCurrentUser = func(c *gin.Context) (currentUser, bool) {
+	user, err := resolveCurrentUser(c)
+	if err != nil {
+		respondUserError(c, err)
+		return currentUser{}, false
+	}
+	return user, true
 }
 
 // GetUserIDFromClerkID retrieves the application-specific user UUID from the database
 // based on the Clerk user ID from the session token.
+// With just-in-time provisioning enabled, a missing user is created from the session claims.
 var GetUserIDFromClerkID = func(c *gin.Context) (uuid.UUID, bool) {
-	clerkID, ok := getClerkID(c)
+	user, ok := getCurrentUser(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: No session found"})
 		return uuid.Nil, false
 	}
+	return user.ID, true
+}
+
+// RejectSuspended completes authentication by rejecting suspended users with 403.
+// Principals without an application user are let through so they can register.
+func RejectSuspended() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := resolveCurrentUser(c)
+		if err == errUserNotFound || err == errNoEmailClaim {
+			c.Next()
+			return
+		}
+		if err != nil {
+			respondUserError(c, err)
+			return
+		}
+		if user.Suspended {
+			apierror.Abort(c, apierror.Forbidden(apierror.CodeAccountSuspended, "Account suspended"))
+			return
+		}
+		c.Next()
+	}
+}
 
-	var userID uuid.UUID
-	err := database.DB.QueryRow("SELECT id FROM users WHERE clerk_id = $1", clerkID).Scan(&userID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusForbidden, gin.H{"error": "User not found in application database"})
-			return uuid.Nil, false
+// RejectPendingDeletion locks accounts scheduled for deletion by rejecting them with 403.
+// It runs after RejectSuspended; the routes that restore an account leave it out.
+func RejectPendingDeletion() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := resolveCurrentUser(c)
+		if err == errUserNotFound || err == errNoEmailClaim {
+			c.Next()
+			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user from database"})
-		return uuid.Nil, false
+		if err != nil {
+			respondUserError(c, err)
+			return
+		}
+		if user.PendingDeletion {
+			apierror.Abort(c, apierror.Forbidden(apierror.CodeAccountDeleting, "Account scheduled for deletion"))
+			return
+		}
+		c.Next()
 	}
+}
 
-	return userID, true
+// RequireAdmin rejects users without the admin role with 403
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := getCurrentUser(c)
+		if !ok {
+			return
+		}
+		if user.Role != models.RoleAdmin {
+			apierror.Abort(c, apierror.Forbidden(apierror.CodeAdminRequired, "Admin role required"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// scanRows calls scan on every row, then closes the rows
+func scanRows(rows *sql.Rows, scan func() error) error {
+	defer rows.Close()
+	for rows.Next() {
+		if err := scan(); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
 }
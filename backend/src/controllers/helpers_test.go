@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"api/src/config"
+	"api/src/database"
+	"api/src/middleware"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+// newSessionContext returns a test context whose request carries the principal
+func newSessionContext(w http.ResponseWriter, principal middleware.Principal) *gin.Context {
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/", nil)
+	c.Request = c.Request.WithContext(middleware.ContextWithPrincipal(c.Request.Context(), &principal))
+	return c
+}
+
+func TestGetUserIDFromClerkID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("existing user is cached", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		testUserID := uuid.New()
+		defer userIDs.forget("user_cached")
+
+		mock.ExpectQuery(`SELECT id, role, suspended_at, deletion_scheduled_for FROM users WHERE clerk_id = \$1`).
+			WithArgs("user_cached").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role", "suspended_at", "deletion_scheduled_for"}).AddRow(testUserID, "user", nil, nil))
+
+		for i := 0; i < 2; i++ {
+			c := newSessionContext(httptest.NewRecorder(), middleware.Principal{Subject: "user_cached"})
+			userID, ok := GetUserIDFromClerkID(c)
+			assert.True(t, ok)
+			assert.Equal(t, testUserID, userID)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unknown user without provisioning", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		principal := middleware.Principal{Subject: "user_new", Email: "new@example.com"}
+
+		mock.ExpectQuery(`SELECT id, role, suspended_at, deletion_scheduled_for FROM users WHERE clerk_id = \$1`).
+			WithArgs("user_new").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role", "suspended_at", "deletion_scheduled_for"}))
+
+		w := httptest.NewRecorder()
+		c := newSessionContext(w, principal)
+		_, ok := GetUserIDFromClerkID(c)
+
+		assert.False(t, ok)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("just-in-time provisioning", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		UserProvisioning = config.ProvisionJIT
+		defer func() { UserProvisioning = config.ProvisionWebhook }()
+
+		testUserID := uuid.New()
+		principal := middleware.Principal{Subject: "user_new", Email: "new@example.com", Name: " New User "}
+		defer userIDs.forget("user_new")
+
+		mock.ExpectQuery(`SELECT id, role, suspended_at, deletion_scheduled_for FROM users WHERE clerk_id = \$1`).
+			WithArgs("user_new").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role", "suspended_at", "deletion_scheduled_for"}))
+		mock.ExpectQuery(`INSERT INTO users \(clerk_id, name, email\) VALUES \(\$1, \$2, \$3\) ON CONFLICT \(clerk_id\) DO UPDATE SET clerk_id = EXCLUDED.clerk_id RETURNING id, role, suspended_at, deletion_scheduled_for`).
+			WithArgs("user_new", "New User", "new@example.com").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role", "suspended_at", "deletion_scheduled_for"}).AddRow(testUserID, "user", nil, nil))
+
+		c := newSessionContext(httptest.NewRecorder(), principal)
+		userID, ok := GetUserIDFromClerkID(c)
+
+		assert.True(t, ok)
+		assert.Equal(t, testUserID, userID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("just-in-time provisioning without email claim", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		UserProvisioning = config.ProvisionJIT
+		defer func() { UserProvisioning = config.ProvisionWebhook }()
+
+		principal := middleware.Principal{Subject: "user_new"}
+
+		mock.ExpectQuery(`SELECT id, role, suspended_at, deletion_scheduled_for FROM users WHERE clerk_id = \$1`).
+			WithArgs("user_new").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role", "suspended_at", "deletion_scheduled_for"}))
+
+		w := httptest.NewRecorder()
+		c := newSessionContext(w, principal)
+		_, ok := GetUserIDFromClerkID(c)
+
+		assert.False(t, ok)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "no email claim")
+	})
+
+	t.Run("just-in-time provisioning with taken email", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		UserProvisioning = config.ProvisionJIT
+		defer func() { UserProvisioning = config.ProvisionWebhook }()
+
+		principal := middleware.Principal{Subject: "user_new", Email: "taken@example.com"}
+
+		mock.ExpectQuery(`SELECT id, role, suspended_at, deletion_scheduled_for FROM users WHERE clerk_id = \$1`).
+			WithArgs("user_new").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role", "suspended_at", "deletion_scheduled_for"}))
+		mock.ExpectQuery(`INSERT INTO users`).
+			WithArgs("user_new", "taken@example.com", "taken@example.com").
+			WillReturnError(&pq.Error{Code: "23505"})
+
+		w := httptest.NewRecorder()
+		c := newSessionContext(w, principal)
+		_, ok := GetUserIDFromClerkID(c)
+
+		assert.False(t, ok)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
+
+func TestUserCacheExpiry(t *testing.T) {
+	uc := &userCache{entries: map[string]userCacheEntry{}}
+	uc.entries["stale"] = userCacheEntry{user: currentUser{ID: uuid.New()}, expires: time.Now().Add(-time.Second)}
+	uc.entries["gone"] = userCacheEntry{user: currentUser{ID: uuid.New()}, expires: time.Now().Add(-time.Second)}
+
+	_, ok := uc.get("stale")
+	assert.False(t, ok)
+	assert.NotContains(t, uc.entries, "stale")
+
+	uc.set("fresh", currentUser{ID: uuid.New()})
+	assert.NotContains(t, uc.entries, "gone")
+	_, ok = uc.get("fresh")
+	assert.True(t, ok)
+}
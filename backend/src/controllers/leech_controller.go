@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"api/src/apierror"
+	"api/src/database"
+	"api/src/models"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ownedFlashcard selects a flashcard of the user: $1 is the flashcard, $2 the user
+const ownedFlashcard = "FROM flashcards f JOIN decks d ON f.parent_deck = d.id WHERE f.id = $1 AND d.owner_id = $2"
+
+const cardStateColumns = "flashcard_id, state, step, ease, interval_days, due_at, reps, lapses, leech, suspended_at, buried_until"
+
+// scanCardState scans a row selected with cardStateColumns
+func scanCardState(row interface{ Scan(...any) error }, s *models.CardState) error {
+	return row.Scan(&s.FlashcardID, &s.State, &s.Step, &s.Ease, &s.Interval, &s.DueAt, &s.Reps, &s.Lapses, &s.Leech, &s.SuspendedAt, &s.BuriedUntil)
+}
+
+// setCardState runs an upsert of the card_states row of a flashcard of the
+// user, returning cardStateColumns, and responds with the new state. args are
+// bound from $3 on. Flashcards that were never studied get a row of a new card.
+func setCardState(c *gin.Context, userID uuid.UUID, query string, args ...any) {
+	flashcardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidID("Flashcard"))
+		return
+	}
+
+	var s models.CardState
+	args = append([]any{flashcardID, userID}, args...)
+	err = scanCardState(database.DB.QueryRowContext(c.Request.Context(), query+" RETURNING "+cardStateColumns, args...), &s)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Abort(c, apierror.NotFound("Flashcard not found or access denied"))
+			return
+		}
+		apierror.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, s)
+}
+
+// SuspendFlashcard leaves a flashcard out of study until it is unsuspended
+func SuspendFlashcard(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	setCardState(c, userID,
+		`INSERT INTO card_states (flashcard_id, suspended_at)
+		 SELECT f.id, NOW() `+ownedFlashcard+`
+		 ON CONFLICT (flashcard_id) DO UPDATE SET suspended_at = COALESCE(card_states.suspended_at, EXCLUDED.suspended_at)`,
+	)
+}
+
+// UnsuspendFlashcard brings a suspended or buried flashcard back to study.
+// Leeches stay tagged as such.
+func UnsuspendFlashcard(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	setCardState(c, userID,
+		`INSERT INTO card_states (flashcard_id)
+		 SELECT f.id `+ownedFlashcard+`
+		 ON CONFLICT (flashcard_id) DO UPDATE SET suspended_at = NULL, buried_until = NULL`,
+	)
+}
+
+// BuryFlashcard leaves a flashcard out of study until the next study day of
+// the user starts
+func BuryFlashcard(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	clock, err := loadStudyClock(c.Request.Context(), userID)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	tomorrow := clock.start(clock.day(time.Now()).AddDate(0, 0, 1))
+
+	setCardState(c, userID,
+		`INSERT INTO card_states (flashcard_id, buried_until)
+		 SELECT f.id, $3 `+ownedFlashcard+`
+		 ON CONFLICT (flashcard_id) DO UPDATE SET buried_until = EXCLUDED.buried_until`,
+		tomorrow,
+	)
+}
+
+// GetLeeches returns the leeches of the authenticated user across decks, the
+// most forgotten first
+func GetLeeches(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	rows, err := database.DB.QueryContext(c.Request.Context(),
+		`SELECT f.id, f.parent_deck, f.starred, f.front, f.back, d.title, s.lapses, s.suspended_at
+		 FROM card_states s
+		 JOIN flashcards f ON f.id = s.flashcard_id
+		 JOIN decks d ON f.parent_deck = d.id
+		 WHERE d.owner_id = $1 AND s.leech
+		 ORDER BY s.lapses DESC, d.title, f.id`,
+		userID,
+	)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	defer rows.Close()
+
+	leeches := []models.Leech{}
+	for rows.Next() {
+		var l models.Leech
+		f := &l.Flashcard
+		if err := rows.Scan(&f.ID, &f.ParentDeck, &f.Starred, &f.Front, &f.Back, &l.DeckTitle, &l.Lapses, &l.SuspendedAt); err != nil {
+			apierror.Abort(c, err)
+			return
+		}
+		leeches = append(leeches, l)
+	}
+	if err := rows.Err(); err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, leeches)
+}
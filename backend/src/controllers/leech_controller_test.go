@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"api/src/models"
+	"api/src/scheduler"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func cardStateRow(flashcardID uuid.UUID, leech bool, suspendedAt, buriedUntil *time.Time) *sqlmock.Rows {
+	return sqlmock.NewRows(strings.Split(cardStateColumns, ", ")).
+		AddRow(flashcardID, scheduler.StateReview, 0, 1.3, 2, time.Now(), 12, 8, leech, suspendedAt, buriedUntil)
+}
+
+func TestSuspendFlashcard(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		w, c, mock, testUserID, cardID := setupDuplicates(t, "POST", "/", "")
+		now := time.Now()
+
+		mock.ExpectQuery(`INSERT INTO card_states \(flashcard_id, suspended_at\) SELECT f.id, NOW\(\) FROM flashcards f JOIN decks d ON f.parent_deck = d.id WHERE f.id = \$1 AND d.owner_id = \$2 `+
+			`ON CONFLICT \(flashcard_id\) DO UPDATE SET suspended_at = COALESCE\(card_states.suspended_at, EXCLUDED.suspended_at\) RETURNING flashcard_id`).
+			WithArgs(cardID, testUserID).
+			WillReturnRows(cardStateRow(cardID, true, &now, nil))
+
+		SuspendFlashcard(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var state models.CardState
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &state))
+		assert.Equal(t, cardID, state.FlashcardID)
+		assert.NotNil(t, state.SuspendedAt)
+		assert.True(t, state.Leech)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("someone else's card", func(t *testing.T) {
+		w, c, mock, testUserID, cardID := setupDuplicates(t, "POST", "/", "")
+
+		mock.ExpectQuery(`INSERT INTO card_states`).
+			WithArgs(cardID, testUserID).
+			WillReturnError(sql.ErrNoRows)
+
+		SuspendFlashcard(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestUnsuspendFlashcard(t *testing.T) {
+	w, c, mock, testUserID, cardID := setupDuplicates(t, "POST", "/", "")
+
+	mock.ExpectQuery(`INSERT INTO card_states \(flashcard_id\) SELECT f.id FROM flashcards f .* DO UPDATE SET suspended_at = NULL, buried_until = NULL`).
+		WithArgs(cardID, testUserID).
+		WillReturnRows(cardStateRow(cardID, true, nil, nil))
+
+	UnsuspendFlashcard(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var state models.CardState
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &state))
+	assert.Nil(t, state.SuspendedAt)
+	assert.True(t, state.Leech)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBuryFlashcard(t *testing.T) {
+	w, c, mock, testUserID, cardID := setupDuplicates(t, "POST", "/", "")
+	// The next study day starts at 04:00 in Tokyo
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	now := time.Now().In(tokyo)
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day(), 4, 0, 0, 0, tokyo)
+	if now.Hour() >= 4 {
+		tomorrow = tomorrow.AddDate(0, 0, 1)
+	}
+	expectStudyGoal(mock, testUserID, "Asia/Tokyo", models.DefaultStudyGoal())
+
+	mock.ExpectQuery(`INSERT INTO card_states \(flashcard_id, buried_until\) SELECT f.id, \$3 FROM flashcards f`).
+		WithArgs(cardID, testUserID, tomorrow).
+		WillReturnRows(cardStateRow(cardID, false, nil, &tomorrow))
+
+	BuryFlashcard(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var state models.CardState
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &state))
+	if assert.NotNil(t, state.BuriedUntil) {
+		assert.True(t, state.BuriedUntil.Equal(tomorrow))
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetLeeches(t *testing.T) {
+	w, c, mock, testUserID, _ := setupDuplicates(t, "GET", "/", "")
+	deckID := uuid.New()
+
+	mock.ExpectQuery(`SELECT f.id, f.parent_deck, f.starred, f.front, f.back, d.title, s.lapses, s.suspended_at FROM card_states s .* WHERE d.owner_id = \$1 AND s.leech ORDER BY s.lapses DESC`).
+		WithArgs(testUserID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "parent_deck", "starred", "front", "back", "title", "lapses", "suspended_at"}).
+			AddRow(uuid.New(), deckID, false, "Ubiquitous", "found everywhere", "Vocabulary", 12, time.Now()).
+			AddRow(uuid.New(), deckID, true, "Ephemeral", "lasting a very short time", "Vocabulary", 8, nil))
+
+	GetLeeches(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var leeches []models.Leech
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &leeches))
+	if assert.Len(t, leeches, 2) {
+		assert.Equal(t, "Ubiquitous", leeches[0].Flashcard.Front)
+		assert.Equal(t, "Vocabulary", leeches[0].DeckTitle)
+		assert.Equal(t, 12, leeches[0].Lapses)
+		assert.NotNil(t, leeches[0].SuspendedAt)
+		assert.Nil(t, leeches[1].SuspendedAt)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"api/src/apierror"
+	"api/src/cardfile"
+	"api/src/database"
+	"api/src/models"
+	"database/sql"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// bindPaste binds a paste import request and parses its text
+func bindPaste(c *gin.Context) (models.PasteImport, []cardfile.Card, []models.ImportWarning, bool) {
+	var req models.PasteImport
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Invalid(err))
+		return req, nil, nil, false
+	}
+
+	cards, parseWarnings, err := cardfile.ParsePaste(req.Text, cardfile.PasteOptions{
+		Term:       req.TermSeparator,
+		Card:       req.CardSeparator,
+		CustomTerm: req.CustomTermSeparator,
+		CustomCard: req.CustomCardSeparator,
+	})
+	if err != nil {
+		apierror.Abort(c, apierror.Invalid(err))
+		return req, nil, nil, false
+	}
+
+	warnings := make([]models.ImportWarning, len(parseWarnings))
+	for i, w := range parseWarnings {
+		warnings[i] = models.ImportWarning{Entry: w.Entry, Text: w.Text, Message: w.Message, Skipped: w.Skipped}
+	}
+	return req, cards, warnings, true
+}
+
+// PreviewPaste shows the cards pasted text would be imported as, and the
+// entries that would be skipped or look wrong. Nothing is saved.
+func PreviewPaste(c *gin.Context) {
+	_, cards, warnings, ok := bindPaste(c)
+	if !ok {
+		return
+	}
+
+	preview := models.PastePreview{Cards: make([]models.PastedCard, len(cards)), Warnings: warnings}
+	for i, card := range cards {
+		preview.Cards[i] = models.PastedCard{Front: card.Front, Back: card.Back}
+	}
+	c.JSON(http.StatusOK, preview)
+}
+
+// ImportPaste adds the cards of pasted text to an existing deck, or to a new
+// deck when no deck_id is given
+func ImportPaste(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	req, cards, warnings, ok := bindPaste(c)
+	if !ok {
+		return
+	}
+	if len(cards) == 0 {
+		apierror.Abort(c, apierror.BadRequest("No cards found in the text"))
+		return
+	}
+
+	deck := models.Deck{OwnerID: userID, Labels: []string{}, Title: req.Title}
+	if req.DeckID == nil {
+		if err := deck.Validate(); err != nil {
+			apierror.Abort(c, apierror.Invalid(err))
+			return
+		}
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	defer tx.Rollback()
+
+	if req.DeckID != nil {
+		err = scanDeck(tx.QueryRow(
+			"SELECT "+deckColumns+" FROM decks WHERE id = $1 AND owner_id = $2 FOR UPDATE",
+			*req.DeckID, userID,
+		), &deck)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				apierror.Abort(c, apierror.NotFound("Deck not found or access denied"))
+				return
+			}
+			apierror.Abort(c, err)
+			return
+		}
+	} else {
+		err = scanDeck(tx.QueryRow(
+			"INSERT INTO decks (owner_id, labels, title, description) VALUES ($1, $2, $3, $4) RETURNING "+deckColumns,
+			deck.OwnerID, pq.StringArray(deck.Labels), deck.Title, deck.Description,
+		), &deck)
+		if err != nil {
+			apierror.Abort(c, err)
+			return
+		}
+	}
+
+	result := models.PasteImportResult{Deck: deck, Flashcards: []models.Flashcard{}, Warnings: warnings}
+	for _, card := range cards {
+		starred := false
+		flashcard := models.Flashcard{ParentDeck: deck.ID, Starred: &starred, Front: card.Front, Back: card.Back}
+		if err := flashcard.Validate(); err != nil {
+			apierror.Abort(c, apierror.Invalid(err))
+			return
+		}
+		err := tx.QueryRow(
+			"INSERT INTO flashcards (parent_deck, starred, front, back) VALUES ($1, $2, $3, $4) RETURNING id",
+			flashcard.ParentDeck, flashcard.Starred, flashcard.Front, flashcard.Back,
+		).Scan(&flashcard.ID)
+		if err != nil {
+			apierror.Abort(c, err)
+			return
+		}
+		result.Flashcards = append(result.Flashcards, flashcard)
+	}
+
+	if err := tx.Commit(); err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
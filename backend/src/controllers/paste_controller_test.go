@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"api/src/database"
+	"api/src/models"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewPaste(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", "/", strings.NewReader(`{"text":"hola - hello\nnope","term_separator":"dash"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		PreviewPaste(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var preview models.PastePreview
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &preview))
+		assert.Equal(t, []models.PastedCard{{Front: "hola", Back: "hello"}}, preview.Cards)
+		assert.Equal(t, []models.ImportWarning{{Entry: 2, Text: "nope", Message: "no term separator found", Skipped: true}}, preview.Warnings)
+	})
+
+	t.Run("unknown separator", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", "/", strings.NewReader(`{"text":"a|b","term_separator":"pipe"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		PreviewPaste(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "term_separator must be tab, comma, dash or custom")
+	})
+}
+
+func TestImportPaste(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	setup := func(t *testing.T, body string) (*httptest.ResponseRecorder, *gin.Context, sqlmock.Sqlmock, uuid.UUID) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		t.Cleanup(func() { mockDB.Close() })
+		database.DB = mockDB
+
+		testUserID := uuid.New()
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", "/", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		originalGetUserID := GetUserIDFromClerkID
+		GetUserIDFromClerkID = func(c *gin.Context) (uuid.UUID, bool) {
+			return testUserID, true
+		}
+		t.Cleanup(func() { GetUserIDFromClerkID = originalGetUserID })
+
+		return w, c, mock, testUserID
+	}
+
+	t.Run("existing deck", func(t *testing.T) {
+		testDeckID := uuid.New()
+		w, c, mock, testUserID := setup(t, `{"text":"hola;hello\n\nadiós;goodbye","term_separator":"custom","custom_term_separator":";","card_separator":"blank_line","deck_id":"`+testDeckID.String()+`"}`)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT id, owner_id, labels, title, description, to_char\(exam_date, 'YYYY-MM-DD'\), leech_threshold, leech_action, preset_id FROM decks WHERE id = \$1 AND owner_id = \$2 FOR UPDATE`).
+			WithArgs(testDeckID, testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id", "labels", "title", "description", "exam_date", "leech_threshold", "leech_action", "preset_id"}).
+				AddRow(testDeckID, testUserID, pq.Array([]string{"es"}), "Spanish", "", "2026-06-01", 5, "tag", nil))
+		mock.ExpectQuery(`INSERT INTO flashcards \(parent_deck, starred, front, back\) VALUES \(\$1, \$2, \$3, \$4\) RETURNING id`).
+			WithArgs(testDeckID, false, "hola", "hello").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+		mock.ExpectQuery(`INSERT INTO flashcards \(parent_deck, starred, front, back\) VALUES \(\$1, \$2, \$3, \$4\) RETURNING id`).
+			WithArgs(testDeckID, false, "adiós", "goodbye").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+		mock.ExpectCommit()
+
+		ImportPaste(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var result models.PasteImportResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		assert.Equal(t, "Spanish", result.Deck.Title)
+		assert.Equal(t, 5, result.Deck.LeechThreshold)
+		assert.Len(t, result.Flashcards, 2)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("new deck needs a title", func(t *testing.T) {
+		w, c, mock, _ := setup(t, `{"text":"hola\thello"}`)
+
+		ImportPaste(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "title is required")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("no cards", func(t *testing.T) {
+		w, c, mock, _ := setup(t, `{"text":"just words","title":"Empty"}`)
+
+		ImportPaste(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "No cards found in the text")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"api/src/apierror"
+	"api/src/database"
+	"api/src/models"
+	"api/src/scheduler"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetDeckPlan returns the day-by-day plan of cramming a deck before its exam:
+// how many new cards to learn and how many cards to review every study day of
+// the user, with the scheduler settings of the preset of the deck
+func GetDeckPlan(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	deckID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidID("Deck"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	var examDate *string
+	err = database.DB.QueryRowContext(ctx,
+		"SELECT to_char(exam_date, 'YYYY-MM-DD') FROM decks WHERE id = $1 AND owner_id = $2",
+		deckID, userID,
+	).Scan(&examDate)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Abort(c, apierror.NotFound("Deck not found or access denied"))
+			return
+		}
+		apierror.Abort(c, err)
+		return
+	}
+	if examDate == nil {
+		apierror.Abort(c, apierror.Conflict("Deck has no exam_date"))
+		return
+	}
+	exam, err := time.Parse(time.DateOnly, *examDate)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	clock, err := loadStudyClock(ctx, userID)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	now := time.Now()
+	today := clock.day(now)
+	if !exam.After(today) {
+		apierror.Abort(c, apierror.Conflict("The exam of this deck is over"))
+		return
+	}
+
+	rows, err := database.DB.QueryContext(ctx,
+		`SELECT COALESCE(s.state, 'new'), COALESCE(s.step, 0), COALESCE(s.ease, 0), COALESCE(s.interval_days, 0),
+		        s.due_at, COALESCE(s.reps, 0), COALESCE(s.lapses, 0)
+		 FROM flashcards f
+		 LEFT JOIN card_states s ON s.flashcard_id = f.id
+		 WHERE f.parent_deck = $1 AND s.suspended_at IS NULL`,
+		deckID,
+	)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	defer rows.Close()
+
+	var cards []scheduler.Card
+	fresh := 0
+	for rows.Next() {
+		var state models.CardState
+		if err := rows.Scan(&state.State, &state.Step, &state.Ease, &state.Interval, &state.DueAt, &state.Reps, &state.Lapses); err != nil {
+			apierror.Abort(c, err)
+			return
+		}
+		if state.State == scheduler.StateNew {
+			fresh++
+		}
+		cards = append(cards, state.Scheduler())
+	}
+	if err := rows.Err(); err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	presets, err := loadDeckPresets(ctx, database.DB, []uuid.UUID{deckID})
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	days := presets[deckID].Settings().Plan(cards, now, clock.start(today), exam)
+	plan := models.CramPlan{
+		DeckID:    deckID,
+		ExamDate:  *examDate,
+		DaysLeft:  len(days),
+		NewPerDay: scheduler.NewPerDay(fresh, len(days)),
+		Days:      make([]models.CramPlanDay, len(days)),
+	}
+	for i, d := range days {
+		plan.Days[i] = models.CramPlanDay{Date: d.Date.Format(time.DateOnly), NewCards: d.New, Reviews: d.Reviews}
+	}
+	c.JSON(http.StatusOK, plan)
+}
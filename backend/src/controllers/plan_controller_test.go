@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"api/src/models"
+	"api/src/scheduler"
+	"api/src/streak"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDeckPlan(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		w, c, mock, testUserID, testDeckID := setupDuplicates(t, "GET", "/", "")
+		// Days in Kiritimati are ahead of UTC
+		loc, err := time.LoadLocation("Pacific/Kiritimati")
+		require.NoError(t, err)
+		goal := models.DefaultStudyGoal()
+		today := streak.Day(time.Now(), loc, goal.DayRollover)
+		exam := today.AddDate(0, 0, 3).Format(time.DateOnly)
+
+		mock.ExpectQuery(`SELECT to_char\(exam_date, 'YYYY-MM-DD'\) FROM decks WHERE id = \$1 AND owner_id = \$2`).
+			WithArgs(testDeckID, testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"exam_date"}).AddRow(exam))
+		expectStudyGoal(mock, testUserID, "Pacific/Kiritimati", goal)
+		mock.ExpectQuery(`SELECT COALESCE\(s.state, 'new'\), .* FROM flashcards f LEFT JOIN card_states s ON s.flashcard_id = f.id WHERE f.parent_deck = \$1`).
+			WithArgs(testDeckID).
+			WillReturnRows(sqlmock.NewRows([]string{"state", "step", "ease", "interval_days", "due_at", "reps", "lapses"}).
+				AddRow(scheduler.StateNew, 0, 0.0, 0, nil, 0, 0).
+				AddRow(scheduler.StateNew, 0, 0.0, 0, nil, 0, 0).
+				AddRow(scheduler.StateNew, 0, 0.0, 0, nil, 0, 0).
+				AddRow(scheduler.StateNew, 0, 0.0, 0, nil, 0, 0).
+				AddRow(scheduler.StateReview, 0, 2.5, 10, streak.Start(today.AddDate(0, 0, 1), loc, goal.DayRollover), 5, 0))
+		mock.ExpectQuery(`FROM decks d JOIN deck_presets p`).
+			WithArgs(pq.Array([]uuid.UUID{testDeckID})).
+			WillReturnRows(deckPresetRows(testDeckID))
+
+		GetDeckPlan(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var plan models.CramPlan
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &plan))
+		assert.Equal(t, exam, plan.ExamDate)
+		assert.Equal(t, 3, plan.DaysLeft)
+		assert.Equal(t, 2, plan.NewPerDay)
+		if assert.Len(t, plan.Days, 3) {
+			assert.Equal(t, today.Format(time.DateOnly), plan.Days[0].Date)
+			assert.Equal(t, 2, plan.Days[0].NewCards)
+			assert.Equal(t, 2, plan.Days[1].NewCards)
+			assert.Equal(t, 0, plan.Days[2].NewCards)
+			assert.Equal(t, 3, plan.Days[1].Reviews)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("no exam date", func(t *testing.T) {
+		w, c, mock, testUserID, testDeckID := setupDuplicates(t, "GET", "/", "")
+
+		mock.ExpectQuery(`SELECT to_char\(exam_date, 'YYYY-MM-DD'\) FROM decks`).
+			WithArgs(testDeckID, testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"exam_date"}).AddRow(nil))
+
+		GetDeckPlan(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("exam over", func(t *testing.T) {
+		w, c, mock, testUserID, testDeckID := setupDuplicates(t, "GET", "/", "")
+
+		mock.ExpectQuery(`SELECT to_char\(exam_date, 'YYYY-MM-DD'\) FROM decks`).
+			WithArgs(testDeckID, testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"exam_date"}).AddRow("2020-01-01"))
+		expectStudyGoal(mock, testUserID, "UTC", models.DefaultStudyGoal())
+
+		GetDeckPlan(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "over")
+	})
+}
@@ -0,0 +1,218 @@
+package controllers
+
+import (
+	"api/src/apierror"
+	"api/src/database"
+	"api/src/models"
+	"api/src/quiz"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Formats of a quiz
+const (
+	quizFormatJSON     = "json"
+	quizFormatMarkdown = "markdown"
+)
+
+// CreateQuiz generates a multiple-choice quiz from the flashcards of a deck.
+// The answers are kept on the server and left out of the response.
+func CreateQuiz(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	deckID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidID("Deck"))
+		return
+	}
+
+	// Every field is optional, so is the body
+	var req models.QuizRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		apierror.Abort(c, apierror.Invalid(err))
+		return
+	}
+	if err := req.Validate(); err != nil {
+		apierror.Abort(c, apierror.Invalid(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	var id uuid.UUID
+	err = database.DB.QueryRowContext(ctx, "SELECT id FROM decks WHERE id = $1 AND owner_id = $2", deckID, userID).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Abort(c, apierror.NotFound("Deck not found or access denied"))
+			return
+		}
+		apierror.Abort(c, err)
+		return
+	}
+
+	flashcards, err := queryFlashcards(ctx, "f.parent_deck = $1", deckID)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	q := models.Quiz{DeckID: deckID, Choices: req.Choices}
+	if req.Seed != nil {
+		q.Seed = *req.Seed
+	} else {
+		// Small enough to be read out and typed back
+		q.Seed = rand.Int63n(1 << 31)
+	}
+	q.Questions, err = quiz.Generate(flashcards[deckID], quiz.Options{Seed: q.Seed, Questions: req.Questions, Choices: req.Choices})
+	if err != nil {
+		if errors.Is(err, quiz.ErrTooFewCards) {
+			apierror.Abort(c, apierror.Conflict("A quiz needs at least two flashcards with different backs"))
+			return
+		}
+		apierror.Abort(c, err)
+		return
+	}
+
+	questions, err := json.Marshal(q.Questions)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	err = database.DB.QueryRowContext(ctx,
+		"INSERT INTO quizzes (deck_id, seed, choices, questions) VALUES ($1, $2, $3, $4) RETURNING id, created_at",
+		deckID, q.Seed, q.Choices, questions,
+	).Scan(&q.ID, &q.CreatedAt)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	q.HideAnswers()
+	c.JSON(http.StatusCreated, q)
+}
+
+// getQuiz loads a quiz with its answers along with the title of its deck
+func getQuiz(c *gin.Context, userID uuid.UUID) (models.Quiz, string, bool) {
+	var q models.Quiz
+	quizID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidID("Quiz"))
+		return q, "", false
+	}
+
+	var title string
+	var questions []byte
+	err = database.DB.QueryRowContext(c.Request.Context(),
+		`SELECT q.id, q.deck_id, q.seed, q.choices, q.questions, q.created_at, d.title
+		 FROM quizzes q
+		 JOIN decks d ON q.deck_id = d.id
+		 WHERE q.id = $1 AND d.owner_id = $2`,
+		quizID, userID,
+	).Scan(&q.ID, &q.DeckID, &q.Seed, &q.Choices, &questions, &q.CreatedAt, &title)
+	if err == nil {
+		err = json.Unmarshal(questions, &q.Questions)
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Abort(c, apierror.NotFound("Quiz not found"))
+			return q, "", false
+		}
+		apierror.Abort(c, err)
+		return q, "", false
+	}
+	return q, title, true
+}
+
+// GetQuiz returns a quiz as JSON, or as printable Markdown with format=markdown.
+// answers=true includes the answer key and needs the decks:write scope, so that
+// tokens only allowed to study can't look the answers up before submitting.
+func GetQuiz(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	format := c.DefaultQuery("format", quizFormatJSON)
+	if format != quizFormatJSON && format != quizFormatMarkdown {
+		apierror.Abort(c, apierror.BadRequest("format must be json or markdown"))
+		return
+	}
+	answers, err := strconv.ParseBool(c.DefaultQuery("answers", "false"))
+	if err != nil {
+		apierror.Abort(c, apierror.BadRequest("answers must be true or false"))
+		return
+	}
+	if answers && !hasScope(c, models.ScopeWriteDecks) {
+		apierror.Abort(c, apierror.Forbidden(apierror.CodeInsufficientScope, "Token is missing the "+models.ScopeWriteDecks+" scope"))
+		return
+	}
+
+	q, title, ok := getQuiz(c, userID)
+	if !ok {
+		return
+	}
+	if !answers {
+		q.HideAnswers()
+	}
+
+	if format == quizFormatJSON {
+		c.JSON(http.StatusOK, q)
+		return
+	}
+	c.Header("Content-Type", "text/markdown; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := quiz.WriteMarkdown(c.Writer, title, q, answers); err != nil {
+		log.Printf("Failed to write quiz %s: %v", q.ID, err)
+	}
+}
+
+// SubmitQuiz grades the answers to a quiz and records the score. The answer
+// key is only returned to principals with the decks:write scope, for the same
+// reason as in GetQuiz: the quiz can be submitted again.
+func SubmitQuiz(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	var submission models.QuizSubmission
+	if err := c.ShouldBindJSON(&submission); err != nil {
+		apierror.Abort(c, apierror.Invalid(err))
+		return
+	}
+
+	q, _, ok := getQuiz(c, userID)
+	if !ok {
+		return
+	}
+	if err := submission.Validate(q); err != nil {
+		apierror.Abort(c, apierror.Invalid(err))
+		return
+	}
+
+	attempt := q.Grade(submission.Answers)
+	err := database.DB.QueryRowContext(c.Request.Context(),
+		"INSERT INTO quiz_attempts (quiz_id, user_id, answers, score, total) VALUES ($1, $2, $3, $4, $5) RETURNING id, submitted_at",
+		q.ID, userID, pq.Array(attempt.Answers), attempt.Score, attempt.Total,
+	).Scan(&attempt.ID, &attempt.SubmittedAt)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	if !hasScope(c, models.ScopeWriteDecks) {
+		attempt.Key = nil
+	}
+	c.JSON(http.StatusCreated, attempt)
+}
@@ -0,0 +1,201 @@
+package controllers
+
+import (
+	"api/src/middleware"
+	"api/src/models"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func quizRow(quizID, deckID uuid.UUID, questions []models.QuizQuestion) *sqlmock.Rows {
+	data, _ := json.Marshal(questions)
+	return sqlmock.NewRows([]string{"id", "deck_id", "seed", "choices", "questions", "created_at", "title"}).
+		AddRow(quizID, deckID, 42, 2, data, time.Now(), "Capitals")
+}
+
+// withScopes authenticates the request of c with a personal access token
+// granted scopes, or with a session when scopes is nil
+func withScopes(c *gin.Context, scopes ...string) {
+	principal := &middleware.Principal{Subject: "user_quiz", Scopes: scopes}
+	if scopes != nil {
+		principal.TokenID = uuid.New()
+	}
+	c.Request = c.Request.WithContext(middleware.ContextWithPrincipal(c.Request.Context(), principal))
+}
+
+func quizQuestions() []models.QuizQuestion {
+	first, second := 1, 0
+	return []models.QuizQuestion{
+		{FlashcardID: uuid.New(), Prompt: "Capital of France", Choices: []string{"Rome", "Paris"}, Answer: &first},
+		{FlashcardID: uuid.New(), Prompt: "Capital of Italy", Choices: []string{"Rome", "Paris"}, Answer: &second},
+	}
+}
+
+func TestCreateQuiz(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		w, c, mock, testUserID, testDeckID := setupDuplicates(t, "POST", "/", `{"seed":42,"choices":2}`)
+		quizID := uuid.New()
+
+		mock.ExpectQuery(`SELECT id FROM decks WHERE id = \$1 AND owner_id = \$2`).
+			WithArgs(testDeckID, testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testDeckID))
+		mock.ExpectQuery(`SELECT f.id, f.parent_deck, f.starred, f.front, f.back FROM flashcards f JOIN decks d ON f.parent_deck = d.id WHERE f.parent_deck = \$1`).
+			WithArgs(testDeckID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "parent_deck", "starred", "front", "back"}).
+				AddRow(uuid.New(), testDeckID, false, "Capital of France", "Paris").
+				AddRow(uuid.New(), testDeckID, false, "Capital of Italy", "Rome"))
+		mock.ExpectQuery(`INSERT INTO quizzes \(deck_id, seed, choices, questions\) VALUES \(\$1, \$2, \$3, \$4\) RETURNING id, created_at`).
+			WithArgs(testDeckID, int64(42), 2, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(quizID, time.Now()))
+
+		CreateQuiz(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var q models.Quiz
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &q))
+		assert.Equal(t, quizID, q.ID)
+		assert.Equal(t, int64(42), q.Seed)
+		if assert.Len(t, q.Questions, 2) {
+			assert.ElementsMatch(t, []string{"Paris", "Rome"}, q.Questions[0].Choices)
+		}
+		assert.NotContains(t, w.Body.String(), `"answer"`)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("too few cards", func(t *testing.T) {
+		w, c, mock, testUserID, testDeckID := setupDuplicates(t, "POST", "/", "")
+
+		mock.ExpectQuery(`SELECT id FROM decks`).
+			WithArgs(testDeckID, testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testDeckID))
+		mock.ExpectQuery(`SELECT f.id, f.parent_deck`).
+			WithArgs(testDeckID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "parent_deck", "starred", "front", "back"}).
+				AddRow(uuid.New(), testDeckID, false, "Capital of France", "Paris"))
+
+		CreateQuiz(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("too many choices", func(t *testing.T) {
+		w, c, _, _, _ := setupDuplicates(t, "POST", "/", `{"choices":9}`)
+
+		CreateQuiz(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestGetQuiz(t *testing.T) {
+	t.Run("json without answers", func(t *testing.T) {
+		w, c, mock, testUserID, quizID := setupDuplicates(t, "GET", "/", "")
+
+		mock.ExpectQuery(`SELECT q.id, q.deck_id, q.seed, q.choices, q.questions, q.created_at, d.title FROM quizzes q JOIN decks d ON q.deck_id = d.id WHERE q.id = \$1 AND d.owner_id = \$2`).
+			WithArgs(quizID, testUserID).
+			WillReturnRows(quizRow(quizID, uuid.New(), quizQuestions()))
+
+		GetQuiz(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "Capital of France")
+		assert.NotContains(t, w.Body.String(), `"answer"`)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("printable answer key", func(t *testing.T) {
+		w, c, mock, testUserID, quizID := setupDuplicates(t, "GET", "/?format=markdown&answers=true", "")
+		withScopes(c)
+
+		mock.ExpectQuery(`FROM quizzes q JOIN decks d`).
+			WithArgs(quizID, testUserID).
+			WillReturnRows(quizRow(quizID, uuid.New(), quizQuestions()))
+
+		GetQuiz(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/markdown; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Body.String(), "# Capitals")
+		assert.Contains(t, w.Body.String(), "## Answers\n\n1. B\n2. A\n")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("answer key needs decks:write", func(t *testing.T) {
+		w, c, _, _, _ := setupDuplicates(t, "GET", "/?answers=true", "")
+		withScopes(c, models.ScopeReadDecks, models.ScopeStudy)
+
+		GetQuiz(c)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "insufficient_scope")
+	})
+}
+
+func TestSubmitQuiz(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		w, c, mock, testUserID, quizID := setupDuplicates(t, "POST", "/", `{"answers":[1,1]}`)
+		withScopes(c)
+		attemptID := uuid.New()
+
+		mock.ExpectQuery(`FROM quizzes q JOIN decks d`).
+			WithArgs(quizID, testUserID).
+			WillReturnRows(quizRow(quizID, uuid.New(), quizQuestions()))
+		mock.ExpectQuery(`INSERT INTO quiz_attempts \(quiz_id, user_id, answers, score, total\) VALUES \(\$1, \$2, \$3, \$4, \$5\) RETURNING id, submitted_at`).
+			WithArgs(quizID, testUserID, pq.Array([]int{1, 1}), 1, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "submitted_at"}).AddRow(attemptID, time.Now()))
+
+		SubmitQuiz(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var attempt models.QuizAttempt
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &attempt))
+		assert.Equal(t, attemptID, attempt.ID)
+		assert.Equal(t, 1, attempt.Score)
+		assert.Equal(t, 50.0, attempt.Percent)
+		assert.Equal(t, []bool{true, false}, attempt.Correct)
+		assert.Equal(t, []int{1, 0}, attempt.Key)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("answer key left out without decks:write", func(t *testing.T) {
+		w, c, mock, testUserID, quizID := setupDuplicates(t, "POST", "/", `{"answers":[1,0]}`)
+		withScopes(c, models.ScopeStudy)
+
+		mock.ExpectQuery(`FROM quizzes q JOIN decks d`).
+			WithArgs(quizID, testUserID).
+			WillReturnRows(quizRow(quizID, uuid.New(), quizQuestions()))
+		mock.ExpectQuery(`INSERT INTO quiz_attempts`).
+			WithArgs(quizID, testUserID, pq.Array([]int{1, 0}), 2, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "submitted_at"}).AddRow(uuid.New(), time.Now()))
+
+		SubmitQuiz(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Contains(t, w.Body.String(), `"correct":[true,true]`)
+		assert.NotContains(t, w.Body.String(), `"key"`)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("wrong number of answers", func(t *testing.T) {
+		w, c, mock, testUserID, quizID := setupDuplicates(t, "POST", "/", `{"answers":[1]}`)
+
+		mock.ExpectQuery(`FROM quizzes q JOIN decks d`).
+			WithArgs(quizID, testUserID).
+			WillReturnRows(quizRow(quizID, uuid.New(), quizQuestions()))
+
+		SubmitQuiz(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
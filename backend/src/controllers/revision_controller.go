@@ -0,0 +1,338 @@
+package controllers
+
+import (
+	"api/src/apierror"
+	"api/src/database"
+	"api/src/models"
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// lockFlashcard locks a flashcard owned by userID until the transaction ends.
+// Returns sql.ErrNoRows when the flashcard does not exist or isn't owned by userID.
+func lockFlashcard(tx *sql.Tx, flashcardID, userID uuid.UUID) error {
+	var id uuid.UUID
+	return tx.QueryRow(
+		`SELECT f.id FROM flashcards f
+		 JOIN decks d ON f.parent_deck = d.id
+		 WHERE f.id = $1 AND d.owner_id = $2
+		 FOR UPDATE OF f`,
+		flashcardID, userID,
+	).Scan(&id)
+}
+
+// saveFlashcardRevision copies the current content of a flashcard into its next revision.
+// The flashcard must already be locked by the transaction.
+func saveFlashcardRevision(tx *sql.Tx, flashcardID, editorID uuid.UUID) error {
+	_, err := tx.Exec(
+		`INSERT INTO flashcard_revisions (flashcard_id, rev, starred, front, back, edited_by)
+		 SELECT id, COALESCE((SELECT MAX(rev) FROM flashcard_revisions WHERE flashcard_id = $1), 0) + 1, starred, front, back, $2
+		 FROM flashcards WHERE id = $1`,
+		flashcardID, editorID,
+	)
+	return err
+}
+
+// lockDeck locks a deck owned by userID until the transaction ends.
+// Returns sql.ErrNoRows when the deck does not exist or isn't owned by userID.
+func lockDeck(tx *sql.Tx, deckID, userID uuid.UUID) error {
+	var id uuid.UUID
+	return tx.QueryRow(
+		"SELECT id FROM decks WHERE id = $1 AND owner_id = $2 FOR UPDATE",
+		deckID, userID,
+	).Scan(&id)
+}
+
+// saveDeckRevision copies the current content of a deck into its next revision.
+// The deck must already be locked by the transaction.
+func saveDeckRevision(tx *sql.Tx, deckID, editorID uuid.UUID) error {
+	_, err := tx.Exec(
+		`INSERT INTO deck_revisions (deck_id, rev, labels, title, description, edited_by)
+		 SELECT id, COALESCE((SELECT MAX(rev) FROM deck_revisions WHERE deck_id = $1), 0) + 1, labels, title, COALESCE(description, ''), $2
+		 FROM decks WHERE id = $1`,
+		deckID, editorID,
+	)
+	return err
+}
+
+// GetFlashcardRevisions returns the edit history of a flashcard, oldest first.
+// Each revision carries a diff describing the edit that replaced it, so the
+// last revision is diffed against the flashcard's current content.
+func GetFlashcardRevisions(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	flashcardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidID("Flashcard"))
+		return
+	}
+
+	var current models.FlashcardRevision
+	err = database.DB.QueryRow(
+		`SELECT f.starred, f.front, f.back
+		 FROM flashcards f
+		 JOIN decks d ON f.parent_deck = d.id
+		 WHERE f.id = $1 AND d.owner_id = $2`,
+		flashcardID, userID,
+	).Scan(&current.Starred, &current.Front, &current.Back)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Abort(c, apierror.NotFound("Flashcard not found or access denied"))
+			return
+		}
+		apierror.Abort(c, err)
+		return
+	}
+
+	rows, err := database.DB.Query(
+		`SELECT flashcard_id, rev, starred, front, back, edited_by, created_at
+		 FROM flashcard_revisions WHERE flashcard_id = $1 ORDER BY rev`,
+		flashcardID,
+	)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	defer rows.Close()
+
+	revisions := []models.FlashcardRevision{}
+	for rows.Next() {
+		var r models.FlashcardRevision
+		if err := rows.Scan(&r.FlashcardID, &r.Rev, &r.Starred, &r.Front, &r.Back, &r.EditedBy, &r.CreatedAt); err != nil {
+			apierror.Abort(c, err)
+			return
+		}
+		revisions = append(revisions, r)
+	}
+	if err := rows.Err(); err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	for i := range revisions {
+		next := current
+		if i+1 < len(revisions) {
+			next = revisions[i+1]
+		}
+		d := models.DiffFlashcards(revisions[i], next)
+		revisions[i].Diff = &d
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// RevertFlashcardRevision restores a flashcard to the content of an earlier revision.
+// The content being replaced is saved as a new revision, so a revert can itself be reverted.
+func RevertFlashcardRevision(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	flashcardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidID("Flashcard"))
+		return
+	}
+
+	rev, err := strconv.Atoi(c.Param("rev"))
+	if err != nil || rev < 1 {
+		apierror.Abort(c, apierror.BadRequest("Invalid revision number"))
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	defer tx.Rollback()
+
+	if err := lockFlashcard(tx, flashcardID, userID); err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Abort(c, apierror.NotFound("Flashcard not found or access denied"))
+			return
+		}
+		apierror.Abort(c, err)
+		return
+	}
+
+	var flashcard models.Flashcard
+	err = tx.QueryRow(
+		"SELECT starred, front, back FROM flashcard_revisions WHERE flashcard_id = $1 AND rev = $2",
+		flashcardID, rev,
+	).Scan(&flashcard.Starred, &flashcard.Front, &flashcard.Back)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Abort(c, apierror.NotFound("Revision not found"))
+			return
+		}
+		apierror.Abort(c, err)
+		return
+	}
+
+	if err := saveFlashcardRevision(tx, flashcardID, userID); err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	err = tx.QueryRow(
+		"UPDATE flashcards SET starred = $1, front = $2, back = $3 WHERE id = $4 RETURNING id, parent_deck",
+		flashcard.Starred, flashcard.Front, flashcard.Back, flashcardID,
+	).Scan(&flashcard.ID, &flashcard.ParentDeck)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, flashcard)
+}
+
+// GetDeckRevisions returns the edit history of a deck, oldest first.
+// Diffs follow the same convention as GetFlashcardRevisions.
+func GetDeckRevisions(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	deckID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidID(""))
+		return
+	}
+
+	var current models.DeckRevision
+	err = database.DB.QueryRow(
+		"SELECT labels, title, COALESCE(description, '') FROM decks WHERE id = $1 AND owner_id = $2",
+		deckID, userID,
+	).Scan(pq.Array(&current.Labels), &current.Title, &current.Description)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Abort(c, apierror.NotFound("Deck not found or access denied"))
+			return
+		}
+		apierror.Abort(c, err)
+		return
+	}
+
+	rows, err := database.DB.Query(
+		`SELECT deck_id, rev, labels, title, description, edited_by, created_at
+		 FROM deck_revisions WHERE deck_id = $1 ORDER BY rev`,
+		deckID,
+	)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	defer rows.Close()
+
+	revisions := []models.DeckRevision{}
+	for rows.Next() {
+		var r models.DeckRevision
+		if err := rows.Scan(&r.DeckID, &r.Rev, pq.Array(&r.Labels), &r.Title, &r.Description, &r.EditedBy, &r.CreatedAt); err != nil {
+			apierror.Abort(c, err)
+			return
+		}
+		revisions = append(revisions, r)
+	}
+	if err := rows.Err(); err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	for i := range revisions {
+		next := current
+		if i+1 < len(revisions) {
+			next = revisions[i+1]
+		}
+		d := models.DiffDecks(revisions[i], next)
+		revisions[i].Diff = &d
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// RevertDeckRevision restores a deck's title, description and labels to an earlier revision.
+func RevertDeckRevision(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	deckID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidID(""))
+		return
+	}
+
+	rev, err := strconv.Atoi(c.Param("rev"))
+	if err != nil || rev < 1 {
+		apierror.Abort(c, apierror.BadRequest("Invalid revision number"))
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	defer tx.Rollback()
+
+	if err := lockDeck(tx, deckID, userID); err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Abort(c, apierror.NotFound("Deck not found or access denied"))
+			return
+		}
+		apierror.Abort(c, err)
+		return
+	}
+
+	var deck models.Deck
+	err = tx.QueryRow(
+		"SELECT labels, title, description FROM deck_revisions WHERE deck_id = $1 AND rev = $2",
+		deckID, rev,
+	).Scan(pq.Array(&deck.Labels), &deck.Title, &deck.Description)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Abort(c, apierror.NotFound("Revision not found"))
+			return
+		}
+		apierror.Abort(c, err)
+		return
+	}
+
+	if err := saveDeckRevision(tx, deckID, userID); err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	err = scanDeck(tx.QueryRow(
+		"UPDATE decks SET labels = $1, title = $2, description = $3 WHERE id = $4 RETURNING "+deckColumns,
+		pq.StringArray(deck.Labels), deck.Title, deck.Description, deckID,
+	), &deck)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deck)
+}
@@ -0,0 +1,243 @@
+package controllers
+
+import (
+	"api/src/database"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetFlashcardRevisions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		testUserID := uuid.New()
+		testFlashcardID := uuid.New()
+
+		mock.ExpectQuery(`SELECT f.starred, f.front, f.back FROM flashcards f JOIN decks d ON f.parent_deck = d.id WHERE f.id = \$1 AND d.owner_id = \$2`).
+			WithArgs(testFlashcardID, testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"starred", "front", "back"}).AddRow(false, "Capital of France", "Paris"))
+
+		rows := sqlmock.NewRows([]string{"flashcard_id", "rev", "starred", "front", "back", "edited_by", "created_at"}).
+			AddRow(testFlashcardID, 1, false, "Capital of France", "Lyon", testUserID, time.Now())
+		mock.ExpectQuery(`SELECT flashcard_id, rev, starred, front, back, edited_by, created_at FROM flashcard_revisions WHERE flashcard_id = \$1 ORDER BY rev`).
+			WithArgs(testFlashcardID).
+			WillReturnRows(rows)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "id", Value: testFlashcardID.String()}}
+
+		originalGetUserID := GetUserIDFromClerkID
+		GetUserIDFromClerkID = func(c *gin.Context) (uuid.UUID, bool) {
+			return testUserID, true
+		}
+		defer func() { GetUserIDFromClerkID = originalGetUserID }()
+
+		GetFlashcardRevisions(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"rev":1`)
+		assert.Contains(t, w.Body.String(), `{"op":"delete","text":"Lyon"},{"op":"insert","text":"Paris"}`)
+	})
+}
+
+func TestRevertFlashcardRevision(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		testUserID := uuid.New()
+		testFlashcardID := uuid.New()
+		testDeckID := uuid.New()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT f.id FROM flashcards f JOIN decks d ON f.parent_deck = d.id WHERE f.id = \$1 AND d.owner_id = \$2 FOR UPDATE OF f`).
+			WithArgs(testFlashcardID, testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testFlashcardID))
+		mock.ExpectQuery(`SELECT starred, front, back FROM flashcard_revisions WHERE flashcard_id = \$1 AND rev = \$2`).
+			WithArgs(testFlashcardID, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"starred", "front", "back"}).AddRow(true, "Old Front", "Old Back"))
+		mock.ExpectExec(`INSERT INTO flashcard_revisions`).
+			WithArgs(testFlashcardID, testUserID).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`UPDATE flashcards SET starred = \$1, front = \$2, back = \$3 WHERE id = \$4 RETURNING id, parent_deck`).
+			WithArgs(sqlmock.AnyArg(), "Old Front", "Old Back", testFlashcardID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "parent_deck"}).AddRow(testFlashcardID, testDeckID))
+		mock.ExpectCommit()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{
+			gin.Param{Key: "id", Value: testFlashcardID.String()},
+			gin.Param{Key: "rev", Value: "2"},
+		}
+
+		originalGetUserID := GetUserIDFromClerkID
+		GetUserIDFromClerkID = func(c *gin.Context) (uuid.UUID, bool) {
+			return testUserID, true
+		}
+		defer func() { GetUserIDFromClerkID = originalGetUserID }()
+
+		RevertFlashcardRevision(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "Old Front")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("revision not found", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		testUserID := uuid.New()
+		testFlashcardID := uuid.New()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT f.id FROM flashcards f`).
+			WithArgs(testFlashcardID, testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testFlashcardID))
+		mock.ExpectQuery(`SELECT starred, front, back FROM flashcard_revisions`).
+			WithArgs(testFlashcardID, 9).
+			WillReturnRows(sqlmock.NewRows([]string{"starred", "front", "back"}))
+		mock.ExpectRollback()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{
+			gin.Param{Key: "id", Value: testFlashcardID.String()},
+			gin.Param{Key: "rev", Value: "9"},
+		}
+
+		originalGetUserID := GetUserIDFromClerkID
+		GetUserIDFromClerkID = func(c *gin.Context) (uuid.UUID, bool) {
+			return testUserID, true
+		}
+		defer func() { GetUserIDFromClerkID = originalGetUserID }()
+
+		RevertFlashcardRevision(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetDeckRevisions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		testUserID := uuid.New()
+		testDeckID := uuid.New()
+
+		mock.ExpectQuery(`SELECT labels, title, COALESCE\(description, ''\) FROM decks WHERE id = \$1 AND owner_id = \$2`).
+			WithArgs(testDeckID, testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"labels", "title", "description"}).
+				AddRow(pq.Array([]string{"science"}), "Biology", ""))
+
+		rows := sqlmock.NewRows([]string{"deck_id", "rev", "labels", "title", "description", "edited_by", "created_at"}).
+			AddRow(testDeckID, 1, pq.Array([]string{"science"}), "Bio", "", testUserID, time.Now())
+		mock.ExpectQuery(`SELECT deck_id, rev, labels, title, description, edited_by, created_at FROM deck_revisions WHERE deck_id = \$1 ORDER BY rev`).
+			WithArgs(testDeckID).
+			WillReturnRows(rows)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "id", Value: testDeckID.String()}}
+
+		originalGetUserID := GetUserIDFromClerkID
+		GetUserIDFromClerkID = func(c *gin.Context) (uuid.UUID, bool) {
+			return testUserID, true
+		}
+		defer func() { GetUserIDFromClerkID = originalGetUserID }()
+
+		GetDeckRevisions(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"title":[{"op":"delete","text":"Bio"},{"op":"insert","text":"Biology"}]`)
+	})
+}
+
+func TestRevertDeckRevision(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	database.DB = mockDB
+
+	testUserID := uuid.New()
+	testDeckID := uuid.New()
+	testPresetID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM decks WHERE id = \$1 AND owner_id = \$2 FOR UPDATE`).
+		WithArgs(testDeckID, testUserID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testDeckID))
+	mock.ExpectQuery(`SELECT labels, title, description FROM deck_revisions WHERE deck_id = \$1 AND rev = \$2`).
+		WithArgs(testDeckID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"labels", "title", "description"}).AddRow(pq.Array([]string{"science"}), "Bio", ""))
+	mock.ExpectExec(`INSERT INTO deck_revisions`).
+		WithArgs(testDeckID, testUserID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`UPDATE decks SET labels = \$1, title = \$2, description = \$3 WHERE id = \$4 RETURNING id, owner_id, labels, title, description, `+
+		`to_char\(exam_date, 'YYYY-MM-DD'\), leech_threshold, leech_action, preset_id`).
+		WithArgs(pq.StringArray{"science"}, "Bio", "", testDeckID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id", "labels", "title", "description", "exam_date", "leech_threshold", "leech_action", "preset_id"}).
+			AddRow(testDeckID, testUserID, pq.Array([]string{"science"}), "Bio", "", "2026-06-01", 5, "suspend", testPresetID))
+	mock.ExpectCommit()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{
+		gin.Param{Key: "id", Value: testDeckID.String()},
+		gin.Param{Key: "rev", Value: "1"},
+	}
+
+	originalGetUserID := GetUserIDFromClerkID
+	GetUserIDFromClerkID = func(c *gin.Context) (uuid.UUID, bool) {
+		return testUserID, true
+	}
+	defer func() { GetUserIDFromClerkID = originalGetUserID }()
+
+	RevertDeckRevision(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"title":"Bio"`)
+	assert.Contains(t, w.Body.String(), `"exam_date":"2026-06-01"`)
+	assert.Contains(t, w.Body.String(), `"leech_threshold":5`)
+	assert.Contains(t, w.Body.String(), testPresetID.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,254 @@
+package controllers
+
+import (
+	"api/src/apierror"
+	"api/src/database"
+	"api/src/models"
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// statsReviews are the review logs of the statistics: those of user $1, in deck
+// $2 unless it is NULL, from $3 on
+const statsReviews = `FROM review_logs r
+	 JOIN flashcards f ON f.id = r.flashcard_id
+	 WHERE r.user_id = $1 AND ($2::uuid IS NULL OR f.parent_deck = $2) AND r.reviewed_at >= $3`
+
+// statsCards are the cards of the statistics: those of the decks of user $1,
+// only deck $2 unless it is NULL
+const statsCards = `FROM flashcards f
+	 JOIN decks d ON d.id = f.parent_deck
+	 LEFT JOIN card_states s ON s.flashcard_id = f.id
+	 WHERE d.owner_id = $1 AND ($2::uuid IS NULL OR d.id = $2)`
+
+// statsDays reads the days query parameter, aborting with 400 when it is invalid
+func statsDays(c *gin.Context) (int, bool) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", strconv.Itoa(models.DefaultStatsDays)))
+	if err != nil || days < 1 || days > models.MaxStatsDays {
+		apierror.Abort(c, apierror.BadRequest("days must be between 1 and "+strconv.Itoa(models.MaxStatsDays)))
+		return 0, false
+	}
+	return days, true
+}
+
+// computeStats aggregates the review logs and card states of a user, or of one
+// of their decks when deckID is set, over the last days. Days and hours follow
+// the study clock of the user.
+func computeStats(ctx context.Context, userID uuid.UUID, deckID *uuid.UUID, days int, now time.Time) (models.Stats, error) {
+	clock, err := loadStudyClock(ctx, userID)
+	if err != nil {
+		return models.Stats{}, err
+	}
+	today := clock.day(now)
+	since := today.AddDate(0, 0, 1-days)
+	stats := models.Stats{
+		DeckID:    deckID,
+		Timezone:  clock.timezone,
+		Days:      days,
+		Reviews:   make([]models.DayStats, days),
+		Retention: make([]models.RetentionStats, len(models.RetentionBounds)),
+		Forecast:  make([]models.ForecastDay, models.ForecastDays),
+		Hours:     make([]models.HourStats, 24),
+	}
+
+	byDate := map[string]*models.DayStats{}
+	for i := range stats.Reviews {
+		d := &stats.Reviews[i]
+		d.Date = since.AddDate(0, 0, i).Format(time.DateOnly)
+		byDate[d.Date] = d
+	}
+	rows, err := database.DB.QueryContext(ctx,
+		`SELECT to_char((r.reviewed_at AT TIME ZONE $4) - make_interval(hours => $5), 'YYYY-MM-DD') AS day, COUNT(*),
+		        COUNT(*) FILTER (WHERE r.grade > 1), COALESCE(SUM(r.duration_ms), 0)
+		 `+statsReviews+`
+		 GROUP BY day`,
+		userID, deckID, clock.start(since), clock.timezone, clock.rollover,
+	)
+	if err != nil {
+		return stats, err
+	}
+	err = scanRows(rows, func() error {
+		var date string
+		var d models.DayStats
+		if err := rows.Scan(&date, &d.Reviews, &d.Correct, &d.TimeMs); err != nil {
+			return err
+		}
+		if day, ok := byDate[date]; ok {
+			d.Date = date
+			*day = d
+		}
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+	for _, d := range stats.Reviews {
+		stats.TotalReviews += d.Reviews
+		stats.TimeSpentMs += d.TimeMs
+	}
+
+	// width_bucket numbers the intervals below the second bound 0, those below the third one 1...
+	for i, lower := range models.RetentionBounds {
+		stats.Retention[i].MinInterval = lower
+		if i+1 < len(models.RetentionBounds) {
+			upper := models.RetentionBounds[i+1] - 1
+			stats.Retention[i].MaxInterval = &upper
+		}
+	}
+	rows, err = database.DB.QueryContext(ctx,
+		`SELECT width_bucket(r.interval_before, $4::int[]) AS bucket, COUNT(*), COUNT(*) FILTER (WHERE r.grade > 1)
+		 `+statsReviews+` AND r.state_before = 'review'
+		 GROUP BY bucket`,
+		userID, deckID, clock.start(since), pq.Array(models.RetentionBounds[1:]),
+	)
+	if err != nil {
+		return stats, err
+	}
+	err = scanRows(rows, func() error {
+		var bucket, reviews, recalled int
+		if err := rows.Scan(&bucket, &reviews, &recalled); err != nil {
+			return err
+		}
+		if bucket >= 0 && bucket < len(stats.Retention) {
+			stats.Retention[bucket].Reviews = reviews
+			stats.Retention[bucket].Recalled = recalled
+			stats.Retention[bucket].Rate = models.SuccessRate(recalled, reviews)
+		}
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	for hour := range stats.Hours {
+		stats.Hours[hour].Hour = hour
+	}
+	rows, err = database.DB.QueryContext(ctx,
+		`SELECT EXTRACT(HOUR FROM r.reviewed_at AT TIME ZONE $4)::int AS hour, COUNT(*), COUNT(*) FILTER (WHERE r.grade > 1)
+		 `+statsReviews+`
+		 GROUP BY hour`,
+		userID, deckID, clock.start(since), clock.timezone,
+	)
+	if err != nil {
+		return stats, err
+	}
+	err = scanRows(rows, func() error {
+		var hour, reviews, correct int
+		if err := rows.Scan(&hour, &reviews, &correct); err != nil {
+			return err
+		}
+		if hour >= 0 && hour < len(stats.Hours) {
+			stats.Hours[hour] = models.HourStats{Hour: hour, Reviews: reviews, Correct: correct, SuccessRate: models.SuccessRate(correct, reviews)}
+		}
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	err = database.DB.QueryRowContext(ctx,
+		`SELECT COUNT(*) FILTER (WHERE s.suspended_at IS NULL AND COALESCE(s.state, 'new') = 'new'),
+		        COUNT(*) FILTER (WHERE s.suspended_at IS NULL AND s.state IN ('learning', 'relearning')),
+		        COUNT(*) FILTER (WHERE s.suspended_at IS NULL AND s.state = 'review' AND s.interval_days < $3),
+		        COUNT(*) FILTER (WHERE s.suspended_at IS NULL AND s.state = 'review' AND s.interval_days >= $3),
+		        COUNT(*) FILTER (WHERE s.suspended_at IS NOT NULL)
+		 `+statsCards,
+		userID, deckID, models.MatureInterval,
+	).Scan(&stats.Cards.New, &stats.Cards.Learning, &stats.Cards.Young, &stats.Cards.Mature, &stats.Cards.Suspended)
+	if err != nil {
+		return stats, err
+	}
+
+	due := map[string]*models.ForecastDay{}
+	for i := range stats.Forecast {
+		d := &stats.Forecast[i]
+		d.Date = today.AddDate(0, 0, i).Format(time.DateOnly)
+		due[d.Date] = d
+	}
+	rows, err = database.DB.QueryContext(ctx,
+		`SELECT to_char((GREATEST(s.due_at, $3) AT TIME ZONE $5) - make_interval(hours => $6), 'YYYY-MM-DD') AS day, COUNT(*)
+		 `+statsCards+` AND s.state <> 'new' AND s.suspended_at IS NULL AND s.due_at < $4
+		 GROUP BY day`,
+		userID, deckID, clock.start(today), clock.start(today.AddDate(0, 0, models.ForecastDays)), clock.timezone, clock.rollover,
+	)
+	if err != nil {
+		return stats, err
+	}
+	err = scanRows(rows, func() error {
+		var date string
+		var n int
+		if err := rows.Scan(&date, &n); err != nil {
+			return err
+		}
+		if d, ok := due[date]; ok {
+			d.Due = n
+		}
+		return nil
+	})
+	return stats, err
+}
+
+// GetStats returns the learning statistics of the authenticated user
+func GetStats(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+	days, ok := statsDays(c)
+	if !ok {
+		return
+	}
+
+	stats, err := computeStats(c.Request.Context(), userID, nil, days, time.Now())
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetDeckStats returns the learning statistics of a deck
+func GetDeckStats(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	deckID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidID("Deck"))
+		return
+	}
+	days, ok := statsDays(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	var exists bool
+	err = database.DB.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM decks WHERE id = $1 AND owner_id = $2)",
+		deckID, userID,
+	).Scan(&exists)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	if !exists {
+		apierror.Abort(c, apierror.NotFound("Deck not found or access denied"))
+		return
+	}
+
+	stats, err := computeStats(ctx, userID, &deckID, days, time.Now())
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
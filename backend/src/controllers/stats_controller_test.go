@@ -0,0 +1,148 @@
+package controllers
+
+import (
+	"api/src/models"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// expectStats expects the queries of computeStats, with a few reviews on day
+// and none on the other days
+func expectStats(mock sqlmock.Sqlmock, userID uuid.UUID, deckID any, timezone string, rollover int, day string) {
+	goal := models.DefaultStudyGoal()
+	goal.DayRollover = rollover
+	expectStudyGoal(mock, userID, timezone, goal)
+	mock.ExpectQuery(`SELECT to_char\(\(r.reviewed_at AT TIME ZONE \$4\) - make_interval\(hours => \$5\), 'YYYY-MM-DD'\) AS day, COUNT\(\*\), `+
+		`COUNT\(\*\) FILTER \(WHERE r.grade > 1\), COALESCE\(SUM\(r.duration_ms\), 0\) FROM review_logs r JOIN flashcards f ON f.id = r.flashcard_id `+
+		`WHERE r.user_id = \$1 AND \(\$2::uuid IS NULL OR f.parent_deck = \$2\) AND r.reviewed_at >= \$3 GROUP BY day`).
+		WithArgs(userID, deckID, sqlmock.AnyArg(), timezone, rollover).
+		WillReturnRows(sqlmock.NewRows([]string{"day", "count", "correct", "duration"}).AddRow(day, 4, 3, 20000))
+	mock.ExpectQuery(`SELECT width_bucket\(r.interval_before, \$4::int\[\]\) AS bucket, .* AND r.state_before = 'review' GROUP BY bucket`).
+		WithArgs(userID, deckID, sqlmock.AnyArg(), pq.Array([]int{7, 21, 90})).
+		WillReturnRows(sqlmock.NewRows([]string{"bucket", "count", "recalled"}).AddRow(0, 2, 1).AddRow(3, 1, 1))
+	mock.ExpectQuery(`SELECT EXTRACT\(HOUR FROM r.reviewed_at AT TIME ZONE \$4\)::int AS hour, .* GROUP BY hour`).
+		WithArgs(userID, deckID, sqlmock.AnyArg(), timezone).
+		WillReturnRows(sqlmock.NewRows([]string{"hour", "count", "correct"}).AddRow(9, 4, 3))
+	mock.ExpectQuery(`FROM flashcards f JOIN decks d ON d.id = f.parent_deck LEFT JOIN card_states s ON s.flashcard_id = f.id `+
+		`WHERE d.owner_id = \$1 AND \(\$2::uuid IS NULL OR d.id = \$2\)$`).
+		WithArgs(userID, deckID, models.MatureInterval).
+		WillReturnRows(sqlmock.NewRows([]string{"new", "learning", "young", "mature", "suspended"}).AddRow(10, 2, 5, 3, 1))
+	mock.ExpectQuery(`SELECT to_char\(\(GREATEST\(s.due_at, \$3\) AT TIME ZONE \$5\) - make_interval\(hours => \$6\), 'YYYY-MM-DD'\) AS day, COUNT\(\*\) .* `+
+		`AND s.state <> 'new' AND s.suspended_at IS NULL AND s.due_at < \$4 GROUP BY day`).
+		WithArgs(userID, deckID, sqlmock.AnyArg(), sqlmock.AnyArg(), timezone, rollover).
+		WillReturnRows(sqlmock.NewRows([]string{"day", "due"}).AddRow(day, 6))
+}
+
+func TestComputeStats(t *testing.T) {
+	_, c, mock, testUserID, _ := setupDuplicates(t, "GET", "/", "")
+	// 01:00 in Tokyo, before the rollover: still the study day of the 9th
+	now := time.Date(2026, 3, 9, 16, 0, 0, 0, time.UTC)
+	expectStats(mock, testUserID, nil, "Asia/Tokyo", 4, "2026-03-09")
+
+	stats, err := computeStats(c.Request.Context(), testUserID, nil, 7, now)
+	require.NoError(t, err)
+	assert.Nil(t, stats.DeckID)
+	assert.Equal(t, "Asia/Tokyo", stats.Timezone)
+	if assert.Len(t, stats.Reviews, 7) {
+		assert.Equal(t, "2026-03-03", stats.Reviews[0].Date)
+		assert.Equal(t, models.DayStats{Date: "2026-03-09", Reviews: 4, Correct: 3, TimeMs: 20000}, stats.Reviews[6])
+	}
+	assert.Equal(t, 4, stats.TotalReviews)
+	assert.Equal(t, int64(20000), stats.TimeSpentMs)
+	if assert.Len(t, stats.Retention, 4) {
+		assert.Equal(t, 1, stats.Retention[0].MinInterval)
+		assert.Equal(t, 6, *stats.Retention[0].MaxInterval)
+		assert.Equal(t, 0.5, stats.Retention[0].Rate)
+		assert.Zero(t, stats.Retention[1].Reviews)
+		assert.Nil(t, stats.Retention[3].MaxInterval)
+		assert.Equal(t, 1.0, stats.Retention[3].Rate)
+	}
+	assert.Equal(t, models.CardCounts{New: 10, Learning: 2, Young: 5, Mature: 3, Suspended: 1}, stats.Cards)
+	if assert.Len(t, stats.Forecast, models.ForecastDays) {
+		assert.Equal(t, models.ForecastDay{Date: "2026-03-09", Due: 6}, stats.Forecast[0])
+		assert.Equal(t, "2026-04-07", stats.Forecast[models.ForecastDays-1].Date)
+	}
+	if assert.Len(t, stats.Hours, 24) {
+		assert.Equal(t, 0.75, stats.Hours[9].SuccessRate)
+		assert.Equal(t, 23, stats.Hours[23].Hour)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetStats(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		w, c, mock, testUserID, _ := setupDuplicates(t, "GET", "/?days=14", "")
+		expectStats(mock, testUserID, nil, "UTC", 0, time.Now().UTC().Format(time.DateOnly))
+
+		GetStats(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var stats models.Stats
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+		assert.Equal(t, 14, stats.Days)
+		assert.Len(t, stats.Reviews, 14)
+		assert.Equal(t, 4, stats.TotalReviews)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("invalid days", func(t *testing.T) {
+		w, c, _, _, _ := setupDuplicates(t, "GET", "/?days=1000", "")
+
+		GetStats(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "days must be between 1 and 365")
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		w, c, mock, testUserID, _ := setupDuplicates(t, "GET", "/", "")
+		expectStudyGoal(mock, testUserID, "UTC", models.DefaultStudyGoal())
+		mock.ExpectQuery(`FROM review_logs r`).
+			WillReturnError(errors.New("connection lost"))
+
+		GetStats(c)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestGetDeckStats(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		w, c, mock, testUserID, testDeckID := setupDuplicates(t, "GET", "/", "")
+		mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM decks WHERE id = \$1 AND owner_id = \$2\)`).
+			WithArgs(testDeckID, testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		expectStats(mock, testUserID, testDeckID, "UTC", 0, time.Now().UTC().Format(time.DateOnly))
+
+		GetDeckStats(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var stats models.Stats
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+		assert.Equal(t, testDeckID, *stats.DeckID)
+		assert.Equal(t, models.DefaultStatsDays, stats.Days)
+		assert.Equal(t, 6, stats.Forecast[0].Due)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("deck of another user", func(t *testing.T) {
+		w, c, mock, testUserID, testDeckID := setupDuplicates(t, "GET", "/", "")
+		mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM decks`).
+			WithArgs(testDeckID, testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+		GetDeckStats(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
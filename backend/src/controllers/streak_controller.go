@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"api/src/apierror"
+	"api/src/database"
+	"api/src/models"
+	"api/src/streak"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// loadStudyGoal returns the daily goal and the timezone of a user, the
+// default goal when they haven't set one
+func loadStudyGoal(ctx context.Context, userID uuid.UUID) (models.StudyGoal, string, error) {
+	goal := models.DefaultStudyGoal()
+	var timezone string
+	err := database.DB.QueryRowContext(ctx,
+		`SELECT u.timezone, COALESCE(g.unit, $2), COALESCE(g.target, $3), COALESCE(g.day_rollover, $4)
+		 FROM users u
+		 LEFT JOIN study_goals g ON g.user_id = u.id
+		 WHERE u.id = $1`,
+		userID, goal.Unit, goal.Target, goal.DayRollover,
+	).Scan(&timezone, &goal.Unit, &goal.Target, &goal.DayRollover)
+	return goal, timezone, err
+}
+
+// studyClock places instants in the study days of a user, which start at the
+// rollover hour of their goal in their timezone
+type studyClock struct {
+	goal     models.StudyGoal
+	timezone string
+	loc      *time.Location
+	rollover int
+}
+
+// loadStudyClock returns the study clock of a user
+func loadStudyClock(ctx context.Context, userID uuid.UUID) (studyClock, error) {
+	goal, timezone, err := loadStudyGoal(ctx, userID)
+	if err != nil {
+		return studyClock{}, err
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return studyClock{}, err
+	}
+	return studyClock{goal: goal, timezone: timezone, loc: loc, rollover: goal.DayRollover}, nil
+}
+
+// day returns the study day of t, see streak.Day
+func (s studyClock) day(t time.Time) time.Time {
+	return streak.Day(t, s.loc, s.rollover)
+}
+
+// start returns the instant a study day starts, see streak.Start
+func (s studyClock) start(day time.Time) time.Time {
+	return streak.Start(day, s.loc, s.rollover)
+}
+
+// GetStudyGoal returns the daily goal of the authenticated user
+func GetStudyGoal(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	goal, _, err := loadStudyGoal(c.Request.Context(), userID)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, goal)
+}
+
+// UpdateStudyGoal sets the daily goal of the authenticated user. Fields left
+// out get the values of models.DefaultStudyGoal.
+func UpdateStudyGoal(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	goal := models.DefaultStudyGoal()
+	if err := c.ShouldBindJSON(&goal); err != nil {
+		apierror.Abort(c, apierror.Invalid(err))
+		return
+	}
+	if err := goal.Validate(); err != nil {
+		apierror.Abort(c, apierror.Invalid(err))
+		return
+	}
+
+	_, err := database.DB.ExecContext(c.Request.Context(),
+		`INSERT INTO study_goals (user_id, unit, target, day_rollover) VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (user_id) DO UPDATE SET unit = EXCLUDED.unit, target = EXCLUDED.target,
+		     day_rollover = EXCLUDED.day_rollover, updated_at = NOW()`,
+		userID, goal.Unit, goal.Target, goal.DayRollover,
+	)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, goal)
+}
+
+// GetStreak returns the current and longest streaks of the authenticated user
+// and the heatmap of their last year, in study days of their timezone
+func GetStreak(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	clock, err := loadStudyClock(ctx, userID)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	// Every day ever studied: the streak may have started before the heatmap
+	rows, err := database.DB.QueryContext(ctx,
+		`SELECT to_char((r.reviewed_at AT TIME ZONE $2) - make_interval(hours => $3), 'YYYY-MM-DD') AS day,
+		        COUNT(*), COALESCE(SUM(r.duration_ms), 0)
+		 FROM review_logs r
+		 WHERE r.user_id = $1
+		 GROUP BY day
+		 ORDER BY day`,
+		userID, clock.timezone, clock.rollover,
+	)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	defer rows.Close()
+
+	var days []models.StudyDay
+	for rows.Next() {
+		var d models.StudyDay
+		if err := rows.Scan(&d.Date, &d.Cards, &d.TimeMs); err != nil {
+			apierror.Abort(c, err)
+			return
+		}
+		days = append(days, d)
+	}
+	if err := rows.Err(); err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	s := streak.Compute(clock.goal, days, clock.day(time.Now()))
+	s.Timezone = clock.timezone
+	c.JSON(http.StatusOK, s)
+}
@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"api/src/models"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// expectStudyGoal expects loadStudyGoal to find the goal of a user in timezone
+func expectStudyGoal(mock sqlmock.Sqlmock, userID uuid.UUID, timezone string, goal models.StudyGoal) {
+	defaults := models.DefaultStudyGoal()
+	mock.ExpectQuery(`SELECT u.timezone, COALESCE\(g.unit, \$2\), COALESCE\(g.target, \$3\), COALESCE\(g.day_rollover, \$4\) `+
+		`FROM users u LEFT JOIN study_goals g ON g.user_id = u.id WHERE u.id = \$1`).
+		WithArgs(userID, defaults.Unit, defaults.Target, defaults.DayRollover).
+		WillReturnRows(sqlmock.NewRows([]string{"timezone", "unit", "target", "day_rollover"}).
+			AddRow(timezone, goal.Unit, goal.Target, goal.DayRollover))
+}
+
+func TestGetStudyGoal(t *testing.T) {
+	w, c, mock, testUserID, _ := setupDuplicates(t, "GET", "/", "")
+	expectStudyGoal(mock, testUserID, "UTC", models.DefaultStudyGoal())
+
+	GetStudyGoal(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var goal models.StudyGoal
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &goal))
+	assert.Equal(t, models.DefaultStudyGoal(), goal)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateStudyGoal(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		w, c, mock, testUserID, _ := setupDuplicates(t, "PUT", "/", `{"unit":"minutes","target":15}`)
+
+		mock.ExpectExec(`INSERT INTO study_goals \(user_id, unit, target, day_rollover\) VALUES \(\$1, \$2, \$3, \$4\) `+
+			`ON CONFLICT \(user_id\) DO UPDATE SET unit = EXCLUDED.unit`).
+			WithArgs(testUserID, models.GoalMinutes, 15, 4).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		UpdateStudyGoal(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		w, c, _, _, _ := setupDuplicates(t, "PUT", "/", `{"unit":"cards","target":0}`)
+
+		UpdateStudyGoal(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "target must be positive")
+	})
+}
+
+func TestGetStreak(t *testing.T) {
+	w, c, mock, testUserID, _ := setupDuplicates(t, "GET", "/", "")
+	goal := models.StudyGoal{Unit: models.GoalCards, Target: 10, DayRollover: 0}
+	expectStudyGoal(mock, testUserID, "Asia/Tokyo", goal)
+
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	today := time.Now().In(tokyo)
+	rows := sqlmock.NewRows([]string{"day", "cards", "duration"})
+	for i := 3; i >= 0; i-- {
+		rows.AddRow(today.AddDate(0, 0, -i).Format(time.DateOnly), 12, 60000)
+	}
+	mock.ExpectQuery(`SELECT to_char\(\(r.reviewed_at AT TIME ZONE \$2\) - make_interval\(hours => \$3\), 'YYYY-MM-DD'\) AS day, `+
+		`COUNT\(\*\), COALESCE\(SUM\(r.duration_ms\), 0\) FROM review_logs r WHERE r.user_id = \$1 GROUP BY day ORDER BY day`).
+		WithArgs(testUserID, "Asia/Tokyo", 0).
+		WillReturnRows(rows)
+
+	GetStreak(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var s models.Streak
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &s))
+	assert.Equal(t, "Asia/Tokyo", s.Timezone)
+	assert.Equal(t, 4, s.Current)
+	assert.Equal(t, 4, s.Longest)
+	assert.Equal(t, today.Format(time.DateOnly), s.Today.Date)
+	assert.True(t, s.Today.GoalMet)
+	assert.Len(t, s.Heatmap, models.HeatmapDays)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
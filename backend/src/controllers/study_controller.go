@@ -0,0 +1,848 @@
+package controllers
+
+import (
+	"api/src/apierror"
+	"api/src/database"
+	"api/src/grading"
+	"api/src/models"
+	"api/src/scheduler"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// StudySessionTimeout is how long an active study session can go without an
+// answer before it is abandoned
+var StudySessionTimeout = 30 * time.Minute
+
+const studySessionColumns = "id, user_id, deck_ids, mode, new_limit, review_limit, card_limit, card_ids, position, status, started_at, last_activity_at, finished_at"
+
+// querier runs queries on the database or within a transaction
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func scanStudySession(row interface{ Scan(...any) error }, s *models.StudySession) error {
+	return row.Scan(
+		&s.ID, &s.UserID, pq.Array(&s.DeckIDs), &s.Mode, &s.NewLimit, &s.ReviewLimit, &s.Limit,
+		pq.Array(&s.CardIDs), &s.Position, &s.Status, &s.StartedAt, &s.LastActivityAt, &s.FinishedAt,
+	)
+}
+
+// getStudySession loads a study session of a user, locking it when lock is set
+func getStudySession(ctx context.Context, q querier, sessionID, userID uuid.UUID, lock bool) (models.StudySession, error) {
+	query := "SELECT " + studySessionColumns + " FROM study_sessions WHERE id = $1 AND user_id = $2"
+	if lock {
+		query += " FOR UPDATE"
+	}
+	var s models.StudySession
+	err := scanStudySession(q.QueryRowContext(ctx, query, sessionID, userID), &s)
+	return s, err
+}
+
+// queryIDs returns the IDs selected by query
+func queryIDs(ctx context.Context, q querier, query string, args ...any) ([]uuid.UUID, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// studyable leaves suspended and buried cards out of study; s is the
+// card_states row of the card, NULL for cards that were never studied
+const studyable = "s.suspended_at IS NULL AND (s.buried_until IS NULL OR s.buried_until <= NOW())"
+
+// studySequence builds the card sequence of a new session of a user. Scheduled
+// sessions study the due cards, oldest first, then new cards; practice sessions
+// every card.
+func studySequence(ctx context.Context, userID uuid.UUID, req *models.StudySessionRequest) ([]uuid.UUID, error) {
+	switch req.Mode {
+	case models.StudyModePractice:
+		return queryIDs(ctx, database.DB,
+			`SELECT f.id FROM flashcards f
+			 LEFT JOIN card_states s ON s.flashcard_id = f.id
+			 WHERE f.parent_deck = ANY($1) AND `+studyable+`
+			 ORDER BY f.parent_deck, f.id`,
+			pq.Array(req.DeckIDs),
+		)
+	case models.StudyModeCram:
+		return cramSequence(ctx, userID, *req)
+	}
+	return scheduledSequence(ctx, userID, req)
+}
+
+// scheduledSequence builds the card sequence of a scheduled session within
+// the daily limits of the preset of every deck, counting the cards already
+// studied on the study day of the user. The limits of the request cap the
+// whole session; they are set to the number of cards it got.
+func scheduledSequence(ctx context.Context, userID uuid.UUID, req *models.StudySessionRequest) ([]uuid.UUID, error) {
+	presets, err := loadDeckPresets(ctx, database.DB, req.DeckIDs)
+	if err != nil {
+		return nil, err
+	}
+	clock, err := loadStudyClock(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := database.DB.QueryContext(ctx,
+		`SELECT f.parent_deck,
+		        COUNT(DISTINCT r.flashcard_id) FILTER (WHERE r.state_before = 'new'),
+		        COUNT(DISTINCT r.flashcard_id) FILTER (WHERE r.state_before <> 'new')
+		 FROM review_logs r
+		 JOIN flashcards f ON f.id = r.flashcard_id
+		 WHERE f.parent_deck = ANY($1) AND r.scheduled AND r.reviewed_at >= $2
+		 GROUP BY f.parent_deck`,
+		pq.Array(req.DeckIDs), clock.start(clock.day(time.Now())),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	type studied struct{ fresh, due int }
+	today := map[uuid.UUID]studied{}
+	for rows.Next() {
+		var deckID uuid.UUID
+		var s studied
+		if err := rows.Scan(&deckID, &s.fresh, &s.due); err != nil {
+			return nil, err
+		}
+		today[deckID] = s
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	freshQuotas := make([]int, len(req.DeckIDs))
+	dueQuotas := make([]int, len(req.DeckIDs))
+	random := make([]bool, len(req.DeckIDs))
+	for i, id := range req.DeckIDs {
+		p := presets[id]
+		freshQuotas[i] = max(0, p.NewPerDay-today[id].fresh)
+		dueQuotas[i] = max(0, p.ReviewsPerDay-today[id].due)
+		random[i] = p.NewOrder == models.NewOrderRandom
+	}
+
+	// A NULL limit is no limit
+	due, err := queryIDs(ctx, database.DB,
+		`SELECT c.id FROM (
+		     SELECT f.id, s.due_at, q.quota, ROW_NUMBER() OVER (PARTITION BY f.parent_deck ORDER BY s.due_at, f.id) AS n
+		     FROM flashcards f
+		     JOIN unnest($1::uuid[], $2::int[]) AS q(deck_id, quota) ON q.deck_id = f.parent_deck
+		     JOIN card_states s ON s.flashcard_id = f.id
+		     WHERE s.state <> 'new' AND s.due_at <= NOW() AND `+studyable+`
+		 ) c
+		 WHERE c.n <= c.quota
+		 ORDER BY c.due_at, c.id LIMIT $3`,
+		pq.Array(req.DeckIDs), pq.Array(dueQuotas), req.ReviewLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	fresh, err := queryIDs(ctx, database.DB,
+		`SELECT c.id FROM (
+		     SELECT f.id, f.parent_deck, q.quota,
+		            ROW_NUMBER() OVER (PARTITION BY f.parent_deck ORDER BY CASE WHEN q.random THEN random() END, f.id) AS n
+		     FROM flashcards f
+		     JOIN unnest($1::uuid[], $2::int[], $3::boolean[]) AS q(deck_id, quota, random) ON q.deck_id = f.parent_deck
+		     LEFT JOIN card_states s ON s.flashcard_id = f.id
+		     WHERE (s.state IS NULL OR s.state = 'new') AND `+studyable+`
+		 ) c
+		 WHERE c.n <= c.quota
+		 ORDER BY c.parent_deck, c.n LIMIT $4`,
+		pq.Array(req.DeckIDs), pq.Array(freshQuotas), pq.Array(random), req.NewLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	newLimit, reviewLimit := len(fresh), len(due)
+	req.NewLimit, req.ReviewLimit = &newLimit, &reviewLimit
+	return append(due, fresh...), nil
+}
+
+// errNoUpcomingExam is returned for cram sessions on decks without an exam ahead
+var errNoUpcomingExam = errors.New("deck without an upcoming exam")
+
+// cramSequence builds the card sequence of a cram session: the due cards,
+// starred and weak cards first, then the new cards of the day of every deck,
+// starred first, paced so every card is learned before the exam. New cards
+// already learned on the study day of the user count towards the day.
+func cramSequence(ctx context.Context, userID uuid.UUID, req models.StudySessionRequest) ([]uuid.UUID, error) {
+	clock, err := loadStudyClock(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	today := clock.day(time.Now())
+
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT id, exam_date - $2::date FROM decks WHERE id = ANY($1) AND exam_date > $2::date",
+		pq.Array(req.DeckIDs), today.Format(time.DateOnly),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	daysLeft := map[uuid.UUID]int{}
+	for rows.Next() {
+		var id uuid.UUID
+		var days int
+		if err := rows.Scan(&id, &days); err != nil {
+			return nil, err
+		}
+		daysLeft[id] = days
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(daysLeft) != len(req.DeckIDs) {
+		return nil, errNoUpcomingExam
+	}
+
+	due, err := queryIDs(ctx, database.DB,
+		`SELECT f.id FROM flashcards f
+		 JOIN card_states s ON s.flashcard_id = f.id
+		 WHERE f.parent_deck = ANY($1) AND s.state <> 'new' AND s.due_at <= NOW() AND `+studyable+`
+		 ORDER BY f.starred DESC, s.lapses DESC, s.ease, s.due_at, f.id LIMIT $2`,
+		pq.Array(req.DeckIDs), *req.ReviewLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = database.DB.QueryContext(ctx,
+		`SELECT f.id, f.parent_deck FROM flashcards f
+		 LEFT JOIN card_states s ON s.flashcard_id = f.id
+		 WHERE f.parent_deck = ANY($1) AND (s.state IS NULL OR s.state = 'new') AND `+studyable+`
+		 ORDER BY f.parent_deck, f.starred DESC, f.id`,
+		pq.Array(req.DeckIDs),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	fresh := map[uuid.UUID][]uuid.UUID{}
+	for rows.Next() {
+		var id, deckID uuid.UUID
+		if err := rows.Scan(&id, &deckID); err != nil {
+			return nil, err
+		}
+		fresh[deckID] = append(fresh[deckID], id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = database.DB.QueryContext(ctx,
+		`SELECT f.parent_deck, COUNT(DISTINCT r.flashcard_id)
+		 FROM review_logs r
+		 JOIN flashcards f ON f.id = r.flashcard_id
+		 WHERE f.parent_deck = ANY($1) AND r.scheduled AND r.state_before = 'new' AND r.reviewed_at >= $2
+		 GROUP BY f.parent_deck`,
+		pq.Array(req.DeckIDs), clock.start(today),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	learned := map[uuid.UUID]int{}
+	for rows.Next() {
+		var deckID uuid.UUID
+		var n int
+		if err := rows.Scan(&deckID, &n); err != nil {
+			return nil, err
+		}
+		learned[deckID] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// The quota of the day is paced from the new cards left when it began
+	ids := due
+	for _, deckID := range req.DeckIDs {
+		n := scheduler.NewPerDay(len(fresh[deckID])+learned[deckID], daysLeft[deckID]) - learned[deckID]
+		ids = append(ids, fresh[deckID][:max(0, min(n, len(fresh[deckID])))]...)
+	}
+	return ids, nil
+}
+
+// studyCards returns the flashcards of a card sequence in order.
+// Flashcards deleted since the sequence was built are skipped.
+func studyCards(ctx context.Context, q querier, ids []uuid.UUID) ([]models.Flashcard, error) {
+	cards := []models.Flashcard{}
+	if len(ids) == 0 {
+		return cards, nil
+	}
+	rows, err := q.QueryContext(ctx,
+		"SELECT id, parent_deck, starred, front, back FROM flashcards WHERE id = ANY($1)",
+		pq.Array(ids),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byID := map[uuid.UUID]models.Flashcard{}
+	for rows.Next() {
+		var f models.Flashcard
+		if err := rows.Scan(&f.ID, &f.ParentDeck, &f.Starred, &f.Front, &f.Back); err != nil {
+			return nil, err
+		}
+		byID[f.ID] = f
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		if f, ok := byID[id]; ok {
+			cards = append(cards, f)
+		}
+	}
+	return cards, nil
+}
+
+// studySummary sums up the review logs of a session
+func studySummary(ctx context.Context, q querier, s models.StudySession) (*models.StudySummary, error) {
+	var summary models.StudySummary
+	err := q.QueryRowContext(ctx,
+		`SELECT COUNT(*),
+		        COUNT(*) FILTER (WHERE grade > 1),
+		        COALESCE(SUM(duration_ms), 0),
+		        COUNT(DISTINCT flashcard_id),
+		        COUNT(*) FILTER (WHERE scheduled AND state_before IN ('new', 'learning') AND state_after = 'review'),
+		        COUNT(*) FILTER (WHERE scheduled AND state_before = 'review' AND grade = 1)
+		 FROM review_logs WHERE session_id = $1`,
+		s.ID,
+	).Scan(&summary.Answers, &summary.Correct, &summary.StudyTimeMs, &summary.CardsStudied, &summary.CardsLearned, &summary.CardsLapsed)
+	if err != nil {
+		return nil, err
+	}
+	if summary.Answers > 0 {
+		summary.Accuracy = float64(summary.Correct) / float64(summary.Answers)
+	}
+	if s.FinishedAt != nil {
+		summary.ElapsedMs = s.FinishedAt.Sub(s.StartedAt).Milliseconds()
+	}
+	return &summary, nil
+}
+
+// StartStudySession builds the card sequence of a new study session over one or more decks
+func StartStudySession(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	var req models.StudySessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Invalid(err))
+		return
+	}
+	if err := req.Validate(); err != nil {
+		apierror.Abort(c, apierror.Invalid(err))
+		return
+	}
+
+	seen := map[uuid.UUID]bool{}
+	var deckIDs []uuid.UUID
+	for _, id := range req.DeckIDs {
+		if !seen[id] {
+			seen[id] = true
+			deckIDs = append(deckIDs, id)
+		}
+	}
+	req.DeckIDs = deckIDs
+
+	ctx := c.Request.Context()
+	var owned int
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM decks WHERE id = ANY($1) AND owner_id = $2",
+		pq.Array(req.DeckIDs), userID,
+	).Scan(&owned)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	if owned != len(req.DeckIDs) {
+		apierror.Abort(c, apierror.NotFound("Deck not found or access denied"))
+		return
+	}
+
+	// Scheduled sessions follow the daily limits of the decks instead
+	if req.Mode != models.StudyModeScheduled {
+		if req.NewLimit == nil {
+			n := models.DefaultNewLimit
+			req.NewLimit = &n
+		}
+		if req.ReviewLimit == nil {
+			n := models.DefaultReviewLimit
+			req.ReviewLimit = &n
+		}
+	}
+	cardIDs, err := studySequence(ctx, userID, &req)
+	if errors.Is(err, errNoUpcomingExam) {
+		apierror.Abort(c, apierror.Conflict("Cram sessions need decks with an upcoming exam_date"))
+		return
+	}
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	if req.Shuffle {
+		rand.Shuffle(len(cardIDs), func(i, j int) { cardIDs[i], cardIDs[j] = cardIDs[j], cardIDs[i] })
+	}
+	if req.Limit > 0 && len(cardIDs) > req.Limit {
+		cardIDs = cardIDs[:req.Limit]
+	}
+	if len(cardIDs) == 0 {
+		apierror.Abort(c, apierror.Conflict("No cards to study in these decks"))
+		return
+	}
+
+	createStudySession(c, models.StudySession{
+		UserID:      userID,
+		DeckIDs:     req.DeckIDs,
+		Mode:        req.Mode,
+		NewLimit:    *req.NewLimit,
+		ReviewLimit: *req.ReviewLimit,
+		Limit:       req.Limit,
+		CardIDs:     cardIDs,
+	})
+}
+
+// createStudySession saves a new study session and responds with it and its cards
+func createStudySession(c *gin.Context, s models.StudySession) {
+	ctx := c.Request.Context()
+	err := database.DB.QueryRowContext(ctx,
+		`INSERT INTO study_sessions (user_id, deck_ids, mode, new_limit, review_limit, card_limit, card_ids)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 RETURNING id, position, status, started_at, last_activity_at`,
+		s.UserID, pq.Array(s.DeckIDs), s.Mode, s.NewLimit, s.ReviewLimit, s.Limit, pq.Array(s.CardIDs),
+	).Scan(&s.ID, &s.Position, &s.Status, &s.StartedAt, &s.LastActivityAt)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	s.Cards, err = studyCards(ctx, database.DB, s.CardIDs)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, s)
+}
+
+// GetStudySession returns a study session with the cards left to study,
+// or the summary of a closed session
+func GetStudySession(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidID("Study session"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	s, err := getStudySession(ctx, database.DB, sessionID, userID, false)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Abort(c, apierror.NotFound("Study session not found"))
+			return
+		}
+		apierror.Abort(c, err)
+		return
+	}
+
+	if s.Status == models.StudySessionActive {
+		s.Cards, err = studyCards(ctx, database.DB, s.CardIDs[s.Position:])
+	} else {
+		s.Cards = []models.Flashcard{}
+		s.Summary, err = studySummary(ctx, database.DB, s)
+	}
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, s)
+}
+
+// maxAnswerBody bounds the size of an answer, a typed answer included
+const maxAnswerBody = 16 << 10
+
+// AnswerStudyCard records the answer to the next card of a study session.
+// Typed answers are graded against the back of the card. In scheduled and
+// cram sessions the answer reschedules the card; cards answered Again, or
+// still in learning, come back at the end of the session. Cards suspended or
+// buried since the session started are skipped. The preset of the deck of the
+// card gives the scheduler settings and caps the answer time.
+func AnswerStudyCard(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidID("Study session"))
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxAnswerBody)
+	var answer models.StudyAnswer
+	if err := c.ShouldBindJSON(&answer); err != nil {
+		apierror.Abort(c, apierror.Invalid(err))
+		return
+	}
+	if err := answer.Validate(); err != nil {
+		apierror.Abort(c, apierror.Invalid(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	defer tx.Rollback()
+
+	s, ok := lockActiveStudySession(c, tx, sessionID, userID)
+	if !ok {
+		return
+	}
+
+	remaining, err := studyCards(ctx, tx, s.CardIDs[s.Position:])
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	if len(remaining) == 0 {
+		apierror.Abort(c, apierror.Conflict("No cards left in this study session"))
+		return
+	}
+	if remaining[0].ID != answer.FlashcardID {
+		apierror.Abort(c, apierror.Conflict(fmt.Sprintf("The next card of this study session is %s", remaining[0].ID)))
+		return
+	}
+	index := s.Position + slices.Index(s.CardIDs[s.Position:], answer.FlashcardID)
+
+	var check *grading.Result
+	if answer.Typed != nil {
+		result := grading.Check(*answer.Typed, remaining[0].Back)
+		check = &result
+		answer.Grade = result.Grade
+	}
+
+	state, err := lockCardState(ctx, tx, answer.FlashcardID)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	deckID := remaining[0].ParentDeck
+	presets, err := loadDeckPresets(ctx, tx, []uuid.UUID{deckID})
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	preset := presets[deckID]
+	answer.DurationMs = min(answer.DurationMs, preset.MaxAnswerSeconds*1000)
+
+	now := time.Now().UTC()
+	before := state.Scheduler()
+	after := before
+	scheduled := s.Mode != models.StudyModePractice
+	if scheduled {
+		settings := preset.Settings()
+		after = settings.Review(before, answer.Grade, now)
+		if s.Mode == models.StudyModeCram {
+			var exam sql.NullTime
+			err := tx.QueryRowContext(ctx, "SELECT exam_date FROM decks WHERE id = $1", deckID).Scan(&exam)
+			if err != nil {
+				apierror.Abort(c, err)
+				return
+			}
+			if exam.Valid {
+				after = settings.Cram(before, answer.Grade, now, exam.Time)
+			}
+		}
+		flags := state
+		state = models.CardStateOf(answer.FlashcardID, after)
+		state.Leech, state.SuspendedAt, state.BuriedUntil = flags.Leech, flags.SuspendedAt, flags.BuriedUntil
+		if err := saveCardState(ctx, tx, state, now); err != nil {
+			apierror.Abort(c, err)
+			return
+		}
+		if after.Lapses > before.Lapses {
+			if err := checkLeech(ctx, tx, &state, deckID, now); err != nil {
+				apierror.Abort(c, err)
+				return
+			}
+		}
+	}
+
+	review := models.ReviewLog{
+		UserID:         userID,
+		FlashcardID:    answer.FlashcardID,
+		SessionID:      &s.ID,
+		Grade:          answer.Grade,
+		TypedAnswer:    answer.Typed,
+		Scheduled:      scheduled,
+		StateBefore:    before.State,
+		StateAfter:     after.State,
+		IntervalBefore: before.Interval,
+		IntervalAfter:  after.Interval,
+		Ease:           after.Ease,
+		DurationMs:     answer.DurationMs,
+		ReviewedAt:     now,
+	}
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO review_logs (user_id, flashcard_id, session_id, grade, typed_answer, scheduled, state_before, state_after,
+		                          interval_before, interval_after, ease, duration_ms, reviewed_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING id`,
+		review.UserID, review.FlashcardID, s.ID, review.Grade, review.TypedAnswer, review.Scheduled, review.StateBefore, review.StateAfter,
+		review.IntervalBefore, review.IntervalAfter, review.Ease, review.DurationMs, now,
+	).Scan(&review.ID)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	next := remaining[1:]
+	requeue := answer.Grade == scheduler.Again
+	if scheduled {
+		requeue = after.State == scheduler.StateLearning || after.State == scheduler.StateRelearning
+	}
+	// Leeches suspended by this answer leave the session
+	if state.SuspendedAt != nil {
+		requeue = false
+	}
+	cardIDs := s.CardIDs
+	if requeue {
+		cardIDs = append(cardIDs, answer.FlashcardID)
+		next = append(next, remaining[0])
+	}
+
+	// Cards suspended or buried since the session started are skipped
+	position := index + 1
+	if len(next) > 0 {
+		ids, err := queryIDs(ctx, tx,
+			`SELECT f.id FROM flashcards f
+			 LEFT JOIN card_states s ON s.flashcard_id = f.id
+			 WHERE f.id = ANY($1) AND `+studyable,
+			pq.Array(cardIDs[position:]),
+		)
+		if err != nil {
+			apierror.Abort(c, err)
+			return
+		}
+		for position < len(cardIDs) && !slices.Contains(ids, cardIDs[position]) {
+			position++
+		}
+		for len(next) > 0 && !slices.Contains(ids, next[0].ID) {
+			next = next[1:]
+		}
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE study_sessions SET card_ids = $1, position = $2, last_activity_at = $3 WHERE id = $4",
+		pq.Array(cardIDs), position, now, s.ID,
+	)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	result := models.StudyAnswerResult{Review: review, State: state, Check: check, Remaining: len(next)}
+	if len(next) > 0 {
+		result.Next = &next[0]
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// FinishStudySession closes a study session and returns its summary
+func FinishStudySession(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidID("Study session"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	defer tx.Rollback()
+
+	s, err := getStudySession(ctx, tx, sessionID, userID, true)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Abort(c, apierror.NotFound("Study session not found"))
+			return
+		}
+		apierror.Abort(c, err)
+		return
+	}
+	if s.Status != models.StudySessionActive {
+		apierror.Abort(c, apierror.Conflict("Study session is already "+s.Status))
+		return
+	}
+
+	err = tx.QueryRowContext(ctx,
+		"UPDATE study_sessions SET status = 'finished', finished_at = NOW() WHERE id = $1 RETURNING finished_at",
+		s.ID,
+	).Scan(&s.FinishedAt)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	s.Status = models.StudySessionFinished
+	s.Cards = []models.Flashcard{}
+
+	s.Summary, err = studySummary(ctx, tx, s)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, s)
+}
+
+// lockActiveStudySession locks a study session that can still be answered.
+// A session past StudySessionTimeout is abandoned on the spot. It aborts the
+// request and returns false otherwise.
+func lockActiveStudySession(c *gin.Context, tx *sql.Tx, sessionID, userID uuid.UUID) (models.StudySession, bool) {
+	ctx := c.Request.Context()
+	s, err := getStudySession(ctx, tx, sessionID, userID, true)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Abort(c, apierror.NotFound("Study session not found"))
+			return s, false
+		}
+		apierror.Abort(c, err)
+		return s, false
+	}
+	if s.Status != models.StudySessionActive {
+		apierror.Abort(c, apierror.Conflict("Study session is "+s.Status))
+		return s, false
+	}
+
+	if time.Since(s.LastActivityAt) > StudySessionTimeout {
+		_, err := tx.ExecContext(ctx,
+			"UPDATE study_sessions SET status = 'abandoned', finished_at = last_activity_at WHERE id = $1",
+			s.ID,
+		)
+		if err == nil {
+			err = tx.Commit()
+		}
+		if err != nil {
+			apierror.Abort(c, err)
+			return s, false
+		}
+		apierror.Abort(c, apierror.Conflict("Study session was abandoned after a period of inactivity"))
+		return s, false
+	}
+	return s, true
+}
+
+// lockCardState locks a flashcard and returns its scheduling state.
+// Flashcards that were never studied are new.
+func lockCardState(ctx context.Context, tx *sql.Tx, flashcardID uuid.UUID) (models.CardState, error) {
+	state := models.CardState{FlashcardID: flashcardID}
+	err := tx.QueryRowContext(ctx,
+		`SELECT COALESCE(s.state, 'new'), COALESCE(s.step, 0), COALESCE(s.ease, 0), COALESCE(s.interval_days, 0),
+		        s.due_at, COALESCE(s.reps, 0), COALESCE(s.lapses, 0), COALESCE(s.leech, FALSE), s.suspended_at, s.buried_until
+		 FROM flashcards f
+		 LEFT JOIN card_states s ON s.flashcard_id = f.id
+		 WHERE f.id = $1
+		 FOR UPDATE OF f`,
+		flashcardID,
+	).Scan(&state.State, &state.Step, &state.Ease, &state.Interval, &state.DueAt, &state.Reps, &state.Lapses,
+		&state.Leech, &state.SuspendedAt, &state.BuriedUntil)
+	return state, err
+}
+
+// checkLeech tags a card that just lapsed as a leech when it hits the leech
+// threshold of its deck, and suspends it if the deck says so
+func checkLeech(ctx context.Context, tx *sql.Tx, state *models.CardState, deckID uuid.UUID, now time.Time) error {
+	var threshold int
+	var action string
+	err := tx.QueryRowContext(ctx, "SELECT leech_threshold, leech_action FROM decks WHERE id = $1", deckID).Scan(&threshold, &action)
+	if err != nil || !scheduler.Leech(state.Lapses, threshold) {
+		return err
+	}
+
+	state.Leech = true
+	if action == models.LeechSuspend && state.SuspendedAt == nil {
+		state.SuspendedAt = &now
+	}
+	_, err = tx.ExecContext(ctx,
+		"UPDATE card_states SET leech = TRUE, suspended_at = $1 WHERE flashcard_id = $2",
+		state.SuspendedAt, state.FlashcardID,
+	)
+	return err
+}
+
+// saveCardState stores the scheduling state of a flashcard reviewed at reviewedAt
+func saveCardState(ctx context.Context, tx *sql.Tx, state models.CardState, reviewedAt time.Time) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO card_states (flashcard_id, state, step, ease, interval_days, due_at, reps, lapses, last_reviewed_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		 ON CONFLICT (flashcard_id) DO UPDATE SET
+		     state = EXCLUDED.state, step = EXCLUDED.step, ease = EXCLUDED.ease, interval_days = EXCLUDED.interval_days,
+		     due_at = EXCLUDED.due_at, reps = EXCLUDED.reps, lapses = EXCLUDED.lapses, last_reviewed_at = EXCLUDED.last_reviewed_at`,
+		state.FlashcardID, state.State, state.Step, state.Ease, state.Interval, state.DueAt, state.Reps, state.Lapses, reviewedAt,
+	)
+	return err
+}
+
+// CloseAbandonedStudySessions abandons the active study sessions without an
+// answer for StudySessionTimeout. It runs periodically as a background job.
+func CloseAbandonedStudySessions(ctx context.Context) error {
+	_, err := database.DB.ExecContext(ctx,
+		"UPDATE study_sessions SET status = 'abandoned', finished_at = last_activity_at WHERE status = 'active' AND last_activity_at < $1",
+		time.Now().Add(-StudySessionTimeout),
+	)
+	return err
+}
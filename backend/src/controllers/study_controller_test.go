@@ -0,0 +1,746 @@
+package controllers
+
+import (
+	"api/src/database"
+	"api/src/models"
+	"api/src/scheduler"
+	"api/src/streak"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupStudy returns a test context for a request on a study session by a fresh user
+func setupStudy(t *testing.T, method, body string) (*httptest.ResponseRecorder, *gin.Context, sqlmock.Sqlmock, uuid.UUID, uuid.UUID) {
+	gin.SetMode(gin.TestMode)
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	t.Cleanup(func() { mockDB.Close() })
+	database.DB = mockDB
+
+	testUserID := uuid.New()
+	testSessionID := uuid.New()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{gin.Param{Key: "id", Value: testSessionID.String()}}
+	c.Request = httptest.NewRequest(method, "/", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	originalGetUserID := GetUserIDFromClerkID
+	GetUserIDFromClerkID = func(c *gin.Context) (uuid.UUID, bool) {
+		return testUserID, true
+	}
+	t.Cleanup(func() { GetUserIDFromClerkID = originalGetUserID })
+
+	return w, c, mock, testUserID, testSessionID
+}
+
+// studySessionRow returns the row of an active scheduled session started a minute ago
+func studySessionRow(s models.StudySession) *sqlmock.Rows {
+	if s.Mode == "" {
+		s.Mode = models.StudyModeScheduled
+	}
+	if s.Status == "" {
+		s.Status = models.StudySessionActive
+	}
+	if s.LastActivityAt.IsZero() {
+		s.LastActivityAt = time.Now().Add(-time.Minute)
+	}
+	deckIDs, _ := pq.Array(s.DeckIDs).Value()
+	cardIDs, _ := pq.Array(s.CardIDs).Value()
+	return sqlmock.NewRows(strings.Split(studySessionColumns, ", ")).AddRow(
+		s.ID, s.UserID, deckIDs, s.Mode, 20, 200, 0, cardIDs, s.Position, s.Status,
+		s.LastActivityAt.Add(-time.Minute), s.LastActivityAt, s.FinishedAt,
+	)
+}
+
+func flashcardRows(deckID uuid.UUID, ids ...uuid.UUID) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "parent_deck", "starred", "front", "back"})
+	for i, id := range ids {
+		rows.AddRow(id, deckID, false, "Front "+string(rune('A'+i)), "Back")
+	}
+	return rows
+}
+
+func TestStartStudySession(t *testing.T) {
+	t.Run("scheduled", func(t *testing.T) {
+		deckID := uuid.New()
+		w, c, mock, testUserID, _ := setupStudy(t, "POST", `{"deck_ids":["`+deckID.String()+`","`+deckID.String()+`"],"new_limit":5}`)
+		due, fresh, sessionID := uuid.New(), uuid.New(), uuid.New()
+
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM decks WHERE id = ANY\(\$1\) AND owner_id = \$2`).
+			WithArgs(pq.Array([]uuid.UUID{deckID}), testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		preset := models.DefaultDeckPreset()
+		preset.ID, preset.NewPerDay, preset.ReviewsPerDay, preset.NewOrder = uuid.New(), 10, 100, models.NewOrderRandom
+		mock.ExpectQuery(`FROM decks d JOIN deck_presets p`).
+			WithArgs(pq.Array([]uuid.UUID{deckID})).
+			WillReturnRows(deckPresetRows(deckID, preset))
+		// Today started at the rollover hour in New York
+		expectStudyGoal(mock, testUserID, "America/New_York", models.DefaultStudyGoal())
+		newYork, err := time.LoadLocation("America/New_York")
+		require.NoError(t, err)
+		now := time.Now().In(newYork)
+		today := time.Date(now.Year(), now.Month(), now.Day(), 4, 0, 0, 0, newYork)
+		if now.Hour() < 4 {
+			today = today.AddDate(0, 0, -1)
+		}
+		mock.ExpectQuery(`SELECT f.parent_deck, .* FROM review_logs r JOIN flashcards f ON f.id = r.flashcard_id `+
+			`WHERE f.parent_deck = ANY\(\$1\) AND r.scheduled AND r.reviewed_at >= \$2 GROUP BY f.parent_deck`).
+			WithArgs(pq.Array([]uuid.UUID{deckID}), today).
+			WillReturnRows(sqlmock.NewRows([]string{"parent_deck", "new", "reviews"}).AddRow(deckID, 3, 40))
+		// The daily limits of the preset, minus the cards studied today
+		mock.ExpectQuery(`JOIN unnest\(\$1::uuid\[\], \$2::int\[\]\) AS q\(deck_id, quota\) .* s.state <> 'new' AND s.due_at <= NOW\(\) AND s.suspended_at IS NULL AND \(s.buried_until IS NULL OR s.buried_until <= NOW\(\)\) \) c WHERE c.n <= c.quota ORDER BY c.due_at, c.id LIMIT \$3`).
+			WithArgs(pq.Array([]uuid.UUID{deckID}), pq.Array([]int{60}), nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(due))
+		mock.ExpectQuery(`JOIN unnest\(\$1::uuid\[\], \$2::int\[\], \$3::boolean\[\]\) .* \(s.state IS NULL OR s.state = 'new'\) AND s.suspended_at IS NULL .* ORDER BY c.parent_deck, c.n LIMIT \$4`).
+			WithArgs(pq.Array([]uuid.UUID{deckID}), pq.Array([]int{7}), pq.Array([]bool{true}), 5).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(fresh))
+		mock.ExpectQuery(`INSERT INTO study_sessions \(user_id, deck_ids, mode, new_limit, review_limit, card_limit, card_ids\)`).
+			WithArgs(testUserID, pq.Array([]uuid.UUID{deckID}), models.StudyModeScheduled, 1, 1, 0, pq.Array([]uuid.UUID{due, fresh})).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "position", "status", "started_at", "last_activity_at"}).
+				AddRow(sessionID, 0, models.StudySessionActive, time.Now(), time.Now()))
+		mock.ExpectQuery(`SELECT id, parent_deck, starred, front, back FROM flashcards WHERE id = ANY\(\$1\)`).
+			WithArgs(pq.Array([]uuid.UUID{due, fresh})).
+			WillReturnRows(flashcardRows(deckID, fresh, due))
+
+		StartStudySession(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var s models.StudySession
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &s))
+		assert.Equal(t, sessionID, s.ID)
+		if assert.Len(t, s.Cards, 2) {
+			assert.Equal(t, due, s.Cards[0].ID)
+			assert.Equal(t, fresh, s.Cards[1].ID)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("deck of another user", func(t *testing.T) {
+		deckID := uuid.New()
+		w, c, mock, testUserID, _ := setupStudy(t, "POST", `{"deck_ids":["`+deckID.String()+`"],"mode":"practice"}`)
+
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM decks`).
+			WithArgs(pq.Array([]uuid.UUID{deckID}), testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		StartStudySession(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("nothing to study", func(t *testing.T) {
+		deckID := uuid.New()
+		w, c, mock, testUserID, _ := setupStudy(t, "POST", `{"deck_ids":["`+deckID.String()+`"],"mode":"practice"}`)
+
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM decks`).
+			WithArgs(pq.Array([]uuid.UUID{deckID}), testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery(`SELECT f.id FROM flashcards f LEFT JOIN card_states s ON s.flashcard_id = f.id WHERE f.parent_deck = ANY\(\$1\) AND s.suspended_at IS NULL .* ORDER BY f.parent_deck, f.id`).
+			WithArgs(pq.Array([]uuid.UUID{deckID})).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		StartStudySession(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("cram", func(t *testing.T) {
+		deckID := uuid.New()
+		w, c, mock, testUserID, _ := setupStudy(t, "POST", `{"deck_ids":["`+deckID.String()+`"],"mode":"cram"}`)
+		due, first, second, third, sessionID := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM decks`).
+			WithArgs(pq.Array([]uuid.UUID{deckID}), testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		// The study day in Kiritimati can be a day ahead of the server's date
+		expectStudyGoal(mock, testUserID, "Pacific/Kiritimati", models.DefaultStudyGoal())
+		loc, err := time.LoadLocation("Pacific/Kiritimati")
+		require.NoError(t, err)
+		today := streak.Day(time.Now(), loc, models.DefaultStudyGoal().DayRollover)
+		mock.ExpectQuery(`SELECT id, exam_date - \$2::date FROM decks WHERE id = ANY\(\$1\) AND exam_date > \$2::date`).
+			WithArgs(pq.Array([]uuid.UUID{deckID}), today.Format(time.DateOnly)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "days"}).AddRow(deckID, 2))
+		mock.ExpectQuery(`s.due_at <= NOW\(\) AND s.suspended_at IS NULL .* ORDER BY f.starred DESC, s.lapses DESC, s.ease, s.due_at, f.id LIMIT \$2`).
+			WithArgs(pq.Array([]uuid.UUID{deckID}), models.DefaultReviewLimit).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(due))
+		mock.ExpectQuery(`SELECT f.id, f.parent_deck FROM flashcards f LEFT JOIN card_states s .* ORDER BY f.parent_deck, f.starred DESC, f.id`).
+			WithArgs(pq.Array([]uuid.UUID{deckID})).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "parent_deck"}).
+				AddRow(first, deckID).AddRow(second, deckID).AddRow(third, deckID))
+		mock.ExpectQuery(`SELECT f.parent_deck, COUNT\(DISTINCT r.flashcard_id\) FROM review_logs r .* r.state_before = 'new' AND r.reviewed_at >= \$2 GROUP BY f.parent_deck`).
+			WithArgs(pq.Array([]uuid.UUID{deckID}), streak.Start(today, loc, models.DefaultStudyGoal().DayRollover)).
+			WillReturnRows(sqlmock.NewRows([]string{"parent_deck", "count"}))
+		// Three new cards over two days: two today
+		mock.ExpectQuery(`INSERT INTO study_sessions`).
+			WithArgs(testUserID, pq.Array([]uuid.UUID{deckID}), models.StudyModeCram, models.DefaultNewLimit, models.DefaultReviewLimit, 0, pq.Array([]uuid.UUID{due, first, second})).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "position", "status", "started_at", "last_activity_at"}).
+				AddRow(sessionID, 0, models.StudySessionActive, time.Now(), time.Now()))
+		mock.ExpectQuery(`SELECT id, parent_deck, starred, front, back FROM flashcards WHERE id = ANY\(\$1\)`).
+			WillReturnRows(flashcardRows(deckID, due, first, second))
+
+		StartStudySession(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("cram after learning new cards today", func(t *testing.T) {
+		deckID := uuid.New()
+		w, c, mock, testUserID, _ := setupStudy(t, "POST", `{"deck_ids":["`+deckID.String()+`"],"mode":"cram"}`)
+		due, first, second, third, sessionID := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM decks`).
+			WithArgs(pq.Array([]uuid.UUID{deckID}), testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		expectStudyGoal(mock, testUserID, "Pacific/Kiritimati", models.DefaultStudyGoal())
+		loc, err := time.LoadLocation("Pacific/Kiritimati")
+		require.NoError(t, err)
+		today := streak.Day(time.Now(), loc, models.DefaultStudyGoal().DayRollover)
+		mock.ExpectQuery(`SELECT id, exam_date - \$2::date FROM decks WHERE id = ANY\(\$1\) AND exam_date > \$2::date`).
+			WithArgs(pq.Array([]uuid.UUID{deckID}), today.Format(time.DateOnly)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "days"}).AddRow(deckID, 2))
+		mock.ExpectQuery(`s.due_at <= NOW\(\) AND s.suspended_at IS NULL .* ORDER BY f.starred DESC, s.lapses DESC, s.ease, s.due_at, f.id LIMIT \$2`).
+			WithArgs(pq.Array([]uuid.UUID{deckID}), models.DefaultReviewLimit).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(due))
+		mock.ExpectQuery(`SELECT f.id, f.parent_deck FROM flashcards f LEFT JOIN card_states s .* ORDER BY f.parent_deck, f.starred DESC, f.id`).
+			WithArgs(pq.Array([]uuid.UUID{deckID})).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "parent_deck"}).
+				AddRow(first, deckID).AddRow(second, deckID).AddRow(third, deckID))
+		mock.ExpectQuery(`SELECT f.parent_deck, COUNT\(DISTINCT r.flashcard_id\) FROM review_logs r`).
+			WithArgs(pq.Array([]uuid.UUID{deckID}), streak.Start(today, loc, models.DefaultStudyGoal().DayRollover)).
+			WillReturnRows(sqlmock.NewRows([]string{"parent_deck", "count"}).AddRow(deckID, 1))
+		// Four new cards over two days, one of today's two already learned
+		mock.ExpectQuery(`INSERT INTO study_sessions`).
+			WithArgs(testUserID, pq.Array([]uuid.UUID{deckID}), models.StudyModeCram, models.DefaultNewLimit, models.DefaultReviewLimit, 0, pq.Array([]uuid.UUID{due, first})).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "position", "status", "started_at", "last_activity_at"}).
+				AddRow(sessionID, 0, models.StudySessionActive, time.Now(), time.Now()))
+		mock.ExpectQuery(`SELECT id, parent_deck, starred, front, back FROM flashcards WHERE id = ANY\(\$1\)`).
+			WillReturnRows(flashcardRows(deckID, due, first))
+
+		StartStudySession(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("cram without an exam", func(t *testing.T) {
+		deckID := uuid.New()
+		w, c, mock, testUserID, _ := setupStudy(t, "POST", `{"deck_ids":["`+deckID.String()+`"],"mode":"cram"}`)
+
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM decks`).
+			WithArgs(pq.Array([]uuid.UUID{deckID}), testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		expectStudyGoal(mock, testUserID, "UTC", models.DefaultStudyGoal())
+		mock.ExpectQuery(`SELECT id, exam_date - \$2::date FROM decks`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "days"}))
+
+		StartStudySession(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "upcoming exam_date")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unknown mode", func(t *testing.T) {
+		w, c, _, _, _ := setupStudy(t, "POST", `{"deck_ids":["`+uuid.NewString()+`"],"mode":"marathon"}`)
+
+		StartStudySession(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestGetStudySession(t *testing.T) {
+	w, c, mock, testUserID, testSessionID := setupStudy(t, "GET", "")
+	deckID, answered, next := uuid.New(), uuid.New(), uuid.New()
+
+	mock.ExpectQuery(`SELECT `+studySessionColumns+` FROM study_sessions WHERE id = \$1 AND user_id = \$2$`).
+		WithArgs(testSessionID, testUserID).
+		WillReturnRows(studySessionRow(models.StudySession{ID: testSessionID, UserID: testUserID, DeckIDs: []uuid.UUID{deckID}, CardIDs: []uuid.UUID{answered, next}, Position: 1}))
+	mock.ExpectQuery(`SELECT id, parent_deck, starred, front, back FROM flashcards WHERE id = ANY\(\$1\)`).
+		WithArgs(pq.Array([]uuid.UUID{next})).
+		WillReturnRows(flashcardRows(deckID, next))
+
+	GetStudySession(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var s models.StudySession
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &s))
+	assert.Equal(t, []uuid.UUID{answered, next}, s.CardIDs)
+	if assert.Len(t, s.Cards, 1) {
+		assert.Equal(t, next, s.Cards[0].ID)
+	}
+	assert.Nil(t, s.Summary)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAnswerStudyCard(t *testing.T) {
+	t.Run("new card stays in learning", func(t *testing.T) {
+		deckID, deleted, card, other := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+		w, c, mock, testUserID, testSessionID := setupStudy(t, "POST", `{"flashcard_id":"`+card.String()+`","grade":3,"duration_ms":4200}`)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT `+studySessionColumns+` FROM study_sessions WHERE id = \$1 AND user_id = \$2 FOR UPDATE`).
+			WithArgs(testSessionID, testUserID).
+			WillReturnRows(studySessionRow(models.StudySession{ID: testSessionID, UserID: testUserID, DeckIDs: []uuid.UUID{deckID}, CardIDs: []uuid.UUID{deleted, card, other}}))
+		mock.ExpectQuery(`SELECT id, parent_deck, starred, front, back FROM flashcards WHERE id = ANY\(\$1\)`).
+			WithArgs(pq.Array([]uuid.UUID{deleted, card, other})).
+			WillReturnRows(flashcardRows(deckID, card, other))
+		mock.ExpectQuery(`FROM flashcards f LEFT JOIN card_states s ON s.flashcard_id = f.id WHERE f.id = \$1 FOR UPDATE OF f`).
+			WithArgs(card).
+			WillReturnRows(sqlmock.NewRows([]string{"state", "step", "ease", "interval_days", "due_at", "reps", "lapses", "leech", "suspended_at", "buried_until"}).
+				AddRow(scheduler.StateNew, 0, 0.0, 0, nil, 0, 0, false, nil, nil))
+		mock.ExpectQuery(`FROM decks d JOIN deck_presets p`).
+			WithArgs(pq.Array([]uuid.UUID{deckID})).
+			WillReturnRows(deckPresetRows(deckID))
+		mock.ExpectExec(`INSERT INTO card_states .* ON CONFLICT \(flashcard_id\) DO UPDATE`).
+			WithArgs(card, scheduler.StateLearning, 1, 2.5, 0, sqlmock.AnyArg(), 1, 0, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`INSERT INTO review_logs`).
+			WithArgs(testUserID, card, testSessionID, scheduler.Good, nil, true, scheduler.StateNew, scheduler.StateLearning, 0, 0, 2.5, 4200, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+		mock.ExpectQuery(`SELECT f.id FROM flashcards f LEFT JOIN card_states s ON s.flashcard_id = f.id WHERE f.id = ANY\(\$1\) AND s.suspended_at IS NULL`).
+			WithArgs(pq.Array([]uuid.UUID{other, card})).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(other).AddRow(card))
+		mock.ExpectExec(`UPDATE study_sessions SET card_ids = \$1, position = \$2, last_activity_at = \$3 WHERE id = \$4`).
+			WithArgs(pq.Array([]uuid.UUID{deleted, card, other, card}), 2, sqlmock.AnyArg(), testSessionID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		AnswerStudyCard(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var result models.StudyAnswerResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		assert.Equal(t, scheduler.StateLearning, result.State.State)
+		assert.Equal(t, 2, result.Remaining)
+		if assert.NotNil(t, result.Next) {
+			assert.Equal(t, other, result.Next.ID)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("preset of the deck", func(t *testing.T) {
+		deckID, card := uuid.New(), uuid.New()
+		w, c, mock, testUserID, testSessionID := setupStudy(t, "POST", `{"flashcard_id":"`+card.String()+`","grade":3,"duration_ms":600000}`)
+		preset := models.DefaultDeckPreset()
+		preset.ID, preset.LearningSteps, preset.GraduatingInterval, preset.MaxAnswerSeconds = uuid.New(), []int{5}, 3, 30
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM study_sessions WHERE id = \$1 AND user_id = \$2 FOR UPDATE`).
+			WithArgs(testSessionID, testUserID).
+			WillReturnRows(studySessionRow(models.StudySession{ID: testSessionID, UserID: testUserID, DeckIDs: []uuid.UUID{deckID}, CardIDs: []uuid.UUID{card}}))
+		mock.ExpectQuery(`SELECT id, parent_deck, starred, front, back FROM flashcards WHERE id = ANY\(\$1\)`).
+			WillReturnRows(flashcardRows(deckID, card))
+		mock.ExpectQuery(`FROM flashcards f LEFT JOIN card_states s`).
+			WithArgs(card).
+			WillReturnRows(sqlmock.NewRows([]string{"state", "step", "ease", "interval_days", "due_at", "reps", "lapses", "leech", "suspended_at", "buried_until"}).
+				AddRow(scheduler.StateLearning, 0, 2.5, 0, time.Now(), 1, 0, false, nil, nil))
+		mock.ExpectQuery(`FROM decks d JOIN deck_presets p`).
+			WithArgs(pq.Array([]uuid.UUID{deckID})).
+			WillReturnRows(deckPresetRows(deckID, preset))
+		// A single learning step: the card graduates to the interval of the preset
+		mock.ExpectExec(`INSERT INTO card_states`).
+			WithArgs(card, scheduler.StateReview, 0, 2.5, 3, sqlmock.AnyArg(), 2, 0, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`INSERT INTO review_logs`).
+			WithArgs(testUserID, card, testSessionID, scheduler.Good, nil, true, scheduler.StateLearning, scheduler.StateReview, 0, 3, 2.5, 30000, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+		mock.ExpectExec(`UPDATE study_sessions SET card_ids`).
+			WithArgs(pq.Array([]uuid.UUID{card}), 1, sqlmock.AnyArg(), testSessionID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		AnswerStudyCard(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("practice answer leaves the schedule alone", func(t *testing.T) {
+		deckID, card := uuid.New(), uuid.New()
+		w, c, mock, testUserID, testSessionID := setupStudy(t, "POST", `{"flashcard_id":"`+card.String()+`","grade":4}`)
+		due := time.Now().Add(72 * time.Hour)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM study_sessions WHERE id = \$1 AND user_id = \$2 FOR UPDATE`).
+			WithArgs(testSessionID, testUserID).
+			WillReturnRows(studySessionRow(models.StudySession{ID: testSessionID, UserID: testUserID, DeckIDs: []uuid.UUID{deckID}, Mode: models.StudyModePractice, CardIDs: []uuid.UUID{card}}))
+		mock.ExpectQuery(`SELECT id, parent_deck, starred, front, back FROM flashcards WHERE id = ANY\(\$1\)`).
+			WithArgs(pq.Array([]uuid.UUID{card})).
+			WillReturnRows(flashcardRows(deckID, card))
+		mock.ExpectQuery(`FROM flashcards f LEFT JOIN card_states s`).
+			WithArgs(card).
+			WillReturnRows(sqlmock.NewRows([]string{"state", "step", "ease", "interval_days", "due_at", "reps", "lapses", "leech", "suspended_at", "buried_until"}).
+				AddRow(scheduler.StateReview, 0, 2.5, 3, due, 4, 0, false, nil, nil))
+		mock.ExpectQuery(`FROM decks d JOIN deck_presets p`).
+			WithArgs(pq.Array([]uuid.UUID{deckID})).
+			WillReturnRows(deckPresetRows(deckID))
+		mock.ExpectQuery(`INSERT INTO review_logs`).
+			WithArgs(testUserID, card, testSessionID, scheduler.Easy, nil, false, scheduler.StateReview, scheduler.StateReview, 3, 3, 2.5, 0, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+		mock.ExpectExec(`UPDATE study_sessions SET card_ids`).
+			WithArgs(pq.Array([]uuid.UUID{card}), 1, sqlmock.AnyArg(), testSessionID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		AnswerStudyCard(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var result models.StudyAnswerResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		assert.Equal(t, 3, result.State.Interval)
+		assert.Nil(t, result.Next)
+		assert.Equal(t, 0, result.Remaining)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("cram answer fits before the exam", func(t *testing.T) {
+		deckID, card := uuid.New(), uuid.New()
+		w, c, mock, testUserID, testSessionID := setupStudy(t, "POST", `{"flashcard_id":"`+card.String()+`","grade":3}`)
+		exam := time.Now().UTC().Add(10 * 24 * time.Hour)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM study_sessions WHERE id = \$1 AND user_id = \$2 FOR UPDATE`).
+			WithArgs(testSessionID, testUserID).
+			WillReturnRows(studySessionRow(models.StudySession{ID: testSessionID, UserID: testUserID, DeckIDs: []uuid.UUID{deckID}, Mode: models.StudyModeCram, CardIDs: []uuid.UUID{card}}))
+		mock.ExpectQuery(`SELECT id, parent_deck, starred, front, back FROM flashcards WHERE id = ANY\(\$1\)`).
+			WillReturnRows(flashcardRows(deckID, card))
+		mock.ExpectQuery(`FROM flashcards f LEFT JOIN card_states s`).
+			WithArgs(card).
+			WillReturnRows(sqlmock.NewRows([]string{"state", "step", "ease", "interval_days", "due_at", "reps", "lapses", "leech", "suspended_at", "buried_until"}).
+				AddRow(scheduler.StateReview, 0, 2.5, 20, time.Now(), 6, 0, false, nil, nil))
+		mock.ExpectQuery(`FROM decks d JOIN deck_presets p`).
+			WithArgs(pq.Array([]uuid.UUID{deckID})).
+			WillReturnRows(deckPresetRows(deckID))
+		mock.ExpectQuery(`SELECT exam_date FROM decks WHERE id = \$1`).
+			WithArgs(deckID).
+			WillReturnRows(sqlmock.NewRows([]string{"exam_date"}).AddRow(exam))
+		mock.ExpectExec(`INSERT INTO card_states`).
+			WithArgs(card, scheduler.StateReview, 0, 2.5, 4, sqlmock.AnyArg(), 7, 0, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`INSERT INTO review_logs`).
+			WithArgs(testUserID, card, testSessionID, scheduler.Good, nil, true, scheduler.StateReview, scheduler.StateReview, 20, 4, 2.5, 0, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+		mock.ExpectExec(`UPDATE study_sessions SET card_ids`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		AnswerStudyCard(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("typed answer", func(t *testing.T) {
+		deckID, card := uuid.New(), uuid.New()
+		w, c, mock, testUserID, testSessionID := setupStudy(t, "POST", `{"flashcard_id":"`+card.String()+`","typed":"mitocondria"}`)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM study_sessions WHERE id = \$1 AND user_id = \$2 FOR UPDATE`).
+			WithArgs(testSessionID, testUserID).
+			WillReturnRows(studySessionRow(models.StudySession{ID: testSessionID, UserID: testUserID, DeckIDs: []uuid.UUID{deckID}, CardIDs: []uuid.UUID{card}}))
+		mock.ExpectQuery(`SELECT id, parent_deck, starred, front, back FROM flashcards WHERE id = ANY\(\$1\)`).
+			WithArgs(pq.Array([]uuid.UUID{card})).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "parent_deck", "starred", "front", "back"}).
+				AddRow(card, deckID, false, "Powerhouse of the cell", "the mitochondria; mitochondrion"))
+		mock.ExpectQuery(`FROM flashcards f LEFT JOIN card_states s`).
+			WithArgs(card).
+			WillReturnRows(sqlmock.NewRows([]string{"state", "step", "ease", "interval_days", "due_at", "reps", "lapses", "leech", "suspended_at", "buried_until"}).
+				AddRow(scheduler.StateReview, 0, 2.5, 10, time.Now(), 4, 0, false, nil, nil))
+		mock.ExpectQuery(`FROM decks d JOIN deck_presets p`).
+			WithArgs(pq.Array([]uuid.UUID{deckID})).
+			WillReturnRows(deckPresetRows(deckID))
+		mock.ExpectExec(`INSERT INTO card_states`).
+			WithArgs(card, scheduler.StateReview, 0, 2.35, 12, sqlmock.AnyArg(), 5, 0, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`INSERT INTO review_logs`).
+			WithArgs(testUserID, card, testSessionID, scheduler.Hard, "mitocondria", true, scheduler.StateReview, scheduler.StateReview, 10, 12, 2.35, 0, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+		mock.ExpectExec(`UPDATE study_sessions SET card_ids`).
+			WithArgs(pq.Array([]uuid.UUID{card}), 1, sqlmock.AnyArg(), testSessionID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		AnswerStudyCard(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var result models.StudyAnswerResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		if assert.NotNil(t, result.Check) {
+			assert.True(t, result.Check.Correct)
+			assert.Equal(t, "the mitochondria", result.Check.Expected)
+			assert.Equal(t, 1, result.Check.Distance)
+		}
+		assert.Equal(t, scheduler.Hard, result.Review.Grade)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("leech suspended", func(t *testing.T) {
+		deckID, card := uuid.New(), uuid.New()
+		w, c, mock, testUserID, testSessionID := setupStudy(t, "POST", `{"flashcard_id":"`+card.String()+`","grade":1}`)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM study_sessions WHERE id = \$1 AND user_id = \$2 FOR UPDATE`).
+			WithArgs(testSessionID, testUserID).
+			WillReturnRows(studySessionRow(models.StudySession{ID: testSessionID, UserID: testUserID, DeckIDs: []uuid.UUID{deckID}, CardIDs: []uuid.UUID{card}}))
+		mock.ExpectQuery(`SELECT id, parent_deck, starred, front, back FROM flashcards WHERE id = ANY\(\$1\)`).
+			WillReturnRows(flashcardRows(deckID, card))
+		mock.ExpectQuery(`FROM flashcards f LEFT JOIN card_states s`).
+			WithArgs(card).
+			WillReturnRows(sqlmock.NewRows([]string{"state", "step", "ease", "interval_days", "due_at", "reps", "lapses", "leech", "suspended_at", "buried_until"}).
+				AddRow(scheduler.StateReview, 0, 1.3, 2, time.Now(), 12, 3, false, nil, nil))
+		mock.ExpectQuery(`FROM decks d JOIN deck_presets p`).
+			WithArgs(pq.Array([]uuid.UUID{deckID})).
+			WillReturnRows(deckPresetRows(deckID))
+		mock.ExpectExec(`INSERT INTO card_states`).
+			WithArgs(card, scheduler.StateRelearning, 0, 1.3, 1, sqlmock.AnyArg(), 13, 4, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`SELECT leech_threshold, leech_action FROM decks WHERE id = \$1`).
+			WithArgs(deckID).
+			WillReturnRows(sqlmock.NewRows([]string{"leech_threshold", "leech_action"}).AddRow(4, models.LeechSuspend))
+		mock.ExpectExec(`UPDATE card_states SET leech = TRUE, suspended_at = \$1 WHERE flashcard_id = \$2`).
+			WithArgs(sqlmock.AnyArg(), card).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`INSERT INTO review_logs`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+		// The card leaves the session instead of coming back to relearn
+		mock.ExpectExec(`UPDATE study_sessions SET card_ids`).
+			WithArgs(pq.Array([]uuid.UUID{card}), 1, sqlmock.AnyArg(), testSessionID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		AnswerStudyCard(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var result models.StudyAnswerResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		assert.True(t, result.State.Leech)
+		assert.NotNil(t, result.State.SuspendedAt)
+		assert.Equal(t, 0, result.Remaining)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("lapse below the leech threshold", func(t *testing.T) {
+		deckID, card := uuid.New(), uuid.New()
+		w, c, mock, testUserID, testSessionID := setupStudy(t, "POST", `{"flashcard_id":"`+card.String()+`","grade":1}`)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM study_sessions WHERE id = \$1 AND user_id = \$2 FOR UPDATE`).
+			WithArgs(testSessionID, testUserID).
+			WillReturnRows(studySessionRow(models.StudySession{ID: testSessionID, UserID: testUserID, DeckIDs: []uuid.UUID{deckID}, CardIDs: []uuid.UUID{card}}))
+		mock.ExpectQuery(`SELECT id, parent_deck, starred, front, back FROM flashcards WHERE id = ANY\(\$1\)`).
+			WillReturnRows(flashcardRows(deckID, card))
+		mock.ExpectQuery(`FROM flashcards f LEFT JOIN card_states s`).
+			WillReturnRows(sqlmock.NewRows([]string{"state", "step", "ease", "interval_days", "due_at", "reps", "lapses", "leech", "suspended_at", "buried_until"}).
+				AddRow(scheduler.StateReview, 0, 2.5, 5, time.Now(), 6, 0, false, nil, nil))
+		mock.ExpectQuery(`FROM decks d JOIN deck_presets p`).
+			WithArgs(pq.Array([]uuid.UUID{deckID})).
+			WillReturnRows(deckPresetRows(deckID))
+		mock.ExpectExec(`INSERT INTO card_states`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`SELECT leech_threshold, leech_action FROM decks`).
+			WithArgs(deckID).
+			WillReturnRows(sqlmock.NewRows([]string{"leech_threshold", "leech_action"}).AddRow(8, models.LeechSuspend))
+		mock.ExpectQuery(`INSERT INTO review_logs`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+		mock.ExpectQuery(`SELECT f.id FROM flashcards f LEFT JOIN card_states s ON s.flashcard_id = f.id WHERE f.id = ANY\(\$1\) AND s.suspended_at IS NULL`).
+			WithArgs(pq.Array([]uuid.UUID{card})).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(card))
+		mock.ExpectExec(`UPDATE study_sessions SET card_ids`).
+			WithArgs(pq.Array([]uuid.UUID{card, card}), 1, sqlmock.AnyArg(), testSessionID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		AnswerStudyCard(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var result models.StudyAnswerResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		assert.False(t, result.State.Leech)
+		assert.Equal(t, 1, result.Remaining)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("cards suspended since the start are skipped", func(t *testing.T) {
+		deckID, card, suspended, other := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+		w, c, mock, testUserID, testSessionID := setupStudy(t, "POST", `{"flashcard_id":"`+card.String()+`","grade":3}`)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM study_sessions WHERE id = \$1 AND user_id = \$2 FOR UPDATE`).
+			WithArgs(testSessionID, testUserID).
+			WillReturnRows(studySessionRow(models.StudySession{ID: testSessionID, UserID: testUserID, DeckIDs: []uuid.UUID{deckID}, CardIDs: []uuid.UUID{card, suspended, other}}))
+		mock.ExpectQuery(`SELECT id, parent_deck, starred, front, back FROM flashcards WHERE id = ANY\(\$1\)`).
+			WillReturnRows(flashcardRows(deckID, card, suspended, other))
+		mock.ExpectQuery(`FROM flashcards f LEFT JOIN card_states s`).
+			WillReturnRows(sqlmock.NewRows([]string{"state", "step", "ease", "interval_days", "due_at", "reps", "lapses", "leech", "suspended_at", "buried_until"}).
+				AddRow(scheduler.StateReview, 0, 2.5, 5, time.Now(), 6, 0, false, nil, nil))
+		mock.ExpectQuery(`FROM decks d JOIN deck_presets p`).
+			WithArgs(pq.Array([]uuid.UUID{deckID})).
+			WillReturnRows(deckPresetRows(deckID))
+		mock.ExpectExec(`INSERT INTO card_states`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`INSERT INTO review_logs`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+		mock.ExpectQuery(`SELECT f.id FROM flashcards f LEFT JOIN card_states s ON s.flashcard_id = f.id WHERE f.id = ANY\(\$1\) AND s.suspended_at IS NULL`).
+			WithArgs(pq.Array([]uuid.UUID{suspended, other})).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(other))
+		mock.ExpectExec(`UPDATE study_sessions SET card_ids`).
+			WithArgs(pq.Array([]uuid.UUID{card, suspended, other}), 2, sqlmock.AnyArg(), testSessionID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		AnswerStudyCard(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var result models.StudyAnswerResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		assert.Equal(t, 1, result.Remaining)
+		if assert.NotNil(t, result.Next) {
+			assert.Equal(t, other, result.Next.ID)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("not the next card", func(t *testing.T) {
+		deckID, card, other := uuid.New(), uuid.New(), uuid.New()
+		w, c, mock, testUserID, testSessionID := setupStudy(t, "POST", `{"flashcard_id":"`+other.String()+`","grade":3}`)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM study_sessions WHERE id = \$1 AND user_id = \$2 FOR UPDATE`).
+			WithArgs(testSessionID, testUserID).
+			WillReturnRows(studySessionRow(models.StudySession{ID: testSessionID, UserID: testUserID, DeckIDs: []uuid.UUID{deckID}, CardIDs: []uuid.UUID{card, other}}))
+		mock.ExpectQuery(`SELECT id, parent_deck, starred, front, back FROM flashcards WHERE id = ANY\(\$1\)`).
+			WithArgs(pq.Array([]uuid.UUID{card, other})).
+			WillReturnRows(flashcardRows(deckID, card, other))
+		mock.ExpectRollback()
+
+		AnswerStudyCard(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), card.String())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("inactive session is abandoned", func(t *testing.T) {
+		card := uuid.New()
+		w, c, mock, testUserID, testSessionID := setupStudy(t, "POST", `{"flashcard_id":"`+card.String()+`","grade":3}`)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM study_sessions WHERE id = \$1 AND user_id = \$2 FOR UPDATE`).
+			WithArgs(testSessionID, testUserID).
+			WillReturnRows(studySessionRow(models.StudySession{ID: testSessionID, UserID: testUserID, CardIDs: []uuid.UUID{card}, LastActivityAt: time.Now().Add(-time.Hour)}))
+		mock.ExpectExec(`UPDATE study_sessions SET status = 'abandoned', finished_at = last_activity_at WHERE id = \$1`).
+			WithArgs(testSessionID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		AnswerStudyCard(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "abandoned")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("invalid grade", func(t *testing.T) {
+		w, c, _, _, _ := setupStudy(t, "POST", `{"flashcard_id":"`+uuid.NewString()+`","grade":5}`)
+
+		AnswerStudyCard(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("oversized body", func(t *testing.T) {
+		typed := strings.Repeat("a", maxAnswerBody)
+		w, c, _, _, _ := setupStudy(t, "POST", `{"flashcard_id":"`+uuid.NewString()+`","typed":"`+typed+`"}`)
+
+		AnswerStudyCard(c)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+}
+
+func TestFinishStudySession(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		w, c, mock, testUserID, testSessionID := setupStudy(t, "POST", "")
+		card := uuid.New()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM study_sessions WHERE id = \$1 AND user_id = \$2 FOR UPDATE`).
+			WithArgs(testSessionID, testUserID).
+			WillReturnRows(studySessionRow(models.StudySession{ID: testSessionID, UserID: testUserID, CardIDs: []uuid.UUID{card}, Position: 1}))
+		mock.ExpectQuery(`UPDATE study_sessions SET status = 'finished', finished_at = NOW\(\) WHERE id = \$1 RETURNING finished_at`).
+			WithArgs(testSessionID).
+			WillReturnRows(sqlmock.NewRows([]string{"finished_at"}).AddRow(time.Now()))
+		mock.ExpectQuery(`FROM review_logs WHERE session_id = \$1`).
+			WithArgs(testSessionID).
+			WillReturnRows(sqlmock.NewRows([]string{"answers", "correct", "study_time", "studied", "learned", "lapsed"}).
+				AddRow(4, 3, 12000, 3, 2, 1))
+		mock.ExpectCommit()
+
+		FinishStudySession(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var s models.StudySession
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &s))
+		assert.Equal(t, models.StudySessionFinished, s.Status)
+		if assert.NotNil(t, s.Summary) {
+			assert.Equal(t, 0.75, s.Summary.Accuracy)
+			assert.Equal(t, int64(12000), s.Summary.StudyTimeMs)
+			assert.Equal(t, 2, s.Summary.CardsLearned)
+			assert.Equal(t, 1, s.Summary.CardsLapsed)
+			assert.Greater(t, s.Summary.ElapsedMs, int64(0))
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("already finished", func(t *testing.T) {
+		w, c, mock, testUserID, testSessionID := setupStudy(t, "POST", "")
+		finished := time.Now()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM study_sessions WHERE id = \$1 AND user_id = \$2 FOR UPDATE`).
+			WithArgs(testSessionID, testUserID).
+			WillReturnRows(studySessionRow(models.StudySession{ID: testSessionID, UserID: testUserID, Status: models.StudySessionFinished, FinishedAt: &finished}))
+		mock.ExpectRollback()
+
+		FinishStudySession(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestCloseAbandonedStudySessions(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	database.DB = mockDB
+
+	mock.ExpectExec(`UPDATE study_sessions SET status = 'abandoned', finished_at = last_activity_at WHERE status = 'active' AND last_activity_at < \$1`).
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	assert.NoError(t, CloseAbandonedStudySessions(context.Background()))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,250 @@
+package controllers
+
+import (
+	"api/src/apierror"
+	"api/src/cardfile"
+	"api/src/database"
+	"api/src/models"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// syncPlan lists the changes that make a deck match a card file
+type syncPlan struct {
+	// create holds the indexes of the file cards without a flashcard
+	create    []int
+	update    []models.Flashcard
+	delete    []uuid.UUID
+	unchanged int
+}
+
+// planSync matches the cards of a file with the flashcards of a deck.
+// Cards are matched by their card comment, then cards without one by front,
+// so a file written before its first sync doesn't duplicate the deck.
+// Matched cards get the ID of their flashcard.
+func planSync(existing []models.Flashcard, file *cardfile.Deck) (syncPlan, error) {
+	var plan syncPlan
+	byID := map[uuid.UUID]models.Flashcard{}
+	for _, f := range existing {
+		byID[f.ID] = f
+	}
+	matched := map[uuid.UUID]bool{}
+
+	for _, card := range file.Cards {
+		if card.ID == "" {
+			continue
+		}
+		id, err := uuid.Parse(card.ID)
+		if err != nil {
+			return plan, fmt.Errorf("card %q: %q is not a flashcard ID", card.Front, card.ID)
+		}
+		if _, ok := byID[id]; !ok {
+			return plan, fmt.Errorf("card %q: flashcard %s is not in this deck", card.Front, id)
+		}
+		if matched[id] {
+			return plan, fmt.Errorf("card %q: flashcard %s appears more than once", card.Front, id)
+		}
+		matched[id] = true
+	}
+
+	for i, card := range file.Cards {
+		if card.ID != "" {
+			continue
+		}
+		for _, f := range existing {
+			if !matched[f.ID] && sameText(f.Front, card.Front) {
+				matched[f.ID] = true
+				file.Cards[i].ID = f.ID.String()
+				break
+			}
+		}
+	}
+
+	for i, card := range file.Cards {
+		if card.ID == "" {
+			plan.create = append(plan.create, i)
+			continue
+		}
+		f := byID[uuid.MustParse(card.ID)]
+		if sameText(f.Front, card.Front) && sameText(f.Back, card.Back) {
+			plan.unchanged++
+			continue
+		}
+		f.Front, f.Back = card.Front, card.Back
+		plan.update = append(plan.update, f)
+	}
+
+	for _, f := range existing {
+		if !matched[f.ID] {
+			plan.delete = append(plan.delete, f.ID)
+		}
+	}
+	return plan, nil
+}
+
+// sameText compares texts ignoring whitespace, since Markdown headings can't
+// hold the line breaks of a front
+func sameText(a, b string) bool {
+	return strings.Join(strings.Fields(a), " ") == strings.Join(strings.Fields(b), " ")
+}
+
+// SyncDeck applies a Markdown card file to a deck: cards new to the deck are
+// created, edited cards are updated with their previous content kept as a
+// revision, and flashcards missing from the file are deleted when the request
+// asks for it. Updated cards keep their ID and starred flag. A file without
+// cards is rejected, as it would empty the deck.
+func SyncDeck(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	deckID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidID("Deck"))
+		return
+	}
+
+	var req models.DeckSync
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Invalid(err))
+		return
+	}
+
+	file, err := cardfile.Read(strings.NewReader(req.Document), cardfile.FormatMarkdown)
+	if err != nil {
+		apierror.Abort(c, apierror.Invalid(err))
+		return
+	}
+	if len(file.Cards) == 0 {
+		apierror.Abort(c, apierror.BadRequest("The document has no cards"))
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	defer tx.Rollback()
+
+	if err := lockDeck(tx, deckID, userID); err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Abort(c, apierror.NotFound("Deck not found or access denied"))
+			return
+		}
+		apierror.Abort(c, err)
+		return
+	}
+
+	existing, err := lockDeckFlashcards(tx, deckID)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	plan, err := planSync(existing, file)
+	if err != nil {
+		apierror.Abort(c, apierror.Invalid(err))
+		return
+	}
+
+	result := models.DeckSyncResult{
+		DryRun:    req.DryRun,
+		Created:   []models.Flashcard{},
+		Updated:   plan.update,
+		Deleted:   []uuid.UUID{},
+		Missing:   []uuid.UUID{},
+		Unchanged: plan.unchanged,
+	}
+	if result.Updated == nil {
+		result.Updated = []models.Flashcard{}
+	}
+	if req.DeleteMissing {
+		result.Deleted = append(result.Deleted, plan.delete...)
+	} else {
+		result.Missing = append(result.Missing, plan.delete...)
+	}
+
+	for _, i := range plan.create {
+		starred := false
+		flashcard := models.Flashcard{ParentDeck: deckID, Starred: &starred, Front: file.Cards[i].Front, Back: file.Cards[i].Back}
+		if !req.DryRun {
+			err := tx.QueryRow(
+				"INSERT INTO flashcards (parent_deck, starred, front, back) VALUES ($1, $2, $3, $4) RETURNING id",
+				flashcard.ParentDeck, flashcard.Starred, flashcard.Front, flashcard.Back,
+			).Scan(&flashcard.ID)
+			if err != nil {
+				apierror.Abort(c, err)
+				return
+			}
+			file.Cards[i].ID = flashcard.ID.String()
+		}
+		result.Created = append(result.Created, flashcard)
+	}
+
+	if !req.DryRun {
+		for _, f := range plan.update {
+			if err := saveFlashcardRevision(tx, f.ID, userID); err != nil {
+				apierror.Abort(c, err)
+				return
+			}
+			if _, err := tx.Exec("UPDATE flashcards SET front = $1, back = $2 WHERE id = $3", f.Front, f.Back, f.ID); err != nil {
+				apierror.Abort(c, err)
+				return
+			}
+		}
+
+		if len(result.Deleted) > 0 {
+			if _, err := tx.Exec("DELETE FROM flashcards WHERE id = ANY($1)", pq.Array(result.Deleted)); err != nil {
+				apierror.Abort(c, err)
+				return
+			}
+		}
+
+		if err := tx.Commit(); err != nil {
+			apierror.Abort(c, err)
+			return
+		}
+	}
+
+	ids := make([]string, len(file.Cards))
+	for i, card := range file.Cards {
+		ids[i] = card.ID
+	}
+	result.Document, err = cardfile.Annotate(req.Document, ids)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// lockDeckFlashcards returns the flashcards of a deck, locked until the transaction ends
+func lockDeckFlashcards(tx *sql.Tx, deckID uuid.UUID) ([]models.Flashcard, error) {
+	rows, err := tx.Query(
+		"SELECT id, parent_deck, starred, front, back FROM flashcards WHERE parent_deck = $1 ORDER BY id FOR UPDATE",
+		deckID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var flashcards []models.Flashcard
+	for rows.Next() {
+		var f models.Flashcard
+		if err := rows.Scan(&f.ID, &f.ParentDeck, &f.Starred, &f.Front, &f.Back); err != nil {
+			return nil, err
+		}
+		flashcards = append(flashcards, f)
+	}
+	return flashcards, rows.Err()
+}
@@ -0,0 +1,217 @@
+package controllers
+
+import (
+	"api/src/cardfile"
+	"api/src/database"
+	"api/src/models"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanSync(t *testing.T) {
+	starred := true
+	france := models.Flashcard{ID: uuid.New(), Starred: &starred, Front: "France", Back: "Paris"}
+	italy := models.Flashcard{ID: uuid.New(), Starred: &starred, Front: "Italy", Back: "Rome"}
+	spain := models.Flashcard{ID: uuid.New(), Starred: &starred, Front: "Spain", Back: "Madrid"}
+	existing := []models.Flashcard{france, italy, spain}
+
+	t.Run("matches by comment then front", func(t *testing.T) {
+		file := &cardfile.Deck{Cards: []cardfile.Card{
+			{ID: france.ID.String(), Front: "France", Back: "Paris"},
+			{Front: "Italy", Back: "Rome,\nsince 1871"},
+			{Front: "Germany", Back: "Berlin"},
+		}}
+
+		plan, err := planSync(existing, file)
+		require.NoError(t, err)
+		assert.Equal(t, 1, plan.unchanged)
+		assert.Equal(t, []int{2}, plan.create)
+		if assert.Len(t, plan.update, 1) {
+			assert.Equal(t, italy.ID, plan.update[0].ID)
+			assert.Equal(t, "Rome,\nsince 1871", plan.update[0].Back)
+			assert.True(t, *plan.update[0].Starred)
+		}
+		assert.Equal(t, []uuid.UUID{spain.ID}, plan.delete)
+		assert.Equal(t, italy.ID.String(), file.Cards[1].ID)
+	})
+
+	t.Run("whitespace is not an edit", func(t *testing.T) {
+		multiline := models.Flashcard{ID: uuid.New(), Front: "Capital of\nFrance", Back: "Paris "}
+		file := &cardfile.Deck{Cards: []cardfile.Card{{ID: multiline.ID.String(), Front: "Capital of France", Back: "Paris"}}}
+
+		plan, err := planSync([]models.Flashcard{multiline}, file)
+		require.NoError(t, err)
+		assert.Equal(t, 1, plan.unchanged)
+		assert.Empty(t, plan.update)
+	})
+
+	t.Run("invalid comments", func(t *testing.T) {
+		for id, want := range map[string]string{
+			"nope":              `card "France": "nope" is not a flashcard ID`,
+			uuid.New().String(): "is not in this deck",
+			italy.ID.String():   "appears more than once",
+		} {
+			file := &cardfile.Deck{Cards: []cardfile.Card{
+				{ID: italy.ID.String(), Front: "Italy", Back: "Rome"},
+				{ID: id, Front: "France", Back: "Paris"},
+			}}
+			_, err := planSync(existing, file)
+			if assert.Error(t, err, id) {
+				assert.Contains(t, err.Error(), want)
+			}
+		}
+	})
+}
+
+func TestSyncDeck(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	setup := func(t *testing.T, body string) (*httptest.ResponseRecorder, *gin.Context, sqlmock.Sqlmock, uuid.UUID, uuid.UUID) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		t.Cleanup(func() { mockDB.Close() })
+		database.DB = mockDB
+
+		testUserID := uuid.New()
+		testDeckID := uuid.New()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "id", Value: testDeckID.String()}}
+		c.Request = httptest.NewRequest("POST", "/", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		originalGetUserID := GetUserIDFromClerkID
+		GetUserIDFromClerkID = func(c *gin.Context) (uuid.UUID, bool) {
+			return testUserID, true
+		}
+		t.Cleanup(func() { GetUserIDFromClerkID = originalGetUserID })
+
+		return w, c, mock, testUserID, testDeckID
+	}
+
+	document := func(t *testing.T, doc string) string {
+		body, err := json.Marshal(models.DeckSync{Document: doc})
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	t.Run("success", func(t *testing.T) {
+		frenchID, italianID, spanishID, germanID := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+		doc := "# Capitals\n\n## France\n<!-- card: " + frenchID.String() + " -->\n\nParis\n\n## Italy\n\nRome, since 1871\n\n## Germany\n\nBerlin\n"
+		body, err := json.Marshal(models.DeckSync{Document: doc, DeleteMissing: true})
+		require.NoError(t, err)
+		w, c, mock, testUserID, testDeckID := setup(t, string(body))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT id FROM decks WHERE id = \$1 AND owner_id = \$2 FOR UPDATE`).
+			WithArgs(testDeckID, testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testDeckID))
+		mock.ExpectQuery(`SELECT id, parent_deck, starred, front, back FROM flashcards WHERE parent_deck = \$1 ORDER BY id FOR UPDATE`).
+			WithArgs(testDeckID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "parent_deck", "starred", "front", "back"}).
+				AddRow(frenchID, testDeckID, false, "France", "Paris").
+				AddRow(italianID, testDeckID, true, "Italy", "Rome").
+				AddRow(spanishID, testDeckID, false, "Spain", "Madrid"))
+		mock.ExpectQuery(`INSERT INTO flashcards \(parent_deck, starred, front, back\) VALUES \(\$1, \$2, \$3, \$4\) RETURNING id`).
+			WithArgs(testDeckID, false, "Germany", "Berlin").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(germanID))
+		mock.ExpectExec(`INSERT INTO flashcard_revisions`).
+			WithArgs(italianID, testUserID).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`UPDATE flashcards SET front = \$1, back = \$2 WHERE id = \$3`).
+			WithArgs("Italy", "Rome, since 1871", italianID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`DELETE FROM flashcards WHERE id = ANY\(\$1\)`).
+			WithArgs(pq.Array([]uuid.UUID{spanishID})).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		SyncDeck(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var result models.DeckSyncResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		assert.False(t, result.DryRun)
+		assert.Equal(t, 1, result.Unchanged)
+		assert.Len(t, result.Created, 1)
+		assert.Len(t, result.Updated, 1)
+		assert.Equal(t, []uuid.UUID{spanishID}, result.Deleted)
+		assert.Empty(t, result.Missing)
+		assert.Contains(t, result.Document, "## Italy\n<!-- card: "+italianID.String()+" -->")
+		assert.Contains(t, result.Document, "## Germany\n<!-- card: "+germanID.String()+" -->")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("missing cards are kept", func(t *testing.T) {
+		frenchID, spanishID := uuid.New(), uuid.New()
+		w, c, mock, testUserID, testDeckID := setup(t, document(t, "## France\n\nParis\n"))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT id FROM decks WHERE id = \$1 AND owner_id = \$2 FOR UPDATE`).
+			WithArgs(testDeckID, testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testDeckID))
+		mock.ExpectQuery(`SELECT id, parent_deck, starred, front, back FROM flashcards WHERE parent_deck = \$1 ORDER BY id FOR UPDATE`).
+			WithArgs(testDeckID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "parent_deck", "starred", "front", "back"}).
+				AddRow(frenchID, testDeckID, false, "France", "Paris").
+				AddRow(spanishID, testDeckID, false, "Spain", "Madrid"))
+		mock.ExpectCommit()
+
+		SyncDeck(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var result models.DeckSyncResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		assert.Empty(t, result.Deleted)
+		assert.Equal(t, []uuid.UUID{spanishID}, result.Missing)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("document without cards", func(t *testing.T) {
+		w, c, mock, _, _ := setup(t, document(t, "# Capitals\n\nNothing here yet.\n"))
+
+		SyncDeck(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "The document has no cards")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("deck not found", func(t *testing.T) {
+		w, c, mock, testUserID, testDeckID := setup(t, document(t, "## France\n\nParis\n"))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT id FROM decks WHERE id = \$1 AND owner_id = \$2 FOR UPDATE`).
+			WithArgs(testDeckID, testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectRollback()
+
+		SyncDeck(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("invalid document", func(t *testing.T) {
+		w, c, mock, _, _ := setup(t, document(t, "## France\n\n"))
+
+		SyncDeck(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `card \"France\" has an empty back`)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"api/src/apierror"
+	"api/src/database"
+	"api/src/middleware"
+	"api/src/models"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// tokenPrefixLength is how much of a token is kept in clear to identify it in listings
+const tokenPrefixLength = 8
+
+// generatePersonalToken returns a new random personal access token
+func generatePersonalToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return middleware.PersonalTokenPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// tokenColumns are the columns scanned by scanToken
+const tokenColumns = "id, user_id, name, token_prefix, scopes, expires_at, last_used_at, created_at, revoked_at"
+
+// scanToken scans a row selected with tokenColumns
+func scanToken(row interface{ Scan(...any) error }, t *models.PersonalAccessToken) error {
+	return row.Scan(&t.ID, &t.UserID, &t.Name, &t.Prefix, pq.Array(&t.Scopes), &t.ExpiresAt, &t.LastUsedAt, &t.CreatedAt, &t.RevokedAt)
+}
+
+// GetTokens returns the personal access tokens of the authenticated user, newest first
+func GetTokens(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	rows, err := database.DB.Query(
+		"SELECT "+tokenColumns+" FROM personal_access_tokens WHERE user_id = $1 ORDER BY created_at DESC",
+		userID,
+	)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	defer rows.Close()
+
+	tokens := []models.PersonalAccessToken{}
+	for rows.Next() {
+		var t models.PersonalAccessToken
+		if err := scanToken(rows, &t); err != nil {
+			apierror.Abort(c, err)
+			return
+		}
+		tokens = append(tokens, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// CreateToken creates a personal access token for the authenticated user.
+// The token itself is only part of this response; just its hash is stored.
+func CreateToken(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	var token models.PersonalAccessToken
+	if err := c.ShouldBindJSON(&token); err != nil {
+		apierror.Abort(c, apierror.Invalid(err))
+		return
+	}
+
+	if err := token.Validate(); err != nil {
+		apierror.Abort(c, apierror.Invalid(err))
+		return
+	}
+
+	secret, err := generatePersonalToken()
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	token.UserID = userID
+	token.Token = secret
+	token.Prefix = secret[:len(middleware.PersonalTokenPrefix)+tokenPrefixLength]
+
+	err = database.DB.QueryRow(
+		`INSERT INTO personal_access_tokens (user_id, name, token_prefix, token_hash, scopes, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`,
+		token.UserID, token.Name, token.Prefix, middleware.HashPersonalToken(secret), pq.StringArray(token.Scopes), token.ExpiresAt,
+	).Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, token)
+}
+
+// RevokeToken revokes one of the authenticated user's personal access tokens
+func RevokeToken(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	tokenID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidID(""))
+		return
+	}
+
+	result, err := database.DB.Exec(
+		"UPDATE personal_access_tokens SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL",
+		tokenID, userID,
+	)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	if rowsAffected == 0 {
+		apierror.Abort(c, apierror.NotFound("Token not found"))
+		return
+	}
+
+	c.AbortWithStatus(http.StatusNoContent)
+}
@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"api/src/database"
+	"api/src/middleware"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		testUserID := uuid.New()
+		rows := sqlmock.NewRows([]string{"id", "user_id", "name", "token_prefix", "scopes", "expires_at", "last_used_at", "created_at", "revoked_at"}).
+			AddRow(uuid.New(), testUserID, "sync script", "fcp_abcdefgh", pq.Array([]string{"decks:read"}), nil, time.Now(), time.Now(), nil)
+		mock.ExpectQuery(`SELECT id, user_id, name, token_prefix, scopes, expires_at, last_used_at, created_at, revoked_at FROM personal_access_tokens WHERE user_id = \$1 ORDER BY created_at DESC`).
+			WithArgs(testUserID).
+			WillReturnRows(rows)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		originalGetUserID := GetUserIDFromClerkID
+		GetUserIDFromClerkID = func(c *gin.Context) (uuid.UUID, bool) {
+			return testUserID, true
+		}
+		defer func() { GetUserIDFromClerkID = originalGetUserID }()
+
+		GetTokens(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "sync script")
+		assert.NotContains(t, w.Body.String(), `"token":`)
+	})
+}
+
+func TestCreateToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		testUserID := uuid.New()
+		newTokenID := uuid.New()
+		tokenJSON := `{"name":"sync script","scopes":["decks:read","decks:write"]}`
+
+		mock.ExpectQuery(`INSERT INTO personal_access_tokens \(user_id, name, token_prefix, token_hash, scopes, expires_at\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6\) RETURNING id, created_at`).
+			WithArgs(testUserID, "sync script", sqlmock.AnyArg(), sqlmock.AnyArg(), pq.StringArray{"decks:read", "decks:write"}, nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(newTokenID, time.Now()))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/", strings.NewReader(tokenJSON))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		originalGetUserID := GetUserIDFromClerkID
+		GetUserIDFromClerkID = func(c *gin.Context) (uuid.UUID, bool) {
+			return testUserID, true
+		}
+		defer func() { GetUserIDFromClerkID = originalGetUserID }()
+
+		CreateToken(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Contains(t, w.Body.String(), `"token":"`+middleware.PersonalTokenPrefix)
+		assert.Contains(t, w.Body.String(), newTokenID.String())
+	})
+
+	t.Run("unknown scope", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/", strings.NewReader(`{"name":"script","scopes":["admin"]}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		originalGetUserID := GetUserIDFromClerkID
+		GetUserIDFromClerkID = func(c *gin.Context) (uuid.UUID, bool) {
+			return uuid.New(), true
+		}
+		defer func() { GetUserIDFromClerkID = originalGetUserID }()
+
+		CreateToken(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestRevokeToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		testUserID := uuid.New()
+		testTokenID := uuid.New()
+
+		mock.ExpectExec(`UPDATE personal_access_tokens SET revoked_at = NOW\(\) WHERE id = \$1 AND user_id = \$2 AND revoked_at IS NULL`).
+			WithArgs(testTokenID, testUserID).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "id", Value: testTokenID.String()}}
+		c.Request, _ = http.NewRequest("DELETE", "/", nil)
+
+		originalGetUserID := GetUserIDFromClerkID
+		GetUserIDFromClerkID = func(c *gin.Context) (uuid.UUID, bool) {
+			return testUserID, true
+		}
+		defer func() { GetUserIDFromClerkID = originalGetUserID }()
+
+		RevokeToken(c)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+}
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"api/src/apierror"
 	"database/sql"
 	"net/http"
 
@@ -11,11 +12,19 @@ import (
 	"github.com/google/uuid"
 )
 
-// GetUsers returns all users
+// userColumns are the columns scanned by scanUser
+const userColumns = "id, clerk_id, name, email, role, suspended_at, timezone"
+
+// scanUser scans a row selected with userColumns
+func scanUser(row interface{ Scan(...any) error }, u *models.User) error {
+	return row.Scan(&u.ID, &u.ClerkID, &u.Name, &u.Email, &u.Role, &u.SuspendedAt, &u.Timezone)
+}
+
+// GetUsers returns all users. Restricted to admins by the router.
 func GetUsers(c *gin.Context) {
-	rows, err := database.DB.Query("SELECT id, clerk_id, name, email FROM users")
+	rows, err := database.DB.Query("SELECT " + userColumns + " FROM users")
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apierror.Abort(c, err)
 		return
 	}
 	defer rows.Close()
@@ -23,40 +32,73 @@ func GetUsers(c *gin.Context) {
 	var users []models.User
 	for rows.Next() {
 		var u models.User
-		if err := rows.Scan(&u.ID, &u.ClerkID, &u.Name, &u.Email); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if err := scanUser(rows, &u); err != nil {
+			apierror.Abort(c, err)
 			return
 		}
 		users = append(users, u)
 	}
 
 	if err := rows.Err(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apierror.Abort(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, users)
 }
 
-// GetUser returns a single user by ID
+// GetUser returns a single user by ID. Users can only see themselves unless they are admins.
 func GetUser(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		apierror.Abort(c, apierror.InvalidID(""))
+		return
+	}
+
+	current, ok := getCurrentUser(c)
+	if !ok {
+		return
+	}
+	if current.ID != id && current.Role != models.RoleAdmin {
+		apierror.Abort(c, apierror.NotFound("User not found"))
 		return
 	}
 
 	var user models.User
-	err = database.DB.QueryRow(
-		"SELECT id, clerk_id, name, email FROM users WHERE id = $1",
+	err = scanUser(database.DB.QueryRow(
+		"SELECT "+userColumns+" FROM users WHERE id = $1",
 		id,
-	).Scan(&user.ID, &user.ClerkID, &user.Name, &user.Email)
+	), &user)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Abort(c, apierror.NotFound("User not found"))
+			return
+		}
+		apierror.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// GetMe returns the profile of the authenticated user
+func GetMe(c *gin.Context) {
+	userID, ok := GetUserIDFromClerkID(c)
+	if !ok {
+		return
+	}
+
+	var user models.User
+	err := scanUser(database.DB.QueryRow(
+		"SELECT "+userColumns+" FROM users WHERE id = $1",
+		userID,
+	), &user)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			apierror.Abort(c, apierror.NotFound("User not found"))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apierror.Abort(c, err)
 		return
 	}
 
@@ -67,29 +109,34 @@ func GetUser(c *gin.Context) {
 func CreateUser(c *gin.Context) {
 	var user models.User
 	if err := c.ShouldBindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Abort(c, apierror.Invalid(err))
 		return
 	}
 
 	clerkID, ok := getClerkID(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		apierror.Abort(c, apierror.Unauthorized("Unauthorized"))
 		return
 	}
 	user.ClerkID = clerkID
+	user.Role = models.RoleUser
+	user.SuspendedAt = nil
+	if user.Timezone == "" {
+		user.Timezone = models.DefaultTimezone
+	}
 
 	if err := user.Validate(); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Abort(c, apierror.Invalid(err))
 		return
 	}
 
 	err := database.DB.QueryRow(
-		"INSERT INTO users (clerk_id, name, email) VALUES ($1, $2, $3) RETURNING id",
-		user.ClerkID, user.Name, user.Email,
+		"INSERT INTO users (clerk_id, name, email, timezone) VALUES ($1, $2, $3, $4) RETURNING id",
+		user.ClerkID, user.Name, user.Email, user.Timezone,
 	).Scan(&user.ID)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apierror.Abort(c, err)
 		return
 	}
 
@@ -100,89 +147,90 @@ func CreateUser(c *gin.Context) {
 func UpdateUser(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		apierror.Abort(c, apierror.InvalidID(""))
 		return
 	}
 
 	clerkID, ok := getClerkID(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		apierror.Abort(c, apierror.Unauthorized("Unauthorized"))
 		return
 	}
 
 	var user models.User
 	if err := c.ShouldBindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Abort(c, apierror.Invalid(err))
 		return
 	}
 
 	if err := user.Validate(); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Abort(c, apierror.Invalid(err))
 		return
 	}
 
+	// A timezone left out keeps the current one
 	result, err := database.DB.Exec(
-		"UPDATE users SET name = $1, email = $2 WHERE id = $3 AND clerk_id = $4",
-		user.Name, user.Email, id, clerkID,
+		"UPDATE users SET name = $1, email = $2, timezone = COALESCE(NULLIF($3, ''), timezone) WHERE id = $4 AND clerk_id = $5",
+		user.Name, user.Email, user.Timezone, id, clerkID,
 	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apierror.Abort(c, err)
 		return
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apierror.Abort(c, err)
 		return
 	}
 
 	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found or access denied"})
+		apierror.Abort(c, apierror.NotFound("User not found or access denied"))
 		return
 	}
 
 	// Get the updated user
-	err = database.DB.QueryRow(
-		"SELECT id, clerk_id, name, email FROM users WHERE id = $1",
+	err = scanUser(database.DB.QueryRow(
+		"SELECT "+userColumns+" FROM users WHERE id = $1",
 		id,
-	).Scan(&user.ID, &user.ClerkID, &user.Name, &user.Email)
+	), &user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apierror.Abort(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, user)
 }
 
-// DeleteUser deletes a user
+// DeleteUser schedules the deletion of a user. The account is locked right away
+// and purged by PurgeDeletedAccounts once the grace period is over,
+// until then CancelAccountDeletion restores it.
 func DeleteUser(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		apierror.Abort(c, apierror.InvalidID(""))
 		return
 	}
 
 	clerkID, ok := getClerkID(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		apierror.Abort(c, apierror.Unauthorized("Unauthorized"))
 		return
 	}
 
-	result, err := database.DB.Exec("DELETE FROM users WHERE id = $1 AND clerk_id = $2", id, clerkID)
+	deletion, err := scheduleAccountDeletion(c.Request.Context(), id, clerkID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get rows affected"})
-		return
-	}
-	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		switch err {
+		case sql.ErrNoRows:
+			apierror.Abort(c, apierror.NotFound("User not found"))
+		case errDeletionPending:
+			apierror.Abort(c, apierror.Conflict("Account deletion already scheduled"))
+		default:
+			apierror.Abort(c, err)
+		}
 		return
 	}
+	userIDs.forget(clerkID)
 
-	c.AbortWithStatus(http.StatusNoContent)
+	c.JSON(http.StatusAccepted, deletion)
 }
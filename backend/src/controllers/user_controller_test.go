@@ -5,12 +5,15 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"api/src/database"
+	"api/src/models"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -25,11 +28,11 @@ func TestGetUsers(t *testing.T) {
 		defer mockDB.Close()
 		database.DB = mockDB
 
-		rows := sqlmock.NewRows([]string{"id", "clerk_id", "name", "email"}).
-			AddRow(uuid.New(), "clerk1", "User One", "user1@example.com").
-			AddRow(uuid.New(), "clerk2", "User Two", "user2@example.com")
+		rows := sqlmock.NewRows([]string{"id", "clerk_id", "name", "email", "role", "suspended_at", "timezone"}).
+			AddRow(uuid.New(), "clerk1", "User One", "user1@example.com", "user", nil, "UTC").
+			AddRow(uuid.New(), "clerk2", "User Two", "user2@example.com", "admin", nil, "UTC")
 
-		mock.ExpectQuery("SELECT id, clerk_id, name, email FROM users").WillReturnRows(rows)
+		mock.ExpectQuery("SELECT id, clerk_id, name, email, role, suspended_at, timezone FROM users").WillReturnRows(rows)
 
 		w := httptest.NewRecorder()
 		c, _ := gin.CreateTestContext(w)
@@ -54,10 +57,10 @@ func TestGetUser(t *testing.T) {
 		database.DB = mockDB
 
 		testUUID := uuid.New()
-		rows := sqlmock.NewRows([]string{"id", "clerk_id", "name", "email"}).
-			AddRow(testUUID, "clerk1", "User One", "user1@example.com")
+		rows := sqlmock.NewRows([]string{"id", "clerk_id", "name", "email", "role", "suspended_at", "timezone"}).
+			AddRow(testUUID, "clerk1", "User One", "user1@example.com", "user", nil, "UTC")
 
-		mock.ExpectQuery("SELECT id, clerk_id, name, email FROM users WHERE id = \\$1").
+		mock.ExpectQuery("SELECT id, clerk_id, name, email, role, suspended_at, timezone FROM users WHERE id = \\$1").
 			WithArgs(testUUID).
 			WillReturnRows(rows)
 
@@ -65,11 +68,68 @@ func TestGetUser(t *testing.T) {
 		c, _ := gin.CreateTestContext(w)
 		c.Params = gin.Params{gin.Param{Key: "id", Value: testUUID.String()}}
 
+		originalGetCurrentUser := getCurrentUser
+		getCurrentUser = func(c *gin.Context) (currentUser, bool) {
+			return currentUser{ID: testUUID, Role: models.RoleUser}, true
+		}
+		defer func() { getCurrentUser = originalGetCurrentUser }()
+
 		GetUser(c)
 
 		assert.Equal(t, http.StatusOK, w.Code)
 		assert.Contains(t, w.Body.String(), testUUID.String())
 	})
+
+	t.Run("other user", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "id", Value: uuid.New().String()}}
+
+		originalGetCurrentUser := getCurrentUser
+		getCurrentUser = func(c *gin.Context) (currentUser, bool) {
+			return currentUser{ID: uuid.New(), Role: models.RoleUser}, true
+		}
+		defer func() { getCurrentUser = originalGetCurrentUser }()
+
+		GetUser(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestGetMe(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		testUUID := uuid.New()
+		rows := sqlmock.NewRows([]string{"id", "clerk_id", "name", "email", "role", "suspended_at", "timezone"}).
+			AddRow(testUUID, "clerk1", "User One", "user1@example.com", "admin", nil, "UTC")
+
+		mock.ExpectQuery("SELECT id, clerk_id, name, email, role, suspended_at, timezone FROM users WHERE id = \\$1").
+			WithArgs(testUUID).
+			WillReturnRows(rows)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		originalGetUserID := GetUserIDFromClerkID
+		GetUserIDFromClerkID = func(c *gin.Context) (uuid.UUID, bool) {
+			return testUUID, true
+		}
+		defer func() { GetUserIDFromClerkID = originalGetUserID }()
+
+		GetMe(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"role":"admin"`)
+	})
 }
 
 func TestCreateUser(t *testing.T) {
@@ -86,8 +146,8 @@ func TestCreateUser(t *testing.T) {
 		newUserID := uuid.New()
 		userJSON := `{"name":"New User","email":"newuser@example.com"}`
 
-		mock.ExpectQuery("INSERT INTO users \\(clerk_id, name, email\\) VALUES \\(\\$1, \\$2, \\$3\\) RETURNING id").
-			WithArgs("test-clerk-id", "New User", "newuser@example.com").
+		mock.ExpectQuery("INSERT INTO users \\(clerk_id, name, email, timezone\\) VALUES \\(\\$1, \\$2, \\$3, \\$4\\) RETURNING id").
+			WithArgs("test-clerk-id", "New User", "newuser@example.com", "UTC").
 			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(newUserID))
 
 		w := httptest.NewRecorder()
@@ -106,6 +166,36 @@ func TestCreateUser(t *testing.T) {
 		assert.Equal(t, http.StatusCreated, w.Code)
 		assert.Contains(t, w.Body.String(), newUserID.String())
 	})
+
+	t.Run("duplicate email", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		mock.ExpectQuery("INSERT INTO users \\(clerk_id, name, email, timezone\\) VALUES \\(\\$1, \\$2, \\$3, \\$4\\) RETURNING id").
+			WithArgs("test-clerk-id", "New User", "taken@example.com", "UTC").
+			WillReturnError(&pq.Error{Code: "23505", Constraint: "users_email_key", Message: "duplicate key value violates unique constraint"})
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/", strings.NewReader(`{"name":"New User","email":"taken@example.com"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		originalGetClerkID := getClerkID
+		getClerkID = func(c *gin.Context) (string, bool) {
+			return "test-clerk-id", true
+		}
+		defer func() { getClerkID = originalGetClerkID }()
+
+		CreateUser(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), `"field":"email"`)
+		assert.NotContains(t, w.Body.String(), "duplicate key value")
+	})
 }
 
 func TestUpdateUser(t *testing.T) {
@@ -122,13 +212,13 @@ func TestUpdateUser(t *testing.T) {
 		testUUID := uuid.New()
 		userJSON := `{"name":"Updated User","email":"updateduser@example.com"}`
 
-		mock.ExpectExec("UPDATE users SET name = \\$1, email = \\$2 WHERE id = \\$3 AND clerk_id = \\$4").
-			WithArgs("Updated User", "updateduser@example.com", testUUID, "test-clerk-id").
+		mock.ExpectExec("UPDATE users SET name = \\$1, email = \\$2, timezone = COALESCE\\(NULLIF\\(\\$3, ''\\), timezone\\) WHERE id = \\$4 AND clerk_id = \\$5").
+			WithArgs("Updated User", "updateduser@example.com", "", testUUID, "test-clerk-id").
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
-		rows := sqlmock.NewRows([]string{"id", "clerk_id", "name", "email"}).
-			AddRow(testUUID, "test-clerk-id", "Updated User", "updateduser@example.com")
-		mock.ExpectQuery("SELECT id, clerk_id, name, email FROM users WHERE id = \\$1").
+		rows := sqlmock.NewRows([]string{"id", "clerk_id", "name", "email", "role", "suspended_at", "timezone"}).
+			AddRow(testUUID, "test-clerk-id", "Updated User", "updateduser@example.com", "user", nil, "UTC")
+		mock.ExpectQuery("SELECT id, clerk_id, name, email, role, suspended_at, timezone FROM users WHERE id = \\$1").
 			WithArgs(testUUID).
 			WillReturnRows(rows)
 
@@ -154,7 +244,7 @@ func TestUpdateUser(t *testing.T) {
 func TestDeleteUser(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
-	t.Run("success", func(t *testing.T) {
+	t.Run("schedules deletion", func(t *testing.T) {
 		mockDB, mock, err := sqlmock.New()
 		if err != nil {
 			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
@@ -163,10 +253,20 @@ func TestDeleteUser(t *testing.T) {
 		database.DB = mockDB
 
 		testUUID := uuid.New()
+		requestedAt := time.Now()
+		scheduledFor := requestedAt.Add(models.AccountDeletionGracePeriod)
 
-		mock.ExpectExec("DELETE FROM users WHERE id = \\$1 AND clerk_id = \\$2").
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT deletion_scheduled_for FROM users WHERE id = \\$1 AND clerk_id = \\$2 FOR UPDATE").
 			WithArgs(testUUID, "test-clerk-id").
+			WillReturnRows(sqlmock.NewRows([]string{"deletion_scheduled_for"}).AddRow(nil))
+		mock.ExpectQuery("UPDATE users SET deletion_requested_at = NOW\\(\\), deletion_scheduled_for = \\$2").
+			WithArgs(testUUID, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"deletion_requested_at", "deletion_scheduled_for"}).AddRow(requestedAt, scheduledFor))
+		mock.ExpectExec("INSERT INTO audit_log \\(user_id, actor_id, action, details\\)").
+			WithArgs(testUUID, &testUUID, models.AuditDeletionRequested, sqlmock.AnyArg()).
 			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
 
 		w := httptest.NewRecorder()
 		c, _ := gin.CreateTestContext(w)
@@ -181,6 +281,41 @@ func TestDeleteUser(t *testing.T) {
 
 		DeleteUser(c)
 
-		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		assert.Contains(t, w.Body.String(), "scheduled_for")
+		assert.NoError(t, mock.ExpectationsWereMet())
 	})
-}
\ No newline at end of file
+
+	t.Run("already scheduled", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		testUUID := uuid.New()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT deletion_scheduled_for FROM users WHERE id = \\$1 AND clerk_id = \\$2 FOR UPDATE").
+			WithArgs(testUUID, "test-clerk-id").
+			WillReturnRows(sqlmock.NewRows([]string{"deletion_scheduled_for"}).AddRow(time.Now()))
+		mock.ExpectRollback()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "id", Value: testUUID.String()}}
+		c.Request, _ = http.NewRequest("DELETE", "/", nil)
+
+		originalGetClerkID := getClerkID
+		getClerkID = func(c *gin.Context) (string, bool) {
+			return "test-clerk-id", true
+		}
+		defer func() { getClerkID = originalGetClerkID }()
+
+		DeleteUser(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
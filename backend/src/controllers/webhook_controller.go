@@ -0,0 +1,235 @@
+package controllers
+
+import (
+	"api/src/apierror"
+	"api/src/database"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// webhookTolerance is how far a Svix timestamp may drift from the server clock
+const webhookTolerance = 5 * time.Minute
+
+// maxWebhookBody is the size limit of a webhook delivery. Clerk user events
+// weigh a few kilobytes.
+const maxWebhookBody = 64 << 10
+
+// clerkUserEvent is the subset of a Clerk webhook payload we use
+type clerkUserEvent struct {
+	Type string `json:"type"`
+	Data struct {
+		ID                    string `json:"id"`
+		FirstName             string `json:"first_name"`
+		LastName              string `json:"last_name"`
+		Username              string `json:"username"`
+		PrimaryEmailAddressID string `json:"primary_email_address_id"`
+		EmailAddresses        []struct {
+			ID           string `json:"id"`
+			EmailAddress string `json:"email_address"`
+		} `json:"email_addresses"`
+	} `json:"data"`
+}
+
+// email returns the user's primary email address, falling back to the first one listed
+func (e *clerkUserEvent) email() string {
+	for _, a := range e.Data.EmailAddresses {
+		if a.ID == e.Data.PrimaryEmailAddressID {
+			return a.EmailAddress
+		}
+	}
+	if len(e.Data.EmailAddresses) > 0 {
+		return e.Data.EmailAddresses[0].EmailAddress
+	}
+	return ""
+}
+
+// name returns the user's display name, falling back to username and then email
+func (e *clerkUserEvent) name() string {
+	name := strings.TrimSpace(e.Data.FirstName + " " + e.Data.LastName)
+	if name == "" {
+		name = e.Data.Username
+	}
+	if name == "" {
+		name = e.email()
+	}
+	return name
+}
+
+// verifySvixSignature checks a webhook delivery against the endpoint secret
+// using the Svix scheme: an HMAC-SHA256 over "id.timestamp.body", keyed with the
+// base64 part of a "whsec_" secret, sent as space separated "v1,<signature>" entries.
+func verifySvixSignature(secret string, header http.Header, body []byte, now time.Time) error {
+	id := header.Get("svix-id")
+	timestamp := header.Get("svix-timestamp")
+	signatures := header.Get("svix-signature")
+	if id == "" || timestamp == "" || signatures == "" {
+		return errors.New("missing svix headers")
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("invalid svix-timestamp")
+	}
+	sent := time.Unix(ts, 0)
+	if now.Sub(sent) > webhookTolerance || sent.Sub(now) > webhookTolerance {
+		return errors.New("svix-timestamp outside of tolerance")
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(secret, "whsec_"))
+	if err != nil {
+		return fmt.Errorf("invalid webhook secret: %v", err)
+	}
+
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%s.%s.", id, timestamp)
+	mac.Write(body)
+	expected := mac.Sum(nil)
+
+	for _, entry := range strings.Fields(signatures) {
+		version, sig, found := strings.Cut(entry, ",")
+		if !found || version != "v1" {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(sig)
+		if err != nil {
+			continue
+		}
+		if hmac.Equal(decoded, expected) {
+			return nil
+		}
+	}
+	return errors.New("no matching signature")
+}
+
+// ClerkWebhook keeps the users table in sync with Clerk. Deliveries are verified
+// with the Svix signing secret in CLERK_WEBHOOK_SECRET and recorded by their
+// svix-id, so redelivered events are acknowledged without being applied twice.
+func ClerkWebhook(c *gin.Context) {
+	secret := os.Getenv("CLERK_WEBHOOK_SECRET")
+	if secret == "" {
+		apierror.Abort(c, apierror.Unavailable("Webhook secret not configured"))
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookBody))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			apierror.Abort(c, apierror.TooLarge("Webhook payload too large"))
+			return
+		}
+		apierror.Abort(c, apierror.BadRequest("Failed to read request body"))
+		return
+	}
+
+	if err := verifySvixSignature(secret, c.Request.Header, body, time.Now()); err != nil {
+		apierror.Abort(c, apierror.Unauthorized("Invalid webhook signature"))
+		return
+	}
+
+	var event clerkUserEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		apierror.Abort(c, apierror.BadRequest("Invalid webhook payload"))
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		"INSERT INTO webhook_events (id, type) VALUES ($1, $2) ON CONFLICT (id) DO NOTHING",
+		c.GetHeader("svix-id"), event.Type,
+	)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	if rowsAffected == 0 {
+		c.JSON(http.StatusOK, gin.H{"status": "duplicate"})
+		return
+	}
+
+	exportFiles, err := applyClerkUserEvent(c.Request.Context(), tx, &event)
+	if err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		apierror.Abort(c, err)
+		return
+	}
+	if event.Type == "user.deleted" {
+		userIDs.forget(event.Data.ID)
+		removeExportFiles(exportFiles)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "processed"})
+}
+
+// applyClerkUserEvent upserts or purges the user a Clerk event refers to.
+// A deleted user goes through the same purge as a scheduled deletion, and the
+// export files to remove once committed are returned. Event types we don't
+// handle are acknowledged and ignored.
+func applyClerkUserEvent(ctx context.Context, tx *sql.Tx, event *clerkUserEvent) ([]string, error) {
+	if event.Data.ID == "" {
+		return nil, errors.New("event is missing a user id")
+	}
+
+	switch event.Type {
+	case "user.created", "user.updated":
+		email := event.email()
+		if email == "" {
+			return nil, fmt.Errorf("user %s has no email address", event.Data.ID)
+		}
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO users (clerk_id, name, email) VALUES ($1, $2, $3)
+			 ON CONFLICT (clerk_id) DO UPDATE SET name = EXCLUDED.name, email = EXCLUDED.email`,
+			event.Data.ID, event.name(), email,
+		)
+		return nil, err
+	case "user.deleted":
+		var userID uuid.UUID
+		err := tx.QueryRowContext(ctx,
+			"SELECT id FROM users WHERE clerk_id = $1 FOR UPDATE",
+			event.Data.ID,
+		).Scan(&userID)
+		// Purging the account deletes the identity at Clerk, which sends this event back
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		// The identity is already gone at Clerk
+		return purgeUser(ctx, tx, userID, nil)
+	default:
+		log.Printf("Ignoring Clerk webhook event of type %q", event.Type)
+		return nil, nil
+	}
+}
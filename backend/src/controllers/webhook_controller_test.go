@@ -0,0 +1,226 @@
+package controllers
+
+import (
+	"api/src/database"
+	"api/src/models"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var testWebhookKey = []byte("test-webhook-signing-key")
+
+// newSignedWebhookRequest builds a webhook delivery signed the way Svix signs them
+func newSignedWebhookRequest(t *testing.T, id, body string, sentAt time.Time) *http.Request {
+	t.Helper()
+	t.Setenv("CLERK_WEBHOOK_SECRET", "whsec_"+base64.StdEncoding.EncodeToString(testWebhookKey))
+
+	timestamp := strconv.FormatInt(sentAt.Unix(), 10)
+	mac := hmac.New(sha256.New, testWebhookKey)
+	mac.Write([]byte(id + "." + timestamp + "." + body))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	req, _ := http.NewRequest("POST", "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("svix-id", id)
+	req.Header.Set("svix-timestamp", timestamp)
+	req.Header.Set("svix-signature", "v1,bm90LXRoaXMtb25l v1,"+signature)
+	return req
+}
+
+const userCreatedEvent = `{
+	"type": "user.created",
+	"data": {
+		"id": "user_123",
+		"first_name": "Ada",
+		"last_name": "Lovelace",
+		"primary_email_address_id": "idn_2",
+		"email_addresses": [
+			{"id": "idn_1", "email_address": "old@example.com"},
+			{"id": "idn_2", "email_address": "ada@example.com"}
+		]
+	}
+}`
+
+func TestClerkWebhook(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("user created", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO webhook_events \(id, type\) VALUES \(\$1, \$2\) ON CONFLICT \(id\) DO NOTHING`).
+			WithArgs("msg_1", "user.created").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`INSERT INTO users \(clerk_id, name, email\) VALUES \(\$1, \$2, \$3\) ON CONFLICT \(clerk_id\) DO UPDATE`).
+			WithArgs("user_123", "Ada Lovelace", "ada@example.com").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newSignedWebhookRequest(t, "msg_1", userCreatedEvent, time.Now())
+
+		ClerkWebhook(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "processed")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("duplicate delivery", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO webhook_events`).
+			WithArgs("msg_1", "user.created").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newSignedWebhookRequest(t, "msg_1", userCreatedEvent, time.Now())
+
+		ClerkWebhook(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "duplicate")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("user deleted", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		testUserID := uuid.New()
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO webhook_events`).
+			WithArgs("msg_2", "user.deleted").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT id FROM users WHERE clerk_id = \$1 FOR UPDATE`).
+			WithArgs("user_123").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testUserID))
+		mock.ExpectExec(`INSERT INTO audit_log`).
+			WithArgs(testUserID, nil, models.AuditPurgeStarted, []byte("{}")).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM flashcards`).
+			WithArgs(testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+		mock.ExpectExec(`DELETE FROM decks WHERE owner_id = \$1`).
+			WithArgs(testUserID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO audit_log`).
+			WithArgs(testUserID, nil, models.AuditContentPurged, []byte(`{"decks":1,"flashcards":3}`)).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT file_path FROM export_jobs`).
+			WithArgs(testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"file_path"}))
+		mock.ExpectExec(`DELETE FROM users WHERE id = \$1`).
+			WithArgs(testUserID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO audit_log`).
+			WithArgs(testUserID, nil, models.AuditAccountPurged, []byte(`{"export_files":0}`)).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		originalDeleteIdentity := DeleteIdentity
+		DeleteIdentity = func(ctx context.Context, clerkID string) error {
+			t.Errorf("identity %s deleted again", clerkID)
+			return nil
+		}
+		defer func() { DeleteIdentity = originalDeleteIdentity }()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newSignedWebhookRequest(t, "msg_2", `{"type":"user.deleted","data":{"id":"user_123","deleted":true}}`, time.Now())
+
+		ClerkWebhook(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("user already purged", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO webhook_events`).
+			WithArgs("msg_5", "user.deleted").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT id FROM users WHERE clerk_id = \$1 FOR UPDATE`).
+			WithArgs("user_123").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectCommit()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newSignedWebhookRequest(t, "msg_5", `{"type":"user.deleted","data":{"id":"user_123","deleted":true}}`, time.Now())
+
+		ClerkWebhook(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("tampered body", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newSignedWebhookRequest(t, "msg_3", userCreatedEvent, time.Now())
+		c.Request.Body = http.NoBody
+
+		ClerkWebhook(c)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("oversized body", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newSignedWebhookRequest(t, "msg_5", strings.Repeat(" ", maxWebhookBody+1), time.Now())
+
+		ClerkWebhook(c)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+
+	t.Run("stale timestamp", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newSignedWebhookRequest(t, "msg_4", userCreatedEvent, time.Now().Add(-time.Hour))
+
+		ClerkWebhook(c)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
@@ -0,0 +1,169 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"time"
+
+	"api/src/models"
+
+	"github.com/google/uuid"
+)
+
+// Account is everything stored about a user, as included in an account archive
+type Account struct {
+	User models.User
+	// StudyGoal is nil for users who kept the default goal
+	StudyGoal     *models.StudyGoal
+	Tokens        []models.PersonalAccessToken
+	DeckPresets   []models.DeckPreset
+	Decks         []DeckExport
+	FilteredDecks []models.FilteredDeck
+	// CardStates are the schedules of the flashcards of the decks
+	CardStates    []models.CardState
+	StudySessions []models.StudySession
+	// ReviewLogs are every answer given while studying, oldest first
+	ReviewLogs   []models.ReviewLog
+	QuizAttempts []models.QuizAttempt
+	GameRounds   []GameRound
+}
+
+// GameRound is a played game round of an account archive. Its fronts, backs
+// and answer key are left out: they follow from the deck and the seed.
+type GameRound struct {
+	ID          uuid.UUID  `json:"id"`
+	DeckID      uuid.UUID  `json:"deck_id"`
+	Kind        string     `json:"kind"`
+	Seed        int64      `json:"seed"`
+	Seeded      bool       `json:"seeded"`
+	StartedAt   time.Time  `json:"started_at"`
+	SubmittedAt *time.Time `json:"submitted_at"`
+	Correct     *int       `json:"correct"`
+	Total       int        `json:"total"`
+	DurationMs  *int64     `json:"duration_ms"`
+}
+
+// DeckExport is one deck of an account archive with its flashcards and edit history
+type DeckExport struct {
+	Deck               models.Deck
+	Flashcards         []models.Flashcard
+	DeckRevisions      []models.DeckRevision
+	FlashcardRevisions []models.FlashcardRevision
+}
+
+// manifest describes the contents of an account archive
+type manifest struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	UserID     string    `json:"user_id"`
+	Decks      int       `json:"decks"`
+	Flashcards int       `json:"flashcards"`
+	Files      []string  `json:"files"`
+}
+
+// WriteAccountArchive writes a zip archive of an account laid out as
+//
+//	manifest.json
+//	profile.json
+//	study_goal.json              the daily goal, null for the default one
+//	tokens.json                  personal access tokens, without their secrets
+//	deck_presets.json
+//	filtered_decks.json
+//	card_states.json             the schedule of every studied flashcard
+//	study_sessions.json
+//	review_logs.json             every answer given while studying
+//	quiz_attempts.json           graded quiz submissions with their answer keys
+//	game_rounds.json
+//	decks/<deck id>/deck.json    the deck in the JSON deck export format
+//	decks/<deck id>/cards.csv    the deck in the CSV deck export format
+//	decks/<deck id>/history.json deck and flashcard revisions
+func WriteAccountArchive(w io.Writer, account Account, exportedAt time.Time) error {
+	zw := zip.NewWriter(w)
+	m := manifest{Version: 1, ExportedAt: exportedAt, UserID: account.User.ID.String(), Decks: len(account.Decks)}
+
+	create := func(name string) (io.Writer, error) {
+		m.Files = append(m.Files, name)
+		return zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: exportedAt})
+	}
+	writeJSON := func(name string, v any) error {
+		f, err := create(name)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	files := []struct {
+		name string
+		v    any
+	}{
+		{"profile.json", account.User},
+		{"study_goal.json", account.StudyGoal},
+		{"tokens.json", orEmpty(account.Tokens)},
+		{"deck_presets.json", orEmpty(account.DeckPresets)},
+		{"filtered_decks.json", orEmpty(account.FilteredDecks)},
+		{"card_states.json", orEmpty(account.CardStates)},
+		{"study_sessions.json", orEmpty(account.StudySessions)},
+		{"review_logs.json", orEmpty(account.ReviewLogs)},
+		{"quiz_attempts.json", orEmpty(account.QuizAttempts)},
+		{"game_rounds.json", orEmpty(account.GameRounds)},
+	}
+	for _, f := range files {
+		if err := writeJSON(f.name, f.v); err != nil {
+			return err
+		}
+	}
+
+	for _, d := range account.Decks {
+		dir := "decks/" + d.Deck.ID.String() + "/"
+		m.Flashcards += len(d.Flashcards)
+
+		f, err := create(dir + "deck.json")
+		if err != nil {
+			return err
+		}
+		if err := WriteDeckJSON(f, d.Deck, d.Flashcards); err != nil {
+			return err
+		}
+
+		f, err = create(dir + "cards.csv")
+		if err != nil {
+			return err
+		}
+		if err := WriteDeckCSV(f, d.Flashcards); err != nil {
+			return err
+		}
+
+		history := struct {
+			Deck       []models.DeckRevision      `json:"deck"`
+			Flashcards []models.FlashcardRevision `json:"flashcards"`
+		}{orEmpty(d.DeckRevisions), orEmpty(d.FlashcardRevisions)}
+		if err := writeJSON(dir+"history.json", history); err != nil {
+			return err
+		}
+	}
+
+	// The manifest is written last so it can list every other file, itself included
+	m.Files = append(m.Files, "manifest.json")
+	f, err := zw.CreateHeader(&zip.FileHeader{Name: "manifest.json", Method: zip.Deflate, Modified: exportedAt})
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(m); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// orEmpty keeps a nil list from being written as null
+func orEmpty[T any](list []T) []T {
+	if list == nil {
+		return []T{}
+	}
+	return list
+}
@@ -0,0 +1,76 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"api/src/models"
+)
+
+// Deck export formats
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+// DeckDocumentVersion is bumped whenever the JSON deck document changes incompatibly
+const DeckDocumentVersion = 1
+
+// DeckDocument is the JSON representation of an exported deck
+type DeckDocument struct {
+	Version    int                `json:"version"`
+	Deck       models.Deck        `json:"deck"`
+	Flashcards []models.Flashcard `json:"flashcards"`
+}
+
+// csvHeader is the first row of a CSV deck export
+var csvHeader = []string{"front", "back", "starred"}
+
+// ContentType returns the MIME type of an export format
+func ContentType(format string) string {
+	if format == FormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/json; charset=utf-8"
+}
+
+// WriteDeck writes a deck and its flashcards in the requested format
+func WriteDeck(w io.Writer, format string, deck models.Deck, flashcards []models.Flashcard) error {
+	switch format {
+	case FormatJSON:
+		return WriteDeckJSON(w, deck, flashcards)
+	case FormatCSV:
+		return WriteDeckCSV(w, flashcards)
+	default:
+		return fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+// WriteDeckJSON writes a deck and its flashcards as an indented DeckDocument
+func WriteDeckJSON(w io.Writer, deck models.Deck, flashcards []models.Flashcard) error {
+	if flashcards == nil {
+		flashcards = []models.Flashcard{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(DeckDocument{Version: DeckDocumentVersion, Deck: deck, Flashcards: flashcards})
+}
+
+// WriteDeckCSV writes flashcards as front,back,starred rows under a header row
+func WriteDeckCSV(w io.Writer, flashcards []models.Flashcard) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, f := range flashcards {
+		starred := f.Starred != nil && *f.Starred
+		if err := cw.Write([]string{f.Front, f.Back, strconv.FormatBool(starred)}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,80 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"api/src/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func testDeck() (models.Deck, []models.Flashcard) {
+	starred, unstarred := true, false
+	deck := models.Deck{ID: uuid.New(), Title: "Capitals", Labels: []string{"geography"}}
+	cards := []models.Flashcard{
+		{ID: uuid.New(), ParentDeck: deck.ID, Front: "France", Back: "Paris", Starred: &starred},
+		{ID: uuid.New(), ParentDeck: deck.ID, Front: "Italy, capital of", Back: "Rome", Starred: &unstarred},
+	}
+	return deck, cards
+}
+
+func TestWriteDeckCSV(t *testing.T) {
+	_, cards := testDeck()
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteDeck(&buf, FormatCSV, models.Deck{}, cards))
+	assert.Equal(t, "front,back,starred\nFrance,Paris,true\n\"Italy, capital of\",Rome,false\n", buf.String())
+}
+
+func TestWriteDeckJSON(t *testing.T) {
+	deck, cards := testDeck()
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteDeck(&buf, FormatJSON, deck, cards))
+
+	var doc DeckDocument
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, DeckDocumentVersion, doc.Version)
+	assert.Equal(t, "Capitals", doc.Deck.Title)
+	assert.Len(t, doc.Flashcards, 2)
+}
+
+func TestWriteDeckUnknownFormat(t *testing.T) {
+	assert.Error(t, WriteDeck(io.Discard, "xml", models.Deck{}, nil))
+}
+
+func TestWriteAccountArchive(t *testing.T) {
+	deck, cards := testDeck()
+	account := Account{
+		User:  models.User{ID: uuid.New(), Name: "Ada", Email: "ada@example.com"},
+		Decks: []DeckExport{{Deck: deck, Flashcards: cards}},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteAccountArchive(&buf, account, time.Now()))
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	dir := "decks/" + deck.ID.String() + "/"
+	assert.Equal(t, []string{"profile.json", "study_goal.json", "tokens.json", "deck_presets.json", "filtered_decks.json", "card_states.json",
+		"study_sessions.json", "review_logs.json", "quiz_attempts.json", "game_rounds.json", dir + "deck.json", dir + "cards.csv", dir + "history.json", "manifest.json"}, names)
+
+	f, err := zr.Open("manifest.json")
+	assert.NoError(t, err)
+	var m manifest
+	assert.NoError(t, json.NewDecoder(f).Decode(&m))
+	assert.Equal(t, 1, m.Decks)
+	assert.Equal(t, 2, m.Flashcards)
+	assert.Contains(t, m.Files, "manifest.json")
+}
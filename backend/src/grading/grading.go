@@ -0,0 +1,112 @@
+// Package grading checks an answer typed for a flashcard against its back.
+//
+// Answers and backs are compared once normalized: case, accents, punctuation,
+// whitespace and a leading article don't count. A back can list alternative
+// answers separated by ";" or by "/" between spaces, any of which is
+// accepted, so "km/h" stays whole. Typos are
+// tolerated depending on the length of the expected answer.
+package grading
+
+import (
+	"api/src/scheduler"
+	"api/src/textdiff"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// articles are the leading words ignored when comparing answers
+var articles = map[string]bool{
+	"a": true, "an": true, "the": true,
+	"le": true, "la": true, "les": true, "l": true, "un": true, "une": true, "des": true,
+	"el": true, "los": true, "las": true, "una": true, "unos": true, "unas": true,
+	"der": true, "die": true, "das": true, "ein": true, "eine": true,
+	"il": true, "lo": true, "gli": true, "uno": true,
+}
+
+// Result is the outcome of checking a typed answer
+type Result struct {
+	// Correct answers match an alternative, with at most Tolerance typos
+	Correct bool            `json:"correct"`
+	Grade   scheduler.Grade `json:"grade"`
+	// Expected is the alternative of the back closest to the answer
+	Expected string `json:"expected"`
+	// Distance is the number of typos between the normalized answer and Expected
+	Distance int `json:"distance"`
+	// Diff turns the normalized answer into the normalized Expected character
+	// by character, so differences that don't count aren't shown as mistakes
+	Diff []textdiff.Segment `json:"diff"`
+}
+
+// slash separates alternatives; a slash within a word, as in "and/or" or
+// "1/2", is part of the answer
+var slash = regexp.MustCompile(`\s/\s`)
+
+// Alternatives splits the back of a flashcard into the answers it accepts
+func Alternatives(back string) []string {
+	var alternatives []string
+	for _, part := range strings.Split(back, ";") {
+		for _, s := range slash.Split(part, -1) {
+			if s = strings.TrimSpace(s); s != "" {
+				alternatives = append(alternatives, s)
+			}
+		}
+	}
+	if len(alternatives) == 0 {
+		return []string{strings.TrimSpace(back)}
+	}
+	return alternatives
+}
+
+// Normalize folds the case and accents of s, drops punctuation, collapses
+// whitespace and removes a leading article
+func Normalize(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		if !unicode.Is(unicode.Mn, r) {
+			b.WriteRune(r)
+		}
+	}
+	// Elided articles like l'eau become separate words once the apostrophe is dropped
+	s = textdiff.Normalize(strings.NewReplacer("'", " ", "’", " ").Replace(b.String()))
+	if first, rest, ok := strings.Cut(s, " "); ok && articles[first] {
+		return rest
+	}
+	return s
+}
+
+// Tolerance returns the number of typos allowed in an answer of n characters:
+// none up to 3 characters, then one more for every 8
+func Tolerance(n int) int {
+	return (n + 4) / 8
+}
+
+// Check grades answer against back. An answer matching exactly once normalized
+// is Good, one within the typo tolerance Hard, and any other Again.
+func Check(answer, back string) Result {
+	normalized := Normalize(answer)
+
+	result := Result{Distance: -1}
+	for _, alternative := range Alternatives(back) {
+		expected := Normalize(alternative)
+		distance := textdiff.Distance(normalized, expected)
+		if result.Distance < 0 || distance < result.Distance {
+			result.Expected = alternative
+			result.Distance = distance
+			result.Correct = normalized != "" && distance <= Tolerance(len([]rune(expected)))
+		}
+	}
+
+	switch {
+	case !result.Correct:
+		result.Grade = scheduler.Again
+	case result.Distance == 0:
+		result.Grade = scheduler.Good
+	default:
+		result.Grade = scheduler.Hard
+	}
+	result.Diff = textdiff.Chars(normalized, Normalize(result.Expected))
+	return result
+}
@@ -0,0 +1,73 @@
+package grading
+
+import (
+	"api/src/scheduler"
+	"api/src/textdiff"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "cafe creme", Normalize("  Café   Crème! "))
+	assert.Equal(t, "eau", Normalize("l'eau"))
+	assert.Equal(t, "hund", Normalize("der Hund"))
+	assert.Equal(t, "capital of france", Normalize("The capital of France"))
+	assert.Equal(t, "the", Normalize("the"))
+}
+
+func TestAlternatives(t *testing.T) {
+	assert.Equal(t, []string{"car", "automobile", "auto"}, Alternatives("car; automobile / auto"))
+	assert.Equal(t, []string{"Paris"}, Alternatives("Paris"))
+	assert.Equal(t, []string{"/"}, Alternatives("/"))
+	assert.Equal(t, []string{"km/h"}, Alternatives("km/h"))
+	assert.Equal(t, []string{"1/2", "one half"}, Alternatives("1/2 / one half"))
+	assert.Equal(t, []string{"and/or"}, Alternatives("and/or"))
+	assert.Equal(t, []string{"bike", "bicycle"}, Alternatives("bike\t/  bicycle"))
+}
+
+func TestTolerance(t *testing.T) {
+	assert.Equal(t, 0, Tolerance(3))
+	assert.Equal(t, 1, Tolerance(4))
+	assert.Equal(t, 1, Tolerance(11))
+	assert.Equal(t, 2, Tolerance(12))
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name, answer, back string
+		grade              scheduler.Grade
+		expected           string
+		distance           int
+	}{
+		{"exact", "Paris", "Paris", scheduler.Good, "Paris", 0},
+		{"case, accents and article", "el nino", "El Niño", scheduler.Good, "El Niño", 0},
+		{"alternative", "automobile", "car; automobile", scheduler.Good, "automobile", 0},
+		{"typo", "mitocondria", "mitochondria", scheduler.Hard, "mitochondria", 1},
+		{"closest alternative", "bicyle", "bike / bicycle", scheduler.Hard, "bicycle", 1},
+		{"slash within an answer", "km/h", "km/h", scheduler.Good, "km/h", 0},
+		{"too many typos", "bycicle", "bicycle", scheduler.Again, "bicycle", 2},
+		{"short answers allow no typo", "cat", "car", scheduler.Again, "car", 1},
+		{"wrong", "Lyon", "Paris", scheduler.Again, "Paris", 5},
+		{"empty", "  ", "Paris", scheduler.Again, "Paris", 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Check(tt.answer, tt.back)
+			assert.Equal(t, tt.grade, result.Grade)
+			assert.Equal(t, tt.grade != scheduler.Again, result.Correct)
+			assert.Equal(t, tt.expected, result.Expected)
+			assert.Equal(t, tt.distance, result.Distance)
+		})
+	}
+}
+
+func TestCheckDiff(t *testing.T) {
+	result := Check("Pari", "Paris")
+	assert.Equal(t, []textdiff.Segment{{Op: textdiff.Equal, Text: "pari"}, {Op: textdiff.Insert, Text: "s"}}, result.Diff)
+	assert.Equal(t, "paris", textdiff.Join(result.Diff, textdiff.Insert))
+
+	// Differences that only come from normalization aren't mistakes
+	result = Check("el nino", "El Niño")
+	assert.Equal(t, []textdiff.Segment{{Op: textdiff.Equal, Text: "nino"}}, result.Diff)
+}
@@ -0,0 +1,98 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// ErrQueueFull is returned by Enqueue when no more jobs can be buffered
+var ErrQueueFull = errors.New("job queue is full")
+
+// Func is a unit of background work. The context is cancelled when the runner stops.
+type Func func(ctx context.Context) error
+
+type job struct {
+	name string
+	fn   Func
+}
+
+// Runner executes jobs on a fixed pool of goroutines, outside of any request
+type Runner struct {
+	queue   chan job
+	workers int
+	wg      sync.WaitGroup
+}
+
+// NewRunner returns a runner with the given number of workers and queue capacity
+func NewRunner(workers, queueSize int) *Runner {
+	return &Runner{queue: make(chan job, queueSize), workers: workers}
+}
+
+// Default is the runner used by the application, started from main
+var Default = NewRunner(2, 100)
+
+// Start launches the workers. They stop once ctx is cancelled; Wait blocks until they have.
+func (r *Runner) Start(ctx context.Context) {
+	for i := 0; i < r.workers; i++ {
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case j := <-r.queue:
+					run(ctx, j)
+				}
+			}
+		}()
+	}
+}
+
+// Wait blocks until every worker and periodic task has stopped
+func (r *Runner) Wait() {
+	r.wg.Wait()
+}
+
+// Enqueue schedules fn to run on a worker
+func (r *Runner) Enqueue(name string, fn Func) error {
+	select {
+	case r.queue <- job{name: name, fn: fn}:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Every runs fn every interval until ctx is cancelled
+func (r *Runner) Every(ctx context.Context, name string, interval time.Duration, fn Func) {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				run(ctx, job{name: name, fn: fn})
+			}
+		}
+	}()
+}
+
+// run executes a job, logging its failure and recovering from panics
+func run(ctx context.Context, j job) {
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf("Job %s panicked: %v", j.name, p)
+		}
+	}()
+	if err := j.fn(ctx); err != nil {
+		log.Printf("Job %s failed: %v", j.name, err)
+	}
+}
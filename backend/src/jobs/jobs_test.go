@@ -0,0 +1,83 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunner(t *testing.T) {
+	t.Run("runs enqueued jobs", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		r := NewRunner(2, 10)
+		r.Start(ctx)
+
+		done := make(chan string, 3)
+		for _, name := range []string{"a", "b", "c"} {
+			name := name
+			assert.NoError(t, r.Enqueue(name, func(context.Context) error {
+				done <- name
+				return nil
+			}))
+		}
+
+		seen := map[string]bool{}
+		for i := 0; i < 3; i++ {
+			select {
+			case name := <-done:
+				seen[name] = true
+			case <-time.After(time.Second):
+				t.Fatal("job did not run")
+			}
+		}
+		assert.Len(t, seen, 3)
+
+		cancel()
+		r.Wait()
+	})
+
+	t.Run("survives failing and panicking jobs", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		r := NewRunner(1, 10)
+		r.Start(ctx)
+
+		done := make(chan struct{})
+		r.Enqueue("fails", func(context.Context) error { return errors.New("boom") })
+		r.Enqueue("panics", func(context.Context) error { panic("boom") })
+		r.Enqueue("works", func(context.Context) error { close(done); return nil })
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("worker stopped after a failing job")
+		}
+	})
+
+	t.Run("queue full", func(t *testing.T) {
+		r := NewRunner(1, 1)
+		noop := func(context.Context) error { return nil }
+
+		assert.NoError(t, r.Enqueue("first", noop))
+		assert.ErrorIs(t, r.Enqueue("second", noop), ErrQueueFull)
+	})
+
+	t.Run("periodic task", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		r := NewRunner(0, 0)
+
+		var runs atomic.Int32
+		r.Every(ctx, "tick", 5*time.Millisecond, func(context.Context) error {
+			runs.Add(1)
+			return nil
+		})
+
+		assert.Eventually(t, func() bool { return runs.Load() >= 2 }, time.Second, 5*time.Millisecond)
+		cancel()
+		r.Wait()
+	})
+}
@@ -1,28 +1,58 @@
 package main
 
 import (
+	"api/src/apierror"
+	"api/src/config"
+	"api/src/controllers"
 	"api/src/database"
+	"api/src/jobs"
+	"api/src/middleware"
 	"api/src/routes"
+	"context"
+	"crypto/rand"
 	"database/sql"
 	"log"
-	"os"
+	"time"
 
-	"github.com/clerk/clerk-sdk-go/v2"
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRouter(db *sql.DB) *gin.Engine {
+func SetupRouter(db *sql.DB, auth middleware.Authenticator) *gin.Engine {
 	database.DB = db
-	r := routes.SetupRouter()
+	r := routes.SetupRouter(auth)
 	return r
 }
 
+// startJobs runs the background workers and periodic maintenance tasks
+func startJobs(ctx context.Context) {
+	jobs.Default.Start(ctx)
+	jobs.Default.Every(ctx, "purge-expired-exports", time.Hour, controllers.PurgeExpiredExports)
+	jobs.Default.Every(ctx, "purge-deleted-accounts", time.Hour, controllers.PurgeDeletedAccounts)
+	jobs.Default.Every(ctx, "close-abandoned-study-sessions", 5*time.Minute, controllers.CloseAbandonedStudySessions)
+}
+
 func main() {
-	clerkKey := os.Getenv("CLERK_SECRET_KEY")
-	if clerkKey == "" {
-		log.Fatal("CLERK_SECRET_KEY not set")
+	cfg := config.Load()
+	auth, err := middleware.NewAuthenticator(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Using %s authentication", cfg.AuthProvider)
+	controllers.UserProvisioning = cfg.UserProvisioning
+	if cfg.AuthProvider == config.AuthClerk {
+		controllers.DeleteIdentity = middleware.DeleteClerkUser
+	}
+	apierror.Production = cfg.Env == config.EnvProduction
+
+	controllers.ExportDir = cfg.ExportDir
+	controllers.ExportSigningKey = []byte(cfg.ExportSigningSecret)
+	if cfg.ExportSigningSecret == "" {
+		// Download links then stop working when the server restarts
+		controllers.ExportSigningKey = make([]byte, 32)
+		if _, err := rand.Read(controllers.ExportSigningKey); err != nil {
+			log.Fatal(err)
+		}
 	}
-	clerk.SetKey(clerkKey)
 
 	db, err := database.InitDB()
 	if err != nil {
@@ -30,14 +60,14 @@ func main() {
 	}
 	defer db.Close()
 
-	r := SetupRouter(db)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	startJobs(ctx)
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
-	log.Printf("Server listening on port %s", port)
-	if err := r.Run(":" + port); err != nil {
+	r := SetupRouter(db, auth)
+
+	log.Printf("Server listening on port %s", cfg.Port)
+	if err := r.Run(":" + cfg.Port); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"api/src/middleware"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -22,7 +23,7 @@ func TestSetupRouter(t *testing.T) {
 
 	mock.ExpectExec("").WillReturnResult(sqlmock.NewResult(1, 1))
 
-	r := SetupRouter(mockDB)
+	r := SetupRouter(mockDB, middleware.StaticTokenAuthenticator{})
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", "/api/go/health", nil)
 	r.ServeHTTP(w, req)
@@ -1,24 +1,101 @@
 package middleware
 
 import (
+	"api/src/apierror"
+	"context"
+	"errors"
 	"net/http"
+	"strings"
 
-	clerkhttp "github.com/clerk/clerk-sdk-go/v2/http"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
-// ClerkMiddleware is a middleware that validates JWT tokens using Clerk
-func ClerkMiddleware() gin.HandlerFunc {
+// ErrUnauthenticated is returned by an Authenticator when a request carries no valid credentials
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	// Subject is the identity provider's user ID, stored as users.clerk_id
+	Subject string
+	// Email and Name are optional profile claims used for just-in-time provisioning
+	Email string
+	Name  string
+	// UserID, TokenID and Scopes are only set for personal access tokens.
+	// A nil Scopes places no restriction on the principal.
+	UserID  uuid.UUID
+	TokenID uuid.UUID
+	Scopes  []string
+}
+
+// Authenticator verifies the credentials of a request and returns its principal
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+type principalKey struct{}
+
+// ContextWithPrincipal returns a copy of ctx carrying the principal
+func ContextWithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the principal stored by the Authenticate middleware
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// Authenticate rejects requests the authenticator can't verify with 401 and
+// stores the principal of the others in the request context
+func Authenticate(a Authenticator) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		clerkHandler := clerkhttp.RequireHeaderAuthorization()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			c.Request = r
-			c.Next()
-		}))
+		principal, err := a.Authenticate(c.Request)
+		if errors.Is(err, ErrUnauthenticated) {
+			apierror.Abort(c, apierror.Unauthorized("Unauthorized"))
+			return
+		}
+		if err != nil {
+			apierror.Abort(c, err)
+			return
+		}
 
-		clerkHandler.ServeHTTP(c.Writer, c.Request)
+		c.Request = c.Request.WithContext(ContextWithPrincipal(c.Request.Context(), principal))
+		c.Next()
+	}
+}
 
-		if c.IsAborted() {
+// RequireScope rejects principals that weren't granted scope with 403
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := PrincipalFromContext(c.Request.Context())
+		if !ok || !principal.HasScope(scope) {
+			apierror.Abort(c, apierror.Forbidden(apierror.CodeInsufficientScope, "Token is missing the "+scope+" scope"))
 			return
 		}
+		c.Next()
+	}
+}
+
+// RequireSession rejects personal access tokens with 403, for routes such as
+// token management that need an interactive session
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := PrincipalFromContext(c.Request.Context())
+		if !ok || principal.IsPersonalToken() {
+			apierror.Abort(c, apierror.Forbidden(apierror.CodeSessionRequired, "This route requires a session, not a personal access token"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// bearerToken extracts the token from an "Authorization: Bearer <token>" header
+func bearerToken(r *http.Request) string {
+	authorization := strings.TrimSpace(r.Header.Get("Authorization"))
+	token, found := strings.CutPrefix(authorization, "Bearer ")
+	if !found {
+		return ""
 	}
+	return strings.TrimSpace(token)
 }
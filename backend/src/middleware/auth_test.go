@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newBearerRequest(token string) *http.Request {
+	req, _ := http.NewRequest("GET", "/", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+func TestLocalJWTAuthenticator(t *testing.T) {
+	secret := []byte("local-secret")
+	auth := LocalJWTAuthenticator{Secret: secret}
+
+	t.Run("valid token", func(t *testing.T) {
+		token, err := IssueLocalToken(secret, Principal{Subject: "user_1", Email: "a@example.com", Name: "Ada"}, time.Hour)
+		assert.NoError(t, err)
+
+		principal, err := auth.Authenticate(newBearerRequest(token))
+		assert.NoError(t, err)
+		assert.Equal(t, &Principal{Subject: "user_1", Email: "a@example.com", Name: "Ada"}, principal)
+	})
+
+	t.Run("wrong secret", func(t *testing.T) {
+		token, _ := IssueLocalToken([]byte("other-secret"), Principal{Subject: "user_1"}, time.Hour)
+
+		_, err := auth.Authenticate(newBearerRequest(token))
+		assert.ErrorIs(t, err, ErrUnauthenticated)
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		token, _ := IssueLocalToken(secret, Principal{Subject: "user_1"}, time.Minute)
+		expired := LocalJWTAuthenticator{Secret: secret, Now: func() time.Time { return time.Now().Add(time.Hour) }}
+
+		_, err := expired.Authenticate(newBearerRequest(token))
+		assert.ErrorIs(t, err, ErrUnauthenticated)
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		_, err := auth.Authenticate(newBearerRequest(""))
+		assert.ErrorIs(t, err, ErrUnauthenticated)
+	})
+}
+
+func TestStaticTokenAuthenticator(t *testing.T) {
+	tokens, err := ParseStaticTokens("alice-token=user_alice, bob-token=user_bob")
+	assert.NoError(t, err)
+	auth := StaticTokenAuthenticator{Tokens: tokens}
+
+	principal, err := auth.Authenticate(newBearerRequest("bob-token"))
+	assert.NoError(t, err)
+	assert.Equal(t, "user_bob", principal.Subject)
+
+	_, err = auth.Authenticate(newBearerRequest("mallory-token"))
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+
+	_, err = ParseStaticTokens("no-subject")
+	assert.Error(t, err)
+}
+
+func TestAuthenticate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	auth := StaticTokenAuthenticator{Tokens: map[string]Principal{"token": {Subject: "user_1"}}}
+	router := gin.New()
+	router.Use(Authenticate(auth))
+	router.GET("/", func(c *gin.Context) {
+		principal, ok := PrincipalFromContext(c.Request.Context())
+		assert.True(t, ok)
+		c.String(http.StatusOK, principal.Subject)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newBearerRequest("token"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "user_1", w.Body.String())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, newBearerRequest("wrong"))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
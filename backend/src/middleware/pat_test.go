@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"api/src/database"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPersonalTokenAuthenticator(t *testing.T) {
+	next := StaticTokenAuthenticator{Tokens: map[string]Principal{"session-token": {Subject: "user_session"}}}
+	auth := PersonalTokenAuthenticator{Next: next}
+
+	t.Run("personal access token", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		tokenID, userID := uuid.New(), uuid.New()
+		mock.ExpectQuery(`SELECT t.id, t.user_id, t.scopes, t.last_used_at, u.clerk_id FROM personal_access_tokens t`).
+			WithArgs(HashPersonalToken("fcp_secret")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "scopes", "last_used_at", "clerk_id"}).
+				AddRow(tokenID, userID, pq.Array([]string{"decks:read"}), time.Now().Add(-time.Hour), "user_token"))
+		mock.ExpectExec(`UPDATE personal_access_tokens SET last_used_at = NOW\(\) WHERE id = \$1 AND \(last_used_at IS NULL OR last_used_at < NOW\(\) - interval '1 minute'\)`).
+			WithArgs(tokenID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		principal, err := auth.Authenticate(newBearerRequest("fcp_secret"))
+		assert.NoError(t, err)
+		assert.Equal(t, "user_token", principal.Subject)
+		assert.Equal(t, userID, principal.UserID)
+		assert.True(t, principal.IsPersonalToken())
+		assert.True(t, principal.HasScope("decks:read"))
+		assert.False(t, principal.HasScope("decks:write"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("token used within the last minute", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		mock.ExpectQuery(`FROM personal_access_tokens t`).
+			WithArgs(HashPersonalToken("fcp_secret")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "scopes", "last_used_at", "clerk_id"}).
+				AddRow(uuid.New(), uuid.New(), pq.Array([]string{"decks:read"}), time.Now().Add(-10*time.Second), "user_token"))
+
+		_, err = auth.Authenticate(newBearerRequest("fcp_secret"))
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet(), "last_used_at is left alone")
+	})
+
+	t.Run("revoked or expired token", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		mock.ExpectQuery(`FROM personal_access_tokens t`).
+			WithArgs(HashPersonalToken("fcp_revoked")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "scopes", "last_used_at", "clerk_id"}))
+
+		_, err = auth.Authenticate(newBearerRequest("fcp_revoked"))
+		assert.ErrorIs(t, err, ErrUnauthenticated)
+	})
+
+	t.Run("session token is passed on", func(t *testing.T) {
+		principal, err := auth.Authenticate(newBearerRequest("session-token"))
+		assert.NoError(t, err)
+		assert.Equal(t, "user_session", principal.Subject)
+		assert.False(t, principal.IsPersonalToken())
+		assert.True(t, principal.HasScope("decks:write"))
+	})
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"api/src/apierror"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Problems renders errors as problem details. Errors that handlers recorded with
+// c.Error without writing a response are rendered after the chain, panics become
+// 500 responses and internal errors are logged with their cause.
+func Problems() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("panic serving %s %s: %v", c.Request.Method, c.Request.URL.Path, r)
+				if !c.Writer.Written() {
+					apierror.Abort(c, apierror.Internal(fmt.Errorf("panic: %v", r)))
+				}
+			}
+		}()
+
+		c.Next()
+
+		last := c.Errors.Last()
+		if last == nil {
+			return
+		}
+		e := apierror.From(last.Err)
+		if e.Status >= http.StatusInternalServerError {
+			log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, e)
+		}
+		if !c.Writer.Written() {
+			apierror.Abort(c, e)
+		}
+	}
+}
+
+// NoRoute renders unknown routes as a 404 problem
+func NoRoute(c *gin.Context) {
+	apierror.Abort(c, apierror.NotFound("No route matches "+c.Request.Method+" "+c.Request.URL.Path))
+}
+
+// NoMethod renders known routes called with an unsupported method as a 405 problem
+func NoMethod(c *gin.Context) {
+	apierror.Abort(c, apierror.New(http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, c.Request.Method+" is not allowed on "+c.Request.URL.Path))
+}
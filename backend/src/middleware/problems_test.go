@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"api/src/apierror"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProblems(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.HandleMethodNotAllowed = true
+	router.NoRoute(NoRoute)
+	router.NoMethod(NoMethod)
+	router.Use(Problems())
+	router.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+	router.GET("/recorded", func(c *gin.Context) {
+		_ = c.Error(apierror.Conflict("Already exists"))
+	})
+	router.GET("/internal", func(c *gin.Context) {
+		_ = c.Error(errors.New("disk full"))
+	})
+
+	tests := []struct {
+		method, path string
+		status       int
+		code         string
+	}{
+		{"GET", "/panic", http.StatusInternalServerError, apierror.CodeInternal},
+		{"GET", "/recorded", http.StatusConflict, apierror.CodeConflict},
+		{"GET", "/internal", http.StatusInternalServerError, apierror.CodeInternal},
+		{"GET", "/missing", http.StatusNotFound, apierror.CodeNotFound},
+		{"POST", "/recorded", http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(tt.method, tt.path, nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, tt.status, w.Code, tt.path)
+		assert.Equal(t, apierror.ContentType, w.Header().Get("Content-Type"), tt.path)
+		assert.Contains(t, w.Body.String(), `"code":"`+tt.code+`"`, tt.path)
+	}
+}
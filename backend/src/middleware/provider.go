@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"api/src/config"
+	"errors"
+	"fmt"
+
+	"github.com/clerk/clerk-sdk-go/v2"
+)
+
+// NewAuthenticator builds the authenticator selected by cfg.AuthProvider.
+// Personal access tokens are accepted alongside whichever provider is chosen.
+func NewAuthenticator(cfg config.Config) (Authenticator, error) {
+	auth, err := newProviderAuthenticator(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return PersonalTokenAuthenticator{Next: auth}, nil
+}
+
+func newProviderAuthenticator(cfg config.Config) (Authenticator, error) {
+	switch cfg.AuthProvider {
+	case config.AuthClerk:
+		if cfg.ClerkSecretKey == "" {
+			return nil, errors.New("CLERK_SECRET_KEY not set")
+		}
+		clerk.SetKey(cfg.ClerkSecretKey)
+		return ClerkAuthenticator{}, nil
+	case config.AuthLocal:
+		if cfg.AuthJWTSecret == "" {
+			return nil, errors.New("AUTH_JWT_SECRET not set")
+		}
+		return LocalJWTAuthenticator{Secret: []byte(cfg.AuthJWTSecret)}, nil
+	case config.AuthStatic:
+		tokens, err := ParseStaticTokens(cfg.AuthStaticTokens)
+		if err != nil {
+			return nil, err
+		}
+		if len(tokens) == 0 {
+			return nil, errors.New("AUTH_STATIC_TOKENS not set")
+		}
+		return StaticTokenAuthenticator{Tokens: tokens}, nil
+	default:
+		return nil, fmt.Errorf("unknown AUTH_PROVIDER %q", cfg.AuthProvider)
+	}
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// StaticTokenAuthenticator accepts a fixed set of bearer tokens, each mapped to a principal.
+// It is meant for local development and tests only.
+type StaticTokenAuthenticator struct {
+	Tokens map[string]Principal
+}
+
+// ParseStaticTokens parses a comma separated list of token=subject pairs
+func ParseStaticTokens(s string) (map[string]Principal, error) {
+	tokens := map[string]Principal{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		token, subject, found := strings.Cut(pair, "=")
+		if !found || token == "" || subject == "" {
+			return nil, fmt.Errorf("invalid static token %q, expected token=subject", pair)
+		}
+		tokens[token] = Principal{Subject: subject}
+	}
+	return tokens, nil
+}
+
+// Authenticate looks the bearer token up in the configured tokens
+func (a StaticTokenAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	token := bearerToken(r)
+	if token == "" {
+		return nil, ErrUnauthenticated
+	}
+	for candidate, principal := range a.Tokens {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(token)) == 1 {
+			p := principal
+			return &p, nil
+		}
+	}
+	return nil, ErrUnauthenticated
+}
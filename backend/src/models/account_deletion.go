@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccountDeletionGracePeriod is how long a scheduled account deletion can be cancelled
+const AccountDeletionGracePeriod = 14 * 24 * time.Hour
+
+// AccountDeletion is the deletion status of an account
+type AccountDeletion struct {
+	UserID       uuid.UUID  `json:"user_id"`
+	RequestedAt  *time.Time `json:"requested_at"`
+	ScheduledFor *time.Time `json:"scheduled_for"` // The account is purged after this, nil when no deletion is pending
+}
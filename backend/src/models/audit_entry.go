@@ -0,0 +1,27 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audit actions
+const (
+	AuditDeletionRequested = "account.deletion_requested"
+	AuditDeletionCancelled = "account.deletion_cancelled"
+	AuditPurgeStarted      = "account.purge_started"
+	AuditContentPurged     = "account.content_purged"
+	AuditAccountPurged     = "account.purged"
+)
+
+// AuditEntry records an action taken on an account. Entries outlive the account they describe.
+type AuditEntry struct {
+	ID        uuid.UUID       `json:"id"`
+	UserID    uuid.UUID       `json:"user_id"`
+	ActorID   *uuid.UUID      `json:"actor_id"` // nil for actions taken by background jobs
+	Action    string          `json:"action"`
+	Details   json.RawMessage `json:"details"`
+	CreatedAt time.Time       `json:"created_at"`
+}
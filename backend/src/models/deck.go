@@ -1,7 +1,9 @@
 package models
 
 import (
+	"api/src/scheduler"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -12,11 +14,45 @@ type Deck struct {
 	Labels      []string  `json:"labels"`
 	Title       string    `json:"title" binding:"required"`
 	Description string    `json:"description"`
+	// ExamDate is the date of an exam on the deck, YYYY-MM-DD. Cram sessions
+	// pace the cards of the deck up to it.
+	ExamDate *string `json:"exam_date"`
+	// LeechThreshold is the number of lapses that makes a card of the deck a
+	// leech, scheduler.DefaultLeechThreshold when 0
+	LeechThreshold int `json:"leech_threshold"`
+	// LeechAction is what happens to leeches: tag them, or also suspend them
+	LeechAction string `json:"leech_action"`
+	// PresetID is the preset of study options of the deck, nil for the
+	// default preset of the owner
+	PresetID *uuid.UUID `json:"preset_id"`
 }
 
+// Leech actions
+const (
+	LeechTag     = "tag"
+	LeechSuspend = "suspend"
+)
+
 func (d *Deck) Validate() error {
 	if d.Title == "" {
 		return fmt.Errorf("title is required")
 	}
+	if d.ExamDate != nil {
+		if _, err := time.Parse(time.DateOnly, *d.ExamDate); err != nil {
+			return fmt.Errorf("exam_date must be a date like 2006-01-02")
+		}
+	}
+	if d.LeechThreshold == 0 {
+		d.LeechThreshold = scheduler.DefaultLeechThreshold
+	}
+	if d.LeechThreshold < 0 {
+		return fmt.Errorf("leech_threshold can't be negative")
+	}
+	if d.LeechAction == "" {
+		d.LeechAction = LeechTag
+	}
+	if d.LeechAction != LeechTag && d.LeechAction != LeechSuspend {
+		return fmt.Errorf("leech_action must be tag or suspend")
+	}
 	return nil
 }
@@ -21,4 +21,23 @@ func TestDeckValidation(t *testing.T) {
 		assert.Error(t, err)
 		assert.EqualError(t, err, "title is required")
 	})
+
+	t.Run("exam date", func(t *testing.T) {
+		date := "2026-06-15"
+		assert.NoError(t, (&Deck{Title: "Test Deck", ExamDate: &date}).Validate())
+
+		date = "15/06/2026"
+		assert.EqualError(t, (&Deck{Title: "Test Deck", ExamDate: &date}).Validate(), "exam_date must be a date like 2006-01-02")
+	})
+
+	t.Run("leech settings", func(t *testing.T) {
+		deck := Deck{Title: "Test Deck"}
+		assert.NoError(t, deck.Validate())
+		assert.Equal(t, 8, deck.LeechThreshold)
+		assert.Equal(t, LeechTag, deck.LeechAction)
+
+		assert.NoError(t, (&Deck{Title: "Test Deck", LeechThreshold: 4, LeechAction: LeechSuspend}).Validate())
+		assert.Error(t, (&Deck{Title: "Test Deck", LeechThreshold: -1}).Validate())
+		assert.EqualError(t, (&Deck{Title: "Test Deck", LeechAction: "delete"}).Validate(), "leech_action must be tag or suspend")
+	})
 } 
\ No newline at end of file
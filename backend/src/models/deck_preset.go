@@ -0,0 +1,112 @@
+package models
+
+import (
+	"api/src/scheduler"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Orders in which new cards are introduced
+const (
+	NewOrderSequential = "sequential"
+	NewOrderRandom     = "random"
+)
+
+// DeckPreset is a set of study options shared by decks. Decks without a
+// preset use the default preset of their owner, or DefaultDeckPreset when the
+// owner has none.
+type DeckPreset struct {
+	ID        uuid.UUID `json:"id"`
+	OwnerID   uuid.UUID `json:"owner_id"`
+	Name      string    `json:"name" binding:"required"`
+	IsDefault bool      `json:"is_default"`
+	// NewPerDay and ReviewsPerDay cap the new and due cards studied each day
+	// in every deck of the preset
+	NewPerDay     int `json:"new_per_day"`
+	ReviewsPerDay int `json:"reviews_per_day"`
+	// LearningSteps are the delays in minutes between the first answers to a new card
+	LearningSteps []int `json:"learning_steps"`
+	// GraduatingInterval is the interval in days of a card after its last learning step
+	GraduatingInterval int `json:"graduating_interval"`
+	MaximumInterval    int `json:"maximum_interval"`
+	// NewOrder is sequential or random
+	NewOrder string `json:"new_order"`
+	// ShowTimer asks clients to show how long a card has been shown
+	ShowTimer bool `json:"show_timer"`
+	// MaxAnswerSeconds caps the time recorded for an answer
+	MaxAnswerSeconds int `json:"max_answer_seconds"`
+	// Scheduler is the algorithm scheduling cards in review, from scheduler.Algorithms
+	Scheduler string    `json:"scheduler"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DefaultDeckPreset returns the options of decks without a preset
+func DefaultDeckPreset() DeckPreset {
+	s := scheduler.DefaultSettings
+	steps := make([]int, len(s.LearningSteps))
+	for i, step := range s.LearningSteps {
+		steps[i] = int(step / time.Minute)
+	}
+	return DeckPreset{
+		Name:               "Default",
+		NewPerDay:          DefaultNewLimit,
+		ReviewsPerDay:      DefaultReviewLimit,
+		LearningSteps:      steps,
+		GraduatingInterval: s.GraduatingInterval,
+		MaximumInterval:    s.MaximumInterval,
+		NewOrder:           NewOrderSequential,
+		ShowTimer:          false,
+		MaxAnswerSeconds:   60,
+		Scheduler:          scheduler.SM2,
+	}
+}
+
+func (p *DeckPreset) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if p.NewPerDay < 0 || p.ReviewsPerDay < 0 {
+		return fmt.Errorf("daily limits can't be negative")
+	}
+	if p.LearningSteps == nil {
+		p.LearningSteps = []int{}
+	}
+	for _, step := range p.LearningSteps {
+		if step <= 0 {
+			return fmt.Errorf("learning steps must be at least a minute")
+		}
+	}
+	if p.GraduatingInterval < 1 {
+		return fmt.Errorf("graduating_interval must be at least a day")
+	}
+	if p.MaximumInterval < p.GraduatingInterval {
+		return fmt.Errorf("maximum_interval can't be below graduating_interval")
+	}
+	if p.NewOrder != NewOrderSequential && p.NewOrder != NewOrderRandom {
+		return fmt.Errorf("new_order must be sequential or random")
+	}
+	if p.MaxAnswerSeconds < 1 {
+		return fmt.Errorf("max_answer_seconds must be at least 1")
+	}
+	if !slices.Contains(scheduler.Algorithms, p.Scheduler) {
+		return fmt.Errorf("unknown scheduler %q", p.Scheduler)
+	}
+	return nil
+}
+
+// Settings returns the scheduler settings of the decks of the preset
+func (p DeckPreset) Settings() scheduler.Settings {
+	s := scheduler.DefaultSettings
+	s.Algorithm = p.Scheduler
+	s.LearningSteps = make([]time.Duration, len(p.LearningSteps))
+	for i, step := range p.LearningSteps {
+		s.LearningSteps[i] = time.Duration(step) * time.Minute
+	}
+	s.GraduatingInterval = p.GraduatingInterval
+	s.EasyInterval = max(s.EasyInterval, p.GraduatingInterval)
+	s.MaximumInterval = p.MaximumInterval
+	return s
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"api/src/scheduler"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeckPresetValidate(t *testing.T) {
+	preset := DefaultDeckPreset()
+	assert.NoError(t, preset.Validate())
+
+	preset.LearningSteps = nil
+	if assert.NoError(t, preset.Validate()) {
+		assert.Equal(t, []int{}, preset.LearningSteps)
+	}
+
+	invalid := func(change func(p *DeckPreset)) error {
+		p := DefaultDeckPreset()
+		change(&p)
+		return p.Validate()
+	}
+	assert.EqualError(t, invalid(func(p *DeckPreset) { p.Name = "" }), "name is required")
+	assert.Error(t, invalid(func(p *DeckPreset) { p.NewPerDay = -1 }))
+	assert.Error(t, invalid(func(p *DeckPreset) { p.LearningSteps = []int{1, 0} }))
+	assert.Error(t, invalid(func(p *DeckPreset) { p.GraduatingInterval = 0 }))
+	assert.Error(t, invalid(func(p *DeckPreset) { p.MaximumInterval = 0 }))
+	assert.Error(t, invalid(func(p *DeckPreset) { p.NewOrder = "alphabetical" }))
+	assert.Error(t, invalid(func(p *DeckPreset) { p.MaxAnswerSeconds = 0 }))
+	assert.Error(t, invalid(func(p *DeckPreset) { p.Scheduler = "fsrs" }))
+}
+
+func TestDeckPresetSettings(t *testing.T) {
+	assert.Equal(t, scheduler.DefaultSettings.LearningSteps, DefaultDeckPreset().Settings().LearningSteps)
+
+	preset := DefaultDeckPreset()
+	preset.LearningSteps = []int{5, 30, 120}
+	preset.GraduatingInterval = 7
+	preset.MaximumInterval = 180
+	preset.Scheduler = scheduler.Leitner
+
+	s := preset.Settings()
+	assert.Equal(t, []time.Duration{5 * time.Minute, 30 * time.Minute, 2 * time.Hour}, s.LearningSteps)
+	assert.Equal(t, 7, s.GraduatingInterval)
+	assert.Equal(t, 7, s.EasyInterval)
+	assert.Equal(t, 180, s.MaximumInterval)
+	assert.Equal(t, scheduler.Leitner, s.Algorithm)
+}
@@ -0,0 +1,28 @@
+package models
+
+import "github.com/google/uuid"
+
+// DeckSync is a Markdown card file to apply to a deck. See package api/src/cardfile for the format.
+type DeckSync struct {
+	Document string `json:"document" binding:"required"`
+	// DryRun reports the changes without making them
+	DryRun bool `json:"dry_run"`
+	// DeleteMissing deletes the flashcards missing from the document. Without
+	// it they are kept and listed in DeckSyncResult.Missing.
+	DeleteMissing bool `json:"delete_missing"`
+}
+
+// DeckSyncResult describes the changes a sync made to a deck
+type DeckSyncResult struct {
+	DryRun  bool        `json:"dry_run"`
+	Created []Flashcard `json:"created"`
+	Updated []Flashcard `json:"updated"`
+	Deleted []uuid.UUID `json:"deleted"`
+	// Missing are the flashcards missing from the document that were kept
+	// because DeleteMissing wasn't set
+	Missing   []uuid.UUID `json:"missing"`
+	Unchanged int         `json:"unchanged"`
+	// Document is the synced file with card comments added to the cards that
+	// had none, to be saved in place of the original. Created cards get none in a dry run.
+	Document string `json:"document"`
+}
@@ -0,0 +1,153 @@
+package models
+
+import (
+	"api/src/textdiff"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// Kinds of duplicates
+const (
+	// DuplicateExact fronts are equal once case, punctuation and whitespace are ignored
+	DuplicateExact = "exact"
+	// DuplicateNear fronts are at least as similar as the threshold
+	DuplicateNear = "near"
+)
+
+// DefaultDuplicateThreshold is the similarity from which fronts are near duplicates
+const DefaultDuplicateThreshold = 0.85
+
+// DuplicateMatch is a flashcard whose front duplicates another one
+type DuplicateMatch struct {
+	FlashcardID uuid.UUID `json:"flashcard_id"`
+	Front       string    `json:"front"`
+	Kind        string    `json:"kind"`
+	Similarity  float64   `json:"similarity"`
+}
+
+// DuplicateGroup is a set of flashcards of a deck that duplicate each other
+type DuplicateGroup struct {
+	// Kind is exact when every front in the group is an exact duplicate
+	Kind string `json:"kind"`
+	// Similarity is the lowest similarity that linked two flashcards of the group
+	Similarity float64     `json:"similarity"`
+	Flashcards []Flashcard `json:"flashcards"`
+}
+
+// DuplicateMerge keeps one flashcard and merges others into it
+type DuplicateMerge struct {
+	Keep  uuid.UUID   `json:"keep"`
+	Merge []uuid.UUID `json:"merge"`
+}
+
+func (m *DuplicateMerge) Validate() error {
+	if m.Keep == uuid.Nil {
+		return fmt.Errorf("keep is required")
+	}
+	if len(m.Merge) == 0 {
+		return fmt.Errorf("merge must list at least one flashcard")
+	}
+	for _, id := range m.Merge {
+		if id == m.Keep {
+			return fmt.Errorf("keep can't also be merged")
+		}
+	}
+	return nil
+}
+
+// duplicateOf compares two normalized fronts
+func duplicateOf(a, b string, threshold float64) (string, float64, bool) {
+	if a == b {
+		return DuplicateExact, 1, true
+	}
+	// The distance is at least the difference in length, so skip pairs that can't reach the threshold
+	la, lb := len([]rune(a)), len([]rune(b))
+	if float64(min(la, lb)) < threshold*float64(max(la, lb)) {
+		return "", 0, false
+	}
+	similarity := textdiff.Similarity(a, b)
+	if similarity < threshold {
+		return "", 0, false
+	}
+	return DuplicateNear, similarity, true
+}
+
+// MatchDuplicates returns the flashcards whose front duplicates front
+func MatchDuplicates(front string, flashcards []Flashcard, threshold float64) []DuplicateMatch {
+	key := textdiff.Normalize(front)
+	var matches []DuplicateMatch
+	for _, f := range flashcards {
+		if kind, similarity, ok := duplicateOf(key, textdiff.Normalize(f.Front), threshold); ok {
+			matches = append(matches, DuplicateMatch{FlashcardID: f.ID, Front: f.Front, Kind: kind, Similarity: similarity})
+		}
+	}
+	return matches
+}
+
+// FindDuplicates groups flashcards whose fronts duplicate each other.
+// Near duplicates are grouped transitively, so a group can hold two
+// flashcards that are only linked through a third.
+func FindDuplicates(flashcards []Flashcard, threshold float64) []DuplicateGroup {
+	keys := make([]string, len(flashcards))
+	for i, f := range flashcards {
+		keys[i] = textdiff.Normalize(f.Front)
+	}
+
+	parent := make([]int, len(flashcards))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	lowest := map[int]float64{}
+	for i := range flashcards {
+		for j := i + 1; j < len(flashcards); j++ {
+			_, similarity, ok := duplicateOf(keys[i], keys[j], threshold)
+			if !ok {
+				continue
+			}
+			ri, rj := find(i), find(j)
+			low := similarity
+			if s, ok := lowest[ri]; ok && s < low {
+				low = s
+			}
+			if s, ok := lowest[rj]; ok && s < low {
+				low = s
+			}
+			parent[rj] = ri
+			delete(lowest, rj)
+			lowest[ri] = low
+		}
+	}
+
+	byRoot := map[int]*DuplicateGroup{}
+	var groups []DuplicateGroup
+	var order []int
+	for i, f := range flashcards {
+		root := find(i)
+		if _, ok := lowest[root]; !ok {
+			continue
+		}
+		g, ok := byRoot[root]
+		if !ok {
+			g = &DuplicateGroup{Kind: DuplicateExact, Similarity: lowest[root]}
+			byRoot[root] = g
+			order = append(order, root)
+		}
+		if keys[i] != keys[root] {
+			g.Kind = DuplicateNear
+		}
+		g.Flashcards = append(g.Flashcards, f)
+	}
+	for _, root := range order {
+		groups = append(groups, *byRoot[root])
+	}
+	return groups
+}
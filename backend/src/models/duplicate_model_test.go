@@ -0,0 +1,62 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	card := func(front string) Flashcard {
+		return Flashcard{ID: uuid.New(), Front: front, Back: "back"}
+	}
+	capital := card("What is the capital of France?")
+	capitalAgain := card("what is the capital of france")
+	capitalTypo := card("What is the capitol of France?")
+	river := card("Longest river in France")
+	other := card("Largest city in Italy")
+	near1, near2 := card("Photosynthesis"), card("photosyntesis")
+
+	groups := FindDuplicates([]Flashcard{capital, river, capitalAgain, other, near1, capitalTypo, near2}, DefaultDuplicateThreshold)
+
+	if assert.Len(t, groups, 2) {
+		assert.Equal(t, DuplicateNear, groups[0].Kind)
+		assert.Equal(t, []Flashcard{capital, capitalAgain, capitalTypo}, groups[0].Flashcards)
+		assert.InDelta(t, 0.96, groups[0].Similarity, 0.01)
+
+		assert.Equal(t, DuplicateNear, groups[1].Kind)
+		assert.Equal(t, []Flashcard{near1, near2}, groups[1].Flashcards)
+	}
+
+	exact := FindDuplicates([]Flashcard{capital, capitalAgain, river}, 1)
+	if assert.Len(t, exact, 1) {
+		assert.Equal(t, DuplicateExact, exact[0].Kind)
+		assert.Equal(t, 1.0, exact[0].Similarity)
+	}
+
+	assert.Empty(t, FindDuplicates([]Flashcard{capital, river, other}, DefaultDuplicateThreshold))
+}
+
+func TestMatchDuplicates(t *testing.T) {
+	capital := Flashcard{ID: uuid.New(), Front: "Capital of France?"}
+	typo := Flashcard{ID: uuid.New(), Front: "Capitol of France"}
+	river := Flashcard{ID: uuid.New(), Front: "River of France"}
+
+	matches := MatchDuplicates("capital of france", []Flashcard{capital, typo, river}, DefaultDuplicateThreshold)
+
+	if assert.Len(t, matches, 2) {
+		assert.Equal(t, DuplicateMatch{FlashcardID: capital.ID, Front: capital.Front, Kind: DuplicateExact, Similarity: 1}, matches[0])
+		assert.Equal(t, DuplicateNear, matches[1].Kind)
+		assert.Equal(t, typo.ID, matches[1].FlashcardID)
+	}
+}
+
+func TestDuplicateMergeValidate(t *testing.T) {
+	keep := uuid.New()
+
+	assert.NoError(t, (&DuplicateMerge{Keep: keep, Merge: []uuid.UUID{uuid.New()}}).Validate())
+	assert.EqualError(t, (&DuplicateMerge{Merge: []uuid.UUID{uuid.New()}}).Validate(), "keep is required")
+	assert.EqualError(t, (&DuplicateMerge{Keep: keep}).Validate(), "merge must list at least one flashcard")
+	assert.EqualError(t, (&DuplicateMerge{Keep: keep, Merge: []uuid.UUID{keep}}).Validate(), "keep can't also be merged")
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Number of cards a filtered deck gathers by default and at most
+const (
+	DefaultFilterLimit = 100
+	MaxFilterLimit     = 1000
+)
+
+// CardFilter selects flashcards across the decks of a user. Every condition
+// set must hold.
+type CardFilter struct {
+	// DeckIDs are the source decks, every deck of the user when empty
+	DeckIDs []uuid.UUID `json:"deck_ids"`
+	Starred bool        `json:"starred"`
+	// Labels selects the cards of decks with any of these labels
+	Labels []string `json:"labels"`
+	// DueWithinDays selects studied cards due within this many days, 0 for cards due now
+	DueWithinDays *int `json:"due_within_days"`
+	// MinLapses selects cards forgotten at least this many times
+	MinLapses *int `json:"min_lapses"`
+	// FailedToday selects cards answered Again since midnight
+	FailedToday bool `json:"failed_today"`
+	// Search selects cards with this text in the front or back
+	Search string `json:"search"`
+	// Limit caps the number of cards, DefaultFilterLimit when 0
+	Limit int `json:"limit"`
+}
+
+func (f *CardFilter) Validate() error {
+	if f.DueWithinDays != nil && *f.DueWithinDays < 0 {
+		return fmt.Errorf("due_within_days can't be negative")
+	}
+	if f.MinLapses != nil && *f.MinLapses < 0 {
+		return fmt.Errorf("min_lapses can't be negative")
+	}
+	if f.Limit == 0 {
+		f.Limit = DefaultFilterLimit
+	}
+	if f.Limit < 0 || f.Limit > MaxFilterLimit {
+		return fmt.Errorf("limit must be between 1 and %d", MaxFilterLimit)
+	}
+	return nil
+}
+
+// FilteredDeck gathers the flashcards matching a filter for study, without
+// moving them out of their decks. The cards are picked when the deck is built
+// and stay the same until it is rebuilt.
+type FilteredDeck struct {
+	ID      uuid.UUID   `json:"id"`
+	OwnerID uuid.UUID   `json:"owner_id"`
+	Title   string      `json:"title" binding:"required"`
+	Filter  CardFilter  `json:"filter"`
+	CardIDs []uuid.UUID `json:"card_ids"`
+	// Cards are the flashcards of the deck in order, left out of listings.
+	// Flashcards deleted since the deck was built are skipped.
+	Cards     []Flashcard `json:"cards,omitempty"`
+	BuiltAt   time.Time   `json:"built_at"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+func (d *FilteredDeck) Validate() error {
+	if d.Title == "" {
+		return fmt.Errorf("title is required")
+	}
+	return d.Filter.Validate()
+}
+
+// FilteredStudyRequest starts a study session on a filtered deck
+type FilteredStudyRequest struct {
+	// Mode is scheduled or practice. Scheduled sessions study every card of
+	// the deck, due or not, and reschedule them.
+	Mode    string `json:"mode"`
+	Limit   int    `json:"limit"`
+	Shuffle bool   `json:"shuffle"`
+}
+
+func (r *FilteredStudyRequest) Validate() error {
+	if r.Mode == "" {
+		r.Mode = StudyModeScheduled
+	}
+	if r.Mode != StudyModeScheduled && r.Mode != StudyModePractice {
+		return fmt.Errorf("filtered decks are studied in scheduled or practice mode")
+	}
+	if r.Limit < 0 {
+		return fmt.Errorf("limit can't be negative")
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilteredDeckValidate(t *testing.T) {
+	deck := FilteredDeck{Title: "Starred", Filter: CardFilter{Starred: true}}
+	if assert.NoError(t, deck.Validate()) {
+		assert.Equal(t, DefaultFilterLimit, deck.Filter.Limit)
+	}
+
+	negative := -1
+	assert.EqualError(t, (&FilteredDeck{}).Validate(), "title is required")
+	assert.Error(t, (&FilteredDeck{Title: "Due", Filter: CardFilter{DueWithinDays: &negative}}).Validate())
+	assert.Error(t, (&FilteredDeck{Title: "Leeches", Filter: CardFilter{MinLapses: &negative}}).Validate())
+	assert.Error(t, (&FilteredDeck{Title: "All", Filter: CardFilter{Limit: MaxFilterLimit + 1}}).Validate())
+}
+
+func TestFilteredStudyRequestValidate(t *testing.T) {
+	req := FilteredStudyRequest{}
+	if assert.NoError(t, req.Validate()) {
+		assert.Equal(t, StudyModeScheduled, req.Mode)
+	}
+	assert.NoError(t, (&FilteredStudyRequest{Mode: StudyModePractice}).Validate())
+	assert.Error(t, (&FilteredStudyRequest{Mode: StudyModeCram}).Validate())
+	assert.Error(t, (&FilteredStudyRequest{Limit: -1}).Validate())
+}
@@ -12,6 +12,8 @@ type Flashcard struct {
 	Starred    *bool     `json:"starred" binding:"required"`
 	Front      string    `json:"front" binding:"required"`
 	Back       string    `json:"back" binding:"required"`
+	// Duplicates lists the flashcards of the deck a newly created flashcard duplicates
+	Duplicates []DuplicateMatch `json:"duplicates,omitempty"`
 }
 
 func (f *Flashcard) Validate() error {
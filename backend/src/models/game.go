@@ -0,0 +1,159 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Kinds of game rounds
+const (
+	// GameMatch pairs N fronts with N backs
+	GameMatch = "match"
+	// GameTrueFalse asks whether fronts are shown with their own back
+	GameTrueFalse = "true_false"
+)
+
+// GameKinds lists every kind of game round
+var GameKinds = []string{GameMatch, GameTrueFalse}
+
+// Default and maximum number of pairs or statements of a round
+const (
+	DefaultMatchSize     = 6
+	MaxMatchSize         = 12
+	DefaultTrueFalseSize = 10
+	MaxTrueFalseSize     = 50
+)
+
+// GameRequest builds a game round from a deck
+type GameRequest struct {
+	Kind string `json:"kind" binding:"required"`
+	// Seed makes the round repeatable, random when left out
+	Seed *int64 `json:"seed"`
+	// Size is the number of pairs of a match round or statements of a true/false round
+	Size int `json:"size"`
+}
+
+func (r *GameRequest) Validate() error {
+	if !slices.Contains(GameKinds, r.Kind) {
+		return fmt.Errorf("unknown game %q", r.Kind)
+	}
+	minSize, defaultSize, maxSize := 1, DefaultTrueFalseSize, MaxTrueFalseSize
+	if r.Kind == GameMatch {
+		minSize, defaultSize, maxSize = 2, DefaultMatchSize, MaxMatchSize
+	}
+	if r.Size == 0 {
+		r.Size = defaultSize
+	}
+	if r.Size < minSize || r.Size > maxSize {
+		return fmt.Errorf("size must be between %d and %d", minSize, maxSize)
+	}
+	return nil
+}
+
+// GameRound is a round of a game. Its answer key stays on the server.
+type GameRound struct {
+	ID     uuid.UUID `json:"id"`
+	DeckID uuid.UUID `json:"deck_id"`
+	Kind   string    `json:"kind"`
+	Seed   int64     `json:"seed"`
+	// Seeded rounds were started with the seed of the request. Their answer
+	// key may already be known, so they don't count towards best times.
+	Seeded bool `json:"seeded"`
+	// Fronts and Backs are the two columns of a match round
+	Fronts []string `json:"fronts,omitempty"`
+	Backs  []string `json:"backs,omitempty"`
+	// Statements are the questions of a true/false round
+	Statements []GameStatement `json:"statements,omitempty"`
+	// Key holds the index of the back of every front of a match round,
+	// or 1 for every true statement and 0 for every false one
+	Key       []int     `json:"-"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// GameStatement claims that Back is the back of the flashcard with Front
+type GameStatement struct {
+	Front string `json:"front"`
+	Back  string `json:"back"`
+}
+
+// GameSubmission answers a round: Matches for a match round, Answers for a true/false round
+type GameSubmission struct {
+	// Matches holds the index of the back picked for every front
+	Matches []int  `json:"matches"`
+	Answers []bool `json:"answers"`
+}
+
+// Validate checks the submission against the round it answers
+func (s *GameSubmission) Validate(round GameRound) error {
+	if round.Kind == GameTrueFalse {
+		if len(s.Answers) != len(round.Statements) {
+			return fmt.Errorf("answers must have one entry per statement, %d", len(round.Statements))
+		}
+		return nil
+	}
+
+	if len(s.Matches) != len(round.Fronts) {
+		return fmt.Errorf("matches must have one entry per front, %d", len(round.Fronts))
+	}
+	used := map[int]bool{}
+	for _, m := range s.Matches {
+		if m < 0 || m >= len(round.Backs) {
+			return fmt.Errorf("%d is not the index of a back", m)
+		}
+		if used[m] {
+			return fmt.Errorf("back %d is matched more than once", m)
+		}
+		used[m] = true
+	}
+	return nil
+}
+
+// Grade counts the correct answers of a submission and lists the indexes of the wrong ones
+func (round GameRound) Grade(s GameSubmission) (correct int, mistakes []int) {
+	mistakes = []int{}
+	for i, key := range round.Key {
+		var right bool
+		if round.Kind == GameTrueFalse {
+			right = s.Answers[i] == (key == 1)
+		} else {
+			right = s.Matches[i] == key
+		}
+		if right {
+			correct++
+		} else {
+			mistakes = append(mistakes, i)
+		}
+	}
+	return correct, mistakes
+}
+
+// GameResult is the outcome of a submitted round
+type GameResult struct {
+	RoundID uuid.UUID `json:"round_id"`
+	Kind    string    `json:"kind"`
+	Correct int       `json:"correct"`
+	Total   int       `json:"total"`
+	// Mistakes are the indexes of the fronts or statements answered wrong
+	Mistakes []int `json:"mistakes"`
+	// Key is the answer key of the round, see GameRound.Key
+	Key []int `json:"key"`
+	// DurationMs is measured by the server from the start of the round
+	DurationMs int64 `json:"duration_ms"`
+	// BestMs is the best time of a round without mistakes nor seed of this kind on the deck
+	BestMs  *int64 `json:"best_ms"`
+	NewBest bool   `json:"new_best"`
+}
+
+// GameBest is the best time of a user at a game on a deck
+type GameBest struct {
+	Kind string `json:"kind"`
+	// BestMs is the time of the fastest round without mistakes nor seed, nil
+	// until one is submitted
+	BestMs     *int64     `json:"best_ms"`
+	AchievedAt *time.Time `json:"achieved_at"`
+	// Rounds counts every submitted round
+	Rounds int `json:"rounds"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGameRequestValidate(t *testing.T) {
+	match := GameRequest{Kind: GameMatch}
+	if assert.NoError(t, match.Validate()) {
+		assert.Equal(t, DefaultMatchSize, match.Size)
+	}
+	trueFalse := GameRequest{Kind: GameTrueFalse}
+	if assert.NoError(t, trueFalse.Validate()) {
+		assert.Equal(t, DefaultTrueFalseSize, trueFalse.Size)
+	}
+
+	assert.Error(t, (&GameRequest{Kind: "hangman"}).Validate())
+	assert.Error(t, (&GameRequest{Kind: GameMatch, Size: 1}).Validate())
+	assert.Error(t, (&GameRequest{Kind: GameMatch, Size: MaxMatchSize + 1}).Validate())
+	assert.Error(t, (&GameRequest{Kind: GameTrueFalse, Size: -1}).Validate())
+}
+
+func TestGameSubmission(t *testing.T) {
+	match := GameRound{Kind: GameMatch, Fronts: []string{"a", "b", "c"}, Backs: []string{"1", "2", "3"}, Key: []int{2, 0, 1}}
+
+	assert.NoError(t, (&GameSubmission{Matches: []int{2, 1, 0}}).Validate(match))
+	assert.Error(t, (&GameSubmission{Matches: []int{2, 1}}).Validate(match))
+	assert.Error(t, (&GameSubmission{Matches: []int{2, 1, 3}}).Validate(match))
+	assert.Error(t, (&GameSubmission{Matches: []int{2, 2, 0}}).Validate(match))
+
+	correct, mistakes := match.Grade(GameSubmission{Matches: []int{2, 1, 0}})
+	assert.Equal(t, 1, correct)
+	assert.Equal(t, []int{1, 2}, mistakes)
+
+	trueFalse := GameRound{Kind: GameTrueFalse, Statements: []GameStatement{{"a", "1"}, {"b", "3"}}, Key: []int{1, 0}}
+	assert.Error(t, (&GameSubmission{Answers: []bool{true}}).Validate(trueFalse))
+	submission := GameSubmission{Answers: []bool{true, false}}
+	if assert.NoError(t, submission.Validate(trueFalse)) {
+		correct, mistakes = trueFalse.Grade(submission)
+		assert.Equal(t, 2, correct)
+		assert.Empty(t, mistakes)
+	}
+}
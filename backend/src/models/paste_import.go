@@ -0,0 +1,47 @@
+package models
+
+import "github.com/google/uuid"
+
+// PasteImport is raw text of term/definition pairs to import, as copied from
+// a Quizlet export or a word list
+type PasteImport struct {
+	Text string `json:"text" binding:"required"`
+	// TermSeparator is tab (the default), comma, dash or custom
+	TermSeparator string `json:"term_separator"`
+	// CardSeparator is newline (the default), semicolon, blank_line or custom
+	CardSeparator       string `json:"card_separator"`
+	CustomTermSeparator string `json:"custom_term_separator"`
+	CustomCardSeparator string `json:"custom_card_separator"`
+	// DeckID is the deck to add the cards to. A new deck named Title is created when empty.
+	DeckID *uuid.UUID `json:"deck_id"`
+	Title  string     `json:"title"`
+}
+
+// PastedCard is a card parsed from pasted text
+type PastedCard struct {
+	Front string `json:"front"`
+	Back  string `json:"back"`
+}
+
+// ImportWarning is a problem with an entry of imported text
+type ImportWarning struct {
+	// Entry is the 1-based position of the entry among the non-empty entries of the text
+	Entry   int    `json:"entry"`
+	Text    string `json:"text"`
+	Message string `json:"message"`
+	// Skipped is set when no card was made from the entry
+	Skipped bool `json:"skipped"`
+}
+
+// PastePreview is how pasted text would be imported
+type PastePreview struct {
+	Cards    []PastedCard    `json:"cards"`
+	Warnings []ImportWarning `json:"warnings"`
+}
+
+// PasteImportResult is the deck pasted text was imported into
+type PasteImportResult struct {
+	Deck       Deck            `json:"deck"`
+	Flashcards []Flashcard     `json:"flashcards"`
+	Warnings   []ImportWarning `json:"warnings"`
+}
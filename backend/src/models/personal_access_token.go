@@ -0,0 +1,59 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Scopes a personal access token can be granted
+const (
+	ScopeReadDecks  = "decks:read"
+	ScopeWriteDecks = "decks:write"
+	ScopeStudy      = "study"
+)
+
+// Scopes lists every valid token scope
+var Scopes = []string{ScopeReadDecks, ScopeWriteDecks, ScopeStudy}
+
+type PersonalAccessToken struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	Name       string     `json:"name" binding:"required"`
+	Prefix     string     `json:"prefix"` // First characters of the token, to tell tokens apart
+	Scopes     []string   `json:"scopes" binding:"required"`
+	ExpiresAt  *time.Time `json:"expires_at"`   // nil means the token never expires
+	LastUsedAt *time.Time `json:"last_used_at"` // Updated at most once a minute
+	CreatedAt  time.Time  `json:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	// Token is the secret itself, only returned once when the token is created
+	Token string `json:"token,omitempty"`
+}
+
+func (t *PersonalAccessToken) Validate() error {
+	if t.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(t.Scopes) == 0 {
+		return fmt.Errorf("at least one scope is required")
+	}
+	for _, scope := range t.Scopes {
+		if !validScope(scope) {
+			return fmt.Errorf("unknown scope %q", scope)
+		}
+	}
+	if t.ExpiresAt != nil && !t.ExpiresAt.After(time.Now()) {
+		return fmt.Errorf("expires_at must be in the future")
+	}
+	return nil
+}
+
+func validScope(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
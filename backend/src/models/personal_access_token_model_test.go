@@ -0,0 +1,41 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPersonalAccessTokenValidation(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		expires := time.Now().Add(24 * time.Hour)
+		token := PersonalAccessToken{
+			Name:      "deploy script",
+			Scopes:    []string{ScopeReadDecks, ScopeWriteDecks},
+			ExpiresAt: &expires,
+		}
+		assert.NoError(t, token.Validate())
+	})
+
+	t.Run("missing name", func(t *testing.T) {
+		token := PersonalAccessToken{Scopes: []string{ScopeStudy}}
+		assert.EqualError(t, token.Validate(), "name is required")
+	})
+
+	t.Run("missing scopes", func(t *testing.T) {
+		token := PersonalAccessToken{Name: "script"}
+		assert.EqualError(t, token.Validate(), "at least one scope is required")
+	})
+
+	t.Run("unknown scope", func(t *testing.T) {
+		token := PersonalAccessToken{Name: "script", Scopes: []string{"admin"}}
+		assert.EqualError(t, token.Validate(), `unknown scope "admin"`)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		expires := time.Now().Add(-time.Hour)
+		token := PersonalAccessToken{Name: "script", Scopes: []string{ScopeStudy}, ExpiresAt: &expires}
+		assert.EqualError(t, token.Validate(), "expires_at must be in the future")
+	})
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Limits on the choices of a quiz question
+const (
+	DefaultQuizChoices = 4
+	MinQuizChoices     = 2
+	MaxQuizChoices     = 6
+)
+
+// QuizRequest generates a multiple-choice quiz from a deck
+type QuizRequest struct {
+	// Seed makes the quiz repeatable: the same seed on an unchanged deck
+	// generates the same questions in the same order. A random seed is
+	// picked when it is left out.
+	Seed *int64 `json:"seed"`
+	// Questions caps the number of questions, 0 for one per card
+	Questions int `json:"questions"`
+	// Choices is the number of choices per question, 4 by default
+	Choices int `json:"choices"`
+}
+
+func (r *QuizRequest) Validate() error {
+	if r.Questions < 0 {
+		return fmt.Errorf("questions can't be negative")
+	}
+	if r.Choices == 0 {
+		r.Choices = DefaultQuizChoices
+	}
+	if r.Choices < MinQuizChoices || r.Choices > MaxQuizChoices {
+		return fmt.Errorf("choices must be between %d and %d", MinQuizChoices, MaxQuizChoices)
+	}
+	return nil
+}
+
+// Quiz is a generated multiple-choice quiz
+type Quiz struct {
+	ID        uuid.UUID      `json:"id"`
+	DeckID    uuid.UUID      `json:"deck_id"`
+	Seed      int64          `json:"seed"`
+	Choices   int            `json:"choices"`
+	Questions []QuizQuestion `json:"questions"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// QuizQuestion asks for the back of a flashcard among distractors taken from
+// the backs of other flashcards of the deck
+type QuizQuestion struct {
+	FlashcardID uuid.UUID `json:"flashcard_id"`
+	Prompt      string    `json:"prompt"`
+	Choices     []string  `json:"choices"`
+	// Answer is the index of the correct choice, only included in answer keys
+	Answer *int `json:"answer,omitempty"`
+}
+
+// HideAnswers removes the answers of every question
+func (q *Quiz) HideAnswers() {
+	for i := range q.Questions {
+		q.Questions[i].Answer = nil
+	}
+}
+
+// QuizSubmission holds the index of the choice picked for every question
+// of a quiz, -1 for questions left unanswered
+type QuizSubmission struct {
+	Answers []int `json:"answers" binding:"required"`
+}
+
+// Validate checks the submission against the quiz it answers
+func (s *QuizSubmission) Validate(q Quiz) error {
+	if len(s.Answers) != len(q.Questions) {
+		return fmt.Errorf("answers must have one entry per question, %d", len(q.Questions))
+	}
+	for i, a := range s.Answers {
+		if a < -1 || a >= len(q.Questions[i].Choices) {
+			return fmt.Errorf("answer %d is not a choice of question %d", a, i+1)
+		}
+	}
+	return nil
+}
+
+// QuizAttempt is a graded submission
+type QuizAttempt struct {
+	ID      uuid.UUID `json:"id"`
+	QuizID  uuid.UUID `json:"quiz_id"`
+	Answers []int     `json:"answers"`
+	Score   int       `json:"score"`
+	Total   int       `json:"total"`
+	Percent float64   `json:"percent"`
+	// Correct tells for every question whether it was answered correctly
+	Correct     []bool    `json:"correct"`
+	SubmittedAt time.Time `json:"submitted_at"`
+	// Key is the index of the correct choice of every question, left out
+	// without the decks:write scope
+	Key []int `json:"key,omitempty"`
+}
+
+// Grade scores the answers of a submission
+func (q Quiz) Grade(answers []int) QuizAttempt {
+	attempt := QuizAttempt{QuizID: q.ID, Answers: answers, Total: len(q.Questions)}
+	for i, question := range q.Questions {
+		key := -1
+		if question.Answer != nil {
+			key = *question.Answer
+		}
+		correct := answers[i] >= 0 && answers[i] == key
+		if correct {
+			attempt.Score++
+		}
+		attempt.Correct = append(attempt.Correct, correct)
+		attempt.Key = append(attempt.Key, key)
+	}
+	if attempt.Total > 0 {
+		attempt.Percent = 100 * float64(attempt.Score) / float64(attempt.Total)
+	}
+	return attempt
+}
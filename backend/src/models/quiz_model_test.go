@@ -0,0 +1,41 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuizRequestValidate(t *testing.T) {
+	req := QuizRequest{}
+	if assert.NoError(t, req.Validate()) {
+		assert.Equal(t, DefaultQuizChoices, req.Choices)
+	}
+	assert.Error(t, (&QuizRequest{Choices: 1}).Validate())
+	assert.Error(t, (&QuizRequest{Choices: 7}).Validate())
+	assert.Error(t, (&QuizRequest{Questions: -1}).Validate())
+}
+
+func TestQuizGrade(t *testing.T) {
+	zero, two := 0, 2
+	q := Quiz{Questions: []QuizQuestion{
+		{Choices: []string{"a", "b", "c"}, Answer: &zero},
+		{Choices: []string{"a", "b", "c"}, Answer: &two},
+		{Choices: []string{"a", "b", "c"}, Answer: &two},
+	}}
+
+	assert.Error(t, (&QuizSubmission{Answers: []int{0, 1}}).Validate(q))
+	assert.Error(t, (&QuizSubmission{Answers: []int{0, 1, 3}}).Validate(q))
+	submission := QuizSubmission{Answers: []int{0, -1, 1}}
+	assert.NoError(t, submission.Validate(q))
+
+	attempt := q.Grade(submission.Answers)
+	assert.Equal(t, 1, attempt.Score)
+	assert.Equal(t, 3, attempt.Total)
+	assert.InDelta(t, 33.3, attempt.Percent, 0.1)
+	assert.Equal(t, []bool{true, false, false}, attempt.Correct)
+	assert.Equal(t, []int{0, 2, 2}, attempt.Key)
+
+	q.HideAnswers()
+	assert.Nil(t, q.Questions[0].Answer)
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"api/src/textdiff"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FlashcardRevision is the content a flashcard had before an edit replaced it
+type FlashcardRevision struct {
+	FlashcardID uuid.UUID      `json:"flashcard_id"`
+	Rev         int            `json:"rev"`
+	Starred     bool           `json:"starred"`
+	Front       string         `json:"front"`
+	Back        string         `json:"back"`
+	EditedBy    *uuid.UUID     `json:"edited_by"`
+	CreatedAt   time.Time      `json:"created_at"`
+	Diff        *FlashcardDiff `json:"diff,omitempty"`
+}
+
+// FlashcardDiff describes the edit that replaced a revision
+type FlashcardDiff struct {
+	Starred *bool              `json:"starred,omitempty"`
+	Front   []textdiff.Segment `json:"front,omitempty"`
+	Back    []textdiff.Segment `json:"back,omitempty"`
+}
+
+// DeckRevision is the content a deck had before an edit replaced it
+type DeckRevision struct {
+	DeckID      uuid.UUID  `json:"deck_id"`
+	Rev         int        `json:"rev"`
+	Labels      []string   `json:"labels"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	EditedBy    *uuid.UUID `json:"edited_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Diff        *DeckDiff  `json:"diff,omitempty"`
+}
+
+// DeckDiff describes the edit that replaced a deck revision
+type DeckDiff struct {
+	LabelsAdded   []string           `json:"labels_added,omitempty"`
+	LabelsRemoved []string           `json:"labels_removed,omitempty"`
+	Title         []textdiff.Segment `json:"title,omitempty"`
+	Description   []textdiff.Segment `json:"description,omitempty"`
+}
+
+// DiffFlashcards returns the changes needed to turn one version of a card
+// into the next. Unchanged fields are left empty.
+func DiffFlashcards(from, to FlashcardRevision) FlashcardDiff {
+	var d FlashcardDiff
+	if from.Starred != to.Starred {
+		starred := to.Starred
+		d.Starred = &starred
+	}
+	if from.Front != to.Front {
+		d.Front = textdiff.Words(from.Front, to.Front)
+	}
+	if from.Back != to.Back {
+		d.Back = textdiff.Words(from.Back, to.Back)
+	}
+	return d
+}
+
+// DiffDecks returns the changes needed to turn one version of a deck into
+// the next. Unchanged fields are left empty.
+func DiffDecks(from, to DeckRevision) DeckDiff {
+	var d DeckDiff
+	d.LabelsAdded = missing(to.Labels, from.Labels)
+	d.LabelsRemoved = missing(from.Labels, to.Labels)
+	if from.Title != to.Title {
+		d.Title = textdiff.Words(from.Title, to.Title)
+	}
+	if from.Description != to.Description {
+		d.Description = textdiff.Words(from.Description, to.Description)
+	}
+	return d
+}
+
+// missing returns the labels in a that are not in b
+func missing(a, b []string) []string {
+	seen := make(map[string]bool, len(b))
+	for _, l := range b {
+		seen[l] = true
+	}
+	var out []string
+	for _, l := range a {
+		if !seen[l] {
+			out = append(out, l)
+		}
+	}
+	return out
+}
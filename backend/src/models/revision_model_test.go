@@ -0,0 +1,42 @@
+package models
+
+import (
+	"testing"
+
+	"api/src/textdiff"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffFlashcards(t *testing.T) {
+	t.Run("changed back and starred", func(t *testing.T) {
+		from := FlashcardRevision{Front: "2+2", Back: "five", Starred: false}
+		to := FlashcardRevision{Front: "2+2", Back: "four", Starred: true}
+
+		d := DiffFlashcards(from, to)
+		assert.Nil(t, d.Front)
+		assert.Equal(t, []textdiff.Segment{
+			{Op: textdiff.Delete, Text: "five"},
+			{Op: textdiff.Insert, Text: "four"},
+		}, d.Back)
+		if assert.NotNil(t, d.Starred) {
+			assert.True(t, *d.Starred)
+		}
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		rev := FlashcardRevision{Front: "2+2", Back: "4"}
+		assert.Equal(t, FlashcardDiff{}, DiffFlashcards(rev, rev))
+	})
+}
+
+func TestDiffDecks(t *testing.T) {
+	from := DeckRevision{Title: "Biology", Labels: []string{"science", "cells"}}
+	to := DeckRevision{Title: "Biology", Labels: []string{"science", "genetics"}}
+
+	d := DiffDecks(from, to)
+	assert.Equal(t, []string{"genetics"}, d.LabelsAdded)
+	assert.Equal(t, []string{"cells"}, d.LabelsRemoved)
+	assert.Nil(t, d.Title)
+	assert.Nil(t, d.Description)
+}
@@ -0,0 +1,95 @@
+package models
+
+import "github.com/google/uuid"
+
+const (
+	// DefaultStatsDays and MaxStatsDays bound the days of review history in Stats
+	DefaultStatsDays = 30
+	MaxStatsDays     = 365
+	// ForecastDays is the number of days of due cards forecast in Stats, today included
+	ForecastDays = 30
+	// MatureInterval is the interval in days from which a card in review is
+	// mature rather than young
+	MatureInterval = 21
+)
+
+// RetentionBounds are the lower bounds in days of the interval buckets
+// retention is broken down by
+var RetentionBounds = []int{1, 7, MatureInterval, 90}
+
+// Stats sums up the study of a user, or of one of their decks. Days are study
+// days of the timezone of the user, starting at the rollover hour of their goal,
+// and hours are hours of their timezone.
+type Stats struct {
+	// DeckID is set for the statistics of a single deck
+	DeckID   *uuid.UUID `json:"deck_id,omitempty"`
+	Timezone string     `json:"timezone"`
+	// Days is the number of days of review history, today included
+	Days int `json:"days"`
+	// Reviews has one entry per day, oldest first
+	Reviews      []DayStats `json:"reviews"`
+	TotalReviews int        `json:"total_reviews"`
+	// TimeSpentMs is the time spent answering over the days
+	TimeSpentMs int64 `json:"time_spent_ms"`
+	// Retention is the share of cards in review that were recalled, by the
+	// interval they were reviewed at
+	Retention []RetentionStats `json:"retention"`
+	Cards     CardCounts       `json:"cards"`
+	// Forecast has one entry per day from today; overdue cards count today
+	Forecast []ForecastDay `json:"forecast"`
+	// Hours has one entry per hour of the day, from 0 to 23
+	Hours []HourStats `json:"hours"`
+}
+
+// DayStats are the answers given on a day
+type DayStats struct {
+	Date    string `json:"date"`
+	Reviews int    `json:"reviews"`
+	// Correct answers are graded hard or better
+	Correct int   `json:"correct"`
+	TimeMs  int64 `json:"time_ms"`
+}
+
+// RetentionStats is the retention of cards reviewed at an interval between
+// MinInterval and MaxInterval days
+type RetentionStats struct {
+	MinInterval int `json:"min_interval"`
+	// MaxInterval is nil for the longest intervals
+	MaxInterval *int    `json:"max_interval"`
+	Reviews     int     `json:"reviews"`
+	Recalled    int     `json:"recalled"`
+	Rate        float64 `json:"rate"`
+}
+
+// CardCounts counts the cards by state. Young and mature cards are in review
+// with an interval below and from MatureInterval; suspended cards only count
+// as suspended.
+type CardCounts struct {
+	New       int `json:"new"`
+	Learning  int `json:"learning"`
+	Young     int `json:"young"`
+	Mature    int `json:"mature"`
+	Suspended int `json:"suspended"`
+}
+
+// ForecastDay is the number of cards due on a day
+type ForecastDay struct {
+	Date string `json:"date"`
+	Due  int    `json:"due"`
+}
+
+// HourStats are the answers given at an hour of the day
+type HourStats struct {
+	Hour        int     `json:"hour"`
+	Reviews     int     `json:"reviews"`
+	Correct     int     `json:"correct"`
+	SuccessRate float64 `json:"success_rate"`
+}
+
+// SuccessRate returns the share of n out of total, 0 without any
+func SuccessRate(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
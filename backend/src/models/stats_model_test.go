@@ -0,0 +1,12 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuccessRate(t *testing.T) {
+	assert.Equal(t, 0.75, SuccessRate(3, 4))
+	assert.Equal(t, 0.0, SuccessRate(0, 0))
+}
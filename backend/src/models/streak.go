@@ -0,0 +1,81 @@
+package models
+
+import "fmt"
+
+// Study goal units
+const (
+	GoalCards   = "cards"
+	GoalMinutes = "minutes"
+)
+
+const (
+	// FreezeEvery is the number of days of goals met in a row that earn a streak freeze
+	FreezeEvery = 7
+	// MaxFreezes is the number of streak freezes a user can hold
+	MaxFreezes = 2
+	// HeatmapDays is the number of days of the heatmap of Streak, today included
+	HeatmapDays = 365
+)
+
+// StudyGoal is the daily goal of a user
+type StudyGoal struct {
+	// Unit is cards for a number of answers, minutes for time spent answering
+	Unit   string `json:"unit"`
+	Target int    `json:"target"`
+	// DayRollover is the hour, in the timezone of the user, at which a new
+	// study day starts: answers before it count for the day before
+	DayRollover int `json:"day_rollover"`
+}
+
+// DefaultStudyGoal is the goal of users who haven't set one
+func DefaultStudyGoal() StudyGoal {
+	return StudyGoal{Unit: GoalCards, Target: 20, DayRollover: 4}
+}
+
+func (g *StudyGoal) Validate() error {
+	if g.Unit != GoalCards && g.Unit != GoalMinutes {
+		return fmt.Errorf("unit must be cards or minutes")
+	}
+	if g.Target < 1 {
+		return fmt.Errorf("target must be positive")
+	}
+	if g.DayRollover < 0 || g.DayRollover > 23 {
+		return fmt.Errorf("day_rollover must be an hour between 0 and 23")
+	}
+	return nil
+}
+
+// Met reports whether the study of a day reaches the goal
+func (g StudyGoal) Met(d StudyDay) bool {
+	if g.Unit == GoalMinutes {
+		return d.TimeMs >= int64(g.Target)*60000
+	}
+	return d.Cards >= g.Target
+}
+
+// StudyDay is the study of a user on a day of their timezone
+type StudyDay struct {
+	Date string `json:"date"`
+	// Cards is the number of answers
+	Cards   int   `json:"cards"`
+	TimeMs  int64 `json:"time_ms"`
+	GoalMet bool  `json:"goal_met"`
+	// Frozen days missed the goal but kept the streak by spending a freeze
+	Frozen bool `json:"frozen"`
+}
+
+// Streak is the run of days a user met their goal. A freeze is earned every
+// FreezeEvery days of goals met in a row, up to MaxFreezes, and spent on its
+// own when a day is missed. Today doesn't break the streak until it is over.
+type Streak struct {
+	Goal     StudyGoal `json:"goal"`
+	Timezone string    `json:"timezone"`
+	Today    StudyDay  `json:"today"`
+	// Current counts the days of goals met of the current streak, frozen days left out
+	Current int `json:"current"`
+	Longest int `json:"longest"`
+	// Freezes is the number of streak freezes held
+	Freezes int `json:"freezes"`
+	// Heatmap has one entry per day of the last HeatmapDays, oldest first
+	Heatmap []StudyDay `json:"heatmap"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStudyGoalValidation(t *testing.T) {
+	goal := DefaultStudyGoal()
+	assert.NoError(t, goal.Validate())
+
+	assert.EqualError(t, (&StudyGoal{Unit: "pages", Target: 5}).Validate(), "unit must be cards or minutes")
+	assert.EqualError(t, (&StudyGoal{Unit: GoalMinutes}).Validate(), "target must be positive")
+	assert.EqualError(t, (&StudyGoal{Unit: GoalCards, Target: 5, DayRollover: 24}).Validate(), "day_rollover must be an hour between 0 and 23")
+}
+
+func TestStudyGoalMet(t *testing.T) {
+	cards := StudyGoal{Unit: GoalCards, Target: 20}
+	assert.True(t, cards.Met(StudyDay{Cards: 20}))
+	assert.False(t, cards.Met(StudyDay{Cards: 19, TimeMs: 3600000}))
+
+	minutes := StudyGoal{Unit: GoalMinutes, Target: 10}
+	assert.True(t, minutes.Met(StudyDay{TimeMs: 600000}))
+	assert.False(t, minutes.Met(StudyDay{Cards: 100, TimeMs: 599999}))
+}
@@ -0,0 +1,241 @@
+package models
+
+import (
+	"api/src/grading"
+	"api/src/scheduler"
+	"fmt"
+	"slices"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Study modes
+const (
+	// StudyModeScheduled studies due cards then new cards; answers update the schedule
+	StudyModeScheduled = "scheduled"
+	// StudyModePractice studies every card; answers are logged but don't change the schedule
+	StudyModePractice = "practice"
+	// StudyModeCram studies decks with an upcoming exam: due cards weak and
+	// starred first, then the new cards of the day; intervals shrink to fit before the exam
+	StudyModeCram = "cram"
+)
+
+// StudyModes lists every valid study mode
+var StudyModes = []string{StudyModeScheduled, StudyModePractice, StudyModeCram}
+
+// Statuses of a study session
+const (
+	StudySessionActive    = "active"
+	StudySessionFinished  = "finished"
+	StudySessionAbandoned = "abandoned" // Closed after a period without answers
+)
+
+// Default limits of practice and cram sessions, and daily limits of decks
+// without a preset
+const (
+	DefaultNewLimit    = 20
+	DefaultReviewLimit = 200
+)
+
+// StudySessionRequest starts a study session
+type StudySessionRequest struct {
+	DeckIDs []uuid.UUID `json:"deck_ids" binding:"required"`
+	// Mode is scheduled by default
+	Mode string `json:"mode"`
+	// NewLimit and ReviewLimit cap the new and due cards of a session. Scheduled
+	// sessions never go past the daily limits of the deck presets. Cram
+	// sessions pace the new cards to the exam instead of NewLimit.
+	NewLimit    *int `json:"new_limit"`
+	ReviewLimit *int `json:"review_limit"`
+	// Limit caps the number of cards of any session, 0 for no limit
+	Limit   int  `json:"limit"`
+	Shuffle bool `json:"shuffle"`
+}
+
+func (r *StudySessionRequest) Validate() error {
+	if len(r.DeckIDs) == 0 {
+		return fmt.Errorf("deck_ids must list at least one deck")
+	}
+	if r.Mode == "" {
+		r.Mode = StudyModeScheduled
+	}
+	if !slices.Contains(StudyModes, r.Mode) {
+		return fmt.Errorf("unknown study mode %q", r.Mode)
+	}
+	if (r.NewLimit != nil && *r.NewLimit < 0) || (r.ReviewLimit != nil && *r.ReviewLimit < 0) || r.Limit < 0 {
+		return fmt.Errorf("limits can't be negative")
+	}
+	return nil
+}
+
+// StudySession is a sitting in which a user goes through a sequence of cards
+type StudySession struct {
+	ID          uuid.UUID   `json:"id"`
+	UserID      uuid.UUID   `json:"user_id"`
+	DeckIDs     []uuid.UUID `json:"deck_ids"`
+	Mode        string      `json:"mode"`
+	NewLimit    int         `json:"new_limit"`
+	ReviewLimit int         `json:"review_limit"`
+	Limit       int         `json:"limit"`
+	Status      string      `json:"status"`
+	// CardIDs is the card sequence built when the session started. Cards
+	// answered Again, or still learning, are appended to it.
+	CardIDs []uuid.UUID `json:"card_ids"`
+	// Position is the number of cards of the sequence answered or skipped
+	Position int `json:"position"`
+	// Cards are the cards left to study in order, cards[0] being the next one.
+	// Cards deleted since the session started are skipped.
+	Cards          []Flashcard   `json:"cards"`
+	StartedAt      time.Time     `json:"started_at"`
+	LastActivityAt time.Time     `json:"last_activity_at"`
+	FinishedAt     *time.Time    `json:"finished_at"`
+	Summary        *StudySummary `json:"summary,omitempty"`
+}
+
+// MaxTypedLength is the number of characters a typed answer can hold
+const MaxTypedLength = 1000
+
+// StudyAnswer is the answer to the next card of a study session
+type StudyAnswer struct {
+	FlashcardID uuid.UUID `json:"flashcard_id"`
+	// Grade is how well the learner remembered a flipped card
+	Grade scheduler.Grade `json:"grade"`
+	// Typed is an answer typed by the learner instead of a grade. The server
+	// grades it against the back of the card.
+	Typed *string `json:"typed"`
+	// DurationMs is how long the card was shown
+	DurationMs int `json:"duration_ms"`
+}
+
+func (a *StudyAnswer) Validate() error {
+	if a.FlashcardID == uuid.Nil {
+		return fmt.Errorf("flashcard_id is required")
+	}
+	if a.Typed != nil {
+		if a.Grade != 0 {
+			return fmt.Errorf("typed answers are graded by the server, grade must be left out")
+		}
+		if utf8.RuneCountInString(*a.Typed) > MaxTypedLength {
+			return fmt.Errorf("typed must be at most %d characters", MaxTypedLength)
+		}
+	} else if err := a.Grade.Validate(); err != nil {
+		return err
+	}
+	if a.DurationMs < 0 {
+		return fmt.Errorf("duration_ms can't be negative")
+	}
+	return nil
+}
+
+// StudyAnswerResult is the outcome of an answer
+type StudyAnswerResult struct {
+	Review ReviewLog `json:"review"`
+	State  CardState `json:"state"`
+	// Check is how a typed answer was graded
+	Check *grading.Result `json:"check,omitempty"`
+	// Next is the next card to study, nil at the end of the session
+	Next      *Flashcard `json:"next"`
+	Remaining int        `json:"remaining"`
+}
+
+// StudySummary sums up the answers of a study session
+type StudySummary struct {
+	Answers int `json:"answers"`
+	// Correct answers are graded Hard or better
+	Correct  int     `json:"correct"`
+	Accuracy float64 `json:"accuracy"`
+	// StudyTimeMs adds up the time cards were shown
+	StudyTimeMs int64 `json:"study_time_ms"`
+	// ElapsedMs is the time from the start to the end of the session
+	ElapsedMs    int64 `json:"elapsed_ms"`
+	CardsStudied int   `json:"cards_studied"`
+	// CardsLearned graduated from learning to review
+	CardsLearned int `json:"cards_learned"`
+	// CardsLapsed were in review and forgotten
+	CardsLapsed int `json:"cards_lapsed"`
+}
+
+// ReviewLog is an answer given to a card
+type ReviewLog struct {
+	ID          uuid.UUID       `json:"id"`
+	UserID      uuid.UUID       `json:"user_id"`
+	FlashcardID uuid.UUID       `json:"flashcard_id"`
+	SessionID   *uuid.UUID      `json:"session_id"`
+	Grade       scheduler.Grade `json:"grade"`
+	// TypedAnswer is set for answers typed by the learner
+	TypedAnswer *string `json:"typed_answer,omitempty"`
+	// Scheduled is false for answers that didn't change the schedule of the card
+	Scheduled      bool      `json:"scheduled"`
+	StateBefore    string    `json:"state_before"`
+	StateAfter     string    `json:"state_after"`
+	IntervalBefore int       `json:"interval_before"`
+	IntervalAfter  int       `json:"interval_after"`
+	Ease           float64   `json:"ease"`
+	DurationMs     int       `json:"duration_ms"`
+	ReviewedAt     time.Time `json:"reviewed_at"`
+}
+
+// CardState is the scheduling state of a flashcard
+type CardState struct {
+	FlashcardID uuid.UUID  `json:"flashcard_id"`
+	State       string     `json:"state"`
+	Step        int        `json:"step"`
+	Ease        float64    `json:"ease"`
+	Interval    int        `json:"interval"`
+	DueAt       *time.Time `json:"due_at"`
+	Reps        int        `json:"reps"`
+	Lapses      int        `json:"lapses"`
+	// Leech is set once the lapses reach the leech threshold of the deck
+	Leech bool `json:"leech"`
+	// Suspended and buried cards are left out of study, buried ones until BuriedUntil
+	SuspendedAt *time.Time `json:"suspended_at"`
+	BuriedUntil *time.Time `json:"buried_until"`
+}
+
+// Leech is a flashcard its owner keeps forgetting
+type Leech struct {
+	Flashcard   Flashcard  `json:"flashcard"`
+	DeckTitle   string     `json:"deck_title"`
+	Lapses      int        `json:"lapses"`
+	SuspendedAt *time.Time `json:"suspended_at"`
+}
+
+// CardStateOf converts a scheduler card to a card state
+func CardStateOf(flashcardID uuid.UUID, c scheduler.Card) CardState {
+	s := CardState{FlashcardID: flashcardID, State: c.State, Step: c.Step, Ease: c.Ease, Interval: c.Interval, Reps: c.Reps, Lapses: c.Lapses}
+	if !c.Due.IsZero() {
+		due := c.Due
+		s.DueAt = &due
+	}
+	return s
+}
+
+// Scheduler converts a card state to a scheduler card
+func (s CardState) Scheduler() scheduler.Card {
+	c := scheduler.Card{State: s.State, Step: s.Step, Ease: s.Ease, Interval: s.Interval, Reps: s.Reps, Lapses: s.Lapses}
+	if s.DueAt != nil {
+		c.Due = *s.DueAt
+	}
+	return c
+}
+
+// CramPlan is the day-by-day workload of cramming a deck before its exam
+type CramPlan struct {
+	DeckID   uuid.UUID `json:"deck_id"`
+	ExamDate string    `json:"exam_date"`
+	DaysLeft int       `json:"days_left"`
+	// NewPerDay is the number of new cards to learn a day
+	NewPerDay int           `json:"new_per_day"`
+	Days      []CramPlanDay `json:"days"`
+}
+
+// CramPlanDay is the workload of a day, assuming every card is remembered
+type CramPlanDay struct {
+	Date string `json:"date"`
+	// NewCards are learned for the first time
+	NewCards int `json:"new_cards"`
+	// Reviews are cards learned before and due that day
+	Reviews int `json:"reviews"`
+}
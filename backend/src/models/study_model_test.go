@@ -0,0 +1,61 @@
+package models
+
+import (
+	"api/src/scheduler"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStudySessionRequestValidate(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		req := StudySessionRequest{DeckIDs: []uuid.UUID{uuid.New()}}
+		if assert.NoError(t, req.Validate()) {
+			assert.Equal(t, StudyModeScheduled, req.Mode)
+			// Scheduled sessions follow the daily limits of the decks
+			assert.Nil(t, req.NewLimit)
+			assert.Nil(t, req.ReviewLimit)
+		}
+	})
+
+	t.Run("no new cards", func(t *testing.T) {
+		none := 0
+		req := StudySessionRequest{DeckIDs: []uuid.UUID{uuid.New()}, NewLimit: &none}
+		if assert.NoError(t, req.Validate()) {
+			assert.Equal(t, 0, *req.NewLimit)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		negative := -1
+		assert.Error(t, (&StudySessionRequest{}).Validate())
+		assert.Error(t, (&StudySessionRequest{DeckIDs: []uuid.UUID{uuid.New()}, Mode: "marathon"}).Validate())
+		assert.Error(t, (&StudySessionRequest{DeckIDs: []uuid.UUID{uuid.New()}, ReviewLimit: &negative}).Validate())
+	})
+}
+
+func TestStudyAnswerValidate(t *testing.T) {
+	assert.NoError(t, (&StudyAnswer{FlashcardID: uuid.New(), Grade: scheduler.Hard}).Validate())
+	assert.Error(t, (&StudyAnswer{Grade: scheduler.Good}).Validate())
+	assert.Error(t, (&StudyAnswer{FlashcardID: uuid.New()}).Validate())
+	assert.Error(t, (&StudyAnswer{FlashcardID: uuid.New(), Grade: scheduler.Good, DurationMs: -5}).Validate())
+
+	typed := "Paris"
+	assert.NoError(t, (&StudyAnswer{FlashcardID: uuid.New(), Typed: &typed}).Validate())
+	assert.Error(t, (&StudyAnswer{FlashcardID: uuid.New(), Typed: &typed, Grade: scheduler.Easy}).Validate())
+	long := strings.Repeat("é", MaxTypedLength+1)
+	assert.Error(t, (&StudyAnswer{FlashcardID: uuid.New(), Typed: &long}).Validate())
+}
+
+func TestCardState(t *testing.T) {
+	id := uuid.New()
+	assert.Nil(t, CardStateOf(id, scheduler.NewCard()).DueAt)
+
+	card := scheduler.Card{State: scheduler.StateReview, Ease: 2.3, Interval: 6, Due: time.Now().Add(6 * 24 * time.Hour), Reps: 5, Lapses: 1}
+	state := CardStateOf(id, card)
+	assert.Equal(t, id, state.FlashcardID)
+	assert.Equal(t, card, state.Scheduler())
+}
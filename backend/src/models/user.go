@@ -2,15 +2,31 @@ package models
 
 import (
 	"fmt"
+	"time"
+	// Timezones are validated the same on hosts without a timezone database
+	_ "time/tzdata"
 
 	"github.com/google/uuid"
 )
 
+// User roles
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// DefaultTimezone is the timezone of users who haven't set one
+const DefaultTimezone = "UTC"
+
 type User struct {
-	ID      uuid.UUID `json:"id"`
-	ClerkID string    `json:"clerk_id"`
-	Name    string    `json:"name" binding:"required"`
-	Email   string    `json:"email" binding:"required"`
+	ID          uuid.UUID  `json:"id"`
+	ClerkID     string     `json:"clerk_id"`
+	Name        string     `json:"name" binding:"required"`
+	Email       string     `json:"email" binding:"required"`
+	Role        string     `json:"role"`
+	SuspendedAt *time.Time `json:"suspended_at"`
+	// Timezone is an IANA name such as Europe/Paris. Study days and streaks follow it.
+	Timezone string `json:"timezone"`
 }
 
 func (u *User) Validate() error {
@@ -20,5 +36,20 @@ func (u *User) Validate() error {
 	if u.Email == "" {
 		return fmt.Errorf("email is required")
 	}
+	if u.Timezone != "" {
+		// Local is the timezone of the server, unknown to PostgreSQL
+		if _, err := time.LoadLocation(u.Timezone); err != nil || u.Timezone == "Local" {
+			return fmt.Errorf("unknown timezone %q", u.Timezone)
+		}
+	}
 	return nil
 }
+
+// UserUsage summarizes what a user has stored, for admins
+type UserUsage struct {
+	UserID       uuid.UUID  `json:"user_id"`
+	Decks        int        `json:"decks"`
+	Flashcards   int        `json:"flashcards"`
+	ActiveTokens int        `json:"active_tokens"`
+	LastTokenUse *time.Time `json:"last_token_use"`
+}
@@ -33,4 +33,16 @@ func TestUserValidation(t *testing.T) {
 		assert.Error(t, err)
 		assert.EqualError(t, err, "email is required")
 	})
+
+	t.Run("unknown timezone", func(t *testing.T) {
+		user := User{
+			Name:     "Test User",
+			Email:    "test@example.com",
+			Timezone: "Mars/Olympus_Mons",
+		}
+		assert.EqualError(t, user.Validate(), `unknown timezone "Mars/Olympus_Mons"`)
+
+		user.Timezone = "America/New_York"
+		assert.NoError(t, user.Validate())
+	})
 } 
\ No newline at end of file
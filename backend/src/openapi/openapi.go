@@ -0,0 +1,347 @@
+// Package openapi builds OpenAPI 3 documents, deriving schemas from Go types
+// through their json struct tags.
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Document is an OpenAPI 3 document
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem maps lower-case HTTP methods to operations
+type PathItem map[string]*Operation
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Required    bool    `json:"required,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema is the subset of JSON Schema used by OpenAPI 3.0
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties any                `json:"additionalProperties,omitempty"`
+}
+
+// Route describes one operation of the API
+type Route struct {
+	Method  string
+	Path    string // gin syntax, e.g. /api/go/decks/:id
+	Summary string
+	// Description adds to the generated description of the access requirements
+	Description string
+	Tag         string
+	// Public routes need no bearer token
+	Public bool
+	// Scope is the personal access token scope the route requires
+	Scope string
+	// SessionOnly routes reject personal access tokens
+	SessionOnly bool
+	// Admin routes require the admin role
+	Admin bool
+	Query []Parameter
+	// Body is a value of the request body type, nil for none
+	Body any
+	// Status is the success status, http.StatusOK when zero
+	Status int
+	// Response is a value of the response body type, nil for none
+	Response any
+	// ContentType of the response, application/json when empty
+	ContentType string
+}
+
+// Builder assembles a Document
+type Builder struct {
+	doc      *Document
+	problem  *Schema
+	problems string
+}
+
+// NewBuilder starts a document. problem is a value of the type used for error responses
+// and problemContentType their media type.
+func NewBuilder(info Info, problem any, problemContentType string) *Builder {
+	b := &Builder{doc: &Document{
+		OpenAPI: "3.0.3",
+		Info:    info,
+		Paths:   map[string]PathItem{},
+		Components: Components{
+			Schemas: map[string]*Schema{},
+			SecuritySchemes: map[string]SecurityScheme{
+				"bearerAuth": {
+					Type:        "http",
+					Scheme:      "bearer",
+					Description: "A session token or a personal access token (fcp_...)",
+				},
+			},
+		},
+	}}
+	b.problem = b.SchemaOf(reflect.TypeOf(problem))
+	b.problems = problemContentType
+	return b
+}
+
+// Document returns the document built so far
+func (b *Builder) Document() *Document {
+	return b.doc
+}
+
+// Add adds an operation for r
+func (b *Builder) Add(r Route) {
+	path, params := convertPath(r.Path)
+	op := &Operation{
+		OperationID: operationID(r.Method, r.Path),
+		Summary:     r.Summary,
+		Description: describeAccess(r),
+		Parameters:  append(params, r.Query...),
+		Responses:   map[string]Response{},
+		Security:    []map[string][]string{{"bearerAuth": {}}},
+	}
+	if r.Tag != "" {
+		op.Tags = []string{r.Tag}
+	}
+	if r.Public {
+		op.Security = []map[string][]string{}
+	}
+	if r.Body != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{"application/json": {Schema: b.SchemaOf(reflect.TypeOf(r.Body))}},
+		}
+	}
+
+	status := r.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := Response{Description: http.StatusText(status)}
+	if r.Response != nil {
+		contentType := r.ContentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		schema := &Schema{Type: "string", Format: "binary"}
+		if contentType == "application/json" {
+			schema = b.SchemaOf(reflect.TypeOf(r.Response))
+		}
+		success.Content = map[string]MediaType{contentType: {Schema: schema}}
+	}
+	op.Responses[strconv.Itoa(status)] = success
+	op.Responses["default"] = Response{
+		Description: "Error",
+		Content:     map[string]MediaType{b.problems: {Schema: b.problem}},
+	}
+
+	item, ok := b.doc.Paths[path]
+	if !ok {
+		item = PathItem{}
+		b.doc.Paths[path] = item
+	}
+	item[strings.ToLower(r.Method)] = op
+}
+
+// describeAccess documents the access requirements of a route
+func describeAccess(r Route) string {
+	var parts []string
+	if r.Description != "" {
+		parts = append(parts, r.Description)
+	}
+	if r.Admin {
+		parts = append(parts, "Requires the admin role.")
+	}
+	if r.SessionOnly {
+		parts = append(parts, "Requires a session token, personal access tokens are rejected.")
+	}
+	if r.Scope != "" {
+		parts = append(parts, "Personal access tokens need the `"+r.Scope+"` scope.")
+	}
+	return strings.Join(parts, " ")
+}
+
+// convertPath turns gin path parameters into OpenAPI ones
+func convertPath(path string) (string, []Parameter) {
+	var params []Parameter
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, ":") {
+			name := s[1:]
+			segments[i] = "{" + name + "}"
+			params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: pathParamSchema(name)})
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// pathParamSchema types path parameters by name: revisions are numbered, everything else is a UUID
+func pathParamSchema(name string) *Schema {
+	if name == "rev" {
+		return &Schema{Type: "integer"}
+	}
+	return &Schema{Type: "string", Format: "uuid"}
+}
+
+// operationID derives a stable operation ID such as get_decks_id_revisions
+func operationID(method, path string) string {
+	var parts []string
+	for _, s := range strings.Split(strings.TrimPrefix(path, "/api/go"), "/") {
+		s = strings.TrimPrefix(s, ":")
+		if s != "" {
+			parts = append(parts, strings.NewReplacer("-", "_", ".", "_").Replace(s))
+		}
+	}
+	return strings.ToLower(method) + "_" + strings.Join(parts, "_")
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	uuidType       = reflect.TypeOf(uuid.UUID{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// SchemaOf returns the schema of t. Named struct types are added to the
+// components and referenced.
+func (b *Builder) SchemaOf(t reflect.Type) *Schema {
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		nullable = true
+	}
+
+	var s *Schema
+	switch {
+	case t == timeType:
+		s = &Schema{Type: "string", Format: "date-time"}
+	case t == uuidType:
+		s = &Schema{Type: "string", Format: "uuid"}
+	case t == rawMessageType:
+		s = &Schema{Type: "object", AdditionalProperties: true}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		name := t.Name()
+		if _, ok := b.doc.Components.Schemas[name]; !ok {
+			// Register first so recursive types terminate
+			b.doc.Components.Schemas[name] = &Schema{}
+			*b.doc.Components.Schemas[name] = *b.structSchema(t)
+		}
+		s = &Schema{Ref: "#/components/schemas/" + name}
+		// Siblings of $ref are ignored in OpenAPI 3.0, so references can't be nullable
+		nullable = false
+	case t.Kind() == reflect.Struct:
+		s = b.structSchema(t)
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		s = &Schema{Type: "string", Format: "byte"}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		s = &Schema{Type: "array", Items: b.SchemaOf(t.Elem())}
+	case t.Kind() == reflect.Map:
+		s = &Schema{Type: "object", AdditionalProperties: b.SchemaOf(t.Elem())}
+	case t.Kind() == reflect.String:
+		s = &Schema{Type: "string"}
+	case t.Kind() == reflect.Bool:
+		s = &Schema{Type: "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		s = &Schema{Type: "integer"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		s = &Schema{Type: "number"}
+	default:
+		// interface{} and anything else accepts any value
+		s = &Schema{}
+	}
+	s.Nullable = nullable
+	return s
+}
+
+// structSchema describes the JSON encoding of a struct
+func (b *Builder) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			embedded := b.structSchema(f.Type)
+			for k, v := range embedded.Properties {
+				s.Properties[k] = v
+			}
+			s.Required = append(s.Required, embedded.Required...)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s.Properties[name] = b.SchemaOf(f.Type)
+		if strings.Contains(f.Tag.Get("binding"), "required") {
+			s.Required = append(s.Required, name)
+		}
+	}
+	sort.Strings(s.Required)
+	return s
+}
@@ -0,0 +1,89 @@
+package openapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type testProblem struct {
+	Code string `json:"code"`
+}
+
+type testItem struct {
+	ID        uuid.UUID  `json:"id"`
+	Name      string     `json:"name" binding:"required"`
+	Tags      []string   `json:"tags"`
+	DeletedAt *time.Time `json:"deleted_at"`
+	Parent    *testItem  `json:"parent,omitempty"`
+	internal  string
+	Skipped   string `json:"-"`
+}
+
+func TestSchemaOf(t *testing.T) {
+	b := NewBuilder(Info{Title: "Test", Version: "1"}, testProblem{}, "application/problem+json")
+
+	ref := b.SchemaOf(reflect.TypeOf([]testItem{}))
+	assert.Equal(t, "array", ref.Type)
+	assert.Equal(t, "#/components/schemas/testItem", ref.Items.Ref)
+
+	s := b.Document().Components.Schemas["testItem"]
+	assert.Equal(t, []string{"name"}, s.Required)
+	assert.Equal(t, &Schema{Type: "string", Format: "uuid"}, s.Properties["id"])
+	assert.Equal(t, &Schema{Type: "string", Format: "date-time", Nullable: true}, s.Properties["deleted_at"])
+	assert.Equal(t, "#/components/schemas/testItem", s.Properties["parent"].Ref)
+	assert.NotContains(t, s.Properties, "internal")
+	assert.NotContains(t, s.Properties, "Skipped")
+}
+
+func TestAdd(t *testing.T) {
+	b := NewBuilder(Info{Title: "Test", Version: "1"}, testProblem{}, "application/problem+json")
+	b.Add(Route{
+		Method:   "POST",
+		Path:     "/api/go/items/:id/revisions/:rev/revert",
+		Scope:    "items:write",
+		Response: testItem{},
+	})
+	b.Add(Route{Method: "DELETE", Path: "/api/go/items/:id", Public: true, Status: http.StatusNoContent})
+
+	op := b.Document().Paths["/api/go/items/{id}/revisions/{rev}/revert"]["post"]
+	if assert.NotNil(t, op) {
+		assert.Equal(t, "post_items_id_revisions_rev_revert", op.OperationID)
+		assert.Equal(t, []Parameter{
+			{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "string", Format: "uuid"}},
+			{Name: "rev", In: "path", Required: true, Schema: &Schema{Type: "integer"}},
+		}, op.Parameters)
+		assert.Contains(t, op.Description, "items:write")
+		assert.Equal(t, "#/components/schemas/testItem", op.Responses["200"].Content["application/json"].Schema.Ref)
+		assert.Equal(t, "#/components/schemas/testProblem", op.Responses["default"].Content["application/problem+json"].Schema.Ref)
+		assert.Equal(t, []map[string][]string{{"bearerAuth": {}}}, op.Security)
+	}
+
+	del := b.Document().Paths["/api/go/items/{id}"]["delete"]
+	if assert.NotNil(t, del) {
+		assert.Empty(t, del.Security)
+		assert.Empty(t, del.Responses["204"].Content)
+	}
+}
+
+func TestDocsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/docs", nil)
+
+	DocsHandler("Test", "/openapi.json")(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `<redoc spec-url="/openapi.json"></redoc>`)
+	if RedocIntegrity == "" {
+		t.Skip("RedocIntegrity is not set")
+	}
+	assert.Contains(t, w.Body.String(), `src="`+RedocScript+`" integrity="`+RedocIntegrity+`" crossorigin="anonymous"`)
+}
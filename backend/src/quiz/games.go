@@ -0,0 +1,93 @@
+package quiz
+
+import (
+	"api/src/grading"
+	"api/src/models"
+	"errors"
+	"math/rand"
+)
+
+// ErrTooFewPairs is returned for decks without two cards with different fronts and backs
+var ErrTooFewPairs = errors.New("a game needs at least two flashcards with different fronts and backs")
+
+// Match builds a match round of up to size pairs. The backs are shuffled and
+// the key maps every front to the index of its back. Cards with the same front
+// or back as a previous card are left out so every front has one match.
+func Match(cards []models.Flashcard, seed int64, size int) (models.GameRound, error) {
+	var pairs []models.Flashcard
+	fronts, backs := map[string]bool{}, map[string]bool{}
+	for _, f := range cards {
+		front, back := grading.Normalize(f.Front), grading.Normalize(f.Back)
+		if fronts[front] || backs[back] {
+			continue
+		}
+		fronts[front], backs[back] = true, true
+		pairs = append(pairs, f)
+	}
+	if len(pairs) < 2 {
+		return models.GameRound{}, ErrTooFewPairs
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	order := rng.Perm(len(pairs))
+	if size > 0 && size < len(order) {
+		order = order[:size]
+	}
+	shuffled := rng.Perm(len(order))
+
+	round := models.GameRound{
+		Kind:   models.GameMatch,
+		Seed:   seed,
+		Fronts: make([]string, len(order)),
+		Backs:  make([]string, len(order)),
+		Key:    make([]int, len(order)),
+	}
+	for i, card := range order {
+		round.Fronts[i] = pairs[card].Front
+		round.Backs[shuffled[i]] = pairs[card].Back
+		round.Key[i] = shuffled[i]
+	}
+	return round, nil
+}
+
+// TrueFalse builds a true/false round of up to size statements, one per card.
+// About half of the statements show the back of another card, picked like the
+// distractors of a quiz so false statements can't be told apart by their form.
+func TrueFalse(cards []models.Flashcard, seed int64, size int) (models.GameRound, error) {
+	var backs []candidate
+	seen := map[string]bool{}
+	for _, f := range cards {
+		n := grading.Normalize(f.Back)
+		if seen[n] {
+			continue
+		}
+		seen[n] = true
+		backs = append(backs, candidate{back: f.Back, normalized: n, kind: kind(f.Back), length: len([]rune(f.Back))})
+	}
+	if len(backs) < 2 {
+		return models.GameRound{}, ErrTooFewPairs
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	order := rng.Perm(len(cards))
+	if size > 0 && size < len(order) {
+		order = order[:size]
+	}
+
+	round := models.GameRound{
+		Kind:       models.GameTrueFalse,
+		Seed:       seed,
+		Statements: make([]models.GameStatement, len(order)),
+		Key:        make([]int, len(order)),
+	}
+	for i, card := range order {
+		statement := models.GameStatement{Front: cards[card].Front, Back: cards[card].Back}
+		round.Key[i] = 1
+		if rng.Intn(2) == 0 {
+			statement.Back = distractors(rng, cards[card].Back, backs, 1)[0]
+			round.Key[i] = 0
+		}
+		round.Statements[i] = statement
+	}
+	return round, nil
+}
@@ -0,0 +1,78 @@
+package quiz
+
+import (
+	"api/src/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func capitals() []models.Flashcard {
+	return deck(
+		"Capital of France", "Paris",
+		"Capital of Italy", "Rome",
+		"Capital of Spain", "Madrid",
+		"Capital of Germany", "Berlin",
+		"Capital of Portugal", "Lisbon",
+		"Capital of Austria", "Vienna",
+	)
+}
+
+func TestMatch(t *testing.T) {
+	cards := capitals()
+
+	round, err := Match(cards, 7, 3)
+	require.NoError(t, err)
+	assert.Equal(t, models.GameMatch, round.Kind)
+	assert.Len(t, round.Fronts, 3)
+	assert.Len(t, round.Backs, 3)
+
+	backOf := map[string]string{}
+	for _, f := range cards {
+		backOf[f.Front] = f.Back
+	}
+	for i, front := range round.Fronts {
+		assert.Equal(t, backOf[front], round.Backs[round.Key[i]])
+	}
+
+	again, err := Match(cards, 7, 3)
+	require.NoError(t, err)
+	assert.Equal(t, round, again)
+}
+
+func TestMatchSkipsAmbiguousPairs(t *testing.T) {
+	cards := deck(
+		"Capital of France", "Paris",
+		"City of Light", "paris",
+		"Capital of Italy", "Rome",
+	)
+
+	round, err := Match(cards, 1, 0)
+	require.NoError(t, err)
+	assert.Len(t, round.Fronts, 2)
+	assert.NotContains(t, round.Fronts, "City of Light")
+
+	_, err = Match(cards[:2], 1, 0)
+	assert.ErrorIs(t, err, ErrTooFewPairs)
+}
+
+func TestTrueFalse(t *testing.T) {
+	cards := capitals()
+	backOf := map[string]string{}
+	for _, f := range cards {
+		backOf[f.Front] = f.Back
+	}
+
+	round, err := TrueFalse(cards, 3, 0)
+	require.NoError(t, err)
+	assert.Equal(t, models.GameTrueFalse, round.Kind)
+	assert.Len(t, round.Statements, len(cards))
+	for i, s := range round.Statements {
+		assert.Equal(t, round.Key[i] == 1, backOf[s.Front] == s.Back, s.Front)
+	}
+	assert.Contains(t, round.Key, 0)
+	assert.Contains(t, round.Key, 1)
+
+	_, err = TrueFalse(cards[:1], 3, 0)
+	assert.ErrorIs(t, err, ErrTooFewPairs)
+}
@@ -0,0 +1,177 @@
+// Package quiz generates multiple-choice quizzes, and the rounds of quick
+// games, from the flashcards of a deck.
+//
+// Every question shows the front of a card and asks for its back among
+// distractors: the backs of other cards of the deck, preferring ones of the
+// same kind (number, single word or phrase) and of similar length. Generation
+// only depends on the cards and a seed, so a quiz can be generated again.
+package quiz
+
+import (
+	"api/src/grading"
+	"api/src/models"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrTooFewCards is returned for decks without two different backs to choose from
+var ErrTooFewCards = errors.New("a quiz needs at least two flashcards with different backs")
+
+// Options tune the generation of a quiz
+type Options struct {
+	Seed int64
+	// Questions caps the number of questions, 0 for one per card
+	Questions int
+	Choices   int
+}
+
+// Kinds of backs
+const (
+	kindNumber = "number"
+	kindWord   = "word"
+	kindPhrase = "phrase"
+)
+
+// kind classifies a back so distractors can't be told apart by their form
+func kind(back string) string {
+	s := strings.ReplaceAll(strings.TrimSpace(back), ",", "")
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return kindNumber
+	}
+	if len(strings.Fields(s)) == 1 {
+		return kindWord
+	}
+	return kindPhrase
+}
+
+// candidate is a back that could serve as a distractor
+type candidate struct {
+	back       string
+	normalized string
+	kind       string
+	length     int
+}
+
+// Generate returns the questions of a quiz over cards. Cards must come in a
+// stable order for the quiz to be repeatable.
+func Generate(cards []models.Flashcard, opts Options) ([]models.QuizQuestion, error) {
+	// Backs that only differ in case, accents or punctuation are one candidate
+	var backs []candidate
+	seen := map[string]bool{}
+	for _, f := range cards {
+		n := grading.Normalize(f.Back)
+		if seen[n] {
+			continue
+		}
+		seen[n] = true
+		backs = append(backs, candidate{back: f.Back, normalized: n, kind: kind(f.Back), length: len([]rune(f.Back))})
+	}
+	if len(backs) < 2 {
+		return nil, ErrTooFewCards
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	order := rng.Perm(len(cards))
+	if opts.Questions > 0 && opts.Questions < len(order) {
+		order = order[:opts.Questions]
+	}
+
+	questions := make([]models.QuizQuestion, 0, len(order))
+	for _, i := range order {
+		card := cards[i]
+		choices := append([]string{card.Back}, distractors(rng, card.Back, backs, opts.Choices-1)...)
+		rng.Shuffle(len(choices), func(a, b int) { choices[a], choices[b] = choices[b], choices[a] })
+
+		answer := 0
+		for j, choice := range choices {
+			if choice == card.Back {
+				answer = j
+				break
+			}
+		}
+		questions = append(questions, models.QuizQuestion{
+			FlashcardID: card.ID,
+			Prompt:      card.Front,
+			Choices:     choices,
+			Answer:      &answer,
+		})
+	}
+	return questions, nil
+}
+
+// distractors picks up to n backs other than answer. Candidates of the same
+// kind and closest in length rank first; n are picked at random among the
+// best 2n so the same card doesn't always get the same distractors.
+func distractors(rng *rand.Rand, answer string, backs []candidate, n int) []string {
+	target := candidate{normalized: grading.Normalize(answer), kind: kind(answer), length: len([]rune(answer))}
+	var pool []candidate
+	for _, b := range backs {
+		if b.normalized != target.normalized {
+			pool = append(pool, b)
+		}
+	}
+	sort.SliceStable(pool, func(i, j int) bool {
+		if (pool[i].kind == target.kind) != (pool[j].kind == target.kind) {
+			return pool[i].kind == target.kind
+		}
+		return lengthRatio(pool[i], target) > lengthRatio(pool[j], target)
+	})
+
+	// Other kinds only make up for a lack of candidates of the same kind
+	same := 0
+	for same < len(pool) && pool[same].kind == target.kind {
+		same++
+	}
+	if same >= n {
+		pool = pool[:min(same, 2*n)]
+	} else if len(pool) > n {
+		pool = pool[:n]
+	}
+	rng.Shuffle(len(pool), func(i, j int) { pool[i], pool[j] = pool[j], pool[i] })
+	if len(pool) > n {
+		pool = pool[:n]
+	}
+
+	picked := make([]string, len(pool))
+	for i, c := range pool {
+		picked[i] = c.back
+	}
+	return picked
+}
+
+// lengthRatio is 1 for backs of the same length and tends to 0 as they differ
+func lengthRatio(a, b candidate) float64 {
+	shorter, longer := min(a.length, b.length), max(a.length, b.length)
+	if longer == 0 {
+		return 1
+	}
+	return float64(shorter) / float64(longer)
+}
+
+// WriteMarkdown writes a printable quiz with lettered choices. The answer key
+// follows the questions when answers is set.
+func WriteMarkdown(w io.Writer, title string, q models.Quiz, answers bool) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\nQuiz %d\n", title, q.Seed)
+	for i, question := range q.Questions {
+		fmt.Fprintf(&b, "\n%d. %s\n\n", i+1, question.Prompt)
+		for j, choice := range question.Choices {
+			fmt.Fprintf(&b, "   %c) %s\n", 'A'+j, choice)
+		}
+	}
+	if answers {
+		b.WriteString("\n## Answers\n\n")
+		for i, question := range q.Questions {
+			if question.Answer != nil {
+				fmt.Fprintf(&b, "%d. %c\n", i+1, 'A'+*question.Answer)
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,113 @@
+package quiz
+
+import (
+	"api/src/models"
+	"bytes"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func deck(pairs ...string) []models.Flashcard {
+	var cards []models.Flashcard
+	for i := 0; i < len(pairs); i += 2 {
+		cards = append(cards, models.Flashcard{ID: uuid.New(), Front: pairs[i], Back: pairs[i+1]})
+	}
+	return cards
+}
+
+func TestGenerate(t *testing.T) {
+	cards := deck(
+		"Capital of France", "Paris",
+		"Capital of Italy", "Rome",
+		"Capital of Spain", "Madrid",
+		"Capital of Germany", "Berlin",
+		"Capital of Portugal", "Lisbon",
+		"Year of the French Revolution", "1789",
+		"Year the Berlin Wall fell", "1989",
+		"Motto of France", "Liberty, equality, fraternity",
+	)
+
+	questions, err := Generate(cards, Options{Seed: 42, Choices: 4})
+	require.NoError(t, err)
+	require.Len(t, questions, len(cards))
+
+	fronts := map[string]bool{}
+	for _, q := range questions {
+		fronts[q.Prompt] = true
+		require.NotNil(t, q.Answer)
+		assert.Len(t, q.Choices, 4)
+
+		var card models.Flashcard
+		for _, c := range cards {
+			if c.ID == q.FlashcardID {
+				card = c
+			}
+		}
+		assert.Equal(t, card.Back, q.Choices[*q.Answer])
+
+		distinct := map[string]bool{}
+		for _, choice := range q.Choices {
+			distinct[choice] = true
+		}
+		assert.Len(t, distinct, 4, "choices of %q repeat", q.Prompt)
+	}
+	assert.Len(t, fronts, len(cards))
+
+	again, err := Generate(cards, Options{Seed: 42, Choices: 4})
+	require.NoError(t, err)
+	assert.Equal(t, questions, again)
+
+	other, err := Generate(cards, Options{Seed: 7, Choices: 4})
+	require.NoError(t, err)
+	assert.NotEqual(t, questions, other)
+}
+
+func TestGenerateDistractorsOfTheSameKind(t *testing.T) {
+	cards := deck(
+		"French Revolution", "1789",
+		"Fall of the Berlin Wall", "1989",
+		"Moon landing", "1969",
+		"Capital of France", "Paris",
+		"Capital of Italy", "Rome",
+	)
+
+	questions, err := Generate(cards, Options{Seed: 1, Questions: 5, Choices: 3})
+	require.NoError(t, err)
+	for _, q := range questions {
+		if q.Prompt == "Moon landing" {
+			assert.ElementsMatch(t, []string{"1789", "1989", "1969"}, q.Choices)
+		}
+	}
+}
+
+func TestGenerateLimits(t *testing.T) {
+	cards := deck("one", "uno", "two", "dos", "three", "tres")
+
+	questions, err := Generate(cards, Options{Seed: 3, Questions: 2, Choices: 6})
+	require.NoError(t, err)
+	if assert.Len(t, questions, 2) {
+		// Only two other backs to pick distractors from
+		assert.Len(t, questions[0].Choices, 3)
+	}
+
+	_, err = Generate(deck("one", "uno", "ONE", "Uno!"), Options{Seed: 3, Choices: 4})
+	assert.ErrorIs(t, err, ErrTooFewCards)
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	answer := 1
+	q := models.Quiz{Seed: 42, Questions: []models.QuizQuestion{
+		{Prompt: "Capital of France", Choices: []string{"Rome", "Paris"}, Answer: &answer},
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteMarkdown(&buf, "Capitals", q, true))
+	assert.Equal(t, "# Capitals\n\nQuiz 42\n\n1. Capital of France\n\n   A) Rome\n   B) Paris\n\n## Answers\n\n1. B\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteMarkdown(&buf, "Capitals", q, false))
+	assert.NotContains(t, buf.String(), "Answers")
+}
@@ -0,0 +1,217 @@
+package routes
+
+import (
+	"api/src/apierror"
+	"api/src/export"
+	"api/src/models"
+	"api/src/openapi"
+	"net/http"
+)
+
+// Paths of the API description
+const (
+	openAPIPath = "/api/go/openapi.json"
+	docsPath    = "/api/go/docs"
+)
+
+var (
+	stringParam = &openapi.Schema{Type: "string"}
+	intParam    = &openapi.Schema{Type: "integer"}
+)
+
+type roleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+type messageResponse map[string]string
+
+// apiRoutes documents every route registered by SetupRouter.
+// TestOpenAPICoversRoutes fails when the two disagree.
+var apiRoutes = []openapi.Route{
+	{Method: "GET", Path: "/api/go/health", Tag: "meta", Public: true, Summary: "Health check", Response: messageResponse{}},
+	{Method: "GET", Path: openAPIPath, Tag: "meta", Public: true, Summary: "This OpenAPI document", Response: map[string]any{}},
+	{Method: "GET", Path: docsPath, Tag: "meta", Public: true, Summary: "Rendered API documentation", Response: []byte{}, ContentType: "text/html"},
+	{Method: "POST", Path: "/api/go/webhooks/clerk", Tag: "meta", Public: true, Summary: "Clerk user webhook",
+		Description: "Verified with the Svix signature headers.", Body: map[string]any{}, Response: messageResponse{}},
+
+	{Method: "GET", Path: "/api/go/me", Tag: "account", SessionOnly: true, Summary: "Get the authenticated user", Response: models.User{}},
+	{Method: "POST", Path: "/api/go/me/export", Tag: "account", SessionOnly: true, Summary: "Start an account export",
+		Status: http.StatusAccepted, Response: models.ExportJob{}},
+	{Method: "GET", Path: "/api/go/me/exports/:id", Tag: "account", SessionOnly: true, Summary: "Get an account export",
+		Description: "Finished exports include a signed download_url.", Response: models.ExportJob{}},
+	{Method: "GET", Path: "/api/go/exports/:id/download", Tag: "account", Public: true, Summary: "Download an account export",
+		Description: "Authenticated by the signature of the link returned by the export.",
+		Query: []openapi.Parameter{
+			{Name: "expires", In: "query", Required: true, Schema: intParam},
+			{Name: "signature", In: "query", Required: true, Schema: stringParam},
+		},
+		Response: []byte{}, ContentType: "application/zip"},
+	{Method: "GET", Path: "/api/go/me/deletion", Tag: "account", SessionOnly: true, Summary: "Get the deletion status of the account",
+		Description: "Reachable while the account is locked for deletion.", Response: models.AccountDeletion{}},
+	{Method: "DELETE", Path: "/api/go/me/deletion", Tag: "account", SessionOnly: true, Summary: "Cancel a scheduled account deletion",
+		Response: models.AccountDeletion{}},
+
+	{Method: "GET", Path: "/api/go/users", Tag: "users", SessionOnly: true, Admin: true, Summary: "List users", Response: []models.User{}},
+	{Method: "GET", Path: "/api/go/users/:id", Tag: "users", SessionOnly: true, Summary: "Get a user",
+		Description: "Users can only get themselves unless they are admins.", Response: models.User{}},
+	{Method: "POST", Path: "/api/go/users", Tag: "users", SessionOnly: true, Summary: "Register the authenticated user",
+		Body: models.User{}, Status: http.StatusCreated, Response: models.User{}},
+	{Method: "PUT", Path: "/api/go/users/:id", Tag: "users", SessionOnly: true, Summary: "Update the authenticated user",
+		Description: "A timezone left out is kept.", Body: models.User{}, Response: models.User{}},
+	{Method: "DELETE", Path: "/api/go/users/:id", Tag: "users", SessionOnly: true, Summary: "Schedule the deletion of the authenticated user",
+		Description: "The account is locked and purged after a 14 day grace period. Purging deletes every deck the user owns; decks can't be shared with collaborators yet, so there is no ownership to transfer.", Status: http.StatusAccepted, Response: models.AccountDeletion{}},
+
+	{Method: "GET", Path: "/api/go/admin/users", Tag: "admin", SessionOnly: true, Admin: true, Summary: "Search users",
+		Query: []openapi.Parameter{
+			{Name: "q", In: "query", Description: "Matched against name and email", Schema: stringParam},
+			{Name: "limit", In: "query", Schema: intParam},
+			{Name: "offset", In: "query", Schema: intParam},
+		},
+		Response: []models.User{}},
+	{Method: "GET", Path: "/api/go/admin/users/:id/usage", Tag: "admin", SessionOnly: true, Admin: true, Summary: "Get the storage usage of a user", Response: models.UserUsage{}},
+	{Method: "GET", Path: "/api/go/admin/users/:id/audit", Tag: "admin", SessionOnly: true, Admin: true, Summary: "Get the audit log of a user", Response: []models.AuditEntry{}},
+	{Method: "POST", Path: "/api/go/admin/users/:id/suspend", Tag: "admin", SessionOnly: true, Admin: true, Summary: "Suspend a user", Response: models.User{}},
+	{Method: "POST", Path: "/api/go/admin/users/:id/unsuspend", Tag: "admin", SessionOnly: true, Admin: true, Summary: "Reinstate a suspended user", Response: models.User{}},
+	{Method: "PUT", Path: "/api/go/admin/users/:id/role", Tag: "admin", SessionOnly: true, Admin: true, Summary: "Set the role of a user", Body: roleRequest{}, Response: models.User{}},
+
+	{Method: "GET", Path: "/api/go/tokens", Tag: "tokens", SessionOnly: true, Summary: "List personal access tokens", Response: []models.PersonalAccessToken{}},
+	{Method: "POST", Path: "/api/go/tokens", Tag: "tokens", SessionOnly: true, Summary: "Create a personal access token",
+		Description: "The token is only returned in this response.", Body: models.PersonalAccessToken{}, Status: http.StatusCreated, Response: models.PersonalAccessToken{}},
+	{Method: "DELETE", Path: "/api/go/tokens/:id", Tag: "tokens", SessionOnly: true, Summary: "Revoke a personal access token", Status: http.StatusNoContent},
+
+	{Method: "GET", Path: "/api/go/decks", Tag: "decks", Scope: models.ScopeReadDecks, Summary: "List decks", Response: []models.Deck{}},
+	{Method: "GET", Path: "/api/go/decks/:id", Tag: "decks", Scope: models.ScopeReadDecks, Summary: "Get a deck", Response: models.Deck{}},
+	{Method: "POST", Path: "/api/go/decks", Tag: "decks", Scope: models.ScopeWriteDecks, Summary: "Create a deck", Body: models.Deck{}, Response: models.Deck{}},
+	{Method: "PUT", Path: "/api/go/decks/:id", Tag: "decks", Scope: models.ScopeWriteDecks, Summary: "Update a deck",
+		Description: "The previous content is kept as a revision.", Body: models.Deck{}, Response: models.Deck{}},
+	{Method: "DELETE", Path: "/api/go/decks/:id", Tag: "decks", Scope: models.ScopeWriteDecks, Summary: "Delete a deck", Status: http.StatusNoContent},
+	{Method: "GET", Path: "/api/go/decks/:id/export", Tag: "decks", Scope: models.ScopeReadDecks, Summary: "Export a deck",
+		Description: "JSON by default, CSV with format=csv.",
+		Query:       []openapi.Parameter{{Name: "format", In: "query", Schema: &openapi.Schema{Type: "string", Enum: []string{export.FormatJSON, export.FormatCSV}}}},
+		Response:    export.DeckDocument{}},
+	{Method: "POST", Path: "/api/go/decks/:id/sync", Tag: "decks", Scope: models.ScopeWriteDecks, Summary: "Sync a deck with a Markdown card file",
+		Description: "Creates and updates flashcards so the deck matches the file, and deletes the flashcards missing from it with delete_missing. Cards are matched by their card comment, then by front. A file without cards is rejected.",
+		Body:        models.DeckSync{}, Response: models.DeckSyncResult{}},
+	{Method: "GET", Path: "/api/go/decks/:id/duplicates", Tag: "decks", Scope: models.ScopeReadDecks, Summary: "Find duplicate flashcards in a deck",
+		Description: "Groups flashcards whose fronts are equal ignoring case, punctuation and whitespace, or at least as similar as threshold.",
+		Query:       []openapi.Parameter{{Name: "threshold", In: "query", Description: "Similarity from 0 to 1, 0.85 by default", Schema: &openapi.Schema{Type: "number"}}},
+		Response:    []models.DuplicateGroup{}},
+	{Method: "POST", Path: "/api/go/decks/:id/duplicates/merge", Tag: "decks", Scope: models.ScopeWriteDecks, Summary: "Merge duplicate flashcards",
+		Description: "Deletes the merged flashcards. The kept flashcard is starred if any of them was, and takes over their review history and the schedule with the longest interval, with the most reps and lapses.",
+		Body:        models.DuplicateMerge{}, Response: models.Flashcard{}},
+	{Method: "POST", Path: "/api/go/decks/:id/quiz", Tag: "quizzes", Scope: models.ScopeStudy, Summary: "Generate a multiple-choice quiz from a deck",
+		Description: "Distractors are the backs of other flashcards of the deck. The same seed on an unchanged deck generates the same quiz. Answers are left out.",
+		Body:        models.QuizRequest{}, Status: http.StatusCreated, Response: models.Quiz{}},
+	{Method: "GET", Path: "/api/go/quizzes/:id", Tag: "quizzes", Scope: models.ScopeReadDecks, Summary: "Get a quiz",
+		Description: "format=markdown returns a printable quiz, answers=true includes the answer key and needs the decks:write scope.",
+		Query: []openapi.Parameter{
+			{Name: "format", In: "query", Schema: &openapi.Schema{Type: "string", Enum: []string{"json", "markdown"}}},
+			{Name: "answers", In: "query", Schema: &openapi.Schema{Type: "boolean"}},
+		},
+		Response: models.Quiz{}},
+	{Method: "POST", Path: "/api/go/quizzes/:id/submit", Tag: "quizzes", Scope: models.ScopeStudy, Summary: "Submit answers to a quiz",
+		Description: "The answers are graded on the server and the score is recorded. The answer key is only returned with the decks:write scope.",
+		Body:        models.QuizSubmission{}, Status: http.StatusCreated, Response: models.QuizAttempt{}},
+	{Method: "POST", Path: "/api/go/import/paste/preview", Tag: "decks", Scope: models.ScopeWriteDecks, Summary: "Preview an import of pasted text",
+		Description: "Shows the cards the text would be split into and warnings about skipped or suspicious entries.",
+		Body:        models.PasteImport{}, Response: models.PastePreview{}},
+	{Method: "POST", Path: "/api/go/import/paste", Tag: "decks", Scope: models.ScopeWriteDecks, Summary: "Import pasted text",
+		Description: "Adds the cards to deck_id, or to a new deck named title.",
+		Body:        models.PasteImport{}, Status: http.StatusCreated, Response: models.PasteImportResult{}},
+	{Method: "GET", Path: "/api/go/decks/:id/revisions", Tag: "revisions", Scope: models.ScopeReadDecks, Summary: "List the revisions of a deck", Response: []models.DeckRevision{}},
+	{Method: "POST", Path: "/api/go/decks/:id/revisions/:rev/revert", Tag: "revisions", Scope: models.ScopeWriteDecks, Summary: "Revert a deck to a revision", Response: models.Deck{}},
+	{Method: "GET", Path: "/api/go/deck-presets", Tag: "decks", Scope: models.ScopeReadDecks, Summary: "List your deck presets", Response: []models.DeckPreset{}},
+	{Method: "POST", Path: "/api/go/deck-presets", Tag: "decks", Scope: models.ScopeWriteDecks, Summary: "Create a deck preset",
+		Description: "Presets hold the study options of the decks using them: daily limits, learning steps, intervals, new card order, answer timer and scheduler. Options left out get the built-in defaults. A default preset replaces the previous one and applies to decks without a preset_id.",
+		Body:        models.DeckPreset{}, Status: http.StatusCreated, Response: models.DeckPreset{}},
+	{Method: "GET", Path: "/api/go/deck-presets/:id", Tag: "decks", Scope: models.ScopeReadDecks, Summary: "Get a deck preset", Response: models.DeckPreset{}},
+	{Method: "PUT", Path: "/api/go/deck-presets/:id", Tag: "decks", Scope: models.ScopeWriteDecks, Summary: "Update a deck preset",
+		Description: "Replaces every option; the new options apply to the next answers in its decks.", Body: models.DeckPreset{}, Response: models.DeckPreset{}},
+	{Method: "DELETE", Path: "/api/go/deck-presets/:id", Tag: "decks", Scope: models.ScopeWriteDecks, Summary: "Delete a deck preset",
+		Description: "Its decks go back to the default preset.", Status: http.StatusNoContent},
+
+	{Method: "GET", Path: "/api/go/decks/:id/flashcards", Tag: "flashcards", Scope: models.ScopeReadDecks, Summary: "List the flashcards of a deck", Response: []models.Flashcard{}},
+	{Method: "POST", Path: "/api/go/decks/:id/flashcards", Tag: "flashcards", Scope: models.ScopeWriteDecks, Summary: "Create a flashcard",
+		Description: "The response lists the flashcards of the deck the new one duplicates.", Body: models.Flashcard{}, Status: http.StatusCreated, Response: models.Flashcard{}},
+	{Method: "GET", Path: "/api/go/flashcards/:id", Tag: "flashcards", Scope: models.ScopeReadDecks, Summary: "Get a flashcard", Response: models.Flashcard{}},
+	{Method: "PUT", Path: "/api/go/flashcards/:id", Tag: "flashcards", Scope: models.ScopeWriteDecks, Summary: "Update a flashcard",
+		Description: "The previous content is kept as a revision.", Body: models.Flashcard{}, Response: messageResponse{}},
+	{Method: "DELETE", Path: "/api/go/flashcards/:id", Tag: "flashcards", Scope: models.ScopeWriteDecks, Summary: "Delete a flashcard", Status: http.StatusNoContent},
+	{Method: "GET", Path: "/api/go/flashcards/:id/revisions", Tag: "revisions", Scope: models.ScopeReadDecks, Summary: "List the revisions of a flashcard", Response: []models.FlashcardRevision{}},
+	{Method: "POST", Path: "/api/go/flashcards/:id/revisions/:rev/revert", Tag: "revisions", Scope: models.ScopeWriteDecks, Summary: "Revert a flashcard to a revision", Response: models.Flashcard{}},
+	{Method: "POST", Path: "/api/go/flashcards/:id/suspend", Tag: "study", Scope: models.ScopeStudy, Summary: "Suspend a flashcard",
+		Description: "Suspended cards are left out of study sessions until unsuspended.", Response: models.CardState{}},
+	{Method: "POST", Path: "/api/go/flashcards/:id/unsuspend", Tag: "study", Scope: models.ScopeStudy, Summary: "Unsuspend a flashcard",
+		Description: "Brings a suspended or buried card back to study. Leeches stay tagged.", Response: models.CardState{}},
+	{Method: "POST", Path: "/api/go/flashcards/:id/bury", Tag: "study", Scope: models.ScopeStudy, Summary: "Bury a flashcard until tomorrow",
+		Description: "The card comes back when your next study day starts, at the day_rollover hour of your study goal in your timezone.", Response: models.CardState{}},
+	{Method: "GET", Path: "/api/go/leeches", Tag: "study", Scope: models.ScopeReadDecks, Summary: "List your leeches across decks",
+		Description: "Leeches are cards whose lapses reached the leech_threshold of their deck. Decks with the suspend leech_action also suspend them.", Response: []models.Leech{}},
+
+	{Method: "POST", Path: "/api/go/study-sessions", Tag: "study", Scope: models.ScopeStudy, Summary: "Start a study session",
+		Description: "Scheduled sessions study the due cards of the decks, then new cards, within the daily limits of the deck presets. Practice sessions study every card without changing the schedule. Cram sessions study decks with an upcoming exam_date, weak and starred cards first, and shrink intervals to fit before the exam.",
+		Body:        models.StudySessionRequest{}, Status: http.StatusCreated, Response: models.StudySession{}},
+	{Method: "GET", Path: "/api/go/study-sessions/:id", Tag: "study", Scope: models.ScopeStudy, Summary: "Get a study session",
+		Description: "Active sessions list the cards left to study, closed sessions include their summary.", Response: models.StudySession{}},
+	{Method: "POST", Path: "/api/go/study-sessions/:id/answer", Tag: "study", Scope: models.ScopeStudy, Summary: "Answer the next card of a study session",
+		Description: "Send a grade, or the typed answer of at most 1000 characters for the server to grade. Cards answered Again, or still learning, come back at the end of the session. Sessions without an answer for 30 minutes are abandoned.",
+		Body:        models.StudyAnswer{}, Response: models.StudyAnswerResult{}},
+	{Method: "POST", Path: "/api/go/study-sessions/:id/finish", Tag: "study", Scope: models.ScopeStudy, Summary: "Finish a study session",
+		Description: "Returns the summary of the session: accuracy, time, cards learned and cards lapsed.", Response: models.StudySession{}},
+
+	{Method: "GET", Path: "/api/go/filtered-decks", Tag: "filtered decks", Scope: models.ScopeReadDecks, Summary: "List your filtered decks", Response: []models.FilteredDeck{}},
+	{Method: "POST", Path: "/api/go/filtered-decks", Tag: "filtered decks", Scope: models.ScopeWriteDecks, Summary: "Create a filtered deck",
+		Description: "Gathers the flashcards matching the filter across your decks: starred, deck labels, due within N days, lapses, failed today and text search. The cards stay in their decks.",
+		Body:        models.FilteredDeck{}, Status: http.StatusCreated, Response: models.FilteredDeck{}},
+	{Method: "GET", Path: "/api/go/filtered-decks/:id", Tag: "filtered decks", Scope: models.ScopeReadDecks, Summary: "Get a filtered deck with its cards", Response: models.FilteredDeck{}},
+	{Method: "PUT", Path: "/api/go/filtered-decks/:id", Tag: "filtered decks", Scope: models.ScopeWriteDecks, Summary: "Update a filtered deck",
+		Description: "Changes the title and filter, and rebuilds the deck.", Body: models.FilteredDeck{}, Response: models.FilteredDeck{}},
+	{Method: "DELETE", Path: "/api/go/filtered-decks/:id", Tag: "filtered decks", Scope: models.ScopeWriteDecks, Summary: "Delete a filtered deck", Status: http.StatusNoContent},
+	{Method: "POST", Path: "/api/go/filtered-decks/:id/rebuild", Tag: "filtered decks", Scope: models.ScopeWriteDecks, Summary: "Rebuild a filtered deck",
+		Description: "Gathers the cards now matching the filter.", Response: models.FilteredDeck{}},
+	{Method: "POST", Path: "/api/go/filtered-decks/:id/study", Tag: "filtered decks", Scope: models.ScopeStudy, Summary: "Study a filtered deck",
+		Description: "Starts a study session on the cards of the deck. Scheduled sessions study every card, due or not, and reschedule it in its own deck.",
+		Body:        models.FilteredStudyRequest{}, Status: http.StatusCreated, Response: models.StudySession{}},
+
+	{Method: "POST", Path: "/api/go/decks/:id/games", Tag: "games", Scope: models.ScopeStudy, Summary: "Start a match or true/false round",
+		Description: "Match rounds pair fronts with shuffled backs, true/false rounds pair fronts with their own back or another one. The same seed on an unchanged deck builds the same round, so rounds started with a seed don't count towards best times. The answer key stays on the server.",
+		Body:        models.GameRequest{}, Status: http.StatusCreated, Response: models.GameRound{}},
+	{Method: "POST", Path: "/api/go/game-rounds/:id/submit", Tag: "games", Scope: models.ScopeStudy, Summary: "Submit the answers to a round",
+		Description: "The round is timed by the server from its start. Rounds without mistakes nor seed count towards the best time.",
+		Body:        models.GameSubmission{}, Response: models.GameResult{}},
+	{Method: "GET", Path: "/api/go/decks/:id/games/best", Tag: "games", Scope: models.ScopeStudy, Summary: "Get the best times on a deck", Response: []models.GameBest{}},
+	{Method: "GET", Path: "/api/go/decks/:id/plan", Tag: "study", Scope: models.ScopeReadDecks, Summary: "Get the cram plan of a deck",
+		Description: "Spreads the new cards of a deck with an exam_date over the study days left before the exam and forecasts the reviews of every day, assuming every card is remembered.",
+		Response:    models.CramPlan{}},
+
+	{Method: "GET", Path: "/api/go/stats", Tag: "stats", Scope: models.ScopeReadDecks, Summary: "Get your learning statistics",
+		Description: "Reviews, time spent and success by hour over the last days, retention by interval, card counts by state and the due cards of the next 30 days, across your decks. Days are study days of your timezone, starting at the day_rollover hour of your study goal, and hours are hours of your timezone.",
+		Query:       statsQuery, Response: models.Stats{}},
+	{Method: "GET", Path: "/api/go/decks/:id/stats", Tag: "stats", Scope: models.ScopeReadDecks, Summary: "Get the learning statistics of a deck",
+		Description: "The statistics of GET /api/go/stats, for the cards of one deck.",
+		Query:       statsQuery, Response: models.Stats{}},
+
+	{Method: "GET", Path: "/api/go/goal", Tag: "streaks", Scope: models.ScopeStudy, Summary: "Get your daily goal", Response: models.StudyGoal{}},
+	{Method: "PUT", Path: "/api/go/goal", Tag: "streaks", Scope: models.ScopeStudy, Summary: "Set your daily goal",
+		Description: "A number of cards answered or minutes spent answering a day. Study days start at day_rollover in your timezone.",
+		Body:        models.StudyGoal{}, Response: models.StudyGoal{}},
+	{Method: "GET", Path: "/api/go/streak", Tag: "streaks", Scope: models.ScopeStudy, Summary: "Get your study streak",
+		Description: "Current and longest runs of days the goal was met, streak freezes held and the heatmap of the last year. A freeze is earned every 7 days of goals met in a row, up to 2, and spent on a missed day.",
+		Response:    models.Streak{}},
+}
+
+// statsQuery are the query parameters of the statistics routes
+var statsQuery = []openapi.Parameter{{Name: "days", In: "query", Description: "Days of review history, 30 by default and 365 at most", Schema: intParam}}
+
+// OpenAPI returns the description of the API
+func OpenAPI() *openapi.Document {
+	b := openapi.NewBuilder(openapi.Info{
+		Title:       "Flashcard API",
+		Version:     "1.0.0",
+		Description: "Errors are returned as RFC 7807 problem details with a machine-readable code.",
+	}, apierror.Problem{}, apierror.ContentType)
+	for _, r := range apiRoutes {
+		b.Add(r)
+	}
+	return b.Document()
+}
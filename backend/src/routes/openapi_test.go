@@ -0,0 +1,84 @@
+package routes
+
+import (
+	"api/src/middleware"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// openAPIPathOf converts a gin route path to its OpenAPI form
+func openAPIPathOf(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, ":") {
+			segments[i] = "{" + s[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := SetupRouter(middleware.StaticTokenAuthenticator{})
+	doc := OpenAPI()
+
+	registered := map[string]bool{}
+	for _, r := range router.Routes() {
+		path := openAPIPathOf(r.Path)
+		key := r.Method + " " + path
+		registered[key] = true
+
+		item, ok := doc.Paths[path]
+		if !assert.True(t, ok, "%s is missing from the OpenAPI document", key) {
+			continue
+		}
+		assert.Contains(t, item, strings.ToLower(r.Method), "%s is missing from the OpenAPI document", key)
+	}
+
+	for path, item := range doc.Paths {
+		for method := range item {
+			key := strings.ToUpper(method) + " " + path
+			assert.True(t, registered[key], "%s is documented but not registered", key)
+		}
+	}
+}
+
+func TestOpenAPIEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := SetupRouter(middleware.StaticTokenAuthenticator{})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/go/openapi.json", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var doc struct {
+		OpenAPI    string `json:"openapi"`
+		Components struct {
+			Schemas map[string]struct {
+				Required   []string       `json:"required"`
+				Properties map[string]any `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+	assert.Equal(t, []string{"title"}, doc.Components.Schemas["Deck"].Required)
+	assert.Equal(t, []string{"back", "front", "starred"}, doc.Components.Schemas["Flashcard"].Required)
+	assert.Contains(t, doc.Components.Schemas["User"].Properties, "email")
+	assert.Contains(t, doc.Components.Schemas["Problem"].Properties, "code")
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/go/docs", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `spec-url="/api/go/openapi.json"`)
+}
@@ -3,39 +3,130 @@ package routes
 import (
 	"api/src/controllers"
 	"api/src/middleware"
+	"api/src/models"
+	"api/src/openapi"
 
 	"github.com/gin-gonic/gin"
 )
 
-// SetupRouter configures all the routes for the application
-func SetupRouter() *gin.Engine {
-	router := gin.Default()
+// SetupRouter configures all the routes for the application.
+// Protected routes require a principal verified by auth.
+func SetupRouter(auth middleware.Authenticator) *gin.Engine {
+	// Problems replaces gin's recovery so panics are answered with problem details too
+	router := gin.New()
+	router.HandleMethodNotAllowed = true
+	router.NoRoute(middleware.NoRoute)
+	router.NoMethod(middleware.NoMethod)
 
-	router.Use(middleware.CORS())
+	router.Use(gin.Logger(), middleware.Problems(), middleware.CORS())
 
 	router.GET("/api/go/health", controllers.HealthCheck)
+	router.GET(openAPIPath, openapi.Handler(OpenAPI()))
+	router.GET(docsPath, openapi.DocsHandler("Flashcard API", openAPIPath))
+	router.POST("/api/go/webhooks/clerk", controllers.ClerkWebhook)
+	// Authenticated by the signature in the link handed out by GetAccountExport
+	router.GET("/api/go/exports/:id/download", controllers.DownloadAccountExport)
+
+	// Accounts scheduled for deletion can only check and cancel the deletion
+	locked := router.Group("/api/go")
+	locked.Use(middleware.Authenticate(auth), controllers.RejectSuspended())
+	{
+		session := middleware.RequireSession()
+
+		locked.GET("/me/deletion", session, controllers.GetAccountDeletion)
+		locked.DELETE("/me/deletion", session, controllers.CancelAccountDeletion)
+	}
 
 	protected := router.Group("/api/go")
-	protected.Use(middleware.ClerkMiddleware())
+	protected.Use(middleware.Authenticate(auth), controllers.RejectSuspended(), controllers.RejectPendingDeletion())
 	{
-		protected.GET("/users", controllers.GetUsers)
-		protected.GET("/users/:id", controllers.GetUser)
-		protected.POST("/users", controllers.CreateUser)
-		protected.PUT("/users/:id", controllers.UpdateUser)
-		protected.DELETE("/users/:id", controllers.DeleteUser)
-
-		protected.GET("/decks", controllers.GetDecks)
-		protected.GET("/decks/:id", controllers.GetDeck)
-		protected.POST("/decks", controllers.CreateDeck)
-		protected.PUT("/decks/:id", controllers.UpdateDeck)
-		protected.DELETE("/decks/:id", controllers.DeleteDeck)
+		readDecks := middleware.RequireScope(models.ScopeReadDecks)
+		writeDecks := middleware.RequireScope(models.ScopeWriteDecks)
+		study := middleware.RequireScope(models.ScopeStudy)
+		session := middleware.RequireSession()
+		admin := controllers.RequireAdmin()
+
+		protected.GET("/me", session, controllers.GetMe)
+		protected.POST("/me/export", session, controllers.RequestAccountExport)
+		protected.GET("/me/exports/:id", session, controllers.GetAccountExport)
+
+		protected.GET("/users", session, admin, controllers.GetUsers)
+		protected.GET("/users/:id", session, controllers.GetUser)
+		protected.POST("/users", session, controllers.CreateUser)
+		protected.PUT("/users/:id", session, controllers.UpdateUser)
+		protected.DELETE("/users/:id", session, controllers.DeleteUser)
+
+		protected.GET("/admin/users", session, admin, controllers.SearchUsers)
+		protected.GET("/admin/users/:id/usage", session, admin, controllers.GetUserUsage)
+		protected.GET("/admin/users/:id/audit", session, admin, controllers.GetUserAudit)
+		protected.POST("/admin/users/:id/suspend", session, admin, controllers.SuspendUser)
+		protected.POST("/admin/users/:id/unsuspend", session, admin, controllers.UnsuspendUser)
+		protected.PUT("/admin/users/:id/role", session, admin, controllers.SetUserRole)
+
+		// Personal access tokens can't be managed with a personal access token
+		protected.GET("/tokens", session, controllers.GetTokens)
+		protected.POST("/tokens", session, controllers.CreateToken)
+		protected.DELETE("/tokens/:id", session, controllers.RevokeToken)
+
+		protected.GET("/decks", readDecks, controllers.GetDecks)
+		protected.GET("/decks/:id", readDecks, controllers.GetDeck)
+		protected.POST("/decks", writeDecks, controllers.CreateDeck)
+		protected.PUT("/decks/:id", writeDecks, controllers.UpdateDeck)
+		protected.DELETE("/decks/:id", writeDecks, controllers.DeleteDeck)
+		protected.GET("/decks/:id/export", readDecks, controllers.ExportDeck)
+		protected.POST("/decks/:id/sync", writeDecks, controllers.SyncDeck)
+		protected.GET("/decks/:id/duplicates", readDecks, controllers.GetDuplicates)
+		protected.POST("/decks/:id/duplicates/merge", writeDecks, controllers.MergeDuplicates)
+		protected.POST("/decks/:id/quiz", study, controllers.CreateQuiz)
+		protected.GET("/quizzes/:id", readDecks, controllers.GetQuiz)
+		protected.POST("/quizzes/:id/submit", study, controllers.SubmitQuiz)
+		protected.POST("/import/paste/preview", writeDecks, controllers.PreviewPaste)
+		protected.POST("/import/paste", writeDecks, controllers.ImportPaste)
+		protected.GET("/decks/:id/revisions", readDecks, controllers.GetDeckRevisions)
+		protected.POST("/decks/:id/revisions/:rev/revert", writeDecks, controllers.RevertDeckRevision)
+		protected.GET("/deck-presets", readDecks, controllers.GetDeckPresets)
+		protected.POST("/deck-presets", writeDecks, controllers.CreateDeckPreset)
+		protected.GET("/deck-presets/:id", readDecks, controllers.GetDeckPreset)
+		protected.PUT("/deck-presets/:id", writeDecks, controllers.UpdateDeckPreset)
+		protected.DELETE("/deck-presets/:id", writeDecks, controllers.DeleteDeckPreset)
 
 		// Flashcard routes
-		protected.GET("/decks/:id/flashcards", controllers.GetFlashcards)
-		protected.POST("/decks/:id/flashcards", controllers.CreateFlashcard)
-		protected.GET("/flashcards/:id", controllers.GetFlashcard)
-		protected.PUT("/flashcards/:id", controllers.UpdateFlashcard)
-		protected.DELETE("/flashcards/:id", controllers.DeleteFlashcard)
+		protected.GET("/decks/:id/flashcards", readDecks, controllers.GetFlashcards)
+		protected.POST("/decks/:id/flashcards", writeDecks, controllers.CreateFlashcard)
+		protected.GET("/flashcards/:id", readDecks, controllers.GetFlashcard)
+		protected.PUT("/flashcards/:id", writeDecks, controllers.UpdateFlashcard)
+		protected.DELETE("/flashcards/:id", writeDecks, controllers.DeleteFlashcard)
+		protected.GET("/flashcards/:id/revisions", readDecks, controllers.GetFlashcardRevisions)
+		protected.POST("/flashcards/:id/revisions/:rev/revert", writeDecks, controllers.RevertFlashcardRevision)
+		protected.POST("/flashcards/:id/suspend", study, controllers.SuspendFlashcard)
+		protected.POST("/flashcards/:id/unsuspend", study, controllers.UnsuspendFlashcard)
+		protected.POST("/flashcards/:id/bury", study, controllers.BuryFlashcard)
+		protected.GET("/leeches", readDecks, controllers.GetLeeches)
+
+		protected.POST("/study-sessions", study, controllers.StartStudySession)
+		protected.GET("/study-sessions/:id", study, controllers.GetStudySession)
+		protected.POST("/study-sessions/:id/answer", study, controllers.AnswerStudyCard)
+		protected.POST("/study-sessions/:id/finish", study, controllers.FinishStudySession)
+
+		protected.GET("/filtered-decks", readDecks, controllers.GetFilteredDecks)
+		protected.POST("/filtered-decks", writeDecks, controllers.CreateFilteredDeck)
+		protected.GET("/filtered-decks/:id", readDecks, controllers.GetFilteredDeck)
+		protected.PUT("/filtered-decks/:id", writeDecks, controllers.UpdateFilteredDeck)
+		protected.DELETE("/filtered-decks/:id", writeDecks, controllers.DeleteFilteredDeck)
+		protected.POST("/filtered-decks/:id/rebuild", writeDecks, controllers.RebuildFilteredDeck)
+		protected.POST("/filtered-decks/:id/study", study, controllers.StudyFilteredDeck)
+
+		protected.POST("/decks/:id/games", study, controllers.StartGame)
+		protected.POST("/game-rounds/:id/submit", study, controllers.SubmitGame)
+		protected.GET("/decks/:id/games/best", study, controllers.GetGameBests)
+		protected.GET("/decks/:id/plan", readDecks, controllers.GetDeckPlan)
+
+		protected.GET("/stats", readDecks, controllers.GetStats)
+		protected.GET("/decks/:id/stats", readDecks, controllers.GetDeckStats)
+
+		protected.GET("/goal", study, controllers.GetStudyGoal)
+		protected.PUT("/goal", study, controllers.UpdateStudyGoal)
+		protected.GET("/streak", study, controllers.GetStreak)
 	}
 
 	return router
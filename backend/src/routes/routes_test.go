@@ -0,0 +1,227 @@
+package routes
+
+import (
+	"api/src/database"
+	"api/src/middleware"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetupRouter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("static token", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		testUserID := uuid.New()
+		mock.ExpectQuery(`SELECT id, role, suspended_at, deletion_scheduled_for FROM users WHERE clerk_id = \$1`).
+			WithArgs("user_static").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role", "suspended_at", "deletion_scheduled_for"}).AddRow(testUserID, "user", nil, nil))
+		mock.ExpectQuery(`SELECT id, owner_id, labels, title, description, to_char\(exam_date, 'YYYY-MM-DD'\), leech_threshold, leech_action, preset_id FROM decks WHERE owner_id = \$1`).
+			WithArgs(testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id", "labels", "title", "description", "exam_date", "leech_threshold", "leech_action", "preset_id"}).
+				AddRow(uuid.New(), testUserID, pq.Array([]string{"math"}), "Algebra", "", nil, 8, "tag", nil))
+
+		auth := middleware.StaticTokenAuthenticator{Tokens: map[string]middleware.Principal{
+			"static-token": {Subject: "user_static"},
+		}}
+		router := SetupRouter(auth)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/go/decks", nil)
+		req.Header.Set("Authorization", "Bearer static-token")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "Algebra")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("local jwt", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		testUserID := uuid.New()
+		mock.ExpectQuery(`SELECT id, role, suspended_at, deletion_scheduled_for FROM users WHERE clerk_id = \$1`).
+			WithArgs("user_local").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role", "suspended_at", "deletion_scheduled_for"}).AddRow(testUserID, "user", nil, nil))
+		mock.ExpectQuery(`SELECT id, clerk_id, name, email, role, suspended_at, timezone FROM users WHERE id = \$1`).
+			WithArgs(testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "clerk_id", "name", "email", "role", "suspended_at", "timezone"}).
+				AddRow(testUserID, "user_local", "Local User", "local@example.com", "user", nil, "UTC"))
+
+		secret := []byte("test-secret")
+		router := SetupRouter(middleware.LocalJWTAuthenticator{Secret: secret})
+		token, err := middleware.IssueLocalToken(secret, middleware.Principal{Subject: "user_local"}, time.Minute)
+		assert.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/go/users/"+testUserID.String(), nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "local@example.com")
+	})
+
+	t.Run("personal access token scopes", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		testUserID := uuid.New()
+		for i := 0; i < 2; i++ {
+			mock.ExpectQuery(`FROM personal_access_tokens t`).
+				WithArgs(middleware.HashPersonalToken("fcp_readonly")).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "scopes", "last_used_at", "clerk_id"}).
+					AddRow(uuid.New(), testUserID, pq.Array([]string{"decks:read"}), time.Now(), "user_token"))
+			if i == 0 {
+				mock.ExpectQuery(`SELECT id, role, suspended_at, deletion_scheduled_for FROM users WHERE clerk_id = \$1`).
+					WithArgs("user_token").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "role", "suspended_at", "deletion_scheduled_for"}).AddRow(testUserID, "user", nil, nil))
+			}
+		}
+
+		router := SetupRouter(middleware.PersonalTokenAuthenticator{})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/go/decks", strings.NewReader(`{"title":"Nope"}`))
+		req.Header.Set("Authorization", "Bearer fcp_readonly")
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/api/go/tokens", nil)
+		req.Header.Set("Authorization", "Bearer fcp_readonly")
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("suspended user", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		mock.ExpectQuery(`SELECT id, role, suspended_at, deletion_scheduled_for FROM users WHERE clerk_id = \$1`).
+			WithArgs("user_suspended").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role", "suspended_at", "deletion_scheduled_for"}).AddRow(uuid.New(), "user", time.Now(), nil))
+
+		auth := middleware.StaticTokenAuthenticator{Tokens: map[string]middleware.Principal{
+			"suspended-token": {Subject: "user_suspended"},
+		}}
+		router := SetupRouter(auth)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/go/decks", nil)
+		req.Header.Set("Authorization", "Bearer suspended-token")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "Account suspended")
+	})
+
+	t.Run("account pending deletion", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		testUserID := uuid.New()
+		scheduledFor := time.Now().Add(time.Hour)
+
+		mock.ExpectQuery(`SELECT id, role, suspended_at, deletion_scheduled_for FROM users WHERE clerk_id = \$1`).
+			WithArgs("user_leaving").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role", "suspended_at", "deletion_scheduled_for"}).AddRow(testUserID, "user", nil, scheduledFor))
+		mock.ExpectQuery(`SELECT deletion_requested_at, deletion_scheduled_for FROM users WHERE id = \$1`).
+			WithArgs(testUserID).
+			WillReturnRows(sqlmock.NewRows([]string{"deletion_requested_at", "deletion_scheduled_for"}).AddRow(time.Now(), scheduledFor))
+
+		auth := middleware.StaticTokenAuthenticator{Tokens: map[string]middleware.Principal{
+			"leaving-token": {Subject: "user_leaving"},
+		}}
+		router := SetupRouter(auth)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/go/decks", nil)
+		req.Header.Set("Authorization", "Bearer leaving-token")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "Account scheduled for deletion")
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/api/go/me/deletion", nil)
+		req.Header.Set("Authorization", "Bearer leaving-token")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("admin routes", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer mockDB.Close()
+		database.DB = mockDB
+
+		mock.ExpectQuery(`SELECT id, role, suspended_at, deletion_scheduled_for FROM users WHERE clerk_id = \$1`).
+			WithArgs("user_plain").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role", "suspended_at", "deletion_scheduled_for"}).AddRow(uuid.New(), "user", nil, nil))
+
+		auth := middleware.StaticTokenAuthenticator{Tokens: map[string]middleware.Principal{
+			"plain-token": {Subject: "user_plain"},
+		}}
+		router := SetupRouter(auth)
+
+		for _, path := range []string{"/api/go/users", "/api/go/admin/users"} {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", path, nil)
+			req.Header.Set("Authorization", "Bearer plain-token")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusForbidden, w.Code, path)
+		}
+	})
+
+	t.Run("missing credentials", func(t *testing.T) {
+		router := SetupRouter(middleware.StaticTokenAuthenticator{})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/go/decks", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Body.String(), `"code":"unauthorized"`)
+	})
+}
@@ -0,0 +1,115 @@
+package scheduler
+
+import (
+	"time"
+)
+
+// Cram returns the state of c after it was answered with g at now, ahead of
+// an exam. The answer is scheduled as usual, then the interval is cut so the
+// card comes back at the latest halfway to the exam, or a third of the way
+// for weak cards: every card is seen more and more often as the exam nears.
+// From the exam on, cards are scheduled as usual from the intervals they reached.
+func (s Settings) Cram(c Card, g Grade, now, exam time.Time) Card {
+	c = s.Review(c, g, now)
+	if c.State != StateReview || !now.Before(exam) {
+		return c
+	}
+	left := int(exam.Sub(now) / day)
+	limit := left / 2
+	if s.Weak(c) {
+		limit = left / 3
+	}
+	limit = max(1, limit)
+	if c.Interval > limit {
+		c.Interval = limit
+		c.Due = now.Add(time.Duration(limit) * day)
+	}
+	return c
+}
+
+// Weak reports whether a card was forgotten before or got hard to remember
+func (s Settings) Weak(c Card) bool {
+	return c.Lapses > 0 || (c.Ease > 0 && c.Ease < s.StartingEase)
+}
+
+// NewPerDay is the number of new cards to learn a day so that all of them
+// are learned in days
+func NewPerDay(newCards, days int) int {
+	if days <= 0 {
+		return newCards
+	}
+	return (newCards + days - 1) / days
+}
+
+// PlanDay is the workload of a day of a cram plan
+type PlanDay struct {
+	Date time.Time
+	// New is the number of cards learned for the first time
+	New int
+	// Reviews is the number of cards learned before and due that day
+	Reviews int
+}
+
+// Plan simulates cramming cards from start until the exam and returns the
+// workload of every study day before the exam day. dayStart is the instant
+// the study day of start began, in the location of the user; the following
+// days begin at the same hour and the date of dayStart is the first of the
+// plan. New cards are spread evenly over the days, every card due on a day is
+// studied that day, and every answer is assumed Good.
+func (s Settings) Plan(cards []Card, start, dayStart, exam time.Time) []PlanDay {
+	first := time.Date(dayStart.Year(), dayStart.Month(), dayStart.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(exam.Sub(first) / day)
+	if days <= 0 {
+		return nil
+	}
+
+	cards = append([]Card(nil), cards...)
+	fresh := 0
+	for _, c := range cards {
+		if c.State == StateNew || c.State == "" {
+			fresh++
+		}
+	}
+	perDay := NewPerDay(fresh, days)
+
+	plan := make([]PlanDay, days)
+	for d := range plan {
+		end := dayStart.AddDate(0, 0, d+1)
+		at := dayStart.AddDate(0, 0, d)
+		if d == 0 {
+			at = start
+		}
+		plan[d].Date = first.AddDate(0, 0, d)
+
+		learned := 0
+		for i, c := range cards {
+			isNew := c.State == StateNew || c.State == ""
+			switch {
+			case isNew && learned < perDay:
+				learned++
+				plan[d].New++
+			case !isNew && c.Due.Before(end):
+				plan[d].Reviews++
+			default:
+				continue
+			}
+			cards[i] = s.cramDay(c, at, end, exam)
+		}
+	}
+	return plan
+}
+
+// cramDay answers c Good until it isn't due before end, as a learning card
+// comes back the same day
+func (s Settings) cramDay(c Card, at, end, exam time.Time) Card {
+	for range 10 {
+		if c.Due.After(at) {
+			at = c.Due
+		}
+		c = s.Cram(c, Good, at, exam)
+		if !c.Due.Before(end) {
+			break
+		}
+	}
+	return c
+}
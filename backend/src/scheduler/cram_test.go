@@ -0,0 +1,93 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCram(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	exam := time.Date(2026, 3, 21, 0, 0, 0, 0, time.UTC)
+	s := DefaultSettings
+	mature := Card{State: StateReview, Ease: 2.5, Interval: 30, Due: now, Reps: 8}
+
+	t.Run("interval cut halfway to the exam", func(t *testing.T) {
+		c := s.Cram(mature, Good, now, exam)
+		assert.Equal(t, 9, c.Interval)
+		assert.Equal(t, now.Add(9*day), c.Due)
+	})
+
+	t.Run("weak cards come back sooner", func(t *testing.T) {
+		weak := mature
+		weak.Lapses = 2
+		assert.True(t, s.Weak(weak))
+		assert.Equal(t, 6, s.Cram(weak, Good, now, exam).Interval)
+	})
+
+	t.Run("short intervals are kept", func(t *testing.T) {
+		young := Card{State: StateReview, Ease: 2.5, Interval: 1, Due: now}
+		assert.Equal(t, s.Review(young, Good, now), s.Cram(young, Good, now, exam))
+	})
+
+	t.Run("at least a day", func(t *testing.T) {
+		eve := exam.Add(-12 * time.Hour)
+		assert.Equal(t, 1, s.Cram(mature, Good, eve, exam).Interval)
+	})
+
+	t.Run("normal scheduling after the exam", func(t *testing.T) {
+		after := exam.Add(2 * day)
+		assert.Equal(t, s.Review(mature, Good, after), s.Cram(mature, Good, after, exam))
+	})
+}
+
+func TestNewPerDay(t *testing.T) {
+	assert.Equal(t, 4, NewPerDay(10, 3))
+	assert.Equal(t, 0, NewPerDay(0, 3))
+	assert.Equal(t, 10, NewPerDay(10, 0))
+}
+
+func TestPlan(t *testing.T) {
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	exam := time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)
+	s := DefaultSettings
+
+	cards := make([]Card, 6)
+	for i := range cards {
+		cards[i] = NewCard()
+	}
+	cards = append(cards, Card{State: StateReview, Ease: 2.5, Interval: 10, Due: start.Add(2 * day)})
+
+	plan := s.Plan(cards, start, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), exam)
+	if assert.Len(t, plan, 4) {
+		assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), plan[0].Date)
+		news := 0
+		for _, d := range plan {
+			news += d.New
+		}
+		assert.Equal(t, 6, news)
+		assert.Equal(t, 2, plan[0].New)
+		assert.Equal(t, 0, plan[3].New)
+		// Cards learned on the first day are due the next day
+		assert.Equal(t, 2, plan[1].Reviews)
+		// Close to the exam every card comes back daily: the cards learned on
+		// the first two days and the review card
+		assert.Equal(t, 5, plan[2].Reviews)
+	}
+	assert.Equal(t, StateNew, cards[0].State, "cards are left untouched")
+
+	assert.Nil(t, s.Plan(cards, exam.Add(time.Hour), exam, exam))
+
+	// Late in the evening in New York the study day is still the previous date
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	evening := time.Date(2026, 2, 28, 21, 0, 0, 0, loc)
+	plan = s.Plan(cards, evening, time.Date(2026, 2, 28, 4, 0, 0, 0, loc), exam)
+	if assert.Len(t, plan, 5) {
+		assert.Equal(t, time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC), plan[0].Date)
+		assert.Equal(t, time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC), plan[4].Date)
+	}
+}
@@ -0,0 +1,14 @@
+package scheduler
+
+// DefaultLeechThreshold is the number of lapses that makes a card a leech
+const DefaultLeechThreshold = 8
+
+// Leech reports whether a card that just lapsed for the lapses-th time hits
+// the leech threshold: on reaching it, then every half threshold after so a
+// leech that was unsuspended and keeps lapsing is caught again
+func Leech(lapses, threshold int) bool {
+	if threshold <= 0 || lapses < threshold {
+		return false
+	}
+	return (lapses-threshold)%max(1, threshold/2) == 0
+}
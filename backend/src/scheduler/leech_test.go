@@ -0,0 +1,21 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLeech(t *testing.T) {
+	var hits []int
+	for lapses := 1; lapses <= 20; lapses++ {
+		if Leech(lapses, DefaultLeechThreshold) {
+			hits = append(hits, lapses)
+		}
+	}
+	assert.Equal(t, []int{8, 12, 16, 20}, hits)
+
+	assert.True(t, Leech(1, 1))
+	assert.True(t, Leech(2, 1))
+	assert.False(t, Leech(3, 0))
+}
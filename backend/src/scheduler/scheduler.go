@@ -0,0 +1,205 @@
+// Package scheduler decides when a flashcard is due again after an answer,
+// with a variant of the SM-2 algorithm used by Anki.
+//
+// New cards go through short learning steps (minutes apart) before they
+// graduate to review, where the interval in days grows by the card's ease
+// after every successful answer. A failed review is a lapse: the ease drops,
+// the interval is cut and the card goes through relearning steps. The Leitner
+// algorithm keeps the steps but ignores ease: intervals double on every
+// successful review, like cards moving up the boxes of a Leitner system.
+package scheduler
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Grade is how well a card was remembered
+type Grade int
+
+const (
+	Again Grade = 1 // Forgotten
+	Hard  Grade = 2 // Remembered with difficulty
+	Good  Grade = 3 // Remembered
+	Easy  Grade = 4 // Remembered effortlessly
+)
+
+// Validate checks that g is one of the four grades
+func (g Grade) Validate() error {
+	if g < Again || g > Easy {
+		return fmt.Errorf("grade must be between %d and %d", Again, Easy)
+	}
+	return nil
+}
+
+// States of a card
+const (
+	StateNew        = "new"
+	StateLearning   = "learning"
+	StateReview     = "review"
+	StateRelearning = "relearning"
+)
+
+// Card is the scheduling state of a flashcard
+type Card struct {
+	State string
+	// Step is the current learning or relearning step
+	Step int
+	Ease float64
+	// Interval is the number of days between reviews, 0 until the card graduates
+	Interval int
+	Due      time.Time
+	Reps     int
+	Lapses   int
+}
+
+// NewCard returns the state of a card that was never studied
+func NewCard() Card {
+	return Card{State: StateNew}
+}
+
+// Algorithms that schedule cards in review
+const (
+	SM2     = "sm2"
+	Leitner = "leitner"
+)
+
+// Algorithms lists the supported algorithms
+var Algorithms = []string{SM2, Leitner}
+
+// Settings tune the scheduler
+type Settings struct {
+	// Algorithm is SM2 when empty
+	Algorithm       string
+	LearningSteps   []time.Duration
+	RelearningSteps []time.Duration
+	// GraduatingInterval is the interval in days after the last learning step
+	GraduatingInterval int
+	// EasyInterval is the interval in days of a new card answered Easy
+	EasyInterval    int
+	MaximumInterval int
+	StartingEase    float64
+	// LapseMultiplier scales the interval of a lapsed card
+	LapseMultiplier float64
+}
+
+// DefaultSettings are Anki's defaults
+var DefaultSettings = Settings{
+	LearningSteps:      []time.Duration{time.Minute, 10 * time.Minute},
+	RelearningSteps:    []time.Duration{10 * time.Minute},
+	GraduatingInterval: 1,
+	EasyInterval:       4,
+	MaximumInterval:    36500,
+	StartingEase:       2.5,
+	LapseMultiplier:    0,
+}
+
+const (
+	minimumEase = 1.3
+	day         = 24 * time.Hour
+)
+
+// Review returns the state of c after it was answered with g at now
+func (s Settings) Review(c Card, g Grade, now time.Time) Card {
+	c.Reps++
+	if c.Ease == 0 {
+		c.Ease = s.StartingEase
+	}
+
+	switch c.State {
+	case StateReview:
+		if s.Algorithm == Leitner {
+			return s.leitner(c, g, now)
+		}
+		return s.review(c, g, now)
+	case StateRelearning:
+		return s.step(c, g, now, s.RelearningSteps, c.Interval, c.Interval)
+	default:
+		c.State = StateLearning
+		return s.step(c, g, now, s.LearningSteps, s.GraduatingInterval, s.EasyInterval)
+	}
+}
+
+// step moves a learning or relearning card through steps. The card returns to
+// review with interval after the last step, or straight away with easy.
+func (s Settings) step(c Card, g Grade, now time.Time, steps []time.Duration, interval, easy int) Card {
+	switch g {
+	case Again:
+		c.Step = 0
+	case Hard:
+		// Repeat the current step
+	case Good:
+		c.Step++
+	case Easy:
+		return s.graduate(c, easy, now)
+	}
+	if c.Step >= len(steps) {
+		return s.graduate(c, interval, now)
+	}
+	c.Due = now.Add(steps[c.Step])
+	return c
+}
+
+func (s Settings) graduate(c Card, interval int, now time.Time) Card {
+	c.State = StateReview
+	c.Step = 0
+	c.Interval = s.clamp(interval)
+	c.Due = now.Add(time.Duration(c.Interval) * day)
+	return c
+}
+
+func (s Settings) review(c Card, g Grade, now time.Time) Card {
+	interval := float64(c.Interval)
+	switch g {
+	case Again:
+		c.Lapses++
+		c.Ease = math.Max(minimumEase, c.Ease-0.2)
+		c.Interval = s.clamp(int(interval * s.LapseMultiplier))
+		if len(s.RelearningSteps) == 0 {
+			c.Due = now.Add(time.Duration(c.Interval) * day)
+			return c
+		}
+		c.State = StateRelearning
+		c.Step = 0
+		c.Due = now.Add(s.RelearningSteps[0])
+		return c
+	case Hard:
+		c.Ease = math.Max(minimumEase, c.Ease-0.15)
+		interval *= 1.2
+	case Good:
+		interval *= c.Ease
+	case Easy:
+		c.Ease += 0.15
+		interval *= c.Ease * 1.3
+	}
+	// Every successful review at least adds a day
+	c.Interval = s.clamp(max(c.Interval+1, int(math.Round(interval))))
+	c.Due = now.Add(time.Duration(c.Interval) * day)
+	return c
+}
+
+// leitner doubles the interval of a remembered card, keeps it on Hard and
+// triples it on Easy. Forgotten cards lapse as with SM-2.
+func (s Settings) leitner(c Card, g Grade, now time.Time) Card {
+	switch g {
+	case Again:
+		return s.review(c, g, now)
+	case Hard:
+		c.Interval = s.clamp(c.Interval)
+	case Good:
+		c.Interval = s.clamp(2 * c.Interval)
+	case Easy:
+		c.Interval = s.clamp(3 * c.Interval)
+	}
+	c.Due = now.Add(time.Duration(c.Interval) * day)
+	return c
+}
+
+// clamp keeps an interval between a day and the maximum interval
+func (s Settings) clamp(interval int) int {
+	if s.MaximumInterval > 0 && interval > s.MaximumInterval {
+		return s.MaximumInterval
+	}
+	return max(1, interval)
+}
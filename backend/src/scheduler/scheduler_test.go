@@ -0,0 +1,103 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReview(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := DefaultSettings
+
+	t.Run("learning steps then graduation", func(t *testing.T) {
+		c := s.Review(NewCard(), Good, now)
+		assert.Equal(t, StateLearning, c.State)
+		assert.Equal(t, 1, c.Step)
+		assert.Equal(t, now.Add(10*time.Minute), c.Due)
+		assert.Equal(t, 2.5, c.Ease)
+
+		c = s.Review(c, Again, now)
+		assert.Equal(t, 0, c.Step)
+		assert.Equal(t, now.Add(time.Minute), c.Due)
+
+		c = s.Review(c, Hard, now)
+		assert.Equal(t, 0, c.Step)
+
+		c = s.Review(s.Review(c, Good, now), Good, now)
+		assert.Equal(t, StateReview, c.State)
+		assert.Equal(t, 1, c.Interval)
+		assert.Equal(t, now.Add(24*time.Hour), c.Due)
+		assert.Equal(t, 5, c.Reps)
+	})
+
+	t.Run("easy new card", func(t *testing.T) {
+		c := s.Review(NewCard(), Easy, now)
+		assert.Equal(t, StateReview, c.State)
+		assert.Equal(t, 4, c.Interval)
+	})
+
+	t.Run("reviews", func(t *testing.T) {
+		c := Card{State: StateReview, Ease: 2.5, Interval: 10}
+
+		good := s.Review(c, Good, now)
+		assert.Equal(t, 25, good.Interval)
+		assert.Equal(t, 2.5, good.Ease)
+
+		hard := s.Review(c, Hard, now)
+		assert.Equal(t, 12, hard.Interval)
+		assert.InDelta(t, 2.35, hard.Ease, 0.001)
+
+		easy := s.Review(c, Easy, now)
+		assert.Equal(t, 34, easy.Interval)
+		assert.InDelta(t, 2.65, easy.Ease, 0.001)
+
+		short := s.Review(Card{State: StateReview, Ease: 1.3, Interval: 1}, Hard, now)
+		assert.Equal(t, 2, short.Interval)
+		assert.Equal(t, 1.3, short.Ease)
+
+		capped := Settings{MaximumInterval: 30}.Review(c, Easy, now)
+		assert.Equal(t, 30, capped.Interval)
+	})
+
+	t.Run("lapse and relearning", func(t *testing.T) {
+		c := s.Review(Card{State: StateReview, Ease: 2.5, Interval: 20}, Again, now)
+		assert.Equal(t, StateRelearning, c.State)
+		assert.Equal(t, 1, c.Lapses)
+		assert.Equal(t, 1, c.Interval)
+		assert.InDelta(t, 2.3, c.Ease, 0.001)
+		assert.Equal(t, now.Add(10*time.Minute), c.Due)
+
+		c = s.Review(c, Good, now)
+		assert.Equal(t, StateReview, c.State)
+		assert.Equal(t, now.Add(24*time.Hour), c.Due)
+
+		kept := Settings{LapseMultiplier: 0.5}.Review(Card{State: StateReview, Ease: 2.5, Interval: 20}, Again, now)
+		assert.Equal(t, StateReview, kept.State)
+		assert.Equal(t, 10, kept.Interval)
+	})
+
+	t.Run("leitner", func(t *testing.T) {
+		l := DefaultSettings
+		l.Algorithm = Leitner
+		card := Card{State: StateReview, Ease: 2.5, Interval: 10}
+
+		good := l.Review(card, Good, now)
+		assert.Equal(t, 20, good.Interval)
+		assert.Equal(t, 2.5, good.Ease)
+		assert.Equal(t, now.Add(20*24*time.Hour), good.Due)
+		assert.Equal(t, 10, l.Review(card, Hard, now).Interval)
+		assert.Equal(t, 30, l.Review(card, Easy, now).Interval)
+
+		lapsed := l.Review(card, Again, now)
+		assert.Equal(t, StateRelearning, lapsed.State)
+		assert.Equal(t, 1, lapsed.Lapses)
+	})
+}
+
+func TestGradeValidate(t *testing.T) {
+	assert.NoError(t, Good.Validate())
+	assert.Error(t, Grade(0).Validate())
+	assert.Error(t, Grade(5).Validate())
+}
@@ -0,0 +1,71 @@
+// Package streak follows the daily goals of a user: the run of days they met
+// their goal, the freezes that keep it alive over a missed day and the
+// heatmap of their last year of study.
+package streak
+
+import (
+	"api/src/models"
+	"time"
+)
+
+// Day returns the study day of t in loc, where days start at the rollover
+// hour, as midnight UTC of its date
+func Day(t time.Time, loc *time.Location, rollover int) time.Time {
+	y, m, d := t.In(loc).Add(-time.Duration(rollover) * time.Hour).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Start returns the instant the study day day, as returned by Day, starts in
+// loc at the rollover hour
+func Start(day time.Time, loc *time.Location, rollover int) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), rollover, 0, 0, 0, loc)
+}
+
+// Compute walks the study days of a user, oldest first, up to today as
+// returned by Day. Days without study may be left out of days.
+func Compute(goal models.StudyGoal, days []models.StudyDay, today time.Time) models.Streak {
+	s := models.Streak{Goal: goal, Heatmap: make([]models.StudyDay, models.HeatmapDays)}
+	first := today.AddDate(0, 0, 1-models.HeatmapDays)
+	start := first
+	byDate := make(map[string]models.StudyDay, len(days))
+	for _, d := range days {
+		byDate[d.Date] = d
+		if t, err := time.Parse(time.DateOnly, d.Date); err == nil && t.Before(start) {
+			start = t
+		}
+	}
+
+	// earned counts the goals met towards the next freeze
+	earned := 0
+	for day := start; !day.After(today); day = day.AddDate(0, 0, 1) {
+		date := day.Format(time.DateOnly)
+		d := byDate[date]
+		d.Date = date
+		d.GoalMet = goal.Met(d)
+		switch {
+		case d.GoalMet:
+			s.Current++
+			earned++
+			if earned == models.FreezeEvery {
+				earned = 0
+				s.Freezes = min(s.Freezes+1, models.MaxFreezes)
+			}
+		case day.Equal(today):
+			// Today can still be studied
+		case s.Current > 0 && s.Freezes > 0:
+			s.Freezes--
+			d.Frozen = true
+		default:
+			s.Current, earned = 0, 0
+		}
+		s.Longest = max(s.Longest, s.Current)
+
+		if !day.Before(first) {
+			s.Heatmap[int(day.Sub(first)/(24*time.Hour))] = d
+		}
+		if day.Equal(today) {
+			s.Today = d
+		}
+	}
+	return s
+}
@@ -0,0 +1,103 @@
+package streak
+
+import (
+	"api/src/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(s string) time.Time {
+	t, _ := time.Parse(time.DateOnly, s)
+	return t
+}
+
+func TestDay(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	// 03:00 in Paris, before the rollover
+	assert.Equal(t, date("2026-03-09"), Day(time.Date(2026, 3, 10, 2, 0, 0, 0, time.UTC), paris, 4))
+	assert.Equal(t, date("2026-03-10"), Day(time.Date(2026, 3, 10, 3, 30, 0, 0, time.UTC), paris, 4))
+	assert.Equal(t, date("2026-03-10"), Day(time.Date(2026, 3, 10, 2, 0, 0, 0, time.UTC), time.UTC, 0))
+	// 22:00 the day before in New York
+	assert.Equal(t, date("2026-03-09"), Day(time.Date(2026, 3, 10, 2, 0, 0, 0, time.UTC), newYork, 0))
+}
+
+func TestStart(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+
+	start := Start(date("2026-03-10"), paris, 4)
+	assert.True(t, start.Equal(time.Date(2026, 3, 10, 3, 0, 0, 0, time.UTC)))
+	assert.Equal(t, date("2026-03-10"), Day(start, paris, 4))
+	assert.Equal(t, date("2026-03-09"), Day(start.Add(-time.Second), paris, 4))
+}
+
+// studied returns n days of study of cards answers from start on
+func studied(start string, n, cards int) []models.StudyDay {
+	days := make([]models.StudyDay, n)
+	for i := range days {
+		days[i] = models.StudyDay{Date: date(start).AddDate(0, 0, i).Format(time.DateOnly), Cards: cards}
+	}
+	return days
+}
+
+func TestCompute(t *testing.T) {
+	goal := models.StudyGoal{Unit: models.GoalCards, Target: 10}
+
+	t.Run("today not studied yet", func(t *testing.T) {
+		s := Compute(goal, studied("2026-03-01", 3, 12), date("2026-03-04"))
+		assert.Equal(t, 3, s.Current)
+		assert.Equal(t, 3, s.Longest)
+		assert.Equal(t, "2026-03-04", s.Today.Date)
+		assert.False(t, s.Today.GoalMet)
+		if assert.Len(t, s.Heatmap, models.HeatmapDays) {
+			assert.Equal(t, s.Today, s.Heatmap[models.HeatmapDays-1])
+			assert.Equal(t, models.StudyDay{Date: "2026-03-03", Cards: 12, GoalMet: true}, s.Heatmap[models.HeatmapDays-2])
+			assert.Equal(t, "2025-03-05", s.Heatmap[0].Date)
+		}
+	})
+
+	t.Run("goal not reached", func(t *testing.T) {
+		days := append(studied("2026-03-01", 2, 12), studied("2026-03-03", 1, 5)...)
+		s := Compute(goal, days, date("2026-03-05"))
+		assert.Equal(t, 0, s.Current)
+		assert.Equal(t, 2, s.Longest)
+	})
+
+	t.Run("freeze", func(t *testing.T) {
+		days := studied("2026-03-01", 8, 10)
+		s := Compute(goal, days, date("2026-03-10"))
+		assert.Equal(t, 8, s.Current)
+		assert.Equal(t, 0, s.Freezes)
+		assert.True(t, s.Heatmap[models.HeatmapDays-2].Frozen)
+
+		s = Compute(goal, days, date("2026-03-11"))
+		assert.Equal(t, 0, s.Current)
+		assert.Equal(t, 8, s.Longest)
+	})
+
+	t.Run("freezes are capped", func(t *testing.T) {
+		s := Compute(goal, studied("2026-01-01", 30, 10), date("2026-01-31"))
+		assert.Equal(t, 30, s.Current)
+		assert.Equal(t, models.MaxFreezes, s.Freezes)
+	})
+
+	t.Run("minutes", func(t *testing.T) {
+		goal := models.StudyGoal{Unit: models.GoalMinutes, Target: 15}
+		s := Compute(goal, []models.StudyDay{{Date: "2026-03-01", Cards: 3, TimeMs: 15 * 60000}}, date("2026-03-01"))
+		assert.Equal(t, 1, s.Current)
+		assert.True(t, s.Today.GoalMet)
+	})
+
+	t.Run("history older than the heatmap", func(t *testing.T) {
+		s := Compute(goal, studied("2024-01-01", 800, 10), date("2026-03-10"))
+		assert.Equal(t, 800, s.Current)
+		assert.True(t, s.Heatmap[0].GoalMet)
+	})
+}
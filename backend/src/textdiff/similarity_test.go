@@ -0,0 +1,27 @@
+package textdiff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "what is the capital of france", Normalize("  What is the\ncapital of France?!"))
+	assert.Equal(t, "dont panic", Normalize("Don't  PANIC"))
+	assert.Equal(t, "", Normalize(" ?! "))
+}
+
+func TestDistance(t *testing.T) {
+	assert.Equal(t, 0, Distance("paris", "paris"))
+	assert.Equal(t, 3, Distance("kitten", "sitting"))
+	assert.Equal(t, 1, Distance("café", "cafe"))
+	assert.Equal(t, 4, Distance("", "rome"))
+}
+
+func TestSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, Similarity("", ""))
+	assert.Equal(t, 1.0, Similarity("rome", "rome"))
+	assert.InDelta(t, 0.75, Similarity("rome", "roma"), 0.001)
+	assert.Equal(t, 0.0, Similarity("abc", "xyz"))
+}
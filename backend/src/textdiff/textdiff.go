@@ -0,0 +1,166 @@
+package textdiff
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Op is the kind of change a Segment represents
+type Op string
+
+const (
+	Equal  Op = "equal"
+	Insert Op = "insert"
+	Delete Op = "delete"
+)
+
+// Segment is a run of text that was kept, inserted or deleted
+type Segment struct {
+	Op   Op     `json:"op"`
+	Text string `json:"text"`
+}
+
+// Words diffs two strings word by word. Whitespace is kept attached to the
+// tokens so that joining the segments reproduces both inputs.
+func Words(a, b string) []Segment {
+	return diff(tokenize(a), tokenize(b))
+}
+
+// Chars diffs two strings rune by rune.
+func Chars(a, b string) []Segment {
+	return diff(splitRunes(a), splitRunes(b))
+}
+
+// Changed reports whether a diff contains any insertions or deletions.
+func Changed(segments []Segment) bool {
+	for _, s := range segments {
+		if s.Op != Equal {
+			return true
+		}
+	}
+	return false
+}
+
+func tokenize(s string) []string {
+	var tokens []string
+	start := 0
+	inSpace := false
+	for i, r := range s {
+		space := unicode.IsSpace(r)
+		if i > start && space != inSpace {
+			tokens = append(tokens, s[start:i])
+			start = i
+		}
+		inSpace = space
+	}
+	if start < len(s) {
+		tokens = append(tokens, s[start:])
+	}
+	return tokens
+}
+
+func splitRunes(s string) []string {
+	tokens := make([]string, 0, len(s))
+	for _, r := range s {
+		tokens = append(tokens, string(r))
+	}
+	return tokens
+}
+
+// maxTableCells bounds the size of the table of diff. Longer texts are diffed
+// as a whole replacement of what differs between their common prefix and suffix.
+const maxTableCells = 1 << 20
+
+// diff computes a longest-common-subsequence diff of two token lists and
+// merges adjacent tokens with the same op into a single segment.
+func diff(a, b []string) []Segment {
+	var segments []Segment
+	add := func(op Op, text string) {
+		if len(segments) > 0 && segments[len(segments)-1].Op == op {
+			segments[len(segments)-1].Text += text
+			return
+		}
+		segments = append(segments, Segment{Op: op, Text: text})
+	}
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	for _, t := range a[:prefix] {
+		add(Equal, t)
+	}
+	middle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix], add)
+	for _, t := range a[len(a)-suffix:] {
+		add(Equal, t)
+	}
+	return segments
+}
+
+// middle diffs the tokens between the common prefix and suffix of two lists
+func middle(a, b []string, add func(Op, string)) {
+	n, m := len(a), len(b)
+	if (n+1)*(m+1) > maxTableCells {
+		for _, t := range a {
+			add(Delete, t)
+		}
+		for _, t := range b {
+			add(Insert, t)
+		}
+		return
+	}
+
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			add(Equal, a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			add(Delete, a[i])
+			i++
+		default:
+			add(Insert, b[j])
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		add(Delete, a[i])
+	}
+	for ; j < m; j++ {
+		add(Insert, b[j])
+	}
+}
+
+// Join concatenates the segments that make up one side of a diff. Passing
+// Delete reconstructs the old text and Insert the new one.
+func Join(segments []Segment, side Op) string {
+	var sb strings.Builder
+	for _, s := range segments {
+		if s.Op == Equal || s.Op == side {
+			sb.WriteString(s.Text)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,69 @@
+package textdiff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWords(t *testing.T) {
+	t.Run("identical", func(t *testing.T) {
+		segments := Words("the mitochondria", "the mitochondria")
+		assert.Equal(t, []Segment{{Op: Equal, Text: "the mitochondria"}}, segments)
+		assert.False(t, Changed(segments))
+	})
+
+	t.Run("replaced word", func(t *testing.T) {
+		segments := Words("capital of France", "capital of Spain")
+		assert.Equal(t, []Segment{
+			{Op: Equal, Text: "capital of "},
+			{Op: Delete, Text: "France"},
+			{Op: Insert, Text: "Spain"},
+		}, segments)
+		assert.True(t, Changed(segments))
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		a := "What is  the powerhouse of the cell?"
+		b := "Which organelle is the powerhouse?"
+		segments := Words(a, b)
+		assert.Equal(t, a, Join(segments, Delete))
+		assert.Equal(t, b, Join(segments, Insert))
+	})
+
+	t.Run("empty sides", func(t *testing.T) {
+		assert.Equal(t, []Segment{{Op: Insert, Text: "new"}}, Words("", "new"))
+		assert.Equal(t, []Segment{{Op: Delete, Text: "old"}}, Words("old", ""))
+		assert.Nil(t, Words("", ""))
+	})
+}
+
+func TestChars(t *testing.T) {
+	segments := Chars("colour", "color")
+	assert.Equal(t, []Segment{
+		{Op: Equal, Text: "colo"},
+		{Op: Delete, Text: "u"},
+		{Op: Equal, Text: "r"},
+	}, segments)
+}
+
+func TestLongTexts(t *testing.T) {
+	a := strings.Repeat("a", 3000) + "middle" + strings.Repeat("b", 2000)
+	b := strings.Repeat("a", 3000) + strings.Repeat("x", 2000) + strings.Repeat("b", 2000)
+
+	segments := Chars(a, b)
+	assert.Equal(t, []Segment{
+		{Op: Equal, Text: strings.Repeat("a", 3000)},
+		{Op: Delete, Text: "middle"},
+		{Op: Insert, Text: strings.Repeat("x", 2000)},
+		{Op: Equal, Text: strings.Repeat("b", 2000)},
+	}, segments)
+
+	// Too long to diff token by token: the differing middle is replaced whole
+	c, d := strings.Repeat("ab", 1000), strings.Repeat("ba", 1000)+"c"
+	segments = Chars(c, d)
+	assert.Equal(t, c, Join(segments, Delete))
+	assert.Equal(t, d, Join(segments, Insert))
+	assert.Len(t, segments, 2)
+}
@@ -47,9 +47,9 @@ export default function CreateDeckPage() {
       let message = "Failed to create deck.";
       if (err?.message) {
         try {
-          // Try to parse as JSON and show the error field if present
+          // Try to parse as JSON and show the problem detail if present
           const parsed = JSON.parse(err.message);
-          message = parsed.error || parsed.message || message;
+          message = parsed.detail || parsed.error || parsed.message || message;
         } catch {
           // If not JSON, just show the message string
           message = err.message;
@@ -76,7 +76,7 @@ export default function EditDeckPage() {
       if (err?.message) {
         try {
           const parsed = JSON.parse(err.message);
-          message = parsed.error || parsed.message || message;
+          message = parsed.detail || parsed.error || parsed.message || message;
         } catch {
           message = err.message;
         }